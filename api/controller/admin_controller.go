@@ -17,47 +17,42 @@ type AdminController struct {
 	UserRoleRepository         domain.UserRoleRepository
 	UserUsecase                domain.UserUsecase
 	ServiceUsecase             domain.ServiceUsecase
+	ScheduledJobUsecase        domain.ScheduledJobUsecase
 	Env                        *bootstrap.Env
 }
 
 // @Summary Get usage statistics
-// @Description Get dashboard statistics for admin with optional filters
+// @Description Get dashboard statistics for admin with optional filters. Usage sessions are counted in the bucket they started; the past days (UTC) are read from the usage rollups, by hour out of UTC, and the current day from the usage logs. The time series covers the whole range in chronological order, every service being in every bucket, zero when unused. Without start date the range starts 7 days (hour), 90 days (day), 52 weeks (week) or 2 years (month) before its end. Time zones offset by a fraction of an hour (e.g. Asia/Kolkata) are refused, usage being recorded by UTC hour.
 // @Tags Admin
 // @ID getUsageStatistics
 // @Security BearerAuth
 // @Produce json
 // @Param organization_id query int false "Organization ID filter"
-// @Param start_date query string false "Start date filter (ISO format)"
-// @Param end_date query string false "End date filter (ISO format)"
+// @Param start_date query string false "Start date filter (2006-01-02, or a time, in tz unless RFC 3339); defaults to a window before the end sized for the granularity"
+// @Param end_date query string false "End date filter (2006-01-02, included, or a time, excluded, in tz unless RFC 3339)"
+// @Param tz query string false "IANA time zone of the dates and of the time series buckets, offset by whole hours (default UTC)"
+// @Param granularity query string false "Time series bucket (default day)" Enums(hour, day, week, month)
 // @Success 200 {object} domain.SuccessResponse{data=domain.UsageStatistics} "Usage statistics"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid date range, time zone or granularity"
 // @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
 // @Router /admin/statistics [get]
 func (ac *AdminController) GetUsageStatistics(c *gin.Context) {
-	// Parse optional query parameters
-	var organizationID *uint
-	if orgIDStr := c.Query("organization_id"); orgIDStr != "" {
-		orgID, err := strconv.Atoi(orgIDStr)
-		if err == nil {
-			orgIDUint := uint(orgID)
-			organizationID = &orgIDUint
-		}
-	}
-
-	var startDate *string
-	if start := c.Query("start_date"); start != "" {
-		startDate = &start
-	}
-
-	var endDate *string
-	if end := c.Query("end_date"); end != "" {
-		endDate = &end
+	var query domain.UsageStatisticsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid input: " + err.Error()})
+		return
 	}
 
-	statistics, err := ac.UserServiceLogUsecase.GetUsageStatistics(c, organizationID, startDate, endDate)
+	statistics, err := ac.UserServiceLogUsecase.GetUsageStatistics(c, &query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{
-			Message: "Failed to fetch usage statistics: " + err.Error(),
-		})
+		switch err {
+		case domain.ErrInvalidDateRange, domain.ErrInvalidTimezone, domain.ErrUnsupportedTimezone, domain.ErrTooManyBuckets:
+			c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse{
+				Message: "Failed to fetch usage statistics: " + err.Error(),
+			})
+		}
 		return
 	}
 
@@ -207,14 +202,14 @@ func (ac *AdminController) GetOrganizationUsers(c *gin.Context) {
 // @ID unlinkServiceFromOrganization
 // @Security BearerAuth
 // @Produce json
-// @Param organizationId path int true "Organization ID"
+// @Param id path int true "Organization ID"
 // @Param serviceId path int true "Service ID"
 // @Success 200 {object} domain.SuccessResponse "Service unlinked successfully"
 // @Failure 400 {object} domain.ErrorResponse "Bad Request"
 // @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
-// @Router /admin/organizations/{organizationId}/services/{serviceId} [delete]
+// @Router /admin/organizations/{id}/services/{serviceId} [delete]
 func (ac *AdminController) UnlinkServiceFromOrganization(c *gin.Context) {
-	orgID := c.Param("organizationId")
+	orgID := c.Param("id")
 	srvID := c.Param("serviceId")
 
 	organizationID, err := strconv.Atoi(orgID)
@@ -294,3 +289,24 @@ func (ac *AdminController) ToggleUserArchiveStatus(c *gin.Context) {
 		"message": "User " + action + "d successfully",
 	}))
 }
+
+// @Summary Get the scheduled jobs
+// @Description Lists the jobs run on a cron schedule (UTC) with their next run and the outcome of their last run. A job is running while one of the replicas holds its lease.
+// @Tags Admin
+// @ID getScheduledJobs
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} domain.SuccessResponse{data=[]domain.PublicScheduledJob} "List of scheduled jobs"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /admin/jobs [get]
+func (ac *AdminController) GetScheduledJobs(c *gin.Context) {
+	jobs, err := ac.ScheduledJobUsecase.Fetch(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{
+			Message: "Failed to fetch scheduled jobs: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(jobs))
+}
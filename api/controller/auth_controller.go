@@ -1,10 +1,15 @@
 package controller
 
 import (
+	"errors"
+	"io"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gabrielfmcoelho/platform-core/bootstrap"
 	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal/parser"
 	"github.com/gin-gonic/gin"
 )
 
@@ -15,16 +20,18 @@ type AuthController struct {
 
 // User Login
 // @Summary Login user
-// @Description Authenticates a user using their email and password, then returns access and refresh tokens for session management.
+// @Description Authenticates a user using their email and password, then returns access and refresh tokens for session management. When the user has MFA enabled (or the organization requires it) an MFA challenge is returned instead, to be completed on /login/mfa. When the user must change their password (forced by an admin or because it breaks the password policy) a single-use reset token is returned instead once every factor is checked, to be used on /reset-password before signing in again.
 // @Tags Auth User
 // @ID login
 // @Accept json
 // @Produce json
 // @Param loginRequest body domain.LoginRequest true "Login Request"
 // @Success 200 {object} domain.LoginResponse "Successful login, returns access and refresh tokens"
+// @Success 202 {object} domain.MFAChallengeResponse "Password accepted, a second factor is required"
+// @Success 202 {object} domain.PasswordChangeChallengeResponse "Password accepted, it must be changed first"
 // @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid input"
 // @Failure 401 {object} domain.ErrorResponse "Unauthorized - Incorrect email or password"
-// @Failure 404 {object} domain.ErrorResponse "Not Found - User not found"
+// @Failure 429 {object} domain.ErrorResponse "Too Many Requests - Account or IP delayed or locked, see Retry-After"
 // @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
 // @Router /login [post]
 func (lc *AuthController) Login(c *gin.Context) {
@@ -37,51 +44,177 @@ func (lc *AuthController) Login(c *gin.Context) {
 		return
 	}
 
-	loginResponse, err := lc.AuthUsecase.LoginUserByEmail(
+	loginResponse, mfaChallenge, passwordChange, err := lc.AuthUsecase.LoginUserByEmail(
 		c,
 		request.Email,
 		request.Password,
+		clientInfo(c),
 		lc.Env.AccessTokenSecret,
 		lc.Env.AccessTokenExpiryHour,
-		lc.Env.RefreshTokenSecret,
 		lc.Env.RefreshTokenExpiryHour,
+		lc.Env.MFAChallengeExpiryMinute,
+		lc.Env.PasswordResetTokenExpiryMinute,
 	)
 
 	if err != nil {
+		if respondLoginThrottled(c, err) {
+			return
+		}
 		switch err {
-		case domain.ErrUserEmailNotFound:
-			c.JSON(http.StatusNotFound, domain.ErrorResponse{Message: err.Error()})
 		case domain.ErrUserPasswordNotMatch:
+			// unknown e-mails get the same answer
+			c.JSON(http.StatusUnauthorized, domain.ErrorResponse{Message: "Incorrect email or password"})
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: err.Error()})
+		}
+		return
+	}
+
+	if passwordChange != nil {
+		c.JSON(http.StatusAccepted, passwordChange)
+		return
+	}
+
+	if mfaChallenge != nil {
+		c.JSON(http.StatusAccepted, mfaChallenge)
+		return
+	}
+
+	c.JSON(http.StatusOK, loginResponse)
+}
+
+// @Summary Login second step (MFA)
+// @Description Completes a login that returned an MFA challenge, using a TOTP code or a recovery code. When the challenge required enrollment, the code confirms the new factor and the recovery codes are returned once. When the user must change their password a single-use reset token is returned instead of the tokens.
+// @Tags Auth User
+// @ID loginMFA
+// @Accept json
+// @Produce json
+// @Param mfaLoginRequest body domain.MFALoginRequest true "MFA Login Request"
+// @Success 200 {object} domain.MFALoginResponse "Successful login, returns access and refresh tokens"
+// @Success 202 {object} domain.PasswordChangeChallengeResponse "Second factor accepted, the password must be changed first"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid input or enrollment not started"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized - Invalid MFA token or code"
+// @Failure 409 {object} domain.ErrorResponse "Conflict - Enrollment already confirmed"
+// @Failure 429 {object} domain.ErrorResponse "Too Many Requests - Account or IP delayed or locked, see Retry-After"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /login/mfa [post]
+func (lc *AuthController) LoginMFA(c *gin.Context) {
+	var request domain.MFALoginRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	loginResponse, passwordChange, err := lc.AuthUsecase.LoginWithMFA(
+		c,
+		request.MFAToken,
+		request.Code,
+		request.RecoveryCode,
+		clientInfo(c),
+		lc.Env.AccessTokenSecret,
+		lc.Env.AccessTokenExpiryHour,
+		lc.Env.RefreshTokenExpiryHour,
+		lc.Env.PasswordResetTokenExpiryMinute,
+	)
+	if err != nil {
+		if respondLoginThrottled(c, err) {
+			return
+		}
+		switch err {
+		case domain.ErrInvalidMFAToken, domain.ErrInvalidMFACode:
 			c.JSON(http.StatusUnauthorized, domain.ErrorResponse{Message: err.Error()})
+		case domain.ErrMFANotEnrolled:
+			c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		case domain.ErrMFAAlreadyEnabled:
+			c.JSON(http.StatusConflict, domain.ErrorResponse{Message: err.Error()})
 		default:
 			c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: err.Error()})
 		}
 		return
 	}
 
+	if passwordChange != nil {
+		c.JSON(http.StatusAccepted, passwordChange)
+		return
+	}
+
 	c.JSON(http.StatusOK, loginResponse)
 }
 
+// @Summary Enroll MFA during login
+// @Description For users of an organization that requires MFA and who have no factor yet: generates the TOTP secret and the otpauth:// URI to render as a QR code. Confirm it with a code on /login/mfa.
+// @Tags Auth User
+// @ID loginMFAEnroll
+// @Accept json
+// @Produce json
+// @Param mfaEnrollLoginRequest body domain.MFAEnrollLoginRequest true "MFA Enroll Login Request"
+// @Success 200 {object} domain.SuccessResponse{data=domain.MFAEnrollment} "TOTP secret and provisioning URI"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid input"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized - Invalid MFA token"
+// @Failure 409 {object} domain.ErrorResponse "Conflict - MFA already enabled"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /login/mfa/enroll [post]
+func (lc *AuthController) LoginMFAEnroll(c *gin.Context) {
+	var request domain.MFAEnrollLoginRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	enrollment, err := lc.AuthUsecase.EnrollMFAForLogin(c, request.MFAToken, lc.Env.AccessTokenSecret)
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidMFAToken:
+			c.JSON(http.StatusUnauthorized, domain.ErrorResponse{Message: err.Error()})
+		case domain.ErrMFAAlreadyEnabled:
+			c.JSON(http.StatusConflict, domain.ErrorResponse{Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(enrollment))
+}
+
 // Login Guest
 // @Summary Login Guest
-// @Description Authenticates a guest user using their IP address, then returns access and refresh tokens for session management
+// @Description Creates an ephemeral guest user for the visitor (its IP address is recorded) and returns access and refresh tokens that expire with the guest session. Without organization_id the first Guest organization open to guests is used.
 // @Tags Auth User
 // @ID loginGuest
 // @Accept json
 // @Produce json
+// @Param request body domain.GuestLoginRequest false "Organization to visit"
 // @Success 200 {object} domain.LoginResponse "Successful login, returns access and refresh tokens"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid input"
+// @Failure 403 {object} domain.ErrorResponse "Guest access disabled for the organization"
+// @Failure 429 {object} domain.ErrorResponse "Too Many Requests - Too many guests created from this IP, see Retry-After"
 // @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
 // @Router /login-guest [post]
 func (lc *AuthController) LoginGuest(c *gin.Context) {
+	// the body is optional
+	var request domain.GuestLoginRequest
+	if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
 	loginResponse, err := lc.AuthUsecase.LoginGuestUser(
 		c,
-		lc.Env.AccessTokenSecret,
+		request.OrganizationID,
+		clientInfo(c),
 		lc.Env.AccessTokenExpiryHour,
-		lc.Env.RefreshTokenSecret,
-		lc.Env.RefreshTokenExpiryHour,
 	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: err.Error()})
+		if respondLoginThrottled(c, err) {
+			return
+		}
+		switch err {
+		case domain.ErrGuestAccessDisabled:
+			c.JSON(http.StatusForbidden, domain.ErrorResponse{Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: err.Error()})
+		}
 		return
 	}
 
@@ -89,36 +222,75 @@ func (lc *AuthController) LoginGuest(c *gin.Context) {
 }
 
 // @Summary Forgot Password
-// @Description Sends an email to the user with a link to reset their password
+// @Description Sends an email to the user with a single-use link to reset their password. The response is the same whether or not the e-mail belongs to an account.
 // @Tags Auth User
 // @ID forgotPassword
 // @Accept json
 // @Produce json
-// @Param email query string true "User email"
+// @Param forgotPasswordRequest body domain.ForgotPasswordRequest true "Forgot Password Request"
 // @Success 200 {object} domain.SuccessResponse "Email sent successfully"
 // @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid input"
-// @Failure 404 {object} domain.ErrorResponse "Not Found - User not found"
 // @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
 // @Router /forgot-password [post]
-func (lc *AuthController) ForgotPassword(c *gin.Context) {}
+func (lc *AuthController) ForgotPassword(c *gin.Context) {
+	var request domain.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	err := lc.AuthUsecase.ForgotPassword(
+		c,
+		request.Email,
+		clientInfo(c),
+		lc.Env.PasswordResetURL,
+		lc.Env.PasswordResetTokenExpiryMinute,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse{Message: "If the e-mail belongs to an account, a reset link was sent."})
+}
 
 // @Summary Reset Password
-// @Description Resets the user's password
+// @Description Resets the user's password using the token received by e-mail, or returned by /login when a password change is required. The new password must satisfy the password policy and differ from the last ones. All sessions of the user are signed out.
 // @Tags Auth User
 // @ID resetPassword
 // @Accept json
 // @Produce json
-// @Param email query string true "User email"
-// @Param newPassword query string true "New password"
+// @Param resetPasswordRequest body domain.ResetPasswordRequest true "Reset Password Request"
 // @Success 200 {object} domain.SuccessResponse "Password reset successfully"
-// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid input"
-// @Failure 404 {object} domain.ErrorResponse "Not Found - User not found"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid input, invalid/expired token or password refused by the policy"
 // @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
 // @Router /reset-password [post]
-func (lc *AuthController) ResetPassword(c *gin.Context) {}
+func (lc *AuthController) ResetPassword(c *gin.Context) {
+	var request domain.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	err := lc.AuthUsecase.ResetPassword(c, request.Token, request.NewPassword, clientInfo(c))
+	if err != nil {
+		if respondPasswordRejected(c, err) {
+			return
+		}
+		switch err {
+		case domain.ErrInvalidResetToken:
+			c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse{Message: "Password reset successfully."})
+}
 
 // @Summary Refresh Token
-// @Description Refreshes the user's access token
+// @Description Exchanges a refresh token for a new access token and a new refresh token. Each refresh token can be used only once; presenting it again revokes the whole session.
 // @Tags Auth User
 // @ID refreshToken
 // @Accept json
@@ -139,16 +311,105 @@ func (lc *AuthController) RefreshToken(c *gin.Context) {
 	refreshResponse, err := lc.AuthUsecase.RefreshToken(
 		c,
 		request.RefreshToken,
-		lc.Env.RefreshTokenSecret,
-		lc.Env.AccessTokenSecret,
+		clientInfo(c),
 		lc.Env.AccessTokenExpiryHour,
 		lc.Env.RefreshTokenExpiryHour,
 	)
 
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, domain.ErrorResponse{Message: "Invalid or expired refresh token"})
+		switch err {
+		case domain.ErrRefreshTokenReused:
+			c.JSON(http.StatusUnauthorized, domain.ErrorResponse{Message: err.Error()})
+		case domain.ErrInvalidRefreshToken:
+			c.JSON(http.StatusUnauthorized, domain.ErrorResponse{Message: "Invalid or expired refresh token"})
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: err.Error()})
+		}
 		return
 	}
 
 	c.JSON(http.StatusOK, refreshResponse)
 }
+
+// @Summary Logout
+// @Description Revokes the session of the given refresh token (this device only)
+// @Tags Auth User
+// @ID logout
+// @Accept json
+// @Produce json
+// @Param logoutRequest body domain.LogoutRequest true "Logout Request"
+// @Success 204 "No Content"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid input"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized - Invalid refresh token"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /logout [post]
+func (lc *AuthController) Logout(c *gin.Context) {
+	var request domain.LogoutRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	if err := lc.AuthUsecase.Logout(c, request.RefreshToken, clientInfo(c)); err != nil {
+		switch err {
+		case domain.ErrInvalidRefreshToken:
+			c.JSON(http.StatusUnauthorized, domain.ErrorResponse{Message: "Invalid or expired refresh token"})
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: err.Error()})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Logout from all devices
+// @Description Revokes every refresh token of the authenticated user. Access tokens already issued remain valid until they expire.
+// @Tags Auth User
+// @ID logoutAll
+// @Security BearerAuth
+// @Produce json
+// @Success 204 "No Content"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /logout-all [post]
+func (lc *AuthController) LogoutAll(c *gin.Context) {
+	userID := uint(c.GetInt("x-user-id"))
+
+	if err := lc.AuthUsecase.LogoutAll(c, userID, clientInfo(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// clientInfo collects the caller device data recorded with sessions and user logs
+func clientInfo(c *gin.Context) domain.ClientInfo {
+	return domain.ClientInfo{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Locale:    c.GetHeader("Accept-Language"),
+	}
+}
+
+// respondLoginThrottled answers 429 with a Retry-After header when the login was refused by the brute-force protection
+func respondLoginThrottled(c *gin.Context, err error) bool {
+	var throttled *domain.LoginThrottledError
+	if !errors.As(err, &throttled) {
+		return false
+	}
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+	c.JSON(http.StatusTooManyRequests, domain.ErrorResponse{Message: err.Error()})
+	return true
+}
+
+// respondPasswordRejected answers 400 with the requirement missed when a new password breaks the password policy
+func respondPasswordRejected(c *gin.Context, err error) bool {
+	var rejected *domain.PasswordPolicyError
+	if !errors.As(err, &rejected) {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+	return true
+}
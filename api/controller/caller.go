@@ -0,0 +1,22 @@
+package controller
+
+import (
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gin-gonic/gin"
+)
+
+// isPlatformAdmin reports whether the caller is an Admin user of an Admin organization
+func isPlatformAdmin(c *gin.Context) bool {
+	return c.GetUint("x-user-role-id") == domain.UserRoleAdmin &&
+		c.GetUint("x-organization-role-id") == domain.OrganizationRoleAdmin
+}
+
+// canManageOrganization reports whether the caller may administer the given organization:
+// platform admins and service accounts (already checked for scope) manage every
+// organization, other callers only their own.
+func canManageOrganization(c *gin.Context, organizationID uint) bool {
+	if _, isServiceAccount := c.Get("x-service-account-id"); isServiceAccount {
+		return true
+	}
+	return isPlatformAdmin(c) || c.GetUint("x-organization-id") == organizationID
+}
@@ -0,0 +1,18 @@
+package controller
+
+import "github.com/gabrielfmcoelho/platform-core/domain"
+
+// errorCode returns the code sent with the errors a client has to tell apart, empty for the others
+func errorCode(err error) string {
+	switch err {
+	case domain.ErrSubscriptionExpired:
+		return domain.ErrorCodeSubscriptionExpired
+	case domain.ErrSubscriptionInactive:
+		return domain.ErrorCodeSubscriptionInactive
+	case domain.ErrUsersLimitReached:
+		return domain.ErrorCodeUsersLimitReached
+	case domain.ErrReportsLimitReached:
+		return domain.ErrorCodeReportsLimitReached
+	}
+	return ""
+}
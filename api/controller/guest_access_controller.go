@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal"
+	"github.com/gabrielfmcoelho/platform-core/internal/parser"
+	"github.com/gin-gonic/gin"
+)
+
+type GuestAccessController struct {
+	GuestAccessUsecase domain.GuestAccessUsecase
+	Env                *bootstrap.Env
+}
+
+// @Summary Get the guest access of an organization
+// @Description Whether visitors may sign in as guests of the organization, for how long and which services they may launch. Organization admins can only see their own organization.
+// @Tags Organization
+// @ID getOrganizationGuestAccess
+// @Security BearerAuth
+// @Produce json
+// @Param identifier path int true "Organization ID"
+// @Success 200 {object} domain.SuccessResponse{data=domain.PublicGuestAccess} "Guest access"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid organization ID"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - Not an admin of this organization"
+// @Failure 404 {object} domain.ErrorResponse "Not Found"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /organization/{identifier}/guest-access [get]
+func (gac *GuestAccessController) GetGuestAccess(c *gin.Context) {
+	id, err := internal.ParseUint(c.Param("identifier"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid organization ID"})
+		return
+	}
+
+	if !canManageOrganization(c, id) {
+		c.JSON(http.StatusForbidden, domain.ErrorResponse{Message: domain.ErrForbidden.Error()})
+		return
+	}
+
+	guestAccess, err := gac.GuestAccessUsecase.Get(c, id)
+	if err != nil {
+		gac.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(guestAccess))
+}
+
+// @Summary Configure the guest access of an organization
+// @Description Enables or disables guest sign-in, sets the guest session length in minutes (kept when omitted) and replaces the services guests may launch, which must be subscribed by the organization. Organization admins can only change their own organization.
+// @Tags Organization
+// @ID setOrganizationGuestAccess
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Organization ID"
+// @Param config body domain.GuestAccessConfig true "Guest access"
+// @Success 200 {object} domain.SuccessResponse{data=domain.PublicGuestAccess} "Updated guest access"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid input or service not subscribed"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - Not an admin of this organization"
+// @Failure 404 {object} domain.ErrorResponse "Not Found"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /organization/{id}/guest-access [put]
+func (gac *GuestAccessController) SetGuestAccess(c *gin.Context) {
+	id, err := internal.ParseUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid organization ID"})
+		return
+	}
+
+	if !canManageOrganization(c, id) {
+		c.JSON(http.StatusForbidden, domain.ErrorResponse{Message: domain.ErrForbidden.Error()})
+		return
+	}
+
+	var config domain.GuestAccessConfig
+	if err := c.ShouldBindJSON(&config); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{
+			Message: "Invalid input: " + err.Error(),
+		})
+		return
+	}
+
+	guestAccess, err := gac.GuestAccessUsecase.Configure(c, id, &config)
+	if err != nil {
+		gac.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(guestAccess))
+}
+
+func (gac *GuestAccessController) respondError(c *gin.Context, err error) {
+	switch err {
+	case domain.ErrNotFound:
+		c.JSON(http.StatusNotFound, domain.ErrorResponse{Message: "Organization not found"})
+	case domain.ErrServiceNotSubscribed:
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: err.Error()})
+	}
+}
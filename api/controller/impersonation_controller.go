@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal"
+	"github.com/gabrielfmcoelho/platform-core/internal/parser"
+	"github.com/gin-gonic/gin"
+)
+
+type ImpersonationController struct {
+	ImpersonationUsecase domain.ImpersonationUsecase
+	Env                  *bootstrap.Env
+}
+
+// @Summary Impersonate a user
+// @Description Issues a short-lived access token of the user for support. The token carries the admin in its act claim, cannot be refreshed and only allows reading: any other method is refused until the impersonation is stopped. The start is recorded in the user logs.
+// @Tags Admin
+// @ID startImpersonation
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param userId path int true "User ID"
+// @Param request body domain.StartImpersonationRequest true "Reason of the access"
+// @Success 201 {object} domain.SuccessResponse{data=domain.ImpersonationResponse} "Impersonation token"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid input or user cannot be impersonated"
+// @Failure 404 {object} domain.ErrorResponse "Not Found"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /admin/users/{userId}/impersonate [post]
+func (ic *ImpersonationController) StartImpersonation(c *gin.Context) {
+	userID, err := internal.ParseUint(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid user ID"})
+		return
+	}
+
+	var request domain.StartImpersonationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{
+			Message: "Invalid input: " + err.Error(),
+		})
+		return
+	}
+
+	response, err := ic.ImpersonationUsecase.Start(c, uint(c.GetInt("x-user-id")), userID, request.Reason, clientInfo(c))
+	if err != nil {
+		ic.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, parser.ToSuccessResponse(response))
+}
+
+// @Summary Stop impersonating
+// @Description Ends the impersonation of the token used to call it. The token is refused from then on and the stop is recorded in the user logs.
+// @Tags Impersonation
+// @ID stopImpersonation
+// @Security BearerAuth
+// @Success 204 "No Content"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Not an impersonation token"
+// @Failure 409 {object} domain.ErrorResponse "Conflict - Impersonation already ended"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /impersonation/stop [post]
+func (ic *ImpersonationController) StopImpersonation(c *gin.Context) {
+	impersonationID := c.GetUint("x-impersonation-id")
+	if impersonationID == 0 {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: domain.ErrNotImpersonating.Error()})
+		return
+	}
+
+	if err := ic.ImpersonationUsecase.Stop(c, impersonationID, clientInfo(c)); err != nil {
+		ic.respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary List accesses by support
+// @Description Lists when platform admins accessed the account of the authenticated user, who and why
+// @Tags Impersonation
+// @ID fetchMyImpersonations
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} domain.SuccessResponse{data=[]domain.PublicImpersonation} "Impersonations"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /me/impersonations [get]
+func (ic *ImpersonationController) FetchMyImpersonations(c *gin.Context) {
+	impersonations, err := ic.ImpersonationUsecase.FetchByUserID(c, uint(c.GetInt("x-user-id")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(impersonations))
+}
+
+// @Summary List impersonations of a user
+// @Description Lists every impersonation of a user, running or ended
+// @Tags Admin
+// @ID fetchUserImpersonations
+// @Security BearerAuth
+// @Produce json
+// @Param userId path int true "User ID"
+// @Success 200 {object} domain.SuccessResponse{data=[]domain.PublicImpersonation} "Impersonations"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid user ID"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /admin/users/{userId}/impersonations [get]
+func (ic *ImpersonationController) FetchUserImpersonations(c *gin.Context) {
+	userID, err := internal.ParseUint(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid user ID"})
+		return
+	}
+
+	impersonations, err := ic.ImpersonationUsecase.FetchByUserID(c, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(impersonations))
+}
+
+func (ic *ImpersonationController) respondError(c *gin.Context, err error) {
+	switch err {
+	case domain.ErrCannotImpersonate:
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+	case domain.ErrNotFound:
+		c.JSON(http.StatusNotFound, domain.ErrorResponse{Message: err.Error()})
+	case domain.ErrImpersonationEnded:
+		c.JSON(http.StatusConflict, domain.ErrorResponse{Message: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: err.Error()})
+	}
+}
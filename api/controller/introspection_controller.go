@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal/parser"
+	"github.com/gin-gonic/gin"
+)
+
+type IntrospectionController struct {
+	IntrospectionUsecase domain.IntrospectionUsecase
+	Env                  *bootstrap.Env
+}
+
+// @Summary Introspect a token
+// @Description RFC 7662 token introspection for service accounts holding the tokens:introspect scope. Accepts user access tokens, refresh tokens and service account tokens; unknown, expired or revoked tokens return only active=false.
+// @Tags Auth Service Account
+// @ID introspectToken
+// @Security BearerAuth
+// @Accept x-www-form-urlencoded,json
+// @Produce json
+// @Param token formData string true "Token to introspect"
+// @Param token_type_hint formData string false "access_token or refresh_token"
+// @Success 200 {object} domain.IntrospectionResponse "Token state"
+// @Failure 400 {object} domain.OAuthErrorResponse "invalid_request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden"
+// @Failure 500 {object} domain.OAuthErrorResponse "server_error"
+// @Router /introspect [post]
+func (ic *IntrospectionController) Introspect(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	var request domain.IntrospectionRequest
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, domain.OAuthErrorResponse{Error: "invalid_request", ErrorDescription: err.Error()})
+		return
+	}
+
+	response, err := ic.IntrospectionUsecase.Introspect(c, &request, ic.Env.ServiceAccountTokenSecret)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.OAuthErrorResponse{Error: "server_error", ErrorDescription: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Get the authenticated user info
+// @Description Returns the user of the access token with its organization, roles and the services the user can launch (active, subscription in good standing, guest allow list)
+// @Tags Auth User
+// @ID getUserInfo
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} domain.SuccessResponse{data=domain.UserInfo} "User info"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Failure 404 {object} domain.ErrorResponse "Not Found"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /userinfo [get]
+func (ic *IntrospectionController) GetUserInfo(c *gin.Context) {
+	userInfo, err := ic.IntrospectionUsecase.GetUserInfo(c, uint(c.GetInt("x-user-id")))
+	if err != nil {
+		switch err {
+		case domain.ErrNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse{Message: "User not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(userInfo))
+}
@@ -0,0 +1,227 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal"
+	"github.com/gabrielfmcoelho/platform-core/internal/parser"
+	"github.com/gin-gonic/gin"
+)
+
+type InvitationController struct {
+	InvitationUsecase domain.InvitationUsecase
+	Env               *bootstrap.Env
+}
+
+// @Summary Invite a member
+// @Description E-mails an invitation to join the organization with the given role. The invitee chooses their password when accepting. Organization admins can only invite to their own organization and never to a role above their own.
+// @Tags Invitation
+// @ID createInvitation
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Organization ID"
+// @Param invitation body domain.CreateInvitation true "Invitation"
+// @Success 201 {object} domain.SuccessResponse{data=domain.PublicInvitation} "Invitation sent"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid input"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - Not an admin of this organization, role above the caller or users limit reached"
+// @Failure 404 {object} domain.ErrorResponse "Not Found"
+// @Failure 409 {object} domain.ErrorResponse "Conflict - User already exists or invitation already pending"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /organization/{id}/invitations [post]
+func (ic *InvitationController) CreateInvitation(c *gin.Context) {
+	organizationID, err := internal.ParseUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid organization ID"})
+		return
+	}
+
+	if !canManageOrganization(c, organizationID) {
+		c.JSON(http.StatusForbidden, domain.ErrorResponse{Message: domain.ErrForbidden.Error()})
+		return
+	}
+
+	var request domain.CreateInvitation
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{
+			Message: "Invalid input: " + err.Error(),
+		})
+		return
+	}
+
+	// lower role IDs are more privileged: nobody grants more than they have
+	if roleID := c.GetUint("x-user-role-id"); roleID != 0 && !isPlatformAdmin(c) && request.RoleID < roleID {
+		c.JSON(http.StatusForbidden, domain.ErrorResponse{Message: domain.ErrForbidden.Error()})
+		return
+	}
+
+	invitation, err := ic.InvitationUsecase.Invite(c, organizationID, uint(c.GetInt("x-user-id")), &request, clientInfo(c))
+	if err != nil {
+		ic.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, parser.ToSuccessResponse(invitation))
+}
+
+// @Summary List invitations
+// @Description Invitations of the organization still waiting for an answer, pending or expired
+// @Tags Invitation
+// @ID fetchInvitations
+// @Security BearerAuth
+// @Produce json
+// @Param identifier path int true "Organization ID"
+// @Success 200 {object} domain.SuccessResponse{data=[]domain.PublicInvitation} "Invitations"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid organization ID"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - Not an admin of this organization"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /organization/{identifier}/invitations [get]
+func (ic *InvitationController) FetchInvitations(c *gin.Context) {
+	organizationID, err := internal.ParseUint(c.Param("identifier"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid organization ID"})
+		return
+	}
+
+	if !canManageOrganization(c, organizationID) {
+		c.JSON(http.StatusForbidden, domain.ErrorResponse{Message: domain.ErrForbidden.Error()})
+		return
+	}
+
+	invitations, err := ic.InvitationUsecase.FetchByOrganization(c, organizationID)
+	if err != nil {
+		ic.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(invitations))
+}
+
+// @Summary Resend an invitation
+// @Description E-mails a new link and restarts the expiry of the invitation. Links sent before stop working.
+// @Tags Invitation
+// @ID resendInvitation
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Organization ID"
+// @Param invitationId path int true "Invitation ID"
+// @Success 200 {object} domain.SuccessResponse{data=domain.PublicInvitation} "Invitation resent"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid ID"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - Not an admin of this organization"
+// @Failure 404 {object} domain.ErrorResponse "Not Found"
+// @Failure 409 {object} domain.ErrorResponse "Conflict - Invitation already accepted or revoked"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /organization/{id}/invitations/{invitationId}/resend [post]
+func (ic *InvitationController) ResendInvitation(c *gin.Context) {
+	organizationID, invitationID, ok := ic.parseIDs(c)
+	if !ok {
+		return
+	}
+
+	invitation, err := ic.InvitationUsecase.Resend(c, organizationID, invitationID, clientInfo(c))
+	if err != nil {
+		ic.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(invitation))
+}
+
+// @Summary Revoke an invitation
+// @Description Cancels an invitation, its link stops working
+// @Tags Invitation
+// @ID revokeInvitation
+// @Security BearerAuth
+// @Param id path int true "Organization ID"
+// @Param invitationId path int true "Invitation ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid ID"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - Not an admin of this organization"
+// @Failure 404 {object} domain.ErrorResponse "Not Found"
+// @Failure 409 {object} domain.ErrorResponse "Conflict - Invitation already accepted or revoked"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /organization/{id}/invitations/{invitationId} [delete]
+func (ic *InvitationController) RevokeInvitation(c *gin.Context) {
+	organizationID, invitationID, ok := ic.parseIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := ic.InvitationUsecase.Revoke(c, organizationID, invitationID); err != nil {
+		ic.respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Accept an invitation
+// @Description Creates the account of the invitee with the token received by e-mail and the chosen password. The new member counts against the users limit of the organization subscription.
+// @Tags Invitation
+// @ID acceptInvitation
+// @Accept json
+// @Produce json
+// @Param request body domain.AcceptInvitationRequest true "Accept Invitation Request"
+// @Success 201 {object} domain.SuccessResponse "Account created"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid input or invalid/expired invitation"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - Users limit reached"
+// @Failure 409 {object} domain.ErrorResponse "Conflict - User already exists"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /invitations/accept [post]
+func (ic *InvitationController) AcceptInvitation(c *gin.Context) {
+	var request domain.AcceptInvitationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	if err := ic.InvitationUsecase.Accept(c, &request, clientInfo(c)); err != nil {
+		if respondPasswordRejected(c, err) {
+			return
+		}
+		switch err {
+		case domain.ErrInvalidInvitation:
+			c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		default:
+			ic.respondError(c, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, domain.SuccessResponse{Message: "Invitation accepted, the account was created."})
+}
+
+// parseIDs reads the organization and invitation IDs and checks the caller manages the organization
+func (ic *InvitationController) parseIDs(c *gin.Context) (organizationID uint, invitationID uint, ok bool) {
+	organizationID, err := internal.ParseUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid organization ID"})
+		return 0, 0, false
+	}
+	invitationID, err = internal.ParseUint(c.Param("invitationId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid invitation ID"})
+		return 0, 0, false
+	}
+
+	if !canManageOrganization(c, organizationID) {
+		c.JSON(http.StatusForbidden, domain.ErrorResponse{Message: domain.ErrForbidden.Error()})
+		return 0, 0, false
+	}
+	return organizationID, invitationID, true
+}
+
+func (ic *InvitationController) respondError(c *gin.Context, err error) {
+	switch err {
+	case domain.ErrNotFound:
+		c.JSON(http.StatusNotFound, domain.ErrorResponse{Message: err.Error()})
+	case domain.ErrUsersLimitReached:
+		c.JSON(http.StatusForbidden, domain.ErrorResponse{Message: err.Error(), Code: errorCode(err)})
+	case domain.ErrUserAlreadyExists, domain.ErrInvitationPending, domain.ErrInvalidInvitation:
+		c.JSON(http.StatusConflict, domain.ErrorResponse{Message: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: err.Error()})
+	}
+}
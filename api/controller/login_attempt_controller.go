@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal"
+	"github.com/gabrielfmcoelho/platform-core/internal/parser"
+	"github.com/gin-gonic/gin"
+)
+
+type LoginAttemptController struct {
+	LoginAttemptUsecase domain.LoginAttemptUsecase
+	Env                 *bootstrap.Env
+}
+
+// @Summary Get the login lockout of a user
+// @Description Failed login attempts, lockouts and current lockout of a user
+// @Tags Admin
+// @ID getUserLockout
+// @Security BearerAuth
+// @Produce json
+// @Param userId path int true "User ID"
+// @Success 200 {object} domain.SuccessResponse{data=domain.LoginLockoutStatus} "Lockout status"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid user ID"
+// @Failure 404 {object} domain.ErrorResponse "Not Found"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /admin/users/{userId}/lockout [get]
+func (lac *LoginAttemptController) GetUserLockout(c *gin.Context) {
+	userID, err := internal.ParseUint(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid user ID"})
+		return
+	}
+
+	status, err := lac.LoginAttemptUsecase.GetStatus(c, userID)
+	if err != nil {
+		lac.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(status))
+}
+
+// @Summary Unlock a user
+// @Description Clears the failed login attempts and the lockout of a user
+// @Tags Admin
+// @ID unlockUser
+// @Security BearerAuth
+// @Param userId path int true "User ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid user ID"
+// @Failure 404 {object} domain.ErrorResponse "Not Found"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /admin/users/{userId}/lockout [delete]
+func (lac *LoginAttemptController) UnlockUser(c *gin.Context) {
+	userID, err := internal.ParseUint(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid user ID"})
+		return
+	}
+
+	if err := lac.LoginAttemptUsecase.Unlock(c, userID, clientInfo(c)); err != nil {
+		lac.respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Unlock a client IP
+// @Description Clears the failed login attempts and the lockout of a client IP address
+// @Tags Admin
+// @ID unlockIP
+// @Security BearerAuth
+// @Param ip path string true "IP address"
+// @Success 204 "No Content"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /admin/ip-lockouts/{ip} [delete]
+func (lac *LoginAttemptController) UnlockIP(c *gin.Context) {
+	if err := lac.LoginAttemptUsecase.UnlockIP(c, c.Param("ip")); err != nil {
+		lac.respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (lac *LoginAttemptController) respondError(c *gin.Context, err error) {
+	switch err {
+	case domain.ErrNotFound:
+		c.JSON(http.StatusNotFound, domain.ErrorResponse{Message: "User not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: err.Error()})
+	}
+}
@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal"
+	"github.com/gabrielfmcoelho/platform-core/internal/parser"
+	"github.com/gin-gonic/gin"
+)
+
+type MetricsController struct {
+	MetricsUsecase domain.MetricsUsecase
+	Env            *bootstrap.Env
+}
+
+// @Summary Get the metrics of an organization
+// @Description Members, subscribed services, reports (in total, in the current month and the last one) and usage time in seconds of the organization. The counters are kept up to date as the events happen. Organization admins can only see their own organization.
+// @Tags Organization
+// @ID getOrganizationMetrics
+// @Security BearerAuth
+// @Produce json
+// @Param identifier path int true "Organization ID"
+// @Success 200 {object} domain.SuccessResponse{data=domain.PublicOrganizationMetrics} "Organization metrics"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid organization ID"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - Not an admin of this organization"
+// @Failure 404 {object} domain.ErrorResponse "Not Found"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /organization/{identifier}/metrics [get]
+func (mc *MetricsController) GetOrganizationMetrics(c *gin.Context) {
+	id, err := internal.ParseUint(c.Param("identifier"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid organization ID"})
+		return
+	}
+
+	if !canManageOrganization(c, id) {
+		c.JSON(http.StatusForbidden, domain.ErrorResponse{Message: domain.ErrForbidden.Error()})
+		return
+	}
+
+	metrics, err := mc.MetricsUsecase.GetOrganizationMetrics(c, id)
+	if err != nil {
+		switch err {
+		case domain.ErrNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse{Message: "Organization not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: "Failed to get organization metrics: " + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(metrics))
+}
+
+// @Summary Get the metrics of a user
+// @Description Logins (count, last one and its IP), usage time in seconds and most used service of the user. Users can see their own metrics, admins and managers those of the members of their organization, service accounts with the statistics:read scope those of every user.
+// @Tags User
+// @ID getUserMetrics
+// @Security BearerAuth
+// @Produce json
+// @Param identifier path int true "User ID"
+// @Success 200 {object} domain.SuccessResponse{data=domain.PublicUserMetrics} "User metrics"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid user ID"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden"
+// @Failure 404 {object} domain.ErrorResponse "Not Found"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /user/{identifier}/metrics [get]
+func (mc *MetricsController) GetUserMetrics(c *gin.Context) {
+	id, err := internal.ParseUint(c.Param("identifier"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid user ID"})
+		return
+	}
+
+	metrics, err := mc.MetricsUsecase.GetUserMetrics(c, id)
+	if err != nil {
+		switch err {
+		case domain.ErrNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse{Message: "User not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: "Failed to get user metrics: " + err.Error()})
+		}
+		return
+	}
+
+	// the metrics of another user are reserved to the managers of its organization; service
+	// accounts were already checked for statistics:read and have no role of their own
+	self := uint(c.GetInt("x-user-id")) == id
+	if _, isServiceAccount := c.Get("x-service-account-id"); !self && !isServiceAccount {
+		roleID := c.GetUint("x-user-role-id")
+		if roleID != domain.UserRoleAdmin && roleID != domain.UserRoleManager || !canManageOrganization(c, metrics.OrganizationID) {
+			c.JSON(http.StatusForbidden, domain.ErrorResponse{Message: domain.ErrForbidden.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(metrics))
+}
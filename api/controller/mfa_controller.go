@@ -0,0 +1,182 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal"
+	"github.com/gabrielfmcoelho/platform-core/internal/parser"
+	"github.com/gin-gonic/gin"
+)
+
+type MFAController struct {
+	MFAUsecase domain.MFAUsecase
+	Env        *bootstrap.Env
+}
+
+// @Summary Get MFA status
+// @Description Returns whether the authenticated user has TOTP enabled, whether the organization requires it and how many recovery codes are left
+// @Tags MFA
+// @ID getMFAStatus
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} domain.SuccessResponse{data=domain.MFAStatus} "MFA status"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /me/mfa [get]
+func (mc *MFAController) GetStatus(c *gin.Context) {
+	status, err := mc.MFAUsecase.GetStatus(c, uint(c.GetInt("x-user-id")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(status))
+}
+
+// @Summary Start TOTP enrollment
+// @Description Generates a new TOTP secret and the otpauth:// URI to render as a QR code. The factor is only enabled after confirming a code on /me/mfa/totp/verify.
+// @Tags MFA
+// @ID enrollTOTP
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} domain.SuccessResponse{data=domain.MFAEnrollment} "TOTP secret and provisioning URI"
+// @Failure 409 {object} domain.ErrorResponse "Conflict - MFA already enabled"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /me/mfa/totp/enroll [post]
+func (mc *MFAController) Enroll(c *gin.Context) {
+	enrollment, err := mc.MFAUsecase.Enroll(c, uint(c.GetInt("x-user-id")))
+	if err != nil {
+		switch err {
+		case domain.ErrMFAAlreadyEnabled:
+			c.JSON(http.StatusConflict, domain.ErrorResponse{Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(enrollment))
+}
+
+// @Summary Confirm TOTP enrollment
+// @Description Enables TOTP with a first code from the authenticator app and returns the recovery codes. They are shown only once.
+// @Tags MFA
+// @ID verifyTOTP
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param mfaCode body domain.MFACodeRequest true "TOTP code"
+// @Success 200 {object} domain.SuccessResponse{data=domain.MFARecoveryCodes} "Recovery codes"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid code or enrollment not started"
+// @Failure 409 {object} domain.ErrorResponse "Conflict - MFA already enabled"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /me/mfa/totp/verify [post]
+func (mc *MFAController) Activate(c *gin.Context) {
+	var request domain.MFACodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	recoveryCodes, err := mc.MFAUsecase.Activate(c, uint(c.GetInt("x-user-id")), request.Code)
+	if err != nil {
+		mc.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(recoveryCodes))
+}
+
+// @Summary Regenerate recovery codes
+// @Description Replaces all recovery codes. Requires a current TOTP code.
+// @Tags MFA
+// @ID regenerateRecoveryCodes
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param mfaCode body domain.MFACodeRequest true "TOTP code"
+// @Success 200 {object} domain.SuccessResponse{data=domain.MFARecoveryCodes} "Recovery codes"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid code or MFA not enabled"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /me/mfa/recovery-codes [post]
+func (mc *MFAController) RegenerateRecoveryCodes(c *gin.Context) {
+	var request domain.MFACodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	recoveryCodes, err := mc.MFAUsecase.RegenerateRecoveryCodes(c, uint(c.GetInt("x-user-id")), request.Code)
+	if err != nil {
+		mc.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(recoveryCodes))
+}
+
+// @Summary Disable TOTP
+// @Description Removes the TOTP factor and the recovery codes. Requires a TOTP or recovery code. Not allowed when the organization requires MFA.
+// @Tags MFA
+// @ID disableTOTP
+// @Security BearerAuth
+// @Accept json
+// @Param mfaCode body domain.MFACodeRequest true "TOTP or recovery code"
+// @Success 204 "No Content"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid code or MFA not enabled"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - MFA required by the organization"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /me/mfa/totp [delete]
+func (mc *MFAController) Disable(c *gin.Context) {
+	var request domain.MFACodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	if err := mc.MFAUsecase.Disable(c, uint(c.GetInt("x-user-id")), request.Code, request.RecoveryCode); err != nil {
+		mc.respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Reset the MFA of a user
+// @Description Removes the TOTP factor of a user who lost their device and recovery codes. If the organization requires MFA the user enrolls again on next login.
+// @Tags Admin
+// @ID resetUserMFA
+// @Security BearerAuth
+// @Param userId path int true "User ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid user ID or MFA not enabled"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /admin/users/{userId}/mfa [delete]
+func (mc *MFAController) ResetUserMFA(c *gin.Context) {
+	userID, err := internal.ParseUint(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid user ID"})
+		return
+	}
+
+	if err := mc.MFAUsecase.Reset(c, userID); err != nil {
+		mc.respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (mc *MFAController) respondError(c *gin.Context, err error) {
+	switch err {
+	case domain.ErrInvalidMFACode, domain.ErrMFANotEnrolled:
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+	case domain.ErrMFAAlreadyEnabled:
+		c.JSON(http.StatusConflict, domain.ErrorResponse{Message: err.Error()})
+	case domain.ErrMFARequired:
+		c.JSON(http.StatusForbidden, domain.ErrorResponse{Message: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: err.Error()})
+	}
+}
@@ -0,0 +1,190 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal"
+	"github.com/gabrielfmcoelho/platform-core/internal/parser"
+	"github.com/gin-gonic/gin"
+)
+
+type OAuthClientController struct {
+	OAuthClientUsecase domain.OAuthClientUsecase
+	Env                *bootstrap.Env
+}
+
+// @Summary Create an OAuth client
+// @Description Registers an application of a service allowed to sign users in through /authorize. The client secret is only returned in this response; public clients get none and rely on PKCE.
+// @Tags Admin
+// @ID createOAuthClient
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param oauthClient body domain.CreateOAuthClient true "OAuth client"
+// @Success 201 {object} domain.SuccessResponse{data=domain.OAuthClientCredentials} "OAuth client credentials"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid input"
+// @Failure 404 {object} domain.ErrorResponse "Service not found"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /admin/oauth-clients [post]
+func (occ *OAuthClientController) CreateOAuthClient(c *gin.Context) {
+	var create domain.CreateOAuthClient
+	if err := c.ShouldBindJSON(&create); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{
+			Message: "Invalid input: " + err.Error(),
+		})
+		return
+	}
+
+	credentials, err := occ.OAuthClientUsecase.Create(c, &create)
+	if err != nil {
+		switch err {
+		case domain.ErrNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse{Message: "Service not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse{
+				Message: "Failed to create OAuth client: " + err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, parser.ToSuccessResponse(credentials))
+}
+
+// @Summary Get all OAuth clients
+// @Description Lists the registered OAuth clients (without secrets)
+// @Tags Admin
+// @ID fetchOAuthClients
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} domain.SuccessResponse{data=[]domain.PublicOAuthClient} "List of OAuth clients"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /admin/oauth-clients [get]
+func (occ *OAuthClientController) FetchOAuthClients(c *gin.Context) {
+	clients, err := occ.OAuthClientUsecase.Fetch(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{
+			Message: "Failed to fetch OAuth clients: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(clients))
+}
+
+// @Summary Update an OAuth client
+// @Description Changes the name, the redirect URIs and the first-party flag (consent skipped) of a client
+// @Tags Admin
+// @ID updateOAuthClient
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "OAuth client ID"
+// @Param oauthClient body domain.UpdateOAuthClient true "OAuth client"
+// @Success 200 {object} domain.SuccessResponse{data=domain.PublicOAuthClient} "Updated OAuth client"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid input"
+// @Failure 404 {object} domain.ErrorResponse "Not Found"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /admin/oauth-clients/{id} [put]
+func (occ *OAuthClientController) UpdateOAuthClient(c *gin.Context) {
+	id, err := internal.ParseUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid OAuth client ID"})
+		return
+	}
+
+	var update domain.UpdateOAuthClient
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{
+			Message: "Invalid input: " + err.Error(),
+		})
+		return
+	}
+
+	client, err := occ.OAuthClientUsecase.Update(c, id, &update)
+	if err != nil {
+		switch err {
+		case domain.ErrNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse{Message: "OAuth client not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse{
+				Message: "Failed to update OAuth client: " + err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(client))
+}
+
+// @Summary Rotate an OAuth client secret
+// @Description Issues a new client secret; the previous one stops working immediately. Public clients have no secret.
+// @Tags Admin
+// @ID rotateOAuthClientSecret
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "OAuth client ID"
+// @Success 200 {object} domain.SuccessResponse{data=domain.OAuthClientCredentials} "New OAuth client credentials"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Public client"
+// @Failure 404 {object} domain.ErrorResponse "Not Found"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /admin/oauth-clients/{id}/rotate-secret [post]
+func (occ *OAuthClientController) RotateOAuthClientSecret(c *gin.Context) {
+	id, err := internal.ParseUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid OAuth client ID"})
+		return
+	}
+
+	credentials, err := occ.OAuthClientUsecase.RotateSecret(c, id)
+	if err != nil {
+		switch err {
+		case domain.ErrNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse{Message: "OAuth client not found"})
+		case domain.ErrBadRequest:
+			c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Public clients have no secret"})
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse{
+				Message: "Failed to rotate OAuth client secret: " + err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(credentials))
+}
+
+// @Summary Delete an OAuth client
+// @Description Revokes an OAuth client; its pending codes can no longer be redeemed and tokens already issued remain valid until they expire
+// @Tags Admin
+// @ID deleteOAuthClient
+// @Security BearerAuth
+// @Param id path int true "OAuth client ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request"
+// @Failure 404 {object} domain.ErrorResponse "Not Found"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /admin/oauth-clients/{id} [delete]
+func (occ *OAuthClientController) DeleteOAuthClient(c *gin.Context) {
+	id, err := internal.ParseUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid OAuth client ID"})
+		return
+	}
+
+	if err := occ.OAuthClientUsecase.Delete(c, id); err != nil {
+		switch err {
+		case domain.ErrNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse{Message: "OAuth client not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse{
+				Message: "Failed to delete OAuth client: " + err.Error(),
+			})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
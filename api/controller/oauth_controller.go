@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal/parser"
+	"github.com/gin-gonic/gin"
+)
+
+type OAuthController struct {
+	OAuthUsecase domain.OAuthUsecase
+	Env          *bootstrap.Env
+}
+
+// @Summary Authorize a service to sign the user in
+// @Description Authorization request of an OAuth client (RFC 6749 section 4.1.1, PKCE with S256 required, openid scope required). The hub forwards the query of the client and sends the browser to redirect_to, which carries the code or the error for the client. Third-party clients the user never consented to answer with consent_required; the hub then shows the client and its scopes and posts the answer. The organization of the user must subscribe to the service of the client.
+// @Tags OAuth
+// @ID authorize
+// @Security BearerAuth
+// @Produce json
+// @Param response_type query string true "Must be code"
+// @Param client_id query string true "Client ID"
+// @Param redirect_uri query string true "One of the registered redirect URIs"
+// @Param scope query string true "Space delimited scopes, openid required"
+// @Param state query string false "Opaque value returned to the client"
+// @Param nonce query string false "Copied into the ID token"
+// @Param code_challenge query string true "PKCE code challenge"
+// @Param code_challenge_method query string true "Must be S256"
+// @Success 200 {object} domain.SuccessResponse{data=domain.AuthorizeResponse} "Redirect or consent required"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Unknown client or redirect URI"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - Impersonated session"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /authorize [get]
+func (oc *OAuthController) Authorize(c *gin.Context) {
+	// an impersonated session must never sign in to another service as the user
+	if _, impersonating := c.Get("x-impersonation-id"); impersonating {
+		c.JSON(http.StatusForbidden, domain.ErrorResponse{Message: domain.ErrImpersonationReadOnly.Error()})
+		return
+	}
+
+	var request domain.AuthorizeRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{
+			Message: "Invalid input: " + err.Error(),
+		})
+		return
+	}
+
+	response, err := oc.OAuthUsecase.Authorize(c, uint(c.GetInt("x-user-id")), &request)
+	if err != nil {
+		oc.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(response))
+}
+
+// @Summary Answer the consent screen of a client
+// @Description Records whether the user grants the requested scopes to a third-party client and returns the redirect for the client: a code when approved, access_denied otherwise. The body repeats the parameters of the authorization request.
+// @Tags OAuth
+// @ID consent
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param consent body domain.ConsentRequest true "Authorization request and the answer of the user"
+// @Success 200 {object} domain.SuccessResponse{data=domain.AuthorizeResponse} "Redirect for the client"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid input, unknown client or redirect URI"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - Impersonated session"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /authorize [post]
+func (oc *OAuthController) Consent(c *gin.Context) {
+	var request domain.ConsentRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{
+			Message: "Invalid input: " + err.Error(),
+		})
+		return
+	}
+
+	response, err := oc.OAuthUsecase.Consent(c, uint(c.GetInt("x-user-id")), &request)
+	if err != nil {
+		oc.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(response))
+}
+
+// @Summary Get the claims of the signed-in user
+// @Description OpenID Connect UserInfo endpoint. Takes the access token returned by /token with the authorization_code grant; the claims are filtered by its scopes.
+// @Tags OAuth
+// @ID userInfo
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Success 200 {object} domain.UserInfoResponse "Claims of the user"
+// @Failure 401 {object} domain.OAuthErrorResponse "invalid_token"
+// @Failure 500 {object} domain.OAuthErrorResponse "server_error"
+// @Router /oauth/userinfo [get]
+func (oc *OAuthController) UserInfo(c *gin.Context) {
+	accessToken, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !found || accessToken == "" {
+		c.Header("WWW-Authenticate", `Bearer realm="oauth"`)
+		c.JSON(http.StatusUnauthorized, domain.OAuthErrorResponse{Error: "invalid_token", ErrorDescription: domain.ErrInvalidOAuthToken.Error()})
+		return
+	}
+
+	userInfo, err := oc.OAuthUsecase.UserInfo(c, accessToken)
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidOAuthToken:
+			c.Header("WWW-Authenticate", `Bearer realm="oauth", error="invalid_token"`)
+			c.JSON(http.StatusUnauthorized, domain.OAuthErrorResponse{Error: "invalid_token", ErrorDescription: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, domain.OAuthErrorResponse{Error: "server_error", ErrorDescription: err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, userInfo)
+}
+
+// @Summary OpenID Connect discovery document
+// @Description Provider metadata of the core, used by the services to configure their OpenID Connect client
+// @Tags OAuth
+// @ID openIDConfiguration
+// @Produce json
+// @Success 200 {object} domain.OpenIDConfiguration "Provider metadata"
+// @Router /.well-known/openid-configuration [get]
+func (oc *OAuthController) Discovery(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.JSON(http.StatusOK, oc.OAuthUsecase.Discovery())
+}
+
+func (oc *OAuthController) respondError(c *gin.Context, err error) {
+	switch err {
+	case domain.ErrInvalidRedirectURI:
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: err.Error()})
+	}
+}
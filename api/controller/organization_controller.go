@@ -2,6 +2,7 @@ package controller
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/gabrielfmcoelho/platform-core/bootstrap"
 	"github.com/gabrielfmcoelho/platform-core/domain"
@@ -16,14 +17,15 @@ type OrganizationController struct {
 }
 
 // @Summary Create a new organization
-// @Description Create a new organization with the input payload
+// @Description Create a new organization with the input payload. The name must be unique, archived organizations included.
 // @Tags Organization
 // @ID createOrganization
 // @Accept json
 // @Produce json
 // @Param organization body domain.CreateOrganization true "Organization object"
 // @Success 201 {object} domain.SuccessResponse{data=domain.PublicOrganization}
-// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid input"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid input or unknown organization role"
+// @Failure 409 {object} domain.ErrorResponse "Conflict - Name already in use"
 // @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
 // @Router /organization [post]
 func (oc *OrganizationController) CreateOrganization(c *gin.Context) {
@@ -37,38 +39,42 @@ func (oc *OrganizationController) CreateOrganization(c *gin.Context) {
 
 	// Create Organization object from CreateOrganization
 	organization := domain.Organization{
-		Name:   createOrg.Name,
-		RoleID: createOrg.OrganizationRoleID,
+		Name:     strings.TrimSpace(createOrg.Name),
+		Nickname: strings.TrimSpace(createOrg.Nickname),
+		LogoUrl:  createOrg.LogoUrl,
+		RoleID:   createOrg.OrganizationRoleID,
 	}
 
 	err := oc.OrganizationUsecase.Create(c, &organization)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{
-			Message: "Failed to create organization: " + err.Error(),
-		})
+		switch err {
+		case domain.ErrOrgRoleNotFound:
+			c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		case domain.ErrOrganizationNameTaken:
+			c.JSON(http.StatusConflict, domain.ErrorResponse{Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse{
+				Message: "Failed to create organization: " + err.Error(),
+			})
+		}
 		return
 	}
 
 	// Return the created organization
-	publicOrg := domain.PublicOrganization{
-		ID:      organization.ID,
-		Name:    organization.Name,
-		LogoUrl: organization.LogoUrl,
-	}
-
-	c.JSON(http.StatusCreated, parser.ToSuccessResponse(publicOrg))
+	c.JSON(http.StatusCreated, parser.ToSuccessResponse(parser.ToPublicOrganization(organization)))
 }
 
 // @Summary Get all organizations
-// @Description Get all organizations from the database
+// @Description Get all organizations from the database, or only the archived ones (to restore them)
 // @Tags Organization
 // @ID fetchOrganizations
 // @Produce json
+// @Param archived query bool false "List the archived organizations instead"
 // @Success 200 {object} domain.SuccessResponse{data=[]domain.PublicOrganization} "List of organizations"
 // @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
 // @Router /organizations [get]
 func (oc *OrganizationController) FetchOrganizations(c *gin.Context) {
-	organizations, err := oc.OrganizationUsecase.Fetch(c)
+	organizations, err := oc.OrganizationUsecase.Fetch(c, c.Query("archived") == "true")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{
 			Message: "Failed to fetch organizations: " + err.Error(),
@@ -80,12 +86,13 @@ func (oc *OrganizationController) FetchOrganizations(c *gin.Context) {
 }
 
 // @Summary Get organization by ID or name
-// @Description Get organization by ID or name
+// @Description Get the details of an organization by ID or name: role, user count, subscribed services and subscription status. Users only see their own organization.
 // @Tags Organization
 // @ID getOrganization
 // @Produce json
 // @Param identifier path string true "Organization ID or name"
-// @Success 200 {object} domain.SuccessResponse{data=domain.PublicOrganization} "Organization object"
+// @Success 200 {object} domain.SuccessResponse{data=domain.OrganizationDetails} "Organization details"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - Another organization"
 // @Failure 404 {object} domain.ErrorResponse "Not Found - Organization not found"
 // @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
 // @Router /organization/{identifier} [get]
@@ -103,6 +110,139 @@ func (oc *OrganizationController) GetOrganization(c *gin.Context) {
 		return
 	}
 
+	if !canManageOrganization(c, organization.ID) {
+		c.JSON(http.StatusForbidden, domain.ErrorResponse{Message: domain.ErrForbidden.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(organization))
+}
+
+// @Summary Update an organization
+// @Description Replaces the name, nickname and logo of an organization. Organization admins can only change their own organization; only platform admins change its role (organization_role_id, kept when omitted).
+// @Tags Organization
+// @ID updateOrganization
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Organization ID"
+// @Param organization body domain.UpdateOrganization true "Organization"
+// @Success 200 {object} domain.SuccessResponse{data=domain.OrganizationDetails} "Updated organization"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid input or unknown organization role"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - Not an admin of this organization or role change"
+// @Failure 404 {object} domain.ErrorResponse "Not Found"
+// @Failure 409 {object} domain.ErrorResponse "Conflict - Name already in use"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /organization/{id} [put]
+func (oc *OrganizationController) UpdateOrganization(c *gin.Context) {
+	var update domain.UpdateOrganization
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{
+			Message: "Invalid input: " + err.Error(),
+		})
+		return
+	}
+
+	patch := update.AsPatch()
+	oc.updateOrganization(c, &patch)
+}
+
+// @Summary Partially update an organization
+// @Description Changes only the fields sent; an empty nickname or logo_url clears it. Organization admins can only change their own organization; only platform admins change its role.
+// @Tags Organization
+// @ID patchOrganization
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Organization ID"
+// @Param organization body domain.PatchOrganization true "Fields to change"
+// @Success 200 {object} domain.SuccessResponse{data=domain.OrganizationDetails} "Updated organization"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid input or unknown organization role"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - Not an admin of this organization or role change"
+// @Failure 404 {object} domain.ErrorResponse "Not Found"
+// @Failure 409 {object} domain.ErrorResponse "Conflict - Name already in use"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /organization/{id} [patch]
+func (oc *OrganizationController) PatchOrganization(c *gin.Context) {
+	var patch domain.PatchOrganization
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{
+			Message: "Invalid input: " + err.Error(),
+		})
+		return
+	}
+
+	oc.updateOrganization(c, &patch)
+}
+
+func (oc *OrganizationController) updateOrganization(c *gin.Context, patch *domain.PatchOrganization) {
+	id, err := internal.ParseUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid organization ID"})
+		return
+	}
+
+	if !canManageOrganization(c, id) {
+		c.JSON(http.StatusForbidden, domain.ErrorResponse{Message: domain.ErrForbidden.Error()})
+		return
+	}
+
+	organization, err := oc.OrganizationUsecase.Update(c, id, patch, isPlatformAdmin(c))
+	if err != nil {
+		switch err {
+		case domain.ErrNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse{Message: "Organization not found"})
+		case domain.ErrForbidden:
+			c.JSON(http.StatusForbidden, domain.ErrorResponse{Message: "Only platform admins can change the role of an organization"})
+		case domain.ErrBadRequest:
+			c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Organization name is required"})
+		case domain.ErrOrgRoleNotFound:
+			c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		case domain.ErrOrganizationNameTaken:
+			c.JSON(http.StatusConflict, domain.ErrorResponse{Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse{
+				Message: "Failed to update organization: " + err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(organization))
+}
+
+// @Summary Restore an organization
+// @Description Brings back an archived (deleted) organization with its users, services and subscription
+// @Tags Organization
+// @ID restoreOrganization
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Organization ID"
+// @Success 200 {object} domain.SuccessResponse{data=domain.OrganizationDetails} "Restored organization"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request"
+// @Failure 404 {object} domain.ErrorResponse "Not Found - No archived organization with this ID"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /organization/{id}/restore [post]
+func (oc *OrganizationController) RestoreOrganization(c *gin.Context) {
+	id, err := internal.ParseUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid organization ID"})
+		return
+	}
+
+	organization, err := oc.OrganizationUsecase.Restore(c, id)
+	if err != nil {
+		switch err {
+		case domain.ErrNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse{Message: "Archived organization not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse{
+				Message: "Failed to restore organization: " + err.Error(),
+			})
+		}
+		return
+	}
+
 	c.JSON(http.StatusOK, parser.ToSuccessResponse(organization))
 }
 
@@ -133,3 +273,54 @@ func (oc *OrganizationController) DeleteOrganization(c *gin.Context) {
 
 	c.JSON(http.StatusOK, parser.ToSuccessResponse(gin.H{"message": "Organization deleted successfully"}))
 }
+
+// @Summary Set the MFA policy of an organization
+// @Description Requires (or stops requiring) MFA for every user of the organization. Users without a factor enroll on their next login. Organization admins can only change their own organization.
+// @Tags Organization
+// @ID setOrganizationMFAPolicy
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Organization ID"
+// @Param policy body domain.OrganizationMFAPolicy true "MFA policy"
+// @Success 200 {object} domain.SuccessResponse{data=domain.PublicOrganization} "Updated organization"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid input"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - Not an admin of this organization"
+// @Failure 404 {object} domain.ErrorResponse "Not Found"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /organization/{id}/mfa-policy [patch]
+func (oc *OrganizationController) SetMFAPolicy(c *gin.Context) {
+	id, err := internal.ParseUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid organization ID"})
+		return
+	}
+
+	if !canManageOrganization(c, id) {
+		c.JSON(http.StatusForbidden, domain.ErrorResponse{Message: domain.ErrForbidden.Error()})
+		return
+	}
+
+	var policy domain.OrganizationMFAPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{
+			Message: "Invalid input: " + err.Error(),
+		})
+		return
+	}
+
+	organization, err := oc.OrganizationUsecase.SetMFAPolicy(c, id, *policy.RequireMFA)
+	if err != nil {
+		switch err {
+		case domain.ErrNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse{Message: "Organization not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse{
+				Message: "Failed to update organization MFA policy: " + err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(organization))
+}
@@ -0,0 +1,204 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal"
+	"github.com/gabrielfmcoelho/platform-core/internal/parser"
+	"github.com/gin-gonic/gin"
+)
+
+type ServiceAccountController struct {
+	ServiceAccountUsecase domain.ServiceAccountUsecase
+	OAuthUsecase          domain.OAuthUsecase
+	Env                   *bootstrap.Env
+}
+
+// @Summary Issue a token
+// @Description OAuth2 client_credentials grant for service accounts (RFC 6749 section 4.4) and authorization_code grant with PKCE for OAuth clients (RFC 6749 section 4.1.3, RFC 7636), which also returns an ID token. Client credentials may be sent with HTTP Basic authentication or in the request body; public OAuth clients only send their client_id.
+// @Tags Auth Service Account
+// @ID issueToken
+// @Accept x-www-form-urlencoded,json
+// @Produce json
+// @Param grant_type formData string true "client_credentials or authorization_code"
+// @Param client_id formData string false "Client ID (when not using HTTP Basic)"
+// @Param client_secret formData string false "Client secret (when not using HTTP Basic)"
+// @Param scope formData string false "Space delimited subset of the account scopes (client_credentials)"
+// @Param code formData string false "Authorization code (authorization_code)"
+// @Param redirect_uri formData string false "Redirect URI of the authorization request (authorization_code)"
+// @Param code_verifier formData string false "PKCE code verifier (authorization_code)"
+// @Success 200 {object} domain.TokenResponse "Access token"
+// @Failure 400 {object} domain.OAuthErrorResponse "invalid_request, invalid_grant, invalid_scope or unsupported_grant_type"
+// @Failure 401 {object} domain.OAuthErrorResponse "invalid_client"
+// @Failure 500 {object} domain.OAuthErrorResponse "server_error"
+// @Router /token [post]
+func (sac *ServiceAccountController) Token(c *gin.Context) {
+	// RFC 6749 section 5.1: token responses must not be cached
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	var request domain.TokenRequest
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, domain.OAuthErrorResponse{Error: "invalid_request", ErrorDescription: err.Error()})
+		return
+	}
+
+	if clientID, clientSecret, ok := c.Request.BasicAuth(); ok {
+		request.ClientID = clientID
+		request.ClientSecret = clientSecret
+	}
+
+	var response domain.TokenResponse
+	var err error
+	if request.GrantType == domain.GrantTypeAuthorizationCode {
+		response, err = sac.OAuthUsecase.ExchangeCode(c, &request)
+	} else {
+		response, err = sac.ServiceAccountUsecase.IssueToken(c, &request, sac.Env.ServiceAccountTokenSecret, sac.Env.ServiceAccountTokenExpiryHour)
+	}
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidClient:
+			c.Header("WWW-Authenticate", `Basic realm="token"`)
+			c.JSON(http.StatusUnauthorized, domain.OAuthErrorResponse{Error: "invalid_client", ErrorDescription: err.Error()})
+		case domain.ErrInvalidGrant:
+			c.JSON(http.StatusBadRequest, domain.OAuthErrorResponse{Error: "invalid_grant", ErrorDescription: err.Error()})
+		case domain.ErrInvalidScope:
+			c.JSON(http.StatusBadRequest, domain.OAuthErrorResponse{Error: "invalid_scope", ErrorDescription: err.Error()})
+		case domain.ErrUnsupportedGrantType:
+			c.JSON(http.StatusBadRequest, domain.OAuthErrorResponse{Error: "unsupported_grant_type", ErrorDescription: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, domain.OAuthErrorResponse{Error: "server_error", ErrorDescription: err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Create a service account
+// @Description Registers a machine identity for another microservice. The client secret is only returned in this response.
+// @Tags Admin
+// @ID createServiceAccount
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param serviceAccount body domain.CreateServiceAccount true "Service account"
+// @Success 201 {object} domain.SuccessResponse{data=domain.ServiceAccountCredentials} "Service account credentials"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid input or scope"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /admin/service-accounts [post]
+func (sac *ServiceAccountController) CreateServiceAccount(c *gin.Context) {
+	var create domain.CreateServiceAccount
+	if err := c.ShouldBindJSON(&create); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{
+			Message: "Invalid input: " + err.Error(),
+		})
+		return
+	}
+
+	credentials, err := sac.ServiceAccountUsecase.Create(c, &create)
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidScope:
+			c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse{
+				Message: "Failed to create service account: " + err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, parser.ToSuccessResponse(credentials))
+}
+
+// @Summary Get all service accounts
+// @Description Lists the registered service accounts (without secrets)
+// @Tags Admin
+// @ID fetchServiceAccounts
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} domain.SuccessResponse{data=[]domain.PublicServiceAccount} "List of service accounts"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /admin/service-accounts [get]
+func (sac *ServiceAccountController) FetchServiceAccounts(c *gin.Context) {
+	serviceAccounts, err := sac.ServiceAccountUsecase.Fetch(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{
+			Message: "Failed to fetch service accounts: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(serviceAccounts))
+}
+
+// @Summary Rotate a service account secret
+// @Description Issues a new client secret; the previous one stops working immediately
+// @Tags Admin
+// @ID rotateServiceAccountSecret
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Service account ID"
+// @Success 200 {object} domain.SuccessResponse{data=domain.ServiceAccountCredentials} "New service account credentials"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request"
+// @Failure 404 {object} domain.ErrorResponse "Not Found"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /admin/service-accounts/{id}/rotate-secret [post]
+func (sac *ServiceAccountController) RotateServiceAccountSecret(c *gin.Context) {
+	id, err := internal.ParseUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid service account ID"})
+		return
+	}
+
+	credentials, err := sac.ServiceAccountUsecase.RotateSecret(c, id)
+	if err != nil {
+		switch err {
+		case domain.ErrNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse{Message: "Service account not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse{
+				Message: "Failed to rotate service account secret: " + err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(credentials))
+}
+
+// @Summary Delete a service account
+// @Description Revokes a service account; tokens already issued remain valid until they expire
+// @Tags Admin
+// @ID deleteServiceAccount
+// @Security BearerAuth
+// @Param id path int true "Service account ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request"
+// @Failure 404 {object} domain.ErrorResponse "Not Found"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /admin/service-accounts/{id} [delete]
+func (sac *ServiceAccountController) DeleteServiceAccount(c *gin.Context) {
+	id, err := internal.ParseUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid service account ID"})
+		return
+	}
+
+	if err := sac.ServiceAccountUsecase.Delete(c, id); err != nil {
+		switch err {
+		case domain.ErrNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse{Message: "Service account not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse{
+				Message: "Failed to delete service account: " + err.Error(),
+			})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -4,18 +4,17 @@ import (
 	"fmt"
 	"net/http"
 
-	"strings"
-
 	"github.com/gabrielfmcoelho/platform-core/bootstrap"
 	"github.com/gabrielfmcoelho/platform-core/domain"
 	"github.com/gabrielfmcoelho/platform-core/internal/parser"
-	"github.com/gabrielfmcoelho/platform-core/internal/tokenutil"
 	"github.com/gin-gonic/gin"
 )
 
 type ServiceController struct {
 	ServiceUsecase domain.ServiceUsecase
 	UserUsecase    domain.UserUsecase
+	LaunchUsecase  domain.LaunchUsecase
+	ReportUsecase  domain.ReportUsecase
 	Env            *bootstrap.Env
 }
 
@@ -182,18 +181,25 @@ func (sc *ServiceController) SetServiceAvailabilityToOrganization(c *gin.Context
 }
 
 // GetServiceApplication
-// @Summary Start using a service (create a usage log)
-// @Description Logs that a user started using a service, returns log ID and public service data
+// @Summary Start using a service (open a usage session)
+// @Description Opens a usage session of the service tied to the login session of the user and returns its log ID and public service data. The session stays open while heartbeats arrive on /service/heartbeat; it ends when closed on /service/close, when the user logs out or after USAGE_HEARTBEAT_TIMEOUT_SECOND seconds without heartbeat. The launch URL carries a single-use launch token for the service, valid for LAUNCH_TOKEN_EXPIRY_SECOND seconds, that its backend redeems on /service/launch/exchange.
 // @Tags Service
 // @Accept json
 // @Produce json
 // @Param serviceID path int true "Service ID"
 // @Success 200 {object} domain.UseService
 // @Failure 400 {object} domain.ErrorResponse
-// @Failure 404 {object} domain.ErrorResponse
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - Impersonated session; service not subscribed by the organization, not active or not available to guests; subscription expired (code subscription_expired) or not active (code subscription_inactive)"
+// @Failure 404 {object} domain.ErrorResponse "Service not found or archived"
 // @Failure 500 {object} domain.ErrorResponse
 // @Router /services/{serviceID}/application [get]
 func (sc *ServiceController) GetServiceApplication(c *gin.Context) {
+	// an impersonated session must never sign in to another service as the user
+	if _, impersonating := c.Get("x-impersonation-id"); impersonating {
+		c.JSON(http.StatusForbidden, domain.ErrorResponse{Message: domain.ErrImpersonationReadOnly.Error()})
+		return
+	}
+
 	// 1) Parse serviceID from path
 	serviceIDParam := c.Param("serviceID")
 	var sID uint
@@ -202,20 +208,17 @@ func (sc *ServiceController) GetServiceApplication(c *gin.Context) {
 		return
 	}
 
-	// 2) extract UserID from jwt token
-	authHeader := c.Request.Header.Get("Authorization")
-	t := strings.Split(authHeader, " ")
-	authToken := t[1]
-	userID, err := tokenutil.ExtractIDFromToken(authToken, sc.Env.AccessTokenSecret)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, domain.ErrorResponse{Message: err.Error()})
-	}
+	// 2) UserID set by JwtAuthMiddleware
+	userID := c.GetInt("x-user-id")
 
 	// 3) Call usecase
-	service, logID, err := sc.ServiceUsecase.Use(c, uint(userID), sID)
+	service, logID, err := sc.ServiceUsecase.Use(c, uint(userID), c.GetUint("x-session-id"), sID)
 	if err != nil {
 		switch err {
-		case domain.ErrNotFound:
+		case domain.ErrGuestServiceForbidden, domain.ErrServiceNotSubscribed, domain.ErrServiceInactive,
+			domain.ErrSubscriptionExpired, domain.ErrSubscriptionInactive:
+			c.JSON(http.StatusForbidden, domain.ErrorResponse{Message: err.Error(), Code: errorCode(err)})
+		case domain.ErrServiceNotFound, domain.ErrNotFound:
 			c.JSON(http.StatusNotFound, domain.ErrorResponse{Message: err.Error()})
 		default:
 			c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: err.Error()})
@@ -225,37 +228,133 @@ func (sc *ServiceController) GetServiceApplication(c *gin.Context) {
 
 	service.LogID = logID
 
-	// 4) Return result
+	// 4) Hand the user over to the service
+	if err := sc.LaunchUsecase.Issue(c, uint(userID), &service); err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	// 5) Return result
 	c.JSON(http.StatusOK, service)
 }
 
+// ExchangeLaunchToken
+// @Summary Redeem a launch token
+// @Description Called by the backend of a service with the launch token its front-end received in the launch URL. The service authenticates with one of its confidential OAuth clients (HTTP Basic or in the body). Each token is redeemed once and only by the service it was issued to; the answer identifies the user, its organization and the usage log to send heartbeats for.
+// @Tags Service
+// @Accept x-www-form-urlencoded,json
+// @Produce json
+// @Param launch_token formData string true "Launch token"
+// @Param client_id formData string false "Client ID (when not using HTTP Basic)"
+// @Param client_secret formData string false "Client secret (when not using HTTP Basic)"
+// @Success 200 {object} domain.LaunchExchangeResponse "User, organization and usage log of the launch"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid, expired or already used launch token"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized - Invalid client credentials"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /service/launch/exchange [post]
+func (sc *ServiceController) ExchangeLaunchToken(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+
+	var request domain.LaunchExchangeRequest
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid input: " + err.Error()})
+		return
+	}
+
+	if clientID, clientSecret, ok := c.Request.BasicAuth(); ok {
+		request.ClientID = clientID
+		request.ClientSecret = clientSecret
+	}
+
+	response, err := sc.LaunchUsecase.Exchange(c, &request)
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidClient:
+			c.Header("WWW-Authenticate", `Basic realm="launch"`)
+			c.JSON(http.StatusUnauthorized, domain.ErrorResponse{Message: err.Error()})
+		case domain.ErrInvalidLaunchToken:
+			c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RecordReport
+// @Summary Record a report against the subscription
+// @Description Called by the backend of a service before it produces a report for a user, with the usage log it received on /service/launch/exchange. The service authenticates with one of its confidential OAuth clients (HTTP Basic or in the body). The report is refused when the subscription of the organization expired or is not active, or when its monthly reports limit is reached.
+// @Tags Service
+// @Accept x-www-form-urlencoded,json
+// @Produce json
+// @Param log_id formData int true "Usage log of the user"
+// @Param client_id formData string false "Client ID (when not using HTTP Basic)"
+// @Param client_secret formData string false "Client secret (when not using HTTP Basic)"
+// @Success 201 {object} domain.ReportQuota "Recorded report and the reports left this month"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid input"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized - Invalid client credentials"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - Codes subscription_expired, subscription_inactive or reports_limit_reached"
+// @Failure 404 {object} domain.ErrorResponse "Usage log not found"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /service/reports [post]
+func (sc *ServiceController) RecordReport(c *gin.Context) {
+	var request domain.ReportRequest
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid input: " + err.Error()})
+		return
+	}
+
+	if clientID, clientSecret, ok := c.Request.BasicAuth(); ok {
+		request.ClientID = clientID
+		request.ClientSecret = clientSecret
+	}
+
+	quota, err := sc.ReportUsecase.Record(c, &request)
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidClient:
+			c.Header("WWW-Authenticate", `Basic realm="report"`)
+			c.JSON(http.StatusUnauthorized, domain.ErrorResponse{Message: err.Error()})
+		case domain.ErrSubscriptionExpired, domain.ErrSubscriptionInactive, domain.ErrReportsLimitReached:
+			c.JSON(http.StatusForbidden, domain.ErrorResponse{Message: err.Error(), Code: errorCode(err)})
+		case domain.ErrUsageLogNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse{Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, quota)
+}
+
 // HeartbeatService
 // @Summary Heartbeat usage
-// @Description Adds usage duration (in seconds) to a log record
+// @Description Keeps a usage session of the caller open. The server credits the time elapsed since the previous heartbeat, up to USAGE_HEARTBEAT_TIMEOUT_SECOND seconds; the duration field of the body is deprecated and ignored.
 // @Tags Service
 // @Accept json
 // @Produce json
 // @Param heartbeat body domain.Heartbeat true "Heartbeat data"
 // @Success 200 {object} domain.SuccessResponse
-// @Failure 404 {object} domain.ErrorResponse
+// @Failure 400 {object} domain.ErrorResponse
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - Usage log of another user"
+// @Failure 404 {object} domain.ErrorResponse "Usage log not found"
+// @Failure 409 {object} domain.ErrorResponse "Usage session already ended"
 // @Failure 500 {object} domain.ErrorResponse
-// @Router /services/heartbeat [patch]
+// @Router /service/heartbeat [patch]
 func (sc *ServiceController) HeartbeatService(c *gin.Context) {
-	// 1) Parse JSON body for logID and duration
+	// 1) Parse JSON body for logID
 	var req domain.Heartbeat
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
 		return
 	}
 
-	err := sc.ServiceUsecase.Heartbeat(c, req.LogID, req.Duration)
+	err := sc.ServiceUsecase.Heartbeat(c, uint(c.GetInt("x-user-id")), req.LogID)
 	if err != nil {
-		switch err {
-		case domain.ErrNotFound:
-			c.JSON(http.StatusNotFound, domain.ErrorResponse{Message: err.Error()})
-		default:
-			c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: err.Error()})
-		}
+		sc.respondUsageError(c, err)
 		return
 	}
 
@@ -263,6 +362,49 @@ func (sc *ServiceController) HeartbeatService(c *gin.Context) {
 	c.JSON(http.StatusOK, domain.SuccessResponse{Message: "Usage duration updated successfully"})
 }
 
+// CloseService
+// @Summary Stop using a service (close a usage session)
+// @Description Ends a usage session of the caller, crediting the time since its last heartbeat. Sent by the hub or the service when the user leaves it.
+// @Tags Service
+// @Accept json
+// @Produce json
+// @Param session body domain.UsageSessionRequest true "Usage session"
+// @Success 200 {object} domain.SuccessResponse
+// @Failure 400 {object} domain.ErrorResponse
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - Usage log of another user"
+// @Failure 404 {object} domain.ErrorResponse "Usage log not found"
+// @Failure 409 {object} domain.ErrorResponse "Usage session already ended"
+// @Failure 500 {object} domain.ErrorResponse
+// @Router /service/close [patch]
+func (sc *ServiceController) CloseService(c *gin.Context) {
+	var req domain.UsageSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	err := sc.ServiceUsecase.Close(c, uint(c.GetInt("x-user-id")), req.LogID)
+	if err != nil {
+		sc.respondUsageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse{Message: "Usage session closed successfully"})
+}
+
+func (sc *ServiceController) respondUsageError(c *gin.Context, err error) {
+	switch err {
+	case domain.ErrUsageLogNotOwned:
+		c.JSON(http.StatusForbidden, domain.ErrorResponse{Message: err.Error()})
+	case domain.ErrUsageLogNotFound:
+		c.JSON(http.StatusNotFound, domain.ErrorResponse{Message: err.Error()})
+	case domain.ErrUsageSessionEnded:
+		c.JSON(http.StatusConflict, domain.ErrorResponse{Message: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: err.Error()})
+	}
+}
+
 // UpdateService atualiza um service
 // @Summary Update Service
 // @Description Updates service data
@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal"
+	"github.com/gabrielfmcoelho/platform-core/internal/parser"
+	"github.com/gin-gonic/gin"
+)
+
+type SessionController struct {
+	SessionUsecase domain.SessionUsecase
+	Env            *bootstrap.Env
+}
+
+// @Summary List my sessions
+// @Description Lists the devices the authenticated user is signed in on, with their user agent, IP and last activity. The session of the calling token is flagged as current.
+// @Tags Session
+// @ID fetchMySessions
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} domain.SuccessResponse{data=[]domain.PublicSession} "Active sessions"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /me/sessions [get]
+func (sc *SessionController) FetchMySessions(c *gin.Context) {
+	sessions, err := sc.SessionUsecase.FetchByUserID(c, uint(c.GetInt("x-user-id")), c.GetUint("x-session-id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(sessions))
+}
+
+// @Summary Revoke one of my sessions
+// @Description Signs a device out: its refresh token stops working and its access tokens are refused right away
+// @Tags Session
+// @ID revokeMySession
+// @Security BearerAuth
+// @Param id path int true "Session ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid session ID"
+// @Failure 404 {object} domain.ErrorResponse "Not Found"
+// @Failure 409 {object} domain.ErrorResponse "Conflict - Session already revoked"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /me/sessions/{id} [delete]
+func (sc *SessionController) RevokeMySession(c *gin.Context) {
+	sessionID, err := internal.ParseUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid session ID"})
+		return
+	}
+
+	if err := sc.SessionUsecase.Revoke(c, uint(c.GetInt("x-user-id")), sessionID, clientInfo(c)); err != nil {
+		sc.respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary List the sessions of a user
+// @Description Lists the devices a user is signed in on
+// @Tags Admin
+// @ID fetchUserSessions
+// @Security BearerAuth
+// @Produce json
+// @Param userId path int true "User ID"
+// @Success 200 {object} domain.SuccessResponse{data=[]domain.PublicSession} "Active sessions"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid user ID"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /admin/users/{userId}/sessions [get]
+func (sc *SessionController) FetchUserSessions(c *gin.Context) {
+	userID, err := internal.ParseUint(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid user ID"})
+		return
+	}
+
+	sessions, err := sc.SessionUsecase.FetchByUserID(c, userID, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(sessions))
+}
+
+// @Summary Revoke a session of a user
+// @Description Signs a device of a user out
+// @Tags Admin
+// @ID revokeUserSession
+// @Security BearerAuth
+// @Param userId path int true "User ID"
+// @Param sessionId path int true "Session ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid user or session ID"
+// @Failure 404 {object} domain.ErrorResponse "Not Found"
+// @Failure 409 {object} domain.ErrorResponse "Conflict - Session already revoked"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /admin/users/{userId}/sessions/{sessionId} [delete]
+func (sc *SessionController) RevokeUserSession(c *gin.Context) {
+	userID, err := internal.ParseUint(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid user ID"})
+		return
+	}
+	sessionID, err := internal.ParseUint(c.Param("sessionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid session ID"})
+		return
+	}
+
+	if err := sc.SessionUsecase.Revoke(c, userID, sessionID, clientInfo(c)); err != nil {
+		sc.respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (sc *SessionController) respondError(c *gin.Context, err error) {
+	switch err {
+	case domain.ErrNotFound:
+		c.JSON(http.StatusNotFound, domain.ErrorResponse{Message: err.Error()})
+	case domain.ErrSessionRevoked:
+		c.JSON(http.StatusConflict, domain.ErrorResponse{Message: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: err.Error()})
+	}
+}
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal/parser"
+	"github.com/gin-gonic/gin"
+)
+
+type SigningKeyController struct {
+	SigningKeyUsecase domain.SigningKeyUsecase
+	Env               *bootstrap.Env
+}
+
+// @Summary JSON Web Key Set
+// @Description Public keys that verify the user access tokens (RFC 7517). Tokens carry the kid of their key in the header; retired keys stay listed until the tokens they signed expire.
+// @Tags Auth User
+// @ID getJWKS
+// @Produce json
+// @Success 200 {object} domain.JWKS "Key set"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /.well-known/jwks.json [get]
+func (skc *SigningKeyController) GetJWKS(c *gin.Context) {
+	jwks, err := skc.SigningKeyUsecase.GetJWKS(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	// short cache so a rotation is picked up quickly by the verifiers
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, jwks)
+}
+
+// @Summary List signing keys
+// @Description Lists the access token signing keys (public metadata only)
+// @Tags Admin
+// @ID fetchSigningKeys
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} domain.SuccessResponse{data=[]domain.PublicSigningKey} "Signing keys"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /admin/signing-keys [get]
+func (skc *SigningKeyController) FetchSigningKeys(c *gin.Context) {
+	signingKeys, err := skc.SigningKeyUsecase.Fetch(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{
+			Message: "Failed to fetch signing keys: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(signingKeys))
+}
+
+// @Summary Rotate the signing key
+// @Description Generates a new active signing key. Tokens signed by the previous key remain valid until they expire.
+// @Tags Admin
+// @ID rotateSigningKey
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param rotate body domain.RotateSigningKey false "Algorithm of the new key (defaults to SIGNING_KEY_ALGORITHM)"
+// @Success 201 {object} domain.SuccessResponse{data=domain.PublicSigningKey} "New active key"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid algorithm"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /admin/signing-keys/rotate [post]
+func (skc *SigningKeyController) RotateSigningKey(c *gin.Context) {
+	var rotate domain.RotateSigningKey
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&rotate); err != nil {
+			c.JSON(http.StatusBadRequest, domain.ErrorResponse{
+				Message: "Invalid input: " + err.Error(),
+			})
+			return
+		}
+	}
+	if rotate.Algorithm == "" {
+		rotate.Algorithm = skc.Env.SigningKeyAlgorithm
+	}
+
+	signingKey, err := skc.SigningKeyUsecase.Rotate(c, rotate.Algorithm, skc.Env.SigningKeyEncryptionKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{
+			Message: "Failed to rotate signing key: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, parser.ToSuccessResponse(signingKey))
+}
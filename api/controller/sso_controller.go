@@ -0,0 +1,196 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal"
+	"github.com/gabrielfmcoelho/platform-core/internal/parser"
+	"github.com/gin-gonic/gin"
+)
+
+type SSOController struct {
+	SSOUsecase domain.SSOUsecase
+	Env        *bootstrap.Env
+}
+
+// @Summary Start a single sign-on login
+// @Description Redirects the browser to the OpenID Connect provider of the organization (authorization code flow with PKCE). The provider sends the user back to OIDC_REDIRECT_URL with a code and a state, to be posted to /sso/callback.
+// @Tags Auth User
+// @ID ssoLogin
+// @Param organizationId path int true "Organization ID"
+// @Success 302 "Redirect to the identity provider"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid organization ID"
+// @Failure 403 {object} domain.ErrorResponse "Single sign-on disabled for the organization"
+// @Failure 502 {object} domain.ErrorResponse "Identity provider unreachable"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /sso/{organizationId}/login [get]
+func (sc *SSOController) Login(c *gin.Context) {
+	id, err := internal.ParseUint(c.Param("organizationId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid organization ID"})
+		return
+	}
+
+	authorizationURL, err := sc.SSOUsecase.AuthorizationURL(c, id)
+	if err != nil {
+		sc.respondError(c, err)
+		return
+	}
+
+	c.Redirect(http.StatusFound, authorizationURL)
+}
+
+// @Summary Complete a single sign-on login
+// @Description Redeems the code returned by the identity provider and returns access and refresh tokens. The user is found by its provider subject, then by a verified e-mail of the same organization; unknown users are created when the organization allows it. The role follows the provider claims when a role claim is configured. Users more privileged than whoever configured the provider cannot sign in through it.
+// @Tags Auth User
+// @ID ssoCallback
+// @Accept json
+// @Produce json
+// @Param request body domain.SSOCallbackRequest true "Code and state returned by the provider"
+// @Success 200 {object} domain.LoginResponse "Successful login, returns access and refresh tokens"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid input or state"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized - Account archived"
+// @Failure 403 {object} domain.ErrorResponse "Single sign-on disabled, provisioning disabled or users limit reached"
+// @Failure 409 {object} domain.ErrorResponse "Conflict - E-mail used by an account that cannot be linked"
+// @Failure 502 {object} domain.ErrorResponse "Identity provider rejected the login"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /sso/callback [post]
+func (sc *SSOController) Callback(c *gin.Context) {
+	var request domain.SSOCallbackRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{
+			Message: "Invalid input: " + err.Error(),
+		})
+		return
+	}
+
+	loginResponse, err := sc.SSOUsecase.Callback(
+		c,
+		&request,
+		clientInfo(c),
+		sc.Env.AccessTokenExpiryHour,
+		sc.Env.RefreshTokenExpiryHour,
+	)
+	if err != nil {
+		sc.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, loginResponse)
+}
+
+// @Summary Get the single sign-on of an organization
+// @Description The OpenID Connect provider of the organization and how its claims map to user roles. The client secret is never returned. Organization admins can only see their own organization.
+// @Tags Organization
+// @ID getOrganizationSSO
+// @Security BearerAuth
+// @Produce json
+// @Param identifier path int true "Organization ID"
+// @Success 200 {object} domain.SuccessResponse{data=domain.PublicIdentityProvider} "Identity provider"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid organization ID"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - Not an admin of this organization"
+// @Failure 404 {object} domain.ErrorResponse "Not Found"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /organization/{identifier}/sso [get]
+func (sc *SSOController) GetSSO(c *gin.Context) {
+	id, err := internal.ParseUint(c.Param("identifier"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid organization ID"})
+		return
+	}
+
+	if !canManageOrganization(c, id) {
+		c.JSON(http.StatusForbidden, domain.ErrorResponse{Message: domain.ErrForbidden.Error()})
+		return
+	}
+
+	identityProvider, err := sc.SSOUsecase.GetConfig(c, id)
+	if err != nil {
+		sc.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(identityProvider))
+}
+
+// @Summary Configure the single sign-on of an organization
+// @Description Sets the OpenID Connect provider of the organization (checked through its discovery document when enabled), the role mapping of its claims and whether unknown users are provisioned on their first login. An omitted client secret keeps the stored one. The mapped and default roles cannot be more privileged than the caller. Organization admins can only change their own organization.
+// @Tags Organization
+// @ID setOrganizationSSO
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Organization ID"
+// @Param config body domain.IdentityProviderConfig true "Identity provider"
+// @Success 200 {object} domain.SuccessResponse{data=domain.PublicIdentityProvider} "Updated identity provider"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid input or issuer not an https url of a public host"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - Not an admin of this organization, or a role above the caller's"
+// @Failure 404 {object} domain.ErrorResponse "Not Found"
+// @Failure 502 {object} domain.ErrorResponse "Identity provider discovery failed"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /organization/{id}/sso [put]
+func (sc *SSOController) SetSSO(c *gin.Context) {
+	id, err := internal.ParseUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid organization ID"})
+		return
+	}
+
+	if !canManageOrganization(c, id) {
+		c.JSON(http.StatusForbidden, domain.ErrorResponse{Message: domain.ErrForbidden.Error()})
+		return
+	}
+
+	var config domain.IdentityProviderConfig
+	if err := c.ShouldBindJSON(&config); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{
+			Message: "Invalid input: " + err.Error(),
+		})
+		return
+	}
+
+	// lower role IDs are more privileged: nobody grants more than they have
+	configuredRoleID := c.GetUint("x-user-role-id")
+	if configuredRoleID == 0 || isPlatformAdmin(c) {
+		configuredRoleID = domain.UserRoleAdmin
+	}
+	if config.DefaultRoleID != 0 && config.DefaultRoleID < configuredRoleID {
+		c.JSON(http.StatusForbidden, domain.ErrorResponse{Message: domain.ErrForbidden.Error()})
+		return
+	}
+	for _, roleID := range config.RoleMapping {
+		if roleID < configuredRoleID {
+			c.JSON(http.StatusForbidden, domain.ErrorResponse{Message: domain.ErrForbidden.Error()})
+			return
+		}
+	}
+
+	identityProvider, err := sc.SSOUsecase.Configure(c, id, &config, configuredRoleID)
+	if err != nil {
+		sc.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(identityProvider))
+}
+
+func (sc *SSOController) respondError(c *gin.Context, err error) {
+	switch err {
+	case domain.ErrNotFound:
+		c.JSON(http.StatusNotFound, domain.ErrorResponse{Message: "Organization not found"})
+	case domain.ErrInvalidSSOState, domain.ErrInvalidIssuer:
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+	case domain.ErrUnauthorized:
+		c.JSON(http.StatusUnauthorized, domain.ErrorResponse{Message: err.Error()})
+	case domain.ErrSSODisabled, domain.ErrSSOUserNotProvisioned, domain.ErrUsersLimitReached:
+		c.JSON(http.StatusForbidden, domain.ErrorResponse{Message: err.Error(), Code: errorCode(err)})
+	case domain.ErrSSOAccountConflict:
+		c.JSON(http.StatusConflict, domain.ErrorResponse{Message: err.Error()})
+	case domain.ErrSSOProviderError:
+		c.JSON(http.StatusBadGateway, domain.ErrorResponse{Message: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: err.Error()})
+	}
+}
@@ -0,0 +1,223 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal"
+	"github.com/gabrielfmcoelho/platform-core/internal/parser"
+	"github.com/gin-gonic/gin"
+)
+
+type SubscriptionController struct {
+	OrganizationSubscriptionUsecase domain.OrganizationSubscriptionUsecase
+	Env                             *bootstrap.Env
+}
+
+// @Summary Get the subscription of an organization
+// @Description Plan, dates, status and usage (members and reports of the current month) of the subscription
+// @Tags Admin
+// @ID getSubscription
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Organization ID"
+// @Success 200 {object} domain.SuccessResponse{data=domain.PublicSubscription} "Subscription"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request"
+// @Failure 404 {object} domain.ErrorResponse "Organization or subscription not found"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /admin/organizations/{id}/subscription [get]
+func (sc *SubscriptionController) GetSubscription(c *gin.Context) {
+	organizationID, err := internal.ParseUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid organization ID"})
+		return
+	}
+
+	subscription, err := sc.OrganizationSubscriptionUsecase.GetByOrganizationID(c, organizationID)
+	if err != nil {
+		sc.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(subscription))
+}
+
+// @Summary Get the renewals of a subscription
+// @Description Billing periods added by the automatic renewal of the subscription, latest first
+// @Tags Admin
+// @ID fetchSubscriptionRenewals
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Organization ID"
+// @Success 200 {object} domain.SuccessResponse{data=[]domain.PublicSubscriptionRenewal} "List of renewals"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request"
+// @Failure 404 {object} domain.ErrorResponse "Organization or subscription not found"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /admin/organizations/{id}/subscription/renewals [get]
+func (sc *SubscriptionController) FetchRenewals(c *gin.Context) {
+	organizationID, err := internal.ParseUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid organization ID"})
+		return
+	}
+
+	renewals, err := sc.OrganizationSubscriptionUsecase.FetchRenewals(c, organizationID)
+	if err != nil {
+		sc.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(renewals))
+}
+
+// @Summary Subscribe an organization to a plan
+// @Description Creates the subscription of an organization. Zero limits mean unlimited; the reports limit counts the reports of each calendar month. The managers are warned before the end date; auto-renewed subscriptions are then extended by one period, the others keep access during the grace period and are deactivated after it.
+// @Tags Admin
+// @ID createSubscription
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Organization ID"
+// @Param subscription body domain.SubscriptionRequest true "Subscription"
+// @Success 201 {object} domain.SuccessResponse{data=domain.PublicSubscription} "Created subscription"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid input"
+// @Failure 404 {object} domain.ErrorResponse "Organization not found"
+// @Failure 409 {object} domain.ErrorResponse "Conflict - The organization already has a subscription"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /admin/organizations/{id}/subscription [post]
+func (sc *SubscriptionController) CreateSubscription(c *gin.Context) {
+	organizationID, request, ok := sc.bindRequest(c)
+	if !ok {
+		return
+	}
+
+	subscription, err := sc.OrganizationSubscriptionUsecase.Create(c, organizationID, &request)
+	if err != nil {
+		sc.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, parser.ToSuccessResponse(subscription))
+}
+
+// @Summary Update the subscription of an organization
+// @Description Replaces the plan and the dates of the subscription. Lowering a limit below the current usage removes no one, it only blocks new users or reports.
+// @Tags Admin
+// @ID updateSubscription
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Organization ID"
+// @Param subscription body domain.SubscriptionRequest true "Subscription"
+// @Success 200 {object} domain.SuccessResponse{data=domain.PublicSubscription} "Updated subscription"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid input"
+// @Failure 404 {object} domain.ErrorResponse "Organization or subscription not found"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /admin/organizations/{id}/subscription [put]
+func (sc *SubscriptionController) UpdateSubscription(c *gin.Context) {
+	organizationID, request, ok := sc.bindRequest(c)
+	if !ok {
+		return
+	}
+
+	subscription, err := sc.OrganizationSubscriptionUsecase.Update(c, organizationID, &request)
+	if err != nil {
+		sc.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(subscription))
+}
+
+// @Summary Activate or deactivate the subscription of an organization
+// @Description Members of an organization with a deactivated subscription cannot launch services nor produce reports
+// @Tags Admin
+// @ID toggleSubscription
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Organization ID"
+// @Param action path string true "activate or deactivate"
+// @Success 200 {object} domain.SuccessResponse{data=domain.PublicSubscription} "Updated subscription"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid action"
+// @Failure 404 {object} domain.ErrorResponse "Organization or subscription not found"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /admin/organizations/{id}/subscription/{action} [patch]
+func (sc *SubscriptionController) ToggleSubscription(c *gin.Context) {
+	organizationID, err := internal.ParseUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid organization ID"})
+		return
+	}
+
+	action := c.Param("action")
+	if action != "activate" && action != "deactivate" {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{
+			Message: "Invalid action. Must be 'activate' or 'deactivate'",
+		})
+		return
+	}
+
+	subscription, err := sc.OrganizationSubscriptionUsecase.SetActive(c, organizationID, action == "activate")
+	if err != nil {
+		sc.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, parser.ToSuccessResponse(subscription))
+}
+
+// @Summary Delete the subscription of an organization
+// @Description Removes the subscription; the organization is no longer limited and may subscribe again
+// @Tags Admin
+// @ID deleteSubscription
+// @Security BearerAuth
+// @Param id path int true "Organization ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request"
+// @Failure 404 {object} domain.ErrorResponse "Organization or subscription not found"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /admin/organizations/{id}/subscription [delete]
+func (sc *SubscriptionController) DeleteSubscription(c *gin.Context) {
+	organizationID, err := internal.ParseUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid organization ID"})
+		return
+	}
+
+	if err := sc.OrganizationSubscriptionUsecase.Delete(c, organizationID); err != nil {
+		sc.respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (sc *SubscriptionController) bindRequest(c *gin.Context) (uint, domain.SubscriptionRequest, bool) {
+	var request domain.SubscriptionRequest
+
+	organizationID, err := internal.ParseUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid organization ID"})
+		return 0, request, false
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{
+			Message: "Invalid input: " + err.Error(),
+		})
+		return 0, request, false
+	}
+	return organizationID, request, true
+}
+
+func (sc *SubscriptionController) respondError(c *gin.Context, err error) {
+	switch err {
+	case domain.ErrNotFound:
+		c.JSON(http.StatusNotFound, domain.ErrorResponse{Message: "Organization or subscription not found"})
+	case domain.ErrSubscriptionExists:
+		c.JSON(http.StatusConflict, domain.ErrorResponse{Message: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: err.Error()})
+	}
+}
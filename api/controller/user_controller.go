@@ -23,7 +23,9 @@ type UserController struct {
 // @Produce json
 // @Param user body domain.CreateUser true "User object"
 // @Success 201 "User created successfully"
-// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid input"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid input or password refused by the policy"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - Users limit of the subscription reached (code users_limit_reached)"
+// @Failure 404 {object} domain.ErrorResponse "Organization not found"
 // @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
 // @Router /user/create [post]
 func (uc *UserController) CreateUser(c *gin.Context) {
@@ -37,6 +39,17 @@ func (uc *UserController) CreateUser(c *gin.Context) {
 
 	err := uc.UserUsecase.Create(c, &user)
 	if err != nil {
+		if respondPasswordRejected(c, err) {
+			return
+		}
+		switch err {
+		case domain.ErrUsersLimitReached:
+			c.JSON(http.StatusForbidden, domain.ErrorResponse{Message: err.Error(), Code: errorCode(err)})
+			return
+		case domain.ErrNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse{Message: "Organization not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{
 			Message: "Failed to create user: " + err.Error(),
 		})
@@ -67,12 +80,13 @@ func (uc *UserController) FetchUsers(c *gin.Context) {
 }
 
 // @Summary Get user by ID or email
-// @Description Get user by ID or email
+// @Description Get user by ID or email. Users can only get themselves, managers the users of their organization.
 // @Tags User
 // @ID getUser
 // @Produce json
 // @Param identifier path string true "User ID or email"
 // @Success 200 {object} domain.SuccessResponse{data=domain.PublicUser} "User object"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden"
 // @Failure 404 {object} domain.ErrorResponse "Not Found - User not found"
 // @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
 // @Router /user/{identifier} [get]
@@ -86,6 +100,17 @@ func (uc *UserController) GetUser(c *gin.Context) {
 		return
 	}
 
+	// other users are reserved to the managers of their organization; service accounts were
+	// already checked for users:read and have no role of their own
+	self := uint(c.GetInt("x-user-id")) == user.ID
+	if _, isServiceAccount := c.Get("x-service-account-id"); !self && !isServiceAccount {
+		roleID := c.GetUint("x-user-role-id")
+		if roleID != domain.UserRoleAdmin && roleID != domain.UserRoleManager || !canManageOrganization(c, user.OrganizationID) {
+			c.JSON(http.StatusForbidden, domain.ErrorResponse{Message: domain.ErrForbidden.Error()})
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, parser.ToSuccessResponse(user))
 }
 
@@ -98,7 +123,7 @@ func (uc *UserController) GetUser(c *gin.Context) {
 // @Param id path string true "User ID"
 // @Param user body domain.User true "User object"
 // @Success 200 "User updated successfully"
-// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid input"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid input or password refused by the policy"
 // @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
 // @Router /user/{id} [put]
 func (uc *UserController) UpdateUser(c *gin.Context) {
@@ -121,6 +146,9 @@ func (uc *UserController) UpdateUser(c *gin.Context) {
 
 	err = uc.UserUsecase.Update(c, id, &user)
 	if err != nil {
+		if respondPasswordRejected(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{
 			Message: "Failed to update user: " + err.Error(),
 		})
@@ -159,3 +187,34 @@ func (uc *UserController) DeleteUser(c *gin.Context) {
 
 	c.JSON(http.StatusNoContent, gin.H{})
 }
+
+// @Summary Require a password change
+// @Description Makes the user change their password on the next login: /login returns a single-use reset token instead of a session
+// @Tags Admin
+// @ID requirePasswordChange
+// @Security BearerAuth
+// @Param userId path int true "User ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid user ID"
+// @Failure 404 {object} domain.ErrorResponse "Not Found"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Router /admin/users/{userId}/require-password-change [post]
+func (uc *UserController) RequirePasswordChange(c *gin.Context) {
+	userID, err := internal.ParseUint(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Message: "Invalid user ID"})
+		return
+	}
+
+	if err := uc.UserUsecase.RequirePasswordChange(c, userID); err != nil {
+		switch err {
+		case domain.ErrNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse{Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Message: err.Error()})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
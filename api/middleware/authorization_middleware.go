@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gin-gonic/gin"
+)
+
+// Policy describes which callers may reach a route. The role lists are
+// matched against the user claims set by JwtAuthMiddleware; an empty list
+// matches any role. Service accounts are only let through when they hold
+// at least one of Scopes, so a policy without scopes is users only.
+// ServiceAccountsOnly turns users away whatever their roles.
+type Policy struct {
+	UserRoles           []uint
+	OrganizationRoles   []uint
+	Scopes              []string
+	ServiceAccountsOnly bool
+}
+
+// WithScopes returns a copy of the policy that also admits service accounts holding any of the scopes
+func (p Policy) WithScopes(scopes ...string) Policy {
+	p.Scopes = scopes
+	return p
+}
+
+// Authorize enforces the given policy. It must run after JwtAuthMiddleware.
+func Authorize(policy Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if scopes, isServiceAccount := c.Get("x-scopes"); isServiceAccount {
+			if !hasScope(policy.Scopes, scopes.([]string)) {
+				c.JSON(http.StatusForbidden, domain.ErrorResponse{Message: domain.ErrForbidden.Error()})
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if _, exists := c.Get("x-user-id"); !exists {
+			c.JSON(http.StatusUnauthorized, domain.ErrorResponse{Message: "Not authorized"})
+			c.Abort()
+			return
+		}
+
+		if policy.ServiceAccountsOnly {
+			c.JSON(http.StatusForbidden, domain.ErrorResponse{Message: domain.ErrForbidden.Error()})
+			c.Abort()
+			return
+		}
+
+		if !hasRole(policy.UserRoles, c.GetUint("x-user-role-id")) ||
+			!hasRole(policy.OrganizationRoles, c.GetUint("x-organization-role-id")) {
+			c.JSON(http.StatusForbidden, domain.ErrorResponse{Message: domain.ErrForbidden.Error()})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func hasRole(allowed []uint, roleID uint) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	return slices.Contains(allowed, roleID)
+}
+
+func hasScope(allowed []string, granted []string) bool {
+	for _, scope := range granted {
+		if slices.Contains(allowed, scope) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gin-gonic/gin"
+)
+
+// ReadOnlyImpersonation keeps impersonation tokens from changing anything: while an
+// admin acts as a user only safe methods go through, besides the allowed route paths
+// (e.g. the one stopping the impersonation). It must run after JwtAuthMiddleware.
+func ReadOnlyImpersonation(allowedPaths ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, impersonating := c.Get("x-impersonation-id"); !impersonating {
+			c.Next()
+			return
+		}
+
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		if slices.Contains(allowedPaths, c.FullPath()) {
+			c.Next()
+			return
+		}
+
+		c.JSON(http.StatusForbidden, domain.ErrorResponse{Message: domain.ErrImpersonationReadOnly.Error()})
+		c.Abort()
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"time"
 
 	"github.com/gabrielfmcoelho/platform-core/api/controller"
+	"github.com/gabrielfmcoelho/platform-core/api/middleware"
 	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
 	"github.com/gabrielfmcoelho/platform-core/repository"
 	"github.com/gabrielfmcoelho/platform-core/usecase"
 	"github.com/gin-gonic/gin"
@@ -19,25 +21,33 @@ func NewAdminRouter(env *bootstrap.Env, timeout time.Duration, db *gorm.DB, grou
 	userRoleRepo := repository.NewUserRoleRepository(db)
 	userRepo := repository.NewUserRepository(db)
 	serviceRepo := repository.NewServiceRepository(db)
+	guestAccessRepo := repository.NewGuestAccessRepository(db)
+	passwordHistoryRepo := repository.NewPasswordHistoryRepository(db)
+	organizationRepo := repository.NewOrganizationRepository(db)
+	scheduledJobRepo := repository.NewScheduledJobRepository(db)
+	usageRollupRepo := repository.NewUsageRollupRepository(db)
+	metricsUsecase := usecase.NewMetricsUsecase(repository.NewOrganizationMetricsRepository(db), repository.NewUserMetricsRepository(db), usageRollupRepo, organizationRepo, userRepo, timeout)
 
 	// Initialize admin controller
 	ac := &controller.AdminController{
-		UserServiceLogUsecase:      usecase.NewUserServiceLogUsecase(userServiceLogRepo, timeout),
+		UserServiceLogUsecase:      usecase.NewUserServiceLogUsecase(userServiceLogRepo, usageRollupRepo, timeout),
 		ContactIntentUsecase:       usecase.NewContactIntentUsecase(contactIntentRepo, timeout),
 		OrganizationRoleRepository: organizationRoleRepo,
 		UserRoleRepository:         userRoleRepo,
-		UserUsecase:                usecase.NewUserUsecase(userRepo, timeout),
-		ServiceUsecase:             usecase.NewServiceUsecase(serviceRepo, userServiceLogRepo, timeout),
+		UserUsecase:                usecase.NewUserUsecase(userRepo, organizationRepo, usecase.NewPasswordUsecase(userRepo, passwordHistoryRepo, bootstrap.NewPasswordPolicy(env), timeout), timeout),
+		ServiceUsecase:             usecase.NewServiceUsecase(serviceRepo, userServiceLogRepo, userRepo, guestAccessRepo, metricsUsecase, time.Duration(env.UsageHeartbeatTimeoutSecond)*time.Second, timeout),
+		ScheduledJobUsecase:        usecase.NewScheduledJobUsecase(scheduledJobRepo, timeout),
 		Env:                        env,
 	}
 
-	// Register admin routes (all protected)
-	group.GET("/admin/statistics", ac.GetUsageStatistics)
-	group.GET("/admin/contact-intents", ac.GetContactIntents)
-	group.GET("/admin/organization-roles", ac.GetOrganizationRoles)
-	group.GET("/admin/user-roles", ac.GetUserRoles)
-	group.GET("/admin/organizations/:id/services", ac.GetOrganizationServices)
-	group.GET("/admin/organizations/:id/users", ac.GetOrganizationUsers)
-	group.DELETE("/admin/organizations/:organizationId/services/:serviceId", ac.UnlinkServiceFromOrganization)
-	group.PATCH("/admin/users/:userId/:action", ac.ToggleUserArchiveStatus)
+	// Register admin routes (all protected, platform admins only)
+	group.GET("/admin/statistics", middleware.Authorize(platformAdmin.WithScopes(domain.ScopeStatisticsRead)), ac.GetUsageStatistics)
+	group.GET("/admin/contact-intents", middleware.Authorize(platformAdmin.WithScopes(domain.ScopeContactIntentsRead)), ac.GetContactIntents)
+	group.GET("/admin/organization-roles", middleware.Authorize(platformAdmin.WithScopes(domain.ScopeOrganizationsRead)), ac.GetOrganizationRoles)
+	group.GET("/admin/user-roles", middleware.Authorize(platformAdmin.WithScopes(domain.ScopeUsersRead)), ac.GetUserRoles)
+	group.GET("/admin/organizations/:id/services", middleware.Authorize(platformAdmin.WithScopes(domain.ScopeOrganizationsRead)), ac.GetOrganizationServices)
+	group.GET("/admin/organizations/:id/users", middleware.Authorize(platformAdmin.WithScopes(domain.ScopeOrganizationsRead)), ac.GetOrganizationUsers)
+	group.DELETE("/admin/organizations/:id/services/:serviceId", middleware.Authorize(platformAdmin.WithScopes(domain.ScopeOrganizationsWrite)), ac.UnlinkServiceFromOrganization)
+	group.GET("/admin/jobs", middleware.Authorize(platformAdmin), ac.GetScheduledJobs)
+	group.PATCH("/admin/users/:userId/:action", middleware.Authorize(platformAdmin.WithScopes(domain.ScopeUsersWrite)), ac.ToggleUserArchiveStatus)
 }
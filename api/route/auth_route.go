@@ -4,24 +4,46 @@ import (
 	"time"
 
 	"github.com/gabrielfmcoelho/platform-core/api/controller"
+	"github.com/gabrielfmcoelho/platform-core/api/middleware"
 	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
 	"github.com/gabrielfmcoelho/platform-core/repository"
 	"github.com/gabrielfmcoelho/platform-core/usecase"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func NewAuthRouter(env *bootstrap.Env, timeout time.Duration, db *gorm.DB, group *gin.RouterGroup) {
+func NewAuthRouter(env *bootstrap.Env, timeout time.Duration, db *gorm.DB, mailer domain.Mailer, keyRing domain.KeyRing, loginAttemptStore domain.LoginAttemptStore, publicGroup *gin.RouterGroup, protectedGroup *gin.RouterGroup) {
 	ur := repository.NewUserRepository(db)
 	ulr := repository.NewUserLogRepository(db)
+	rtr := repository.NewRefreshTokenRepository(db)
+	sr := repository.NewSessionRepository(db)
+	prr := repository.NewPasswordResetTokenRepository(db)
+	phr := repository.NewPasswordHistoryRepository(db)
+	pu := usecase.NewPasswordUsecase(ur, phr, bootstrap.NewPasswordPolicy(env), timeout)
+	mor := repository.NewMailOutboxRepository(db)
+	mou := usecase.NewMailOutboxUsecase(mor, mailer, timeout)
+	mr := repository.NewMFARepository(db)
+	mu := usecase.NewMFAUsecase(mr, ur, ulr, env.MFAIssuer, env.MFAEncryptionKey, timeout)
+	gar := repository.NewGuestAccessRepository(db)
+	gur := repository.NewGuestUserRepository(db)
+	uslr := repository.NewUserServiceLogRepository(db)
+	or := repository.NewOrganizationRepository(db)
+	mtu := usecase.NewMetricsUsecase(repository.NewOrganizationMetricsRepository(db), repository.NewUserMetricsRepository(db), repository.NewUsageRollupRepository(db), or, ur, timeout)
+	lau := usecase.NewLoginAttemptUsecase(loginAttemptStore, ur, ulr, bootstrap.NewLoginThrottlePolicy(env), timeout)
 	ac := &controller.AuthController{
-		AuthUsecase: usecase.NewAuthUsecase(ur, ulr, timeout),
+		AuthUsecase: usecase.NewAuthUsecase(ur, ulr, rtr, sr, prr, pu, mou, mu, lau, gar, gur, uslr, mtu, keyRing, timeout),
 		Env:         env,
 	}
 
-	group.POST("/login", ac.Login)
-	group.POST("/login-guest", ac.LoginGuest)
-	group.POST("/forgot-password", ac.ForgotPassword)
-	group.POST("/reset-password", ac.ResetPassword)
-	group.POST("/refresh-token", ac.RefreshToken)
+	publicGroup.POST("/login", ac.Login)
+	publicGroup.POST("/login/mfa", ac.LoginMFA)
+	publicGroup.POST("/login/mfa/enroll", ac.LoginMFAEnroll)
+	publicGroup.POST("/login-guest", ac.LoginGuest)
+	publicGroup.POST("/forgot-password", ac.ForgotPassword)
+	publicGroup.POST("/reset-password", ac.ResetPassword)
+	publicGroup.POST("/refresh-token", ac.RefreshToken)
+	publicGroup.POST("/logout", ac.Logout)
+
+	protectedGroup.POST("/logout-all", middleware.Authorize(authenticated), ac.LogoutAll)
 }
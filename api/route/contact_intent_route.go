@@ -4,7 +4,9 @@ import (
 	"time"
 
 	"github.com/gabrielfmcoelho/platform-core/api/controller"
+	"github.com/gabrielfmcoelho/platform-core/api/middleware"
 	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
 	"github.com/gabrielfmcoelho/platform-core/repository"
 	"github.com/gabrielfmcoelho/platform-core/usecase"
 	"github.com/gin-gonic/gin"
@@ -22,6 +24,6 @@ func NewContactIntentRouter(env *bootstrap.Env, timeout time.Duration, db *gorm.
 	publicGroup.POST("/contact-intent", cic.CreateContactIntent)
 
 	// Protected routes - admin only
-	protectedGroup.GET("/contact-intents", cic.FetchContactIntents)
-	protectedGroup.PATCH("/contact-intent/:id/status", cic.UpdateContactIntentStatus)
+	protectedGroup.GET("/contact-intents", middleware.Authorize(platformAdmin.WithScopes(domain.ScopeContactIntentsRead)), cic.FetchContactIntents)
+	protectedGroup.PATCH("/contact-intent/:id/status", middleware.Authorize(platformAdmin.WithScopes(domain.ScopeContactIntentsWrite)), cic.UpdateContactIntentStatus)
 }
@@ -0,0 +1,27 @@
+package route
+
+import (
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/api/controller"
+	"github.com/gabrielfmcoelho/platform-core/api/middleware"
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/repository"
+	"github.com/gabrielfmcoelho/platform-core/usecase"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func NewGuestAccessRouter(env *bootstrap.Env, timeout time.Duration, db *gorm.DB, group *gin.RouterGroup) {
+	gar := repository.NewGuestAccessRepository(db)
+	gur := repository.NewGuestUserRepository(db)
+	or := repository.NewOrganizationRepository(db)
+	gac := &controller.GuestAccessController{
+		GuestAccessUsecase: usecase.NewGuestAccessUsecase(gar, gur, or, env.GuestSessionMinute, timeout),
+		Env:                env,
+	}
+
+	group.GET("/organization/:identifier/guest-access", middleware.Authorize(organizationAdmin.WithScopes(domain.ScopeOrganizationsRead)), gac.GetGuestAccess) // Guest access of the organization
+	group.PUT("/organization/:id/guest-access", middleware.Authorize(organizationAdmin.WithScopes(domain.ScopeOrganizationsWrite)), gac.SetGuestAccess)        // Configure the guest access
+}
@@ -0,0 +1,21 @@
+package route
+
+import (
+	"github.com/gabrielfmcoelho/platform-core/api/controller"
+	"github.com/gabrielfmcoelho/platform-core/api/middleware"
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gin-gonic/gin"
+)
+
+func NewImpersonationRouter(env *bootstrap.Env, impersonationUsecase domain.ImpersonationUsecase, group *gin.RouterGroup) {
+	ic := &controller.ImpersonationController{
+		ImpersonationUsecase: impersonationUsecase,
+		Env:                  env,
+	}
+
+	group.POST("/admin/users/:userId/impersonate", middleware.Authorize(platformAdmin), ic.StartImpersonation)        // Act as a user (read only)
+	group.GET("/admin/users/:userId/impersonations", middleware.Authorize(platformAdmin), ic.FetchUserImpersonations) // Impersonations of a user
+	group.POST("/impersonation/stop", middleware.Authorize(authenticated), ic.StopImpersonation)                      // End the impersonation of the token
+	group.GET("/me/impersonations", middleware.Authorize(member), ic.FetchMyImpersonations)                           // When support accessed my account
+}
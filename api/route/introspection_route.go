@@ -0,0 +1,31 @@
+package route
+
+import (
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/api/controller"
+	"github.com/gabrielfmcoelho/platform-core/api/middleware"
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/repository"
+	"github.com/gabrielfmcoelho/platform-core/usecase"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func NewIntrospectionRouter(env *bootstrap.Env, timeout time.Duration, db *gorm.DB, keyRing domain.KeyRing, group *gin.RouterGroup) {
+	ur := repository.NewUserRepository(db)
+	or := repository.NewOrganizationRepository(db)
+	rtr := repository.NewRefreshTokenRepository(db)
+	sar := repository.NewServiceAccountRepository(db)
+	imr := repository.NewImpersonationRepository(db)
+	sr := repository.NewSessionRepository(db)
+	gar := repository.NewGuestAccessRepository(db)
+	ic := &controller.IntrospectionController{
+		IntrospectionUsecase: usecase.NewIntrospectionUsecase(ur, or, rtr, sar, imr, sr, gar, keyRing, timeout),
+		Env:                  env,
+	}
+
+	group.POST("/introspect", middleware.Authorize(serviceAccountOnly.WithScopes(domain.ScopeTokensIntrospect)), ic.Introspect) // For the launched microservices
+	group.GET("/userinfo", middleware.Authorize(authenticated), ic.GetUserInfo)                                                 // Caller's own profile and entitlements
+}
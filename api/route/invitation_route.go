@@ -0,0 +1,36 @@
+package route
+
+import (
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/api/controller"
+	"github.com/gabrielfmcoelho/platform-core/api/middleware"
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/repository"
+	"github.com/gabrielfmcoelho/platform-core/usecase"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func NewInvitationRouter(env *bootstrap.Env, timeout time.Duration, db *gorm.DB, mailer domain.Mailer, publicGroup *gin.RouterGroup, protectedGroup *gin.RouterGroup) {
+	ir := repository.NewInvitationRepository(db)
+	ur := repository.NewUserRepository(db)
+	or := repository.NewOrganizationRepository(db)
+	ulr := repository.NewUserLogRepository(db)
+	mor := repository.NewMailOutboxRepository(db)
+	phr := repository.NewPasswordHistoryRepository(db)
+	pu := usecase.NewPasswordUsecase(ur, phr, bootstrap.NewPasswordPolicy(env), timeout)
+	mou := usecase.NewMailOutboxUsecase(mor, mailer, timeout)
+	ic := &controller.InvitationController{
+		InvitationUsecase: usecase.NewInvitationUsecase(ir, ur, or, ulr, mou, pu, env.AccessTokenSecret, env.InvitationURL, env.InvitationExpiryHour, timeout),
+		Env:               env,
+	}
+
+	publicGroup.POST("/invitations/accept", ic.AcceptInvitation) // Create the account of an invitee
+
+	protectedGroup.POST("/organization/:id/invitations", middleware.Authorize(organizationAdmin.WithScopes(domain.ScopeUsersWrite)), ic.CreateInvitation)                      // Invite a member
+	protectedGroup.GET("/organization/:identifier/invitations", middleware.Authorize(organizationAdmin.WithScopes(domain.ScopeUsersRead)), ic.FetchInvitations)                // Invitations waiting for an answer
+	protectedGroup.POST("/organization/:id/invitations/:invitationId/resend", middleware.Authorize(organizationAdmin.WithScopes(domain.ScopeUsersWrite)), ic.ResendInvitation) // Send a new link
+	protectedGroup.DELETE("/organization/:id/invitations/:invitationId", middleware.Authorize(organizationAdmin.WithScopes(domain.ScopeUsersWrite)), ic.RevokeInvitation)      // Revoke an invitation
+}
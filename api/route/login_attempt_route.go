@@ -0,0 +1,27 @@
+package route
+
+import (
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/api/controller"
+	"github.com/gabrielfmcoelho/platform-core/api/middleware"
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/repository"
+	"github.com/gabrielfmcoelho/platform-core/usecase"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func NewLoginAttemptRouter(env *bootstrap.Env, timeout time.Duration, db *gorm.DB, loginAttemptStore domain.LoginAttemptStore, group *gin.RouterGroup) {
+	ur := repository.NewUserRepository(db)
+	ulr := repository.NewUserLogRepository(db)
+	lac := &controller.LoginAttemptController{
+		LoginAttemptUsecase: usecase.NewLoginAttemptUsecase(loginAttemptStore, ur, ulr, bootstrap.NewLoginThrottlePolicy(env), timeout),
+		Env:                 env,
+	}
+
+	group.GET("/admin/users/:userId/lockout", middleware.Authorize(platformAdmin), lac.GetUserLockout) // Failed logins and lockout of a user
+	group.DELETE("/admin/users/:userId/lockout", middleware.Authorize(platformAdmin), lac.UnlockUser)  // Unlock a user
+	group.DELETE("/admin/ip-lockouts/:ip", middleware.Authorize(platformAdmin), lac.UnlockIP)          // Unlock a client IP
+}
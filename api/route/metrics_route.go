@@ -0,0 +1,29 @@
+package route
+
+import (
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/api/controller"
+	"github.com/gabrielfmcoelho/platform-core/api/middleware"
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/repository"
+	"github.com/gabrielfmcoelho/platform-core/usecase"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func NewMetricsRouter(env *bootstrap.Env, timeout time.Duration, db *gorm.DB, group *gin.RouterGroup) {
+	omr := repository.NewOrganizationMetricsRepository(db)
+	umr := repository.NewUserMetricsRepository(db)
+	urr := repository.NewUsageRollupRepository(db)
+	or := repository.NewOrganizationRepository(db)
+	ur := repository.NewUserRepository(db)
+	mc := &controller.MetricsController{
+		MetricsUsecase: usecase.NewMetricsUsecase(omr, umr, urr, or, ur, timeout),
+		Env:            env,
+	}
+
+	group.GET("/organization/:identifier/metrics", middleware.Authorize(organizationAdmin.WithScopes(domain.ScopeStatisticsRead)), mc.GetOrganizationMetrics) // Metrics of the organization
+	group.GET("/user/:identifier/metrics", middleware.Authorize(authenticated.WithScopes(domain.ScopeStatisticsRead)), mc.GetUserMetrics)                     // Metrics of the user
+}
@@ -0,0 +1,30 @@
+package route
+
+import (
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/api/controller"
+	"github.com/gabrielfmcoelho/platform-core/api/middleware"
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/repository"
+	"github.com/gabrielfmcoelho/platform-core/usecase"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func NewMFARouter(env *bootstrap.Env, timeout time.Duration, db *gorm.DB, group *gin.RouterGroup) {
+	mr := repository.NewMFARepository(db)
+	ur := repository.NewUserRepository(db)
+	ulr := repository.NewUserLogRepository(db)
+	mc := &controller.MFAController{
+		MFAUsecase: usecase.NewMFAUsecase(mr, ur, ulr, env.MFAIssuer, env.MFAEncryptionKey, timeout),
+		Env:        env,
+	}
+
+	group.GET("/me/mfa", middleware.Authorize(member), mc.GetStatus)                               // MFA status of the authenticated user
+	group.POST("/me/mfa/totp/enroll", middleware.Authorize(member), mc.Enroll)                     // Start TOTP enrollment
+	group.POST("/me/mfa/totp/verify", middleware.Authorize(member), mc.Activate)                   // Confirm TOTP enrollment
+	group.POST("/me/mfa/recovery-codes", middleware.Authorize(member), mc.RegenerateRecoveryCodes) // Regenerate recovery codes
+	group.DELETE("/me/mfa/totp", middleware.Authorize(member), mc.Disable)                         // Disable TOTP
+	group.DELETE("/admin/users/:userId/mfa", middleware.Authorize(platformAdmin), mc.ResetUserMFA) // Reset the MFA of a user
+}
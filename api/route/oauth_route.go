@@ -0,0 +1,42 @@
+package route
+
+import (
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/api/controller"
+	"github.com/gabrielfmcoelho/platform-core/api/middleware"
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/repository"
+	"github.com/gabrielfmcoelho/platform-core/usecase"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func NewOAuthRouter(env *bootstrap.Env, timeout time.Duration, db *gorm.DB, ou domain.OAuthUsecase, publicGroup *gin.RouterGroup, protectedGroup *gin.RouterGroup) {
+	ocr := repository.NewOAuthClientRepository(db)
+	sr := repository.NewServiceRepository(db)
+	oc := &controller.OAuthController{
+		OAuthUsecase: ou,
+		Env:          env,
+	}
+	occ := &controller.OAuthClientController{
+		OAuthClientUsecase: usecase.NewOAuthClientUsecase(ocr, sr, timeout),
+		Env:                env,
+	}
+
+	// Public routes - used by the services signing their users in
+	publicGroup.GET("/.well-known/openid-configuration", oc.Discovery) // Provider metadata
+	publicGroup.GET("/oauth/userinfo", oc.UserInfo)                    // Claims behind an OAuth access token (/userinfo is the platform profile)
+	publicGroup.POST("/oauth/userinfo", oc.UserInfo)
+
+	protectedGroup.GET("/authorize", middleware.Authorize(authenticated), oc.Authorize) // Authorization request of a client, forwarded by the hub
+	protectedGroup.POST("/authorize", middleware.Authorize(authenticated), oc.Consent)  // Answer of the consent screen
+
+	// Protected routes - platform admins only
+	protectedGroup.POST("/admin/oauth-clients", middleware.Authorize(platformAdmin), occ.CreateOAuthClient)
+	protectedGroup.GET("/admin/oauth-clients", middleware.Authorize(platformAdmin), occ.FetchOAuthClients)
+	protectedGroup.PUT("/admin/oauth-clients/:id", middleware.Authorize(platformAdmin), occ.UpdateOAuthClient)
+	protectedGroup.POST("/admin/oauth-clients/:id/rotate-secret", middleware.Authorize(platformAdmin), occ.RotateOAuthClientSecret)
+	protectedGroup.DELETE("/admin/oauth-clients/:id", middleware.Authorize(platformAdmin), occ.DeleteOAuthClient)
+}
@@ -4,7 +4,9 @@ import (
 	"time"
 
 	"github.com/gabrielfmcoelho/platform-core/api/controller"
+	"github.com/gabrielfmcoelho/platform-core/api/middleware"
 	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
 	"github.com/gabrielfmcoelho/platform-core/repository"
 	"github.com/gabrielfmcoelho/platform-core/usecase"
 	"github.com/gin-gonic/gin"
@@ -13,13 +15,18 @@ import (
 
 func NewOrganizationRouter(env *bootstrap.Env, timeout time.Duration, db *gorm.DB, group *gin.RouterGroup) {
 	or := repository.NewOrganizationRepository(db)
+	orr := repository.NewOrganizationRoleRepository(db)
 	oc := &controller.OrganizationController{
-		OrganizationUsecase: usecase.NewOrganizationUsecase(or),
+		OrganizationUsecase: usecase.NewOrganizationUsecase(or, orr),
 		Env:                 env,
 	}
 
-	group.POST("/organization", oc.CreateOrganization)            // Create a new organization
-	group.GET("/organizations", oc.FetchOrganizations)            // Get all organizations
-	group.GET("/organization/:identifier", oc.GetOrganization)    // Get organization by ID or name
-	group.DELETE("/organization/:id", oc.DeleteOrganization)      // Delete organization
+	group.POST("/organization", middleware.Authorize(platformAdmin.WithScopes(domain.ScopeOrganizationsWrite)), oc.CreateOrganization)               // Create a new organization
+	group.GET("/organizations", middleware.Authorize(platformAdmin.WithScopes(domain.ScopeOrganizationsRead)), oc.FetchOrganizations)                // Get all organizations
+	group.GET("/organization/:identifier", middleware.Authorize(authenticated.WithScopes(domain.ScopeOrganizationsRead)), oc.GetOrganization)        // Get organization by ID or name
+	group.PUT("/organization/:id", middleware.Authorize(organizationAdmin.WithScopes(domain.ScopeOrganizationsWrite)), oc.UpdateOrganization)        // Update organization
+	group.PATCH("/organization/:id", middleware.Authorize(organizationAdmin.WithScopes(domain.ScopeOrganizationsWrite)), oc.PatchOrganization)       // Update some fields of the organization
+	group.POST("/organization/:id/restore", middleware.Authorize(platformAdmin.WithScopes(domain.ScopeOrganizationsWrite)), oc.RestoreOrganization)  // Restore an archived organization
+	group.PATCH("/organization/:id/mfa-policy", middleware.Authorize(organizationAdmin.WithScopes(domain.ScopeOrganizationsWrite)), oc.SetMFAPolicy) // Require MFA for the organization
+	group.DELETE("/organization/:id", middleware.Authorize(platformAdmin.WithScopes(domain.ScopeOrganizationsWrite)), oc.DeleteOrganization)         // Delete organization
 }
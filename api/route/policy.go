@@ -0,0 +1,32 @@
+package route
+
+import (
+	"github.com/gabrielfmcoelho/platform-core/api/middleware"
+	"github.com/gabrielfmcoelho/platform-core/domain"
+)
+
+// Access policies shared by the protected routers. Every protected route
+// declares one of them at registration time.
+var (
+	// Platform administrators: Admin users of an Admin organization (Solude)
+	platformAdmin = middleware.Policy{
+		UserRoles:         []uint{domain.UserRoleAdmin},
+		OrganizationRoles: []uint{domain.OrganizationRoleAdmin},
+	}
+
+	// Administrators and managers of an organization (ownership is checked by the handler)
+	organizationAdmin = middleware.Policy{
+		UserRoles: []uint{domain.UserRoleAdmin, domain.UserRoleManager},
+	}
+
+	// Users with an account of their own (not guests)
+	member = middleware.Policy{
+		UserRoles: []uint{domain.UserRoleAdmin, domain.UserRoleManager, domain.UserRoleUser},
+	}
+
+	// Any authenticated caller, guests included
+	authenticated = middleware.Policy{}
+
+	// Machine callers only (combine with WithScopes), users are always refused
+	serviceAccountOnly = middleware.Policy{ServiceAccountsOnly: true}
+)
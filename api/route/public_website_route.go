@@ -14,8 +14,12 @@ import (
 func NewPublicWebsiteRouter(env *bootstrap.Env, timeout time.Duration, db *gorm.DB, group *gin.RouterGroup) {
 	sr := repository.NewServiceRepository(db)
 	uslr := repository.NewUserServiceLogRepository(db)
+	ur := repository.NewUserRepository(db)
+	gar := repository.NewGuestAccessRepository(db)
+	or := repository.NewOrganizationRepository(db)
+	mtu := usecase.NewMetricsUsecase(repository.NewOrganizationMetricsRepository(db), repository.NewUserMetricsRepository(db), repository.NewUsageRollupRepository(db), or, ur, timeout)
 	pwc := &controller.PublicWebsiteController{
-		ServiceUsecase: usecase.NewServiceUsecase(sr, uslr, timeout),
+		ServiceUsecase: usecase.NewServiceUsecase(sr, uslr, ur, gar, mtu, time.Duration(env.UsageHeartbeatTimeoutSecond)*time.Second, timeout),
 	}
 
 	group.GET("/services/marketing", pwc.GetMarketingServices)
@@ -5,6 +5,9 @@ import (
 
 	"github.com/gabrielfmcoelho/platform-core/api/middleware"
 	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/repository"
+	"github.com/gabrielfmcoelho/platform-core/usecase"
 
 	//_ "github.com/gabrielfmcoelho/platform-coredocs"
 	"github.com/gin-gonic/gin"
@@ -14,7 +17,7 @@ import (
 	"gorm.io/gorm"
 )
 
-func Setup(env *bootstrap.Env, timeout time.Duration, db *gorm.DB, router *gin.Engine) {
+func Setup(env *bootstrap.Env, timeout time.Duration, db *gorm.DB, mailer domain.Mailer, keyRing domain.KeyRing, loginAttemptStore domain.LoginAttemptStore, router *gin.Engine) {
 	// Router documentation binding
 	doc := redoc.Redoc{
 		Title:       "Platform Core API",
@@ -28,23 +31,83 @@ func Setup(env *bootstrap.Env, timeout time.Duration, db *gorm.DB, router *gin.E
 	// All Public APIs
 	publicRouter := router.Group("/")
 	//NewSignupRouter(env, timeout, db, publicRouter)
-	NewAuthRouter(env, timeout, db, publicRouter)
 	NewPublicWebsiteRouter(env, timeout, db, publicRouter)
 	//NewRefreshTokenRouter(env, timeout, db, publicRouter)
 
+	// Sessions, impersonation and service account tokens are checked on every protected request
+	su := usecase.NewSessionUsecase(
+		repository.NewSessionRepository(db),
+		repository.NewUserLogRepository(db),
+		repository.NewUserServiceLogRepository(db),
+		timeout,
+	)
+	iu := usecase.NewImpersonationUsecase(
+		repository.NewImpersonationRepository(db),
+		repository.NewUserRepository(db),
+		repository.NewUserLogRepository(db),
+		keyRing,
+		env.ImpersonationExpiryMinute,
+		timeout,
+	)
+
+	sau := usecase.NewServiceAccountUsecase(repository.NewServiceAccountRepository(db), timeout)
+
 	// All Private APIs
 	protectedRouter := router.Group("/")
 	/// Middleware to verify AccessToken
-	protectedRouter.Use(middleware.JwtAuthMiddleware(env.AccessTokenSecret))
+	protectedRouter.Use(middleware.JwtAuthMiddleware(keyRing, env.ServiceAccountTokenSecret, su, iu, sau))
+	/// Middleware to keep impersonating admins from changing anything
+	protectedRouter.Use(middleware.ReadOnlyImpersonation("/impersonation/stop"))
 	NewUserRouter(env, timeout, db, protectedRouter)
 	NewOrganizationRouter(env, timeout, db, protectedRouter)
-	NewServiceRouter(env, timeout, db, protectedRouter)
+	NewGuestAccessRouter(env, timeout, db, protectedRouter)
+	NewMetricsRouter(env, timeout, db, protectedRouter)
+	NewServiceRouter(env, timeout, db, keyRing, publicRouter, protectedRouter)
+	NewMFARouter(env, timeout, db, protectedRouter)
+	NewSessionRouter(env, su, protectedRouter)
+	NewIntrospectionRouter(env, timeout, db, keyRing, protectedRouter)
 	//NewProfileRouter(env, timeout, db, protectedRouter)
 	//NewTaskRouter(env, timeout, db, protectedRouter)
 
+	// Auth Routes (public login/refresh/logout, protected logout-all)
+	NewAuthRouter(env, timeout, db, mailer, keyRing, loginAttemptStore, publicRouter, protectedRouter)
+
+	// Single Sign-On Routes (public login/callback, protected configuration)
+	NewSSORouter(env, timeout, db, mailer, keyRing, loginAttemptStore, publicRouter, protectedRouter)
+
 	// Contact Intent Routes (both public and protected)
 	NewContactIntentRouter(env, timeout, db, publicRouter, protectedRouter)
 
+	// Invitation Routes (public acceptance, protected management)
+	NewInvitationRouter(env, timeout, db, mailer, publicRouter, protectedRouter)
+
 	// Admin Routes (all protected)
 	NewAdminRouter(env, timeout, db, protectedRouter)
+	NewSubscriptionRouter(env, timeout, db, protectedRouter)
+	NewLoginAttemptRouter(env, timeout, db, loginAttemptStore, protectedRouter)
+	NewImpersonationRouter(env, iu, protectedRouter)
+
+	// The OAuth provider answers on its own routes and on the authorization_code grant of /token
+	ou := usecase.NewOAuthUsecase(
+		repository.NewOAuthClientRepository(db),
+		repository.NewOAuthAuthorizationCodeRepository(db),
+		repository.NewOAuthConsentRepository(db),
+		repository.NewUserRepository(db),
+		repository.NewOrganizationRepository(db),
+		repository.NewUserLogRepository(db),
+		keyRing,
+		env.OAuthIssuer,
+		env.OAuthAuthorizationURL,
+		env.OAuthTokenExpiryMinute,
+		timeout,
+	)
+
+	// Service Account Routes (public token endpoint, protected management)
+	NewServiceAccountRouter(env, timeout, db, ou, publicRouter, protectedRouter)
+
+	// Signing Key Routes (public JWKS, protected key management)
+	NewSigningKeyRouter(env, timeout, db, keyRing, publicRouter, protectedRouter)
+
+	// OAuth Routes (public discovery and userinfo, protected authorization and client management)
+	NewOAuthRouter(env, timeout, db, ou, publicRouter, protectedRouter)
 }
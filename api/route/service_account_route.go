@@ -0,0 +1,32 @@
+package route
+
+import (
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/api/controller"
+	"github.com/gabrielfmcoelho/platform-core/api/middleware"
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/repository"
+	"github.com/gabrielfmcoelho/platform-core/usecase"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func NewServiceAccountRouter(env *bootstrap.Env, timeout time.Duration, db *gorm.DB, ou domain.OAuthUsecase, publicGroup *gin.RouterGroup, protectedGroup *gin.RouterGroup) {
+	sar := repository.NewServiceAccountRepository(db)
+	sac := &controller.ServiceAccountController{
+		ServiceAccountUsecase: usecase.NewServiceAccountUsecase(sar, timeout),
+		OAuthUsecase:          ou,
+		Env:                   env,
+	}
+
+	// Public route - client_credentials grant for the other microservices, authorization_code grant for the OAuth clients
+	publicGroup.POST("/token", sac.Token)
+
+	// Protected routes - platform admins only, service accounts cannot manage service accounts
+	protectedGroup.POST("/admin/service-accounts", middleware.Authorize(platformAdmin), sac.CreateServiceAccount)
+	protectedGroup.GET("/admin/service-accounts", middleware.Authorize(platformAdmin), sac.FetchServiceAccounts)
+	protectedGroup.POST("/admin/service-accounts/:id/rotate-secret", middleware.Authorize(platformAdmin), sac.RotateServiceAccountSecret)
+	protectedGroup.DELETE("/admin/service-accounts/:id", middleware.Authorize(platformAdmin), sac.DeleteServiceAccount)
+}
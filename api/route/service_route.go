@@ -4,30 +4,47 @@ import (
 	"time"
 
 	"github.com/gabrielfmcoelho/platform-core/api/controller"
+	"github.com/gabrielfmcoelho/platform-core/api/middleware"
 	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
 	"github.com/gabrielfmcoelho/platform-core/repository"
 	"github.com/gabrielfmcoelho/platform-core/usecase"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func NewServiceRouter(env *bootstrap.Env, timeout time.Duration, db *gorm.DB, group *gin.RouterGroup) {
+func NewServiceRouter(env *bootstrap.Env, timeout time.Duration, db *gorm.DB, keyRing domain.KeyRing, publicGroup *gin.RouterGroup, protectedGroup *gin.RouterGroup) {
 	sr := repository.NewServiceRepository(db)
 	uslr := repository.NewUserServiceLogRepository(db)
 	ur := repository.NewUserRepository(db)
+	gar := repository.NewGuestAccessRepository(db)
+	phr := repository.NewPasswordHistoryRepository(db)
+	slr := repository.NewServiceLaunchRepository(db)
+	ocr := repository.NewOAuthClientRepository(db)
+	or := repository.NewOrganizationRepository(db)
+	srr := repository.NewServiceReportRepository(db)
+	mtu := usecase.NewMetricsUsecase(repository.NewOrganizationMetricsRepository(db), repository.NewUserMetricsRepository(db), repository.NewUsageRollupRepository(db), or, ur, timeout)
 	sc := &controller.ServiceController{
-		ServiceUsecase: usecase.NewServiceUsecase(sr, uslr, timeout),
-		UserUsecase:    usecase.NewUserUsecase(ur, timeout),
+		ServiceUsecase: usecase.NewServiceUsecase(sr, uslr, ur, gar, mtu, time.Duration(env.UsageHeartbeatTimeoutSecond)*time.Second, timeout),
+		UserUsecase:    usecase.NewUserUsecase(ur, or, usecase.NewPasswordUsecase(ur, phr, bootstrap.NewPasswordPolicy(env), timeout), timeout),
+		LaunchUsecase:  usecase.NewLaunchUsecase(slr, ur, ocr, keyRing, env.OAuthIssuer, env.LaunchTokenExpirySecond, timeout),
+		ReportUsecase:  usecase.NewReportUsecase(srr, uslr, ur, ocr, mtu, timeout),
 		Env:            env,
 	}
 
-	group.POST("/service", sc.CreateService)
-	group.GET("/services", sc.FetchServices)
-	group.GET("/service/info", sc.GetServiceByIdentifier)
-	group.GET("/service/:serviceID/application", sc.GetServiceApplication) // "start" usage
-	group.POST("/service/:serviceID/organization/:organizationID", sc.SetServiceAvailabilityToOrganization)
-	group.GET("/services/organization", sc.GetServicesByOrganization)
-	group.PUT("/service/:serviceID", sc.UpdateService)
-	group.DELETE("/service/:serviceID", sc.DeleteService)
-	group.PATCH("/service/heartbeat", sc.HeartbeatService) // "update" usage duration
+	// Public route - the backend of a launched service redeems its launch token
+	publicGroup.POST("/service/launch/exchange", sc.ExchangeLaunchToken)
+	// Public route - the backend of a service records a report against the limit of the organization
+	publicGroup.POST("/service/reports", sc.RecordReport)
+
+	protectedGroup.POST("/service", middleware.Authorize(platformAdmin.WithScopes(domain.ScopeServicesWrite)), sc.CreateService)
+	protectedGroup.GET("/services", middleware.Authorize(authenticated.WithScopes(domain.ScopeServicesRead)), sc.FetchServices)
+	protectedGroup.GET("/service/info", middleware.Authorize(authenticated.WithScopes(domain.ScopeServicesRead)), sc.GetServiceByIdentifier)
+	protectedGroup.GET("/service/:serviceID/application", middleware.Authorize(authenticated), sc.GetServiceApplication) // "start" usage
+	protectedGroup.POST("/service/:serviceID/organization/:organizationID", middleware.Authorize(platformAdmin.WithScopes(domain.ScopeServicesWrite)), sc.SetServiceAvailabilityToOrganization)
+	protectedGroup.GET("/services/organization", middleware.Authorize(authenticated), sc.GetServicesByOrganization)
+	protectedGroup.PUT("/service/:serviceID", middleware.Authorize(platformAdmin.WithScopes(domain.ScopeServicesWrite)), sc.UpdateService)
+	protectedGroup.DELETE("/service/:serviceID", middleware.Authorize(platformAdmin.WithScopes(domain.ScopeServicesWrite)), sc.DeleteService)
+	protectedGroup.PATCH("/service/heartbeat", middleware.Authorize(authenticated), sc.HeartbeatService) // "update" usage duration
+	protectedGroup.PATCH("/service/close", middleware.Authorize(authenticated), sc.CloseService)         // "stop" usage
 }
@@ -0,0 +1,21 @@
+package route
+
+import (
+	"github.com/gabrielfmcoelho/platform-core/api/controller"
+	"github.com/gabrielfmcoelho/platform-core/api/middleware"
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gin-gonic/gin"
+)
+
+func NewSessionRouter(env *bootstrap.Env, sessionUsecase domain.SessionUsecase, group *gin.RouterGroup) {
+	sc := &controller.SessionController{
+		SessionUsecase: sessionUsecase,
+		Env:            env,
+	}
+
+	group.GET("/me/sessions", middleware.Authorize(member), sc.FetchMySessions)                                         // Devices the user is signed in on
+	group.DELETE("/me/sessions/:id", middleware.Authorize(member), sc.RevokeMySession)                                  // Sign a device out
+	group.GET("/admin/users/:userId/sessions", middleware.Authorize(platformAdmin), sc.FetchUserSessions)               // Devices a user is signed in on
+	group.DELETE("/admin/users/:userId/sessions/:sessionId", middleware.Authorize(platformAdmin), sc.RevokeUserSession) // Sign a device of a user out
+}
@@ -0,0 +1,29 @@
+package route
+
+import (
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/api/controller"
+	"github.com/gabrielfmcoelho/platform-core/api/middleware"
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/repository"
+	"github.com/gabrielfmcoelho/platform-core/usecase"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func NewSigningKeyRouter(env *bootstrap.Env, timeout time.Duration, db *gorm.DB, keyRing domain.KeyRing, publicGroup *gin.RouterGroup, protectedGroup *gin.RouterGroup) {
+	skr := repository.NewSigningKeyRepository(db)
+	skc := &controller.SigningKeyController{
+		SigningKeyUsecase: usecase.NewSigningKeyUsecase(skr, keyRing, timeout),
+		Env:               env,
+	}
+
+	// Public route - key set used by the other services to verify access tokens
+	publicGroup.GET("/.well-known/jwks.json", skc.GetJWKS)
+
+	// Protected routes - platform admins only
+	protectedGroup.GET("/admin/signing-keys", middleware.Authorize(platformAdmin), skc.FetchSigningKeys)
+	protectedGroup.POST("/admin/signing-keys/rotate", middleware.Authorize(platformAdmin), skc.RotateSigningKey)
+}
@@ -0,0 +1,48 @@
+package route
+
+import (
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/api/controller"
+	"github.com/gabrielfmcoelho/platform-core/api/middleware"
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/repository"
+	"github.com/gabrielfmcoelho/platform-core/usecase"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func NewSSORouter(env *bootstrap.Env, timeout time.Duration, db *gorm.DB, mailer domain.Mailer, keyRing domain.KeyRing, loginAttemptStore domain.LoginAttemptStore, publicGroup *gin.RouterGroup, protectedGroup *gin.RouterGroup) {
+	ipr := repository.NewIdentityProviderRepository(db)
+	lsr := repository.NewSSOLoginStateRepository(db)
+	uir := repository.NewUserIdentityRepository(db)
+	ur := repository.NewUserRepository(db)
+	or := repository.NewOrganizationRepository(db)
+	ulr := repository.NewUserLogRepository(db)
+	rtr := repository.NewRefreshTokenRepository(db)
+	sr := repository.NewSessionRepository(db)
+	prr := repository.NewPasswordResetTokenRepository(db)
+	phr := repository.NewPasswordHistoryRepository(db)
+	pu := usecase.NewPasswordUsecase(ur, phr, bootstrap.NewPasswordPolicy(env), timeout)
+	mor := repository.NewMailOutboxRepository(db)
+	mou := usecase.NewMailOutboxUsecase(mor, mailer, timeout)
+	mr := repository.NewMFARepository(db)
+	mu := usecase.NewMFAUsecase(mr, ur, ulr, env.MFAIssuer, env.MFAEncryptionKey, timeout)
+	gar := repository.NewGuestAccessRepository(db)
+	gur := repository.NewGuestUserRepository(db)
+	uslr := repository.NewUserServiceLogRepository(db)
+	mtu := usecase.NewMetricsUsecase(repository.NewOrganizationMetricsRepository(db), repository.NewUserMetricsRepository(db), repository.NewUsageRollupRepository(db), or, ur, timeout)
+	lau := usecase.NewLoginAttemptUsecase(loginAttemptStore, ur, ulr, bootstrap.NewLoginThrottlePolicy(env), timeout)
+	au := usecase.NewAuthUsecase(ur, ulr, rtr, sr, prr, pu, mou, mu, lau, gar, gur, uslr, mtu, keyRing, timeout)
+	sc := &controller.SSOController{
+		SSOUsecase: usecase.NewSSOUsecase(ipr, lsr, uir, ur, or, ulr, au, bootstrap.NewOIDCClient(env), env.OIDCRedirectURL, env.OIDCEncryptionKey, env.OIDCStateExpiryMinute, timeout),
+		Env:        env,
+	}
+
+	publicGroup.GET("/sso/:organizationId/login", sc.Login) // Redirect to the identity provider of the organization
+	publicGroup.POST("/sso/callback", sc.Callback)          // Exchange the provider code for tokens
+
+	protectedGroup.GET("/organization/:identifier/sso", middleware.Authorize(organizationAdmin.WithScopes(domain.ScopeOrganizationsRead)), sc.GetSSO) // Identity provider of the organization
+	protectedGroup.PUT("/organization/:id/sso", middleware.Authorize(organizationAdmin.WithScopes(domain.ScopeOrganizationsWrite)), sc.SetSSO)        // Configure the identity provider
+}
@@ -0,0 +1,31 @@
+package route
+
+import (
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/api/controller"
+	"github.com/gabrielfmcoelho/platform-core/api/middleware"
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/repository"
+	"github.com/gabrielfmcoelho/platform-core/usecase"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func NewSubscriptionRouter(env *bootstrap.Env, timeout time.Duration, db *gorm.DB, group *gin.RouterGroup) {
+	osr := repository.NewOrganizationSubscriptionRepository(db)
+	or := repository.NewOrganizationRepository(db)
+	srr := repository.NewServiceReportRepository(db)
+	sc := &controller.SubscriptionController{
+		OrganizationSubscriptionUsecase: usecase.NewOrganizationSubscriptionUsecase(osr, or, srr, env.SubscriptionGraceDays, timeout),
+		Env:                             env,
+	}
+
+	group.GET("/admin/organizations/:id/subscription", middleware.Authorize(platformAdmin.WithScopes(domain.ScopeOrganizationsRead)), sc.GetSubscription)               // Subscription of the organization with its usage
+	group.GET("/admin/organizations/:id/subscription/renewals", middleware.Authorize(platformAdmin.WithScopes(domain.ScopeOrganizationsRead)), sc.FetchRenewals)        // Periods added by the automatic renewal
+	group.POST("/admin/organizations/:id/subscription", middleware.Authorize(platformAdmin.WithScopes(domain.ScopeOrganizationsWrite)), sc.CreateSubscription)          // Subscribe the organization to a plan
+	group.PUT("/admin/organizations/:id/subscription", middleware.Authorize(platformAdmin.WithScopes(domain.ScopeOrganizationsWrite)), sc.UpdateSubscription)           // Replace the plan and the dates
+	group.PATCH("/admin/organizations/:id/subscription/:action", middleware.Authorize(platformAdmin.WithScopes(domain.ScopeOrganizationsWrite)), sc.ToggleSubscription) // Activate or deactivate the subscription
+	group.DELETE("/admin/organizations/:id/subscription", middleware.Authorize(platformAdmin.WithScopes(domain.ScopeOrganizationsWrite)), sc.DeleteSubscription)        // Remove the subscription
+}
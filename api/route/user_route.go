@@ -4,7 +4,9 @@ import (
 	"time"
 
 	"github.com/gabrielfmcoelho/platform-core/api/controller"
+	"github.com/gabrielfmcoelho/platform-core/api/middleware"
 	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
 	"github.com/gabrielfmcoelho/platform-core/repository"
 	"github.com/gabrielfmcoelho/platform-core/usecase"
 	"github.com/gin-gonic/gin"
@@ -13,16 +15,19 @@ import (
 
 func NewUserRouter(env *bootstrap.Env, timeout time.Duration, db *gorm.DB, group *gin.RouterGroup) {
 	ur := repository.NewUserRepository(db)
+	phr := repository.NewPasswordHistoryRepository(db)
+	or := repository.NewOrganizationRepository(db)
 	uc := &controller.UserController{
-		UserUsecase: usecase.NewUserUsecase(ur, timeout),
+		UserUsecase: usecase.NewUserUsecase(ur, or, usecase.NewPasswordUsecase(ur, phr, bootstrap.NewPasswordPolicy(env), timeout), timeout),
 		Env:         env,
 	}
 
-	group.POST("/user/create", uc.CreateUser)  // Create a new user account
-	group.GET("/users", uc.FetchUsers)         // Get all users
-	group.GET("/user/:identifier", uc.GetUser) // Get user by ID or email
-	group.PUT("/user/:id", uc.UpdateUser)      // Update basic user information (email, password, etc)
-	group.DELETE("/user/:id", uc.DeleteUser)   // Soft delete user (archive)
+	group.POST("/user/create", middleware.Authorize(platformAdmin.WithScopes(domain.ScopeUsersWrite)), uc.CreateUser)                                            // Create a new user account
+	group.GET("/users", middleware.Authorize(platformAdmin.WithScopes(domain.ScopeUsersRead)), uc.FetchUsers)                                                    // Get all users
+	group.GET("/user/:identifier", middleware.Authorize(authenticated.WithScopes(domain.ScopeUsersRead)), uc.GetUser)                                            // Get user by ID or email
+	group.PUT("/user/:id", middleware.Authorize(platformAdmin.WithScopes(domain.ScopeUsersWrite)), uc.UpdateUser)                                                // Update basic user information (email, password, etc)
+	group.POST("/admin/users/:userId/require-password-change", middleware.Authorize(platformAdmin.WithScopes(domain.ScopeUsersWrite)), uc.RequirePasswordChange) // Change the password on next login
+	group.DELETE("/user/:id", middleware.Authorize(platformAdmin.WithScopes(domain.ScopeUsersWrite)), uc.DeleteUser)                                             // Soft delete user (archive)
 }
 
 // DOUBT: How to implement query parameters in the routes in go?
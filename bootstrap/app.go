@@ -3,12 +3,17 @@ package bootstrap
 import (
 	"log"
 
+	"github.com/gabrielfmcoelho/platform-core/domain"
 	"gorm.io/gorm"
 )
 
 type Application struct {
-	Env *Env
-	DB  *gorm.DB
+	Env     *Env
+	DB      *gorm.DB
+	Mailer  domain.Mailer
+	KeyRing domain.KeyRing
+
+	LoginAttemptStore domain.LoginAttemptStore
 }
 
 func App() Application {
@@ -17,10 +22,16 @@ func App() Application {
 	app.DB = NewDatabaseConnection(app.Env)
 
 	// Run auto-migration
-	AutoMigrate(app.DB)
+	AutoMigrate(app.DB, app.Env)
 
 	RunSeeds(app.DB)
 
+	app.Mailer = NewMailer(app.Env)
+
+	app.KeyRing = NewKeyRing(app.Env, app.DB)
+
+	app.LoginAttemptStore = NewLoginAttemptStore(app.Env, app.DB)
+
 	return *app
 }
 
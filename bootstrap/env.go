@@ -2,14 +2,19 @@ package bootstrap
 
 import (
 	"log"
+	"net/url"
 	"os"
+	"strings"
 
+	"github.com/gabrielfmcoelho/platform-core/domain"
 	"github.com/spf13/viper"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type Env struct {
 	AppEnv                 string `mapstructure:"APP_ENV"`
 	ServerAddress          string `mapstructure:"SERVER_ADDRESS"`
+	TrustedProxies         string `mapstructure:"TRUSTED_PROXIES"` // comma separated IPs or CIDRs allowed to set X-Forwarded-For
 	ContextTimeout         int    `mapstructure:"CONTEXT_TIMEOUT"`
 	DBType                 string `mapstructure:"DB_TYPE"`
 	DBHost                 string `mapstructure:"DB_HOST"`
@@ -20,7 +25,56 @@ type Env struct {
 	AccessTokenExpiryHour  int    `mapstructure:"ACCESS_TOKEN_EXPIRY_HOUR"`
 	RefreshTokenExpiryHour int    `mapstructure:"REFRESH_TOKEN_EXPIRY_HOUR"`
 	AccessTokenSecret      string `mapstructure:"ACCESS_TOKEN_SECRET"`
-	RefreshTokenSecret     string `mapstructure:"REFRESH_TOKEN_SECRET"`
+
+	ServiceAccountTokenSecret     string `mapstructure:"SERVICE_ACCOUNT_TOKEN_SECRET"`
+	ServiceAccountTokenExpiryHour int    `mapstructure:"SERVICE_ACCOUNT_TOKEN_EXPIRY_HOUR"`
+
+	SigningKeyAlgorithm     string `mapstructure:"SIGNING_KEY_ALGORITHM"`
+	SigningKeyEncryptionKey string `mapstructure:"SIGNING_KEY_ENCRYPTION_KEY"`
+
+	LoginAttemptStore        string `mapstructure:"LOGIN_ATTEMPT_STORE"`
+	LoginMaxFailedAttempts   int    `mapstructure:"LOGIN_MAX_FAILED_ATTEMPTS"`
+	LoginIPMaxFailedAttempts int    `mapstructure:"LOGIN_IP_MAX_FAILED_ATTEMPTS"`
+	LoginFailureWindowMinute int    `mapstructure:"LOGIN_FAILURE_WINDOW_MINUTE"`
+	LoginLockoutMinute       int    `mapstructure:"LOGIN_LOCKOUT_MINUTE"`
+
+	PasswordMinLength           int  `mapstructure:"PASSWORD_MIN_LENGTH"`
+	PasswordMinCharacterClasses int  `mapstructure:"PASSWORD_MIN_CHARACTER_CLASSES"`
+	PasswordHistorySize         int  `mapstructure:"PASSWORD_HISTORY_SIZE"`
+	PasswordBcryptCost          int  `mapstructure:"PASSWORD_BCRYPT_COST"`
+	PasswordAllowWeakLogin      bool `mapstructure:"PASSWORD_ALLOW_WEAK_LOGIN"`
+
+	GuestSessionMinute         int `mapstructure:"GUEST_SESSION_MINUTE"`
+	GuestCleanupIntervalMinute int `mapstructure:"GUEST_CLEANUP_INTERVAL_MINUTE"`
+	GuestMaxPerIPHour          int `mapstructure:"GUEST_MAX_PER_IP_HOUR"`
+
+	MailDriver                     string `mapstructure:"MAIL_DRIVER"`
+	MailFrom                       string `mapstructure:"MAIL_FROM"`
+	MailFileDir                    string `mapstructure:"MAIL_FILE_DIR"`
+	MailOutboxPollSecond           int    `mapstructure:"MAIL_OUTBOX_POLL_SECOND"`
+	SMTPHost                       string `mapstructure:"SMTP_HOST"`
+	SMTPPort                       string `mapstructure:"SMTP_PORT"`
+	SMTPUser                       string `mapstructure:"SMTP_USER"`
+	SMTPPass                       string `mapstructure:"SMTP_PASS"`
+	MFAIssuer                      string `mapstructure:"MFA_ISSUER"`
+	MFAEncryptionKey               string `mapstructure:"MFA_ENCRYPTION_KEY"`
+	MFAChallengeExpiryMinute       int    `mapstructure:"MFA_CHALLENGE_EXPIRY_MINUTE"`
+	PasswordResetURL               string `mapstructure:"PASSWORD_RESET_URL"`
+	PasswordResetTokenExpiryMinute int    `mapstructure:"PASSWORD_RESET_TOKEN_EXPIRY_MINUTE"`
+	InvitationURL                  string `mapstructure:"INVITATION_URL"`
+	InvitationExpiryHour           int    `mapstructure:"INVITATION_EXPIRY_HOUR"`
+	ImpersonationExpiryMinute      int    `mapstructure:"IMPERSONATION_EXPIRY_MINUTE"`
+	OIDCRedirectURL                string `mapstructure:"OIDC_REDIRECT_URL"`
+	OIDCEncryptionKey              string `mapstructure:"OIDC_ENCRYPTION_KEY"`
+	OIDCStateExpiryMinute          int    `mapstructure:"OIDC_STATE_EXPIRY_MINUTE"`
+	OAuthIssuer                    string `mapstructure:"OAUTH_ISSUER"`
+	OAuthAuthorizationURL          string `mapstructure:"OAUTH_AUTHORIZATION_URL"`
+	OAuthTokenExpiryMinute         int    `mapstructure:"OAUTH_TOKEN_EXPIRY_MINUTE"`
+	LaunchTokenExpirySecond        int    `mapstructure:"LAUNCH_TOKEN_EXPIRY_SECOND"`
+	UsageHeartbeatTimeoutSecond    int    `mapstructure:"USAGE_HEARTBEAT_TIMEOUT_SECOND"`
+	SubscriptionWarningDays        int    `mapstructure:"SUBSCRIPTION_WARNING_DAYS"`
+	SubscriptionGraceDays          int    `mapstructure:"SUBSCRIPTION_GRACE_DAYS"` // 0 for no grace period
+	UsageRollupReconcileDays       int    `mapstructure:"USAGE_ROLLUP_RECONCILE_DAYS"`
 }
 
 // Helper function to handle writing environment variables and errors
@@ -36,6 +90,7 @@ func exportEnvToFile() {
 	envVars := map[string]string{
 		"APP_ENV":                   os.Getenv("APP_ENV"),
 		"SERVER_ADDRESS":            os.Getenv("SERVER_ADDRESS"),
+		"TRUSTED_PROXIES":           os.Getenv("TRUSTED_PROXIES"),
 		"CONTEXT_TIMEOUT":           os.Getenv("CONTEXT_TIMEOUT"),
 		"DB_TYPE":                   os.Getenv("DB_TYPE"),
 		"DB_HOST":                   os.Getenv("DB_HOST"),
@@ -46,7 +101,55 @@ func exportEnvToFile() {
 		"ACCESS_TOKEN_EXPIRY_HOUR":  os.Getenv("ACCESS_TOKEN_EXPIRY_HOUR"),
 		"REFRESH_TOKEN_EXPIRY_HOUR": os.Getenv("REFRESH_TOKEN_EXPIRY_HOUR"),
 		"ACCESS_TOKEN_SECRET":       os.Getenv("ACCESS_TOKEN_SECRET"),
-		"REFRESH_TOKEN_SECRET":      os.Getenv("REFRESH_TOKEN_SECRET"),
+
+		"SERVICE_ACCOUNT_TOKEN_SECRET":      os.Getenv("SERVICE_ACCOUNT_TOKEN_SECRET"),
+		"SERVICE_ACCOUNT_TOKEN_EXPIRY_HOUR": os.Getenv("SERVICE_ACCOUNT_TOKEN_EXPIRY_HOUR"),
+
+		"SIGNING_KEY_ALGORITHM":      os.Getenv("SIGNING_KEY_ALGORITHM"),
+		"SIGNING_KEY_ENCRYPTION_KEY": os.Getenv("SIGNING_KEY_ENCRYPTION_KEY"),
+
+		"LOGIN_ATTEMPT_STORE":          os.Getenv("LOGIN_ATTEMPT_STORE"),
+		"LOGIN_MAX_FAILED_ATTEMPTS":    os.Getenv("LOGIN_MAX_FAILED_ATTEMPTS"),
+		"LOGIN_IP_MAX_FAILED_ATTEMPTS": os.Getenv("LOGIN_IP_MAX_FAILED_ATTEMPTS"),
+		"LOGIN_FAILURE_WINDOW_MINUTE":  os.Getenv("LOGIN_FAILURE_WINDOW_MINUTE"),
+		"LOGIN_LOCKOUT_MINUTE":         os.Getenv("LOGIN_LOCKOUT_MINUTE"),
+
+		"GUEST_SESSION_MINUTE":          os.Getenv("GUEST_SESSION_MINUTE"),
+		"GUEST_CLEANUP_INTERVAL_MINUTE": os.Getenv("GUEST_CLEANUP_INTERVAL_MINUTE"),
+		"GUEST_MAX_PER_IP_HOUR":         os.Getenv("GUEST_MAX_PER_IP_HOUR"),
+
+		"MAIL_DRIVER":                        os.Getenv("MAIL_DRIVER"),
+		"MAIL_FROM":                          os.Getenv("MAIL_FROM"),
+		"MAIL_FILE_DIR":                      os.Getenv("MAIL_FILE_DIR"),
+		"MAIL_OUTBOX_POLL_SECOND":            os.Getenv("MAIL_OUTBOX_POLL_SECOND"),
+		"SMTP_HOST":                          os.Getenv("SMTP_HOST"),
+		"SMTP_PORT":                          os.Getenv("SMTP_PORT"),
+		"SMTP_USER":                          os.Getenv("SMTP_USER"),
+		"SMTP_PASS":                          os.Getenv("SMTP_PASS"),
+		"MFA_ISSUER":                         os.Getenv("MFA_ISSUER"),
+		"MFA_ENCRYPTION_KEY":                 os.Getenv("MFA_ENCRYPTION_KEY"),
+		"MFA_CHALLENGE_EXPIRY_MINUTE":        os.Getenv("MFA_CHALLENGE_EXPIRY_MINUTE"),
+		"PASSWORD_RESET_URL":                 os.Getenv("PASSWORD_RESET_URL"),
+		"PASSWORD_RESET_TOKEN_EXPIRY_MINUTE": os.Getenv("PASSWORD_RESET_TOKEN_EXPIRY_MINUTE"),
+		"INVITATION_URL":                     os.Getenv("INVITATION_URL"),
+		"INVITATION_EXPIRY_HOUR":             os.Getenv("INVITATION_EXPIRY_HOUR"),
+		"PASSWORD_MIN_LENGTH":                os.Getenv("PASSWORD_MIN_LENGTH"),
+		"PASSWORD_MIN_CHARACTER_CLASSES":     os.Getenv("PASSWORD_MIN_CHARACTER_CLASSES"),
+		"PASSWORD_HISTORY_SIZE":              os.Getenv("PASSWORD_HISTORY_SIZE"),
+		"PASSWORD_BCRYPT_COST":               os.Getenv("PASSWORD_BCRYPT_COST"),
+		"PASSWORD_ALLOW_WEAK_LOGIN":          os.Getenv("PASSWORD_ALLOW_WEAK_LOGIN"),
+		"IMPERSONATION_EXPIRY_MINUTE":        os.Getenv("IMPERSONATION_EXPIRY_MINUTE"),
+		"OIDC_REDIRECT_URL":                  os.Getenv("OIDC_REDIRECT_URL"),
+		"OIDC_ENCRYPTION_KEY":                os.Getenv("OIDC_ENCRYPTION_KEY"),
+		"OIDC_STATE_EXPIRY_MINUTE":           os.Getenv("OIDC_STATE_EXPIRY_MINUTE"),
+		"OAUTH_ISSUER":                       os.Getenv("OAUTH_ISSUER"),
+		"OAUTH_AUTHORIZATION_URL":            os.Getenv("OAUTH_AUTHORIZATION_URL"),
+		"OAUTH_TOKEN_EXPIRY_MINUTE":          os.Getenv("OAUTH_TOKEN_EXPIRY_MINUTE"),
+		"LAUNCH_TOKEN_EXPIRY_SECOND":         os.Getenv("LAUNCH_TOKEN_EXPIRY_SECOND"),
+		"USAGE_HEARTBEAT_TIMEOUT_SECOND":     os.Getenv("USAGE_HEARTBEAT_TIMEOUT_SECOND"),
+		"SUBSCRIPTION_WARNING_DAYS":          os.Getenv("SUBSCRIPTION_WARNING_DAYS"),
+		"SUBSCRIPTION_GRACE_DAYS":            os.Getenv("SUBSCRIPTION_GRACE_DAYS"),
+		"USAGE_ROLLUP_RECONCILE_DAYS":        os.Getenv("USAGE_ROLLUP_RECONCILE_DAYS"),
 	}
 
 	// Create the .env file
@@ -62,6 +165,11 @@ func exportEnvToFile() {
 	}
 }
 
+// isUnset reports whether a setting is missing or left empty, a zero being a setting of its own
+func isUnset(key string) bool {
+	return !viper.IsSet(key) || strings.TrimSpace(viper.GetString(key)) == ""
+}
+
 func NewEnv() *Env {
 	value := os.Getenv("APP_ENV")
 	log.Println("Environment variable: ", value)
@@ -79,16 +187,171 @@ func NewEnv() *Env {
 	}
 
 	err = viper.Unmarshal(&env)
-	log.Println("Environment data: ", env)
 
 	if err != nil || env.AppEnv == "" {
 		log.Fatal("Error upon loading can't be loaded: ")
 	}
 
+	// The secrets have no default: HMAC tokens verified with an empty key could be forged by anyone
+	if env.AccessTokenSecret == "" {
+		log.Fatal("ACCESS_TOKEN_SECRET must be set")
+	}
+	if env.ServiceAccountTokenSecret == "" {
+		log.Fatal("SERVICE_ACCOUNT_TOKEN_SECRET must be set")
+	}
+
+	// The reset e-mails link to this page: without a host the link is a bare ?token=...
+	if resetURL, err := url.Parse(env.PasswordResetURL); err != nil || !resetURL.IsAbs() || resetURL.Host == "" {
+		log.Fatal("PASSWORD_RESET_URL must be an absolute URL")
+	}
+
+	// Defaults for optional settings (the exported .env may carry them empty)
+	if env.ServiceAccountTokenExpiryHour == 0 {
+		env.ServiceAccountTokenExpiryHour = 1
+	}
+	if env.SigningKeyAlgorithm == "" {
+		env.SigningKeyAlgorithm = domain.SigningAlgorithmRS256
+	}
+	if env.SigningKeyEncryptionKey == "" {
+		log.Println("SIGNING_KEY_ENCRYPTION_KEY is not set, signing keys are encrypted with ACCESS_TOKEN_SECRET")
+		env.SigningKeyEncryptionKey = env.AccessTokenSecret
+	}
+	if env.LoginAttemptStore == "" {
+		env.LoginAttemptStore = "memory"
+	}
+	if env.LoginMaxFailedAttempts == 0 {
+		env.LoginMaxFailedAttempts = 5
+	}
+	if env.LoginIPMaxFailedAttempts == 0 {
+		env.LoginIPMaxFailedAttempts = 20
+	}
+	if env.LoginFailureWindowMinute == 0 {
+		env.LoginFailureWindowMinute = 15
+	}
+	if env.LoginLockoutMinute == 0 {
+		env.LoginLockoutMinute = 15
+	}
+	if env.PasswordMinLength == 0 {
+		env.PasswordMinLength = 8
+	}
+	if env.PasswordMinCharacterClasses == 0 {
+		env.PasswordMinCharacterClasses = 3
+	}
+	if isUnset("PASSWORD_HISTORY_SIZE") {
+		env.PasswordHistorySize = 5 // 0 turns the history off
+	}
+	if env.PasswordBcryptCost == 0 {
+		env.PasswordBcryptCost = bcrypt.DefaultCost
+	}
+	if env.PasswordBcryptCost < bcrypt.MinCost || env.PasswordBcryptCost > bcrypt.MaxCost {
+		log.Fatalf("PASSWORD_BCRYPT_COST must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	if env.GuestSessionMinute == 0 {
+		env.GuestSessionMinute = 60
+	}
+	if env.GuestCleanupIntervalMinute == 0 {
+		env.GuestCleanupIntervalMinute = 15
+	}
+	if env.GuestMaxPerIPHour == 0 {
+		env.GuestMaxPerIPHour = 10
+	}
+	if env.MailDriver == "" {
+		// outside development the e-mails must really be sent, not silently dropped
+		if env.AppEnv != "development" {
+			log.Fatal("MAIL_DRIVER must be set")
+		}
+		env.MailDriver = "log"
+	}
+	if env.MailFileDir == "" {
+		env.MailFileDir = "mail"
+	}
+	if env.MailOutboxPollSecond == 0 {
+		env.MailOutboxPollSecond = 10
+	}
+	if env.SMTPPort == "" {
+		env.SMTPPort = "587"
+	}
+	if env.MFAIssuer == "" {
+		env.MFAIssuer = "Solude"
+	}
+	if env.MFAEncryptionKey == "" {
+		log.Println("MFA_ENCRYPTION_KEY is not set, TOTP secrets are encrypted with ACCESS_TOKEN_SECRET")
+		env.MFAEncryptionKey = env.AccessTokenSecret
+	}
+	if env.MFAChallengeExpiryMinute == 0 {
+		env.MFAChallengeExpiryMinute = 5
+	}
+	if env.PasswordResetTokenExpiryMinute == 0 {
+		env.PasswordResetTokenExpiryMinute = 30
+	}
+	if env.InvitationExpiryHour == 0 {
+		env.InvitationExpiryHour = 72
+	}
+	if env.ImpersonationExpiryMinute == 0 {
+		env.ImpersonationExpiryMinute = 15
+	}
+	if env.OIDCEncryptionKey == "" {
+		log.Println("OIDC_ENCRYPTION_KEY is not set, identity provider secrets are encrypted with ACCESS_TOKEN_SECRET")
+		env.OIDCEncryptionKey = env.AccessTokenSecret
+	}
+	if env.OIDCStateExpiryMinute == 0 {
+		env.OIDCStateExpiryMinute = 10
+	}
+	if env.OAuthIssuer == "" {
+		// SERVER_ADDRESS is usually ":port"
+		host := env.ServerAddress
+		if strings.HasPrefix(host, ":") {
+			host = "localhost" + host
+		}
+		env.OAuthIssuer = "http://" + host
+		log.Println("OAUTH_ISSUER is not set, using", env.OAuthIssuer)
+	}
+	env.OAuthIssuer = strings.TrimSuffix(env.OAuthIssuer, "/")
+	if env.OAuthAuthorizationURL == "" {
+		env.OAuthAuthorizationURL = "http://localhost:3000/authorize"
+	}
+	if env.OAuthTokenExpiryMinute == 0 {
+		env.OAuthTokenExpiryMinute = 60
+	}
+	if env.LaunchTokenExpirySecond == 0 {
+		env.LaunchTokenExpirySecond = 60
+	}
+	if env.UsageHeartbeatTimeoutSecond == 0 {
+		env.UsageHeartbeatTimeoutSecond = 120
+	}
+	if env.SubscriptionWarningDays == 0 {
+		env.SubscriptionWarningDays = 7
+	}
+	if isUnset("SUBSCRIPTION_GRACE_DAYS") {
+		env.SubscriptionGraceDays = 3
+	}
+	if env.UsageRollupReconcileDays == 0 {
+		env.UsageRollupReconcileDays = 2
+	}
+
 	if env.AppEnv == "development" {
-		log.Println("Environment data: ", env)
+		log.Println("Environment data: ", env.redacted())
 		log.Println("The App is running in development environment")
 	}
 
 	return &env
 }
+
+// redacted returns a copy of the environment fit for the logs, without the secrets and credentials
+func (env Env) redacted() Env {
+	for _, secret := range []*string{
+		&env.DBPass,
+		&env.AccessTokenSecret,
+		&env.ServiceAccountTokenSecret,
+		&env.SigningKeyEncryptionKey,
+		&env.SMTPUser,
+		&env.SMTPPass,
+		&env.MFAEncryptionKey,
+		&env.OIDCEncryptionKey,
+	} {
+		if *secret != "" {
+			*secret = "[redacted]"
+		}
+	}
+	return env
+}
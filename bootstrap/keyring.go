@@ -0,0 +1,53 @@
+package bootstrap
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal/keyring"
+	"github.com/gabrielfmcoelho/platform-core/repository"
+	"gorm.io/gorm"
+)
+
+// NewKeyRing loads the signing keys of the access, impersonation, OAuth and launch
+// tokens, generating the first one when the database has no active key yet. A retired
+// key stays verifiable for as long as the longest lived of these tokens.
+func NewKeyRing(env *Env, db *gorm.DB) domain.KeyRing {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(env.ContextTimeout)*time.Second)
+	defer cancel()
+
+	skr := repository.NewSigningKeyRepository(db)
+	verifyWindow := max(
+		time.Duration(env.AccessTokenExpiryHour)*time.Hour,
+		time.Duration(env.ImpersonationExpiryMinute)*time.Minute,
+		time.Duration(env.OAuthTokenExpiryMinute)*time.Minute,
+		time.Duration(env.LaunchTokenExpirySecond)*time.Second,
+	)
+	keyRing := keyring.New(skr, env.SigningKeyEncryptionKey, verifyWindow)
+
+	signingKeys, err := skr.FetchVerifiable(ctx, time.Now().Add(-verifyWindow))
+	if err != nil {
+		log.Fatal("Failed to load signing keys: ", err)
+	}
+	hasActive := false
+	for _, signingKey := range signingKeys {
+		hasActive = hasActive || signingKey.IsActive
+	}
+	if !hasActive {
+		log.Printf("No active signing key found, generating a %s key", env.SigningKeyAlgorithm)
+		signingKey, err := keyring.GenerateKey(env.SigningKeyAlgorithm, env.SigningKeyEncryptionKey)
+		if err != nil {
+			log.Fatal("Failed to generate signing key: ", err)
+		}
+		if err := skr.Activate(ctx, signingKey, time.Now()); err != nil {
+			log.Fatal("Failed to store signing key: ", err)
+		}
+	}
+
+	if err := keyRing.Reload(ctx); err != nil {
+		log.Fatal("Failed to load signing keys: ", err)
+	}
+	return keyRing
+}
@@ -0,0 +1,36 @@
+package bootstrap
+
+import (
+	"log"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal/loginattempt"
+	"github.com/gabrielfmcoelho/platform-core/repository"
+	"gorm.io/gorm"
+)
+
+func NewLoginAttemptStore(env *Env, db *gorm.DB) domain.LoginAttemptStore {
+	log.Default().Printf("Using %s login attempt store", env.LoginAttemptStore)
+
+	switch env.LoginAttemptStore {
+	case "memory":
+		return loginattempt.NewMemoryStore()
+	case "database":
+		return repository.NewLoginAttemptRepository(db)
+	default:
+		log.Fatal("Unsupported login attempt store")
+	}
+	return nil
+}
+
+// NewLoginThrottlePolicy reads the brute-force protection limits
+func NewLoginThrottlePolicy(env *Env) domain.LoginThrottlePolicy {
+	return domain.LoginThrottlePolicy{
+		MaxAccountFailures: env.LoginMaxFailedAttempts,
+		MaxIPFailures:      env.LoginIPMaxFailedAttempts,
+		FailureWindow:      time.Duration(env.LoginFailureWindowMinute) * time.Minute,
+		LockoutDuration:    time.Duration(env.LoginLockoutMinute) * time.Minute,
+		MaxGuestsPerIPHour: env.GuestMaxPerIPHour,
+	}
+}
@@ -0,0 +1,24 @@
+package bootstrap
+
+import (
+	"log"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal/mailer"
+)
+
+func NewMailer(env *Env) domain.Mailer {
+	log.Default().Printf("Using %s mail driver", env.MailDriver)
+
+	switch env.MailDriver {
+	case "smtp":
+		return mailer.NewSMTPMailer(env.MailFrom, env.SMTPHost, env.SMTPPort, env.SMTPUser, env.SMTPPass)
+	case "log", "stdout":
+		return mailer.NewLogMailer(env.MailFrom)
+	case "file":
+		return mailer.NewFileMailer(env.MailFrom, env.MailFileDir)
+	default:
+		log.Fatal("Unsupported mail driver")
+	}
+	return nil
+}
@@ -1,13 +1,20 @@
 package bootstrap
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"time"
 
 	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/repository"
 	"gorm.io/gorm"
 )
 
-func AutoMigrate(db *gorm.DB) {
+func AutoMigrate(db *gorm.DB, env *Env) {
+	// the usage rollups start from the usage logs recorded before them
+	backfillUsageRollups := !db.Migrator().HasTable(&domain.UsageDailyRollup{})
+
 	err := db.AutoMigrate(
 		// domains like: &domain.User{},
 		&domain.User{},
@@ -15,8 +22,124 @@ func AutoMigrate(db *gorm.DB) {
 		&domain.UserServiceLog{},
 		&domain.Service{},
 		&domain.ContactIntent{},
+		&domain.ServiceAccount{},
+		&domain.RefreshToken{},
+		&domain.PasswordResetToken{},
+		&domain.MailOutbox{},
+		&domain.UserMFA{},
+		&domain.MFARecoveryCode{},
+		&domain.SigningKey{},
+		&domain.LoginAttempt{},
+		&domain.OrganizationGuestAccess{},
+		&domain.GuestUser{},
+		&domain.OrganizationSubscription{},
+		&domain.Invitation{},
+		&domain.Impersonation{},
+		&domain.Session{},
+		&domain.PasswordHistory{},
+		&domain.OrganizationIdentityProvider{},
+		&domain.SSOLoginState{},
+		&domain.UserIdentity{},
+		&domain.OAuthClient{},
+		&domain.OAuthAuthorizationCode{},
+		&domain.OAuthConsent{},
+		&domain.ServiceLaunch{},
+		&domain.ServiceReport{},
+		&domain.SubscriptionRenewal{},
+		&domain.ScheduledJob{},
+		&domain.OrganizationMetrics{},
+		&domain.UserMetrics{},
+		&domain.UsageHourlyRollup{},
+		&domain.UsageDailyRollup{},
 	)
 	if err != nil {
 		log.Fatalf("Failed to auto-migrate models: %v", err)
 	}
+
+	// usage logs recorded before usage sessions existed are kept as sessions closed at their last update
+	err = db.Model(&domain.UserServiceLog{}).Where("started_at IS NULL").UpdateColumns(map[string]interface{}{
+		"started_at":        gorm.Expr("created_at"),
+		"last_heartbeat_at": gorm.Expr("updated_at"),
+		"ended_at":          gorm.Expr("updated_at"),
+		"end_reason":        domain.UsageEndReasonTimeout,
+	}).Error
+	if err != nil {
+		log.Fatalf("Failed to migrate usage logs to usage sessions: %v", err)
+	}
+
+	if err := migrateSubscriptionDates(db, env.SubscriptionGraceDays); err != nil {
+		log.Fatalf("Failed to migrate subscription dates: %v", err)
+	}
+
+	if backfillUsageRollups {
+		rebuilt, err := repository.NewUsageRollupRepository(db).Reconcile(context.Background(), time.Time{})
+		if err != nil {
+			log.Fatalf("Failed to backfill usage rollups: %v", err)
+		}
+		log.Printf("Backfilled %d usage rollups", rebuilt)
+	}
+}
+
+// subscriptionDateLayouts are the formats the subscription dates were stored with as text
+var subscriptionDateLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02", "02/01/2006"}
+
+// migrateSubscriptionDates copies the old text dates of the subscriptions into starts_at,
+// ends_at and grace_ends_at, then drops the text columns. The text columns are kept
+// while any subscription still has dates that could not be read.
+func migrateSubscriptionDates(db *gorm.DB, graceDays int) error {
+	migrator := db.Migrator()
+	if !migrator.HasColumn(&domain.OrganizationSubscription{}, "subscription_init_date") {
+		return nil
+	}
+
+	var rows []struct {
+		ID                   uint
+		SubscriptionInitDate string
+		SubscriptionEndDate  string
+	}
+	err := db.Table("organization_subscriptions").
+		Select("id, subscription_init_date, subscription_end_date").
+		Where("starts_at IS NULL OR ends_at IS NULL").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	var unreadable []uint
+	for _, row := range rows {
+		startsAt, startOK := parseSubscriptionDate(row.SubscriptionInitDate)
+		endsAt, endOK := parseSubscriptionDate(row.SubscriptionEndDate)
+		if !startOK || !endOK {
+			log.Printf("Subscription %d has unreadable dates (%q, %q), set its starts_at and ends_at", row.ID, row.SubscriptionInitDate, row.SubscriptionEndDate)
+			unreadable = append(unreadable, row.ID)
+			continue
+		}
+		err := db.Table("organization_subscriptions").Where("id = ?", row.ID).UpdateColumns(map[string]interface{}{
+			"starts_at":     startsAt,
+			"ends_at":       endsAt,
+			"grace_ends_at": endsAt.AddDate(0, 0, max(graceDays, 0)),
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+	if len(unreadable) > 0 {
+		return fmt.Errorf("subscriptions %v have unreadable dates, the text date columns are kept until they are fixed", unreadable)
+	}
+
+	for _, column := range []string{"subscription_init_date", "subscription_end_date"} {
+		if err := migrator.DropColumn(&domain.OrganizationSubscription{}, column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseSubscriptionDate(value string) (time.Time, bool) {
+	for _, layout := range subscriptionDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date.UTC(), true
+		}
+	}
+	return time.Time{}, false
 }
@@ -0,0 +1,9 @@
+package bootstrap
+
+import "github.com/gabrielfmcoelho/platform-core/internal/oidc"
+
+// NewOIDCClient builds the client of the external identity providers; only development
+// reaches providers over http or on a private network (e.g. cmd/mockoidc)
+func NewOIDCClient(env *Env) *oidc.Client {
+	return oidc.NewClient(env.AppEnv == "development")
+}
@@ -0,0 +1,14 @@
+package bootstrap
+
+import "github.com/gabrielfmcoelho/platform-core/domain"
+
+// NewPasswordPolicy reads the requirements of new passwords
+func NewPasswordPolicy(env *Env) domain.PasswordPolicy {
+	return domain.PasswordPolicy{
+		MinLength:           env.PasswordMinLength,
+		MinCharacterClasses: env.PasswordMinCharacterClasses,
+		HistorySize:         env.PasswordHistorySize,
+		BcryptCost:          env.PasswordBcryptCost,
+		ChangeWeakOnLogin:   !env.PasswordAllowWeakLogin,
+	}
+}
@@ -31,6 +31,10 @@ func RunSeeds(db *gorm.DB) {
 			return err
 		}
 
+		if err := seeds.SeedGuestAccess(tx); err != nil {
+			return err
+		}
+
 		return nil
 	})
 	if err != nil {
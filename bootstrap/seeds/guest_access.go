@@ -0,0 +1,40 @@
+package seeds
+
+import (
+	"log"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"gorm.io/gorm"
+)
+
+// SeedGuestAccess abre o acesso de convidados das organizações Guest, se ainda não configurado
+func SeedGuestAccess(db *gorm.DB) error {
+	var count int64
+	if err := db.Model(&domain.OrganizationGuestAccess{}).Count(&count).Error; err != nil {
+		return err
+	}
+
+	if count == 0 {
+		var orgs []domain.Organization
+		if err := db.Preload("SubscribedServices").
+			Where("role_id = ?", domain.OrganizationRoleGuest).
+			Find(&orgs).Error; err != nil {
+			return err
+		}
+
+		for _, org := range orgs {
+			guestAccess := domain.OrganizationGuestAccess{
+				OrganizationID:  org.ID,
+				Enabled:         true,
+				SessionMinute:   60,
+				AllowedServices: org.SubscribedServices, // convidados usam os serviços da organização
+			}
+			if err := db.Create(&guestAccess).Error; err != nil {
+				return err
+			}
+		}
+
+		log.Printf("[SeedGuestAccess] Acesso de convidados habilitado em %d organizações\n", len(orgs))
+	}
+	return nil
+}
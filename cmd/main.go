@@ -1,11 +1,14 @@
 package main
 
 import (
+	"context"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/gabrielfmcoelho/platform-core/api/route"
 	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/worker"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
@@ -40,12 +43,38 @@ func main() {
 	// Database instance (Gorm DB)
 	db := app.DB
 
+	// Mailer used for transactional e-mails
+	mailer := app.Mailer
+
+	// Key ring signing and verifying the user access tokens
+	keyRing := app.KeyRing
+
+	// Failed login counters (brute-force protection)
+	loginAttemptStore := app.LoginAttemptStore
+
 	// Context timeout
 	timeout := time.Duration(env.ContextTimeout) * time.Second
 
+	// Background jobs (mail outbox, signing key reload, ...)
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	worker.Setup(workerCtx, env, timeout, db, mailer, keyRing, loginAttemptStore)
+
 	// Create a Gin router instancehttps://github.com/inova-data-tech/Solude-api.git
 	router := gin.Default()
 
+	// Only the listed proxies may set X-Forwarded-For; with none, the client IP
+	// (login limits, audit logs) is the address of the peer itself
+	var trustedProxies []string
+	for _, proxy := range strings.Split(env.TrustedProxies, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			trustedProxies = append(trustedProxies, proxy)
+		}
+	}
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
 	// CORS
 	router.Use(cors.New(cors.Config{
 		//AllowAllOrigins: true,
@@ -57,7 +86,7 @@ func main() {
 	}))
 
 	// Route binding
-	route.Setup(env, timeout, db, router)
+	route.Setup(env, timeout, db, mailer, keyRing, loginAttemptStore, router)
 
 	// Run the server
 	if err := router.Run(env.ServerAddress); err != nil {
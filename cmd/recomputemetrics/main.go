@@ -0,0 +1,46 @@
+// Command recomputemetrics rebuilds the organization and user metrics and the usage
+// rollups from the user logs, the usage logs and the reports, e.g. to backfill them
+// after a migration or to repair counters that drifted. It uses the configuration of the API:
+//
+//	go run ./cmd/recomputemetrics
+//
+// Events recorded while it runs may be counted twice or missed; run it again once
+// the traffic is low to settle them.
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/repository"
+	"github.com/gabrielfmcoelho/platform-core/usecase"
+)
+
+func main() {
+	env := bootstrap.NewEnv()
+	db := bootstrap.NewDatabaseConnection(env)
+	bootstrap.AutoMigrate(db, env)
+
+	timeout := time.Duration(env.ContextTimeout) * time.Second
+	mu := usecase.NewMetricsUsecase(
+		repository.NewOrganizationMetricsRepository(db),
+		repository.NewUserMetricsRepository(db),
+		repository.NewUsageRollupRepository(db),
+		repository.NewOrganizationRepository(db),
+		repository.NewUserRepository(db),
+		timeout,
+	)
+
+	started := time.Now()
+	organizations, users, err := mu.Recompute(context.Background())
+	if err != nil {
+		log.Fatalf("Failed to recompute metrics: %v", err)
+	}
+	rollups, err := mu.ReconcileUsageRollups(context.Background(), time.Time{})
+	if err != nil {
+		log.Fatalf("Failed to rebuild usage rollups: %v", err)
+	}
+	log.Printf("Recomputed the metrics of %d organizations and %d users and %d usage rollups in %s", organizations, users, rollups, time.Since(started).Round(time.Millisecond))
+}
@@ -23,13 +23,25 @@ type RefreshTokenResponse struct {
 	RefreshToken string `json:"refreshToken"`
 }
 
+// ClientInfo identifies the device a request comes from
+type ClientInfo struct {
+	IPAddress string
+	UserAgent string
+	Locale    string // raw Accept-Language, used to pick e-mail templates
+}
+
 type AuthUsecase interface {
-	LoginUserByEmail(ctx context.Context, email string, password string, accessSecret string, accessExpiry int, refreshSecret string, refreshExpiry int) (loginResponse *LoginResponse, err error)
-	LoginGuestUser(ctx context.Context, accessSecret string, accessExpiry int, refreshSecret string, refreshExpiry int) (loginResponse *LoginResponse, err error)
-	CreateAccessToken(user *User, accessSecret string, accessExpiry int) (accessToken string, err error)
-	CreateRefreshToken(user *User, refreshSecret string, refreshExpiry int) (refreshToken string, err error)
-	RefreshToken(ctx context.Context, refreshToken string, refreshSecret string, accessSecret string, accessExpiry int, refreshExpiry int) (refreshResponse *RefreshTokenResponse, err error)
-
-	ForgotPassword(ctx context.Context, email string) (err error)
-	ResetPassword(ctx context.Context, email string, newPassword string) (err error)
+	LoginUserByEmail(ctx context.Context, email string, password string, client ClientInfo, accessSecret string, accessExpiry int, refreshExpiry int, mfaChallengeExpiry int, resetExpiry int) (loginResponse *LoginResponse, mfaChallenge *MFAChallengeResponse, passwordChange *PasswordChangeChallengeResponse, err error)
+	LoginWithMFA(ctx context.Context, mfaToken string, code string, recoveryCode string, client ClientInfo, accessSecret string, accessExpiry int, refreshExpiry int, resetExpiry int) (loginResponse *MFALoginResponse, passwordChange *PasswordChangeChallengeResponse, err error)
+	EnrollMFAForLogin(ctx context.Context, mfaToken string, accessSecret string) (enrollment *MFAEnrollment, err error)
+	LoginExternalUser(ctx context.Context, user *User, client ClientInfo, accessExpiry int, refreshExpiry int) (loginResponse *LoginResponse, err error)
+	LoginGuestUser(ctx context.Context, organizationID uint, client ClientInfo, accessExpiry int) (loginResponse *LoginResponse, err error)
+	CreateAccessToken(user *User, accessExpiry int) (accessToken string, err error)
+	CreateRefreshToken(ctx context.Context, user *User, client ClientInfo, refreshExpiry int) (refreshToken string, err error)
+	RefreshToken(ctx context.Context, refreshToken string, client ClientInfo, accessExpiry int, refreshExpiry int) (refreshResponse *RefreshTokenResponse, err error)
+	Logout(ctx context.Context, refreshToken string, client ClientInfo) (err error)
+	LogoutAll(ctx context.Context, userID uint, client ClientInfo) (err error)
+
+	ForgotPassword(ctx context.Context, email string, client ClientInfo, resetURL string, resetExpiry int) (err error)
+	ResetPassword(ctx context.Context, resetToken string, newPassword string, client ClientInfo) (err error)
 }
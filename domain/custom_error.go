@@ -12,6 +12,7 @@ var (
 	ErrUserWebUnauthorized   = errors.New("user unauthorized to login via web")
 	ErrUserPasswordNotMatch  = errors.New("password does not match")
 	ErrUnauthorized          = errors.New("unauthorized by the system")
+	ErrForbidden             = errors.New("forbidden: insufficient permissions")
 	ErrNotFound              = errors.New("not found")
 	ErrBadRequest            = errors.New("bad request")
 	ErrInternalServerError   = errors.New("internal server error")
@@ -19,4 +20,62 @@ var (
 	ErrInvalidIdentifier     = errors.New("invalid identifier (email or id)")
 	ErrInvalidNumberToParse  = errors.New("invalid number to parse")
 	ErrCategoryAlreadyExists = errors.New("category already exists")
+	ErrInvalidClient         = errors.New("invalid client credentials")
+	ErrInvalidScope          = errors.New("invalid scope")
+	ErrUnsupportedGrantType  = errors.New("unsupported grant type")
+	ErrInvalidRefreshToken   = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused    = errors.New("refresh token reuse detected, session revoked")
+	ErrInvalidResetToken     = errors.New("invalid or expired password reset token")
+	ErrInvalidMFAToken       = errors.New("invalid or expired mfa token")
+	ErrInvalidMFACode        = errors.New("invalid mfa code")
+	ErrMFANotEnrolled        = errors.New("mfa is not enrolled")
+	ErrMFAAlreadyEnabled     = errors.New("mfa is already enabled")
+	ErrMFARequired           = errors.New("mfa is required by the organization")
+	ErrTooManyLoginAttempts  = errors.New("too many login attempts, try again later")
+	ErrAccountLocked         = errors.New("account temporarily locked after too many failed logins")
+	ErrGuestAccessDisabled   = errors.New("guest access is disabled for this organization")
+	ErrTooManyGuests         = errors.New("too many guest logins from this address, try again later")
+	ErrGuestServiceForbidden = errors.New("service not available to guests")
+	ErrServiceNotSubscribed  = errors.New("service is not subscribed by the organization")
+	ErrServiceNotFound       = errors.New("service not found or archived")
+	ErrServiceInactive       = errors.New("service is not active")
+	ErrUsageLogNotFound      = errors.New("usage log not found")
+	ErrUsageLogNotOwned      = errors.New("usage log belongs to another user")
+	ErrUsageSessionEnded     = errors.New("usage session already ended")
+	ErrOrganizationNameTaken = errors.New("organization name already in use")
+	ErrOrgRoleNotFound       = errors.New("organization role not found")
+	ErrSubscriptionExists    = errors.New("organization already has a subscription")
+	ErrSubscriptionExpired   = errors.New("subscription of the organization expired")
+	ErrSubscriptionInactive  = errors.New("subscription of the organization is not active")
+	ErrReportsLimitReached   = errors.New("organization reached the reports limit of its subscription")
+	ErrSubscriptionChanged   = errors.New("subscription changed while it was being renewed")
+	ErrInvalidInvitation     = errors.New("invalid or expired invitation")
+	ErrInvitationPending     = errors.New("an invitation is already pending for this e-mail")
+	ErrUsersLimitReached     = errors.New("organization reached the users limit of its subscription")
+	ErrCannotImpersonate     = errors.New("this user cannot be impersonated")
+	ErrImpersonationEnded    = errors.New("impersonation ended")
+	ErrImpersonationReadOnly = errors.New("action not allowed while impersonating a user")
+	ErrNotImpersonating      = errors.New("the token is not an impersonation token")
+	ErrSessionRevoked        = errors.New("session revoked or expired")
+	ErrServiceAccountRevoked = errors.New("service account deleted or its secret rotated")
+	ErrPasswordTooShort      = errors.New("password is too short")
+	ErrPasswordTooLong       = errors.New("password is too long")
+	ErrPasswordTooSimple     = errors.New("password is too simple")
+	ErrPasswordTooCommon     = errors.New("password is too common")
+	ErrPasswordReused        = errors.New("password was used recently")
+	ErrSSODisabled           = errors.New("single sign-on is disabled for this organization")
+	ErrInvalidSSOState       = errors.New("invalid or expired single sign-on state")
+	ErrSSOProviderError      = errors.New("identity provider rejected the login")
+	ErrSSOAccountConflict    = errors.New("e-mail already used by an account that cannot be linked")
+	ErrSSOUserNotProvisioned = errors.New("no account for this identity and provisioning is disabled")
+	ErrInvalidIssuer         = errors.New("issuer must be an https url of a public host")
+	ErrInvalidGrant          = errors.New("invalid, expired or already used authorization code")
+	ErrInvalidRedirectURI    = errors.New("unknown client or redirect uri not registered")
+	ErrInvalidOAuthToken     = errors.New("invalid or expired oauth access token")
+	ErrInvalidLaunchToken    = errors.New("invalid, expired or already used launch token")
+	ErrJobLeaseLost          = errors.New("lease of the scheduled job was taken over")
+	ErrInvalidDateRange      = errors.New("invalid date range, expected dates like 2006-01-02 or RFC 3339 times")
+	ErrInvalidTimezone       = errors.New("unknown time zone, expected an IANA name like America/Sao_Paulo")
+	ErrTooManyBuckets        = errors.New("date range too long for the granularity")
+	ErrUnsupportedTimezone   = errors.New("time zones offset by a fraction of an hour are not supported, usage is recorded by hour")
 )
@@ -1,7 +1,16 @@
 package domain
 
+// Codes sent with the errors a client has to tell apart, see ErrorResponse.Code
+const (
+	ErrorCodeSubscriptionExpired  = "subscription_expired"
+	ErrorCodeSubscriptionInactive = "subscription_inactive"
+	ErrorCodeUsersLimitReached    = "users_limit_reached"
+	ErrorCodeReportsLimitReached  = "reports_limit_reached"
+)
+
 type ErrorResponse struct {
 	Message string `json:"message"`
+	Code    string `json:"code,omitempty"` // machine readable reason, for some errors only
 }
 
 type SuccessResponse struct {
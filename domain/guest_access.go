@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ONE TO ONE WITH ORGANIZATION
+
+// OrganizationGuestAccess configures the anonymous access (/login-guest) to an
+// organization. Each visitor gets an ephemeral guest user that only lasts
+// SessionMinute and can only launch the AllowedServices.
+type OrganizationGuestAccess struct {
+	gorm.Model
+	OrganizationID  uint      `gorm:"not null;uniqueIndex"`
+	Enabled         bool      `gorm:"not null;default:false"`
+	SessionMinute   int       `gorm:"not null"`
+	AllowedServices []Service `gorm:"many2many:organization_guest_services;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// Allows reports whether guests may launch the service
+func (g OrganizationGuestAccess) Allows(serviceID uint) bool {
+	if !g.Enabled {
+		return false
+	}
+	for _, service := range g.AllowedServices {
+		if service.ID == serviceID {
+			return true
+		}
+	}
+	return false
+}
+
+// MANY TO ONE WITH ORGANIZATION
+
+// GuestUser marks a user created by /login-guest. The user and everything it
+// produced are removed once ExpiresAt is past.
+type GuestUser struct {
+	gorm.Model
+	UserID         uint      `gorm:"not null;uniqueIndex"`
+	OrganizationID uint      `gorm:"not null;Index"`
+	IPAddress      string    `gorm:"size:255"`
+	UserAgent      string    `gorm:"size:512"`
+	ExpiresAt      time.Time `gorm:"not null;Index"`
+}
+
+type GuestLoginRequest struct {
+	OrganizationID uint `json:"organization_id"`
+}
+
+type GuestAccessConfig struct {
+	Enabled       *bool  `json:"enabled" binding:"required"`
+	SessionMinute int    `json:"session_minute" binding:"omitempty,min=5,max=1440"`
+	ServiceIDs    []uint `json:"service_ids"`
+}
+
+type PublicGuestAccess struct {
+	OrganizationID  uint            `json:"organization_id"`
+	Enabled         bool            `json:"enabled"`
+	SessionMinute   int             `json:"session_minute"`
+	AllowedServices []PublicService `json:"allowed_services"`
+}
+
+type GuestAccessRepository interface {
+	GetByOrganizationID(ctx context.Context, organizationID uint) (OrganizationGuestAccess, error)
+	// GetDefault returns the first enabled guest access of a Guest organization
+	GetDefault(ctx context.Context) (OrganizationGuestAccess, error)
+	// Save creates or updates the configuration and replaces its allowed services
+	Save(ctx context.Context, guestAccess *OrganizationGuestAccess, serviceIDs []uint) error
+}
+
+type GuestUserRepository interface {
+	// Create inserts the user and its guest record together
+	Create(ctx context.Context, user *User, guestUser *GuestUser) error
+	GetByUserID(ctx context.Context, userID uint) (GuestUser, error)
+	// DeleteExpired removes the guests expired before the given time with their logs, usage rollups,
+	// tokens and settings; their reports are kept and still count towards the reports limit
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+type GuestAccessUsecase interface {
+	Get(ctx context.Context, organizationID uint) (PublicGuestAccess, error)
+	Configure(ctx context.Context, organizationID uint, config *GuestAccessConfig) (PublicGuestAccess, error)
+	CleanupExpired(ctx context.Context) (int64, error)
+}
@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ONE TO ONE WITH ORGANIZATION
+
+// OrganizationIdentityProvider lets the members of an organization sign in with
+// their corporate OpenID Connect provider (authorization code + PKCE). Unknown
+// users are created on their first login when AllowProvisioning is set. When
+// RoleClaim is set, the values of that claim are mapped to user roles through
+// RoleMapping (the most privileged match wins, DefaultRoleID otherwise) and the
+// role is synchronized on every login. The provider never grants more than the
+// role of whoever configured it (ConfiguredRoleID), nor signs in, links or remaps
+// a user more privileged than that role: its configurer controls what it asserts.
+type OrganizationIdentityProvider struct {
+	gorm.Model
+	OrganizationID    uint            `gorm:"not null;uniqueIndex"`
+	Enabled           bool            `gorm:"not null;default:false"`
+	Issuer            string          `gorm:"size:255;not null"`
+	ClientID          string          `gorm:"size:255;not null"`
+	ClientSecret      string          `gorm:"size:512"` // AES-GCM sealed with OIDC_ENCRYPTION_KEY
+	Scopes            string          `gorm:"size:255"` // space separated, openid is always requested
+	RoleClaim         string          `gorm:"size:255"`
+	RoleMapping       map[string]uint `gorm:"serializer:json"` // claim value -> user role ID
+	DefaultRoleID     uint            `gorm:"not null"`
+	AllowProvisioning bool            `gorm:"not null;default:false"`
+	ConfiguredRoleID  uint            `gorm:"not null;default:2"` // user role ID of the configurer, Manager for the providers configured before
+}
+
+// SSOLoginState is an authorization request waiting for the provider callback.
+// The state sent to the provider is only stored as a SHA-256 hash and can be
+// used once.
+type SSOLoginState struct {
+	gorm.Model
+	StateHash      string    `gorm:"size:64;uniqueIndex;not null"`
+	OrganizationID uint      `gorm:"not null;Index"`
+	Nonce          string    `gorm:"size:255;not null"`
+	CodeVerifier   string    `gorm:"size:255;not null"`
+	ExpiresAt      time.Time `gorm:"not null;Index"`
+	UsedAt         *time.Time
+}
+
+// MANY TO ONE WITH USER
+
+// UserIdentity links a user to its subject at an identity provider
+type UserIdentity struct {
+	gorm.Model
+	UserID             uint   `gorm:"not null;Index"`
+	User               User   `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	IdentityProviderID uint   `gorm:"not null;uniqueIndex:idx_user_identity_subject"`
+	Subject            string `gorm:"size:255;not null;uniqueIndex:idx_user_identity_subject"`
+}
+
+// IdentityProviderConfig configures the single sign-on of an organization. An
+// omitted client secret keeps the stored one.
+type IdentityProviderConfig struct {
+	Enabled           *bool           `json:"enabled" binding:"required"`
+	Issuer            string          `json:"issuer" binding:"required,http_url"`
+	ClientID          string          `json:"client_id" binding:"required"`
+	ClientSecret      string          `json:"client_secret"`
+	Scopes            string          `json:"scopes"`
+	RoleClaim         string          `json:"role_claim"`
+	RoleMapping       map[string]uint `json:"role_mapping" binding:"omitempty,dive,oneof=1 2 3"`
+	DefaultRoleID     uint            `json:"default_role_id" binding:"omitempty,oneof=1 2 3"`
+	AllowProvisioning bool            `json:"allow_provisioning"`
+}
+
+type PublicIdentityProvider struct {
+	OrganizationID    uint            `json:"organization_id"`
+	Enabled           bool            `json:"enabled"`
+	Issuer            string          `json:"issuer"`
+	ClientID          string          `json:"client_id"`
+	HasClientSecret   bool            `json:"has_client_secret"`
+	Scopes            string          `json:"scopes"`
+	RoleClaim         string          `json:"role_claim"`
+	RoleMapping       map[string]uint `json:"role_mapping"`
+	DefaultRoleID     uint            `json:"default_role_id"`
+	AllowProvisioning bool            `json:"allow_provisioning"`
+	RedirectURL       string          `json:"redirect_url"` // to be registered at the provider
+}
+
+type SSOCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
+
+type IdentityProviderRepository interface {
+	GetByOrganizationID(ctx context.Context, organizationID uint) (OrganizationIdentityProvider, error)
+	// Save creates or updates the configuration of the organization
+	Save(ctx context.Context, identityProvider *OrganizationIdentityProvider) error
+}
+
+type SSOLoginStateRepository interface {
+	Create(ctx context.Context, state *SSOLoginState) error
+	// Consume marks the state used and returns it. It fails with ErrInvalidSSOState
+	// when the state is unknown, already used or expired.
+	Consume(ctx context.Context, stateHash string, at time.Time) (SSOLoginState, error)
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+type UserIdentityRepository interface {
+	GetBySubject(ctx context.Context, identityProviderID uint, subject string) (UserIdentity, error)
+	Create(ctx context.Context, identity *UserIdentity) error
+	// CreateWithUser inserts a provisioned user and its identity together
+	CreateWithUser(ctx context.Context, user *User, identity *UserIdentity) error
+}
+
+type SSOUsecase interface {
+	GetConfig(ctx context.Context, organizationID uint) (PublicIdentityProvider, error)
+	// Configure saves the provider of the organization, configured by a user of role configuredRoleID
+	Configure(ctx context.Context, organizationID uint, config *IdentityProviderConfig, configuredRoleID uint) (PublicIdentityProvider, error)
+	// AuthorizationURL starts a login and returns where to send the browser
+	AuthorizationURL(ctx context.Context, organizationID uint) (string, error)
+	// Callback completes a login with the code returned by the provider
+	Callback(ctx context.Context, request *SSOCallbackRequest, client ClientInfo, accessExpiry int, refreshExpiry int) (*LoginResponse, error)
+}
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MANY TO ONE WITH USER
+
+// Impersonation is the audit record of a platform admin acting as a user
+// ("log in as user"). The access token issued for it carries the target user
+// and an act claim naming the admin; it stops working once EndedAt is set.
+type Impersonation struct {
+	gorm.Model
+	ImpersonatorID uint      `gorm:"not null;Index"`
+	Impersonator   User      `gorm:"foreignKey:ImpersonatorID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	UserID         uint      `gorm:"not null;Index"`
+	Reason         string    `gorm:"size:512;not null"`
+	IPAddress      string    `gorm:"size:255"`
+	ExpiresAt      time.Time `gorm:"not null"`
+	EndedAt        *time.Time
+}
+
+type StartImpersonationRequest struct {
+	Reason string `json:"reason" binding:"required,max=512"`
+}
+
+type ImpersonationResponse struct {
+	AccessToken     string     `json:"accessToken"`
+	ExpiresIn       int        `json:"expiresIn"`
+	ImpersonationID uint       `json:"impersonationId"`
+	User            PublicUser `json:"user"`
+}
+
+type PublicImpersonation struct {
+	ID               uint   `json:"id"`
+	UserID           uint   `json:"user_id"`
+	ImpersonatorID   uint   `json:"impersonator_id"`
+	ImpersonatorName string `json:"impersonator_name"`
+	Reason           string `json:"reason"`
+	StartedAt        string `json:"started_at"`
+	ExpiresAt        string `json:"expires_at"`
+	EndedAt          string `json:"ended_at"`
+}
+
+type ImpersonationRepository interface {
+	Create(ctx context.Context, impersonation *Impersonation) error
+	GetByID(ctx context.Context, id uint) (Impersonation, error)
+	FetchByUserID(ctx context.Context, userID uint) ([]Impersonation, error)
+	End(ctx context.Context, impersonationID uint, endedAt time.Time) error
+}
+
+type ImpersonationUsecase interface {
+	Start(ctx context.Context, impersonatorID uint, userID uint, reason string, client ClientInfo) (*ImpersonationResponse, error)
+	Stop(ctx context.Context, impersonationID uint, client ClientInfo) error
+	// IsActive reports whether the tokens of an impersonation are still accepted
+	IsActive(ctx context.Context, impersonationID uint) (bool, error)
+	FetchByUserID(ctx context.Context, userID uint) ([]PublicImpersonation, error)
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"context"
+)
+
+// Token introspection (RFC 7662) and userinfo, used by the microservices
+// launched from the hub to find out who a token belongs to.
+
+// Values of the token_type_hint parameter and of the token_use response member
+const (
+	TokenTypeHintAccessToken  = "access_token"
+	TokenTypeHintRefreshToken = "refresh_token"
+
+	TokenUseAccess  = "access"
+	TokenUseRefresh = "refresh"
+)
+
+// IntrospectionRequest follows RFC 7662 section 2.1
+type IntrospectionRequest struct {
+	Token         string `form:"token" json:"token" binding:"required"`
+	TokenTypeHint string `form:"token_type_hint" json:"token_type_hint"`
+}
+
+// IntrospectionResponse follows RFC 7662 section 2.2. An inactive token only
+// carries active=false; the user and organization members are extensions.
+type IntrospectionResponse struct {
+	Active             bool   `json:"active"`
+	TokenUse           string `json:"token_use,omitempty"` // access, refresh or service
+	TokenType          string `json:"token_type,omitempty"`
+	Scope              string `json:"scope,omitempty"`
+	ClientID           string `json:"client_id,omitempty"`
+	Username           string `json:"username,omitempty"`
+	Sub                string `json:"sub,omitempty"`
+	Exp                int64  `json:"exp,omitempty"`
+	Iat                int64  `json:"iat,omitempty"`
+	UserID             uint   `json:"user_id,omitempty"`
+	UserRoleID         uint   `json:"user_role_id,omitempty"`
+	OrganizationID     uint   `json:"organization_id,omitempty"`
+	OrganizationRoleID uint   `json:"organization_role_id,omitempty"`
+	// Act names the admin when the token was issued for an impersonation
+	Act *ActorClaims `json:"act,omitempty"`
+}
+
+// UserInfo describes the caller of GET /userinfo and what its organization is entitled to
+type UserInfo struct {
+	User             PublicUser             `json:"user"`
+	UserRole         PublicUserRole         `json:"user_role"`
+	Organization     PublicOrganization     `json:"organization"`
+	OrganizationRole PublicOrganizationRole `json:"organization_role"`
+	Services         []PublicService        `json:"services"`
+}
+
+type IntrospectionUsecase interface {
+	Introspect(ctx context.Context, request *IntrospectionRequest, serviceAccountSecret string) (IntrospectionResponse, error)
+	GetUserInfo(ctx context.Context, userID uint) (UserInfo, error)
+}
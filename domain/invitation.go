@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	InvitationStatusPending  = "pending"
+	InvitationStatusExpired  = "expired"
+	InvitationStatusAccepted = "accepted"
+	InvitationStatusRevoked  = "revoked"
+)
+
+// MANY TO ONE WITH ORGANIZATION
+
+// Invitation lets someone join an organization with a given role. The link sent by
+// e-mail carries a signed token (INVITATION_EXPIRY_HOUR) whose nonce is stored as a
+// SHA-256 hash; resending rotates the nonce, so only the latest link works.
+type Invitation struct {
+	gorm.Model
+	OrganizationID uint         `gorm:"not null;Index"`
+	Organization   Organization `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Email          string       `gorm:"size:255;not null;Index"`
+	Name           string       `gorm:"size:255"`
+	RoleID         uint         `gorm:"not null"`
+	InvitedByID    uint         `gorm:"not null"`
+	NonceHash      string       `gorm:"size:64;uniqueIndex;not null"`
+	SendCount      int          `gorm:"not null;default:0"`
+	LastSentAt     time.Time    `gorm:"not null"`
+	ExpiresAt      time.Time    `gorm:"not null"`
+	AcceptedAt     *time.Time
+	RevokedAt      *time.Time
+}
+
+type CreateInvitation struct {
+	Email  string `json:"email" binding:"required,email"`
+	Name   string `json:"name"`
+	RoleID uint   `json:"role_id" binding:"required,oneof=1 2 3"`
+}
+
+type AcceptInvitationRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Name     string `json:"name"`
+	Password string `json:"password" binding:"required"`
+}
+
+type PublicInvitation struct {
+	ID             uint   `json:"id"`
+	OrganizationID uint   `json:"organization_id"`
+	Email          string `json:"email"`
+	Name           string `json:"name"`
+	RoleID         uint   `json:"role_id"`
+	InvitedByID    uint   `json:"invited_by_id"`
+	Status         string `json:"status"`
+	SendCount      int    `json:"send_count"`
+	LastSentAt     string `json:"last_sent_at"`
+	ExpiresAt      string `json:"expires_at"`
+	CreatedAt      string `json:"created_at"`
+}
+
+type InvitationRepository interface {
+	Create(ctx context.Context, invitation *Invitation) error
+	GetByID(ctx context.Context, id uint) (Invitation, error)
+	// FetchOpenByOrganization returns the invitations neither accepted nor revoked (pending or expired)
+	FetchOpenByOrganization(ctx context.Context, organizationID uint) ([]Invitation, error)
+	GetOpenByEmail(ctx context.Context, organizationID uint, email string) (Invitation, error)
+	Renew(ctx context.Context, invitationID uint, nonceHash string, sentAt time.Time, expiresAt time.Time) error
+	Revoke(ctx context.Context, invitationID uint, revokedAt time.Time) error
+	// Accept marks the invitation accepted and creates the user in one transaction.
+	// It fails with ErrInvalidInvitation when the invitation is no longer open.
+	Accept(ctx context.Context, invitationID uint, user *User, acceptedAt time.Time) error
+}
+
+type InvitationUsecase interface {
+	Invite(ctx context.Context, organizationID uint, invitedByID uint, invitation *CreateInvitation, client ClientInfo) (PublicInvitation, error)
+	FetchByOrganization(ctx context.Context, organizationID uint) ([]PublicInvitation, error)
+	Resend(ctx context.Context, organizationID uint, invitationID uint, client ClientInfo) (PublicInvitation, error)
+	Revoke(ctx context.Context, organizationID uint, invitationID uint) error
+	Accept(ctx context.Context, request *AcceptInvitationRequest, client ClientInfo) error
+}
@@ -7,21 +7,97 @@ import (
 // JWT has a lot of predefined claims that can be used known as Registered Claims (like Issuer, Subject, Audience, Expiration Time, Not Before, Issued At, JWT ID)
 // You can also add custom claims to a JWT token. Custom claims are claims that are not registered in the IANA "JSON Web Token Claims" registry or the reserved claim names defined in the JWT specification.
 type JwtCustomClaims struct {
-	OrganizationID       uint   `json:"organization_id"`
-	OrganizationRoleID   uint   `json:"organization_role_id"`
-	OrganizationName     string `json:"organization_name"`
-	UserRoleID           uint   `json:"user_role_id"`
-	UserID               uint   `json:"user_id"`
-	jwt.RegisteredClaims        // userID, user.BioInfo.FirstName, ExpiresAt
+	OrganizationID       uint         `json:"organization_id"`
+	OrganizationRoleID   uint         `json:"organization_role_id"`
+	OrganizationName     string       `json:"organization_name"`
+	UserRoleID           uint         `json:"user_role_id"`
+	UserID               uint         `json:"user_id"`
+	SessionID            uint         `json:"sid,omitempty"` // domain.Session the token was issued for
+	Act                  *ActorClaims `json:"act,omitempty"` // set when an admin impersonates the user
+	jwt.RegisteredClaims              // userID, user.BioInfo.FirstName, ExpiresAt
+}
+
+// ActorClaims is the act claim of RFC 8693: the one actually acting on behalf of the subject.
+// The ID (jti) of an impersonation token is the ID of its domain.Impersonation.
+type ActorClaims struct {
+	Subject string `json:"sub"`
+	UserID  uint   `json:"user_id"`
+}
+
+// Value of the token_use claim of service account tokens
+const TokenUseService = "service"
+
+// Claims of the tokens issued to service accounts through the client_credentials grant.
+// TokenUse is always "service" so they can never be mistaken for a user token.
+type JwtServiceAccountClaims struct {
+	ServiceAccountID     uint   `json:"service_account_id"`
+	ClientID             string `json:"client_id"`
+	Scope                string `json:"scope"`
+	TokenUse             string `json:"token_use"`
+	SecretVersion        int    `json:"secret_version"` // ServiceAccount.SecretVersion when the token was issued
+	jwt.RegisteredClaims        // ClientID, ExpiresAt, IssuedAt
+}
+
+// Value of the token_use claim of MFA challenge tokens
+const TokenUseMFAChallenge = "mfa_challenge"
+
+// Claims of the short-lived token returned by /login when a second factor is required.
+// It carries the user only in Subject (no user_id), so it is never accepted as an access token.
+type JwtMFAChallengeClaims struct {
+	TokenUse             string `json:"token_use"`
+	Enrollment           bool   `json:"mfa_enrollment"`
+	jwt.RegisteredClaims        // Subject (user ID), ExpiresAt, IssuedAt
 }
 
-type JwtCustomRefreshClaims struct {
+// Value of the token_use claim of invitation tokens
+const TokenUseInvitation = "invitation"
+
+// Claims of the token sent in an invitation e-mail. Subject is the invitation ID and
+// ID (jti) a nonce whose hash is stored with the invitation, so a resent or revoked
+// invitation invalidates the links sent before.
+type JwtInvitationClaims struct {
+	TokenUse             string `json:"token_use"`
+	Email                string `json:"email"`
+	jwt.RegisteredClaims        // Subject (invitation ID), ID (nonce), ExpiresAt, IssuedAt
+}
+
+// Value of the token_use claim of the access tokens issued to OAuth clients
+const TokenUseOAuthAccess = "oauth_access"
+
+// Claims of the access token returned by the authorization_code grant. It has no
+// user_id, so the core API never takes it for a user token; it is only good for /userinfo.
+type JwtOAuthAccessClaims struct {
+	TokenUse             string `json:"token_use"`
+	ClientID             string `json:"client_id"`
+	Scope                string `json:"scope"`
+	jwt.RegisteredClaims        // Issuer, Subject (user ID), Audience (client ID), ExpiresAt, IssuedAt
+}
+
+// Claims of the ID tokens issued to OAuth clients (OpenID Connect Core 1.0, 2). The
+// profile, email and organization claims are only set when their scope was granted.
+type JwtIDTokenClaims struct {
+	Nonce                string `json:"nonce,omitempty"`
+	Name                 string `json:"name,omitempty"`
+	Email                string `json:"email,omitempty"`
+	EmailVerified        *bool  `json:"email_verified,omitempty"`
+	UserRoleID           uint   `json:"user_role_id,omitempty"`
+	OrganizationID       uint   `json:"organization_id,omitempty"`
+	OrganizationName     string `json:"organization_name,omitempty"`
+	OrganizationRoleID   uint   `json:"organization_role_id,omitempty"`
+	jwt.RegisteredClaims        // Issuer, Subject (user ID), Audience (client ID), ExpiresAt, IssuedAt
+}
+
+// Value of the token_use claim of launch tokens
+const TokenUseLaunch = "launch"
+
+// Claims of the token handed to a service opened from the hub. ID (jti) is a nonce
+// whose hash is stored in domain.ServiceLaunch, so the token is redeemed only once.
+type JwtLaunchClaims struct {
+	TokenUse             string `json:"token_use"`
 	OrganizationID       uint   `json:"organization_id"`
-	OrganizationRoleID   uint   `json:"organization_role_id"`
-	OrganizationName     string `json:"organization_name"`
-	UserRoleID           uint   `json:"user_role_id"`
-	UserID               uint   `json:"user_id"`
-	jwt.RegisteredClaims        // userID, user.BioInfo.FirstName, ExpiresAt
+	ServiceID            uint   `json:"service_id"`
+	LogID                uint   `json:"log_id"`
+	jwt.RegisteredClaims        // Issuer, Subject (user ID), Audience (service), ID (nonce), ExpiresAt, IssuedAt
 }
 
 // TokenUtil contains the methods to create and validate JWT tokens defined here
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Opening a service from the hub hands the user over to the service with a
+// launch token: a short-lived JWT signed by the key ring, whose only audience is
+// the launched service. The service front-end receives it in the launch URL and
+// its backend redeems it once on /service/launch/exchange, authenticated with an
+// OAuth client of the service, to learn who opened it and which usage log to
+// heartbeat against.
+
+// ServiceLaunch records a launch token so it can be redeemed only once. The nonce
+// (the jti of the token) is only stored as a SHA-256 hash.
+type ServiceLaunch struct {
+	gorm.Model
+	NonceHash        string    `gorm:"size:64;uniqueIndex;not null"`
+	UserID           uint      `gorm:"not null;Index"`
+	OrganizationID   uint      `gorm:"not null"`
+	ServiceID        uint      `gorm:"not null;Index"`
+	UserServiceLogID uint      `gorm:"not null"`
+	ExpiresAt        time.Time `gorm:"not null;Index"`
+	UsedAt           *time.Time
+}
+
+// LaunchAudience is the audience of the launch tokens of a service
+func LaunchAudience(serviceID uint) string {
+	return "service:" + strconv.FormatUint(uint64(serviceID), 10)
+}
+
+// LaunchExchangeRequest is sent by the backend of a service. The credentials of
+// its OAuth client may also be sent with HTTP Basic authentication.
+type LaunchExchangeRequest struct {
+	LaunchToken  string `form:"launch_token" json:"launch_token" binding:"required"`
+	ClientID     string `form:"client_id" json:"client_id"`
+	ClientSecret string `form:"client_secret" json:"client_secret"`
+}
+
+// LaunchExchangeResponse tells the service who opened it, from which organization,
+// and the usage log to send heartbeats for
+type LaunchExchangeResponse struct {
+	UserInfoResponse
+	ServiceID uint `json:"service_id"`
+	LogID     uint `json:"log_id"`
+}
+
+type ServiceLaunchRepository interface {
+	Create(ctx context.Context, launch *ServiceLaunch) error
+	// Consume marks the launch used and returns it. It fails with ErrInvalidLaunchToken
+	// when the nonce is unknown, already used or expired.
+	Consume(ctx context.Context, nonceHash string, at time.Time) (ServiceLaunch, error)
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+type LaunchUsecase interface {
+	// Issue signs the launch token of a usage log just opened and sets the launch URL of useService
+	Issue(ctx context.Context, userID uint, useService *UseService) error
+	Exchange(ctx context.Context, request *LaunchExchangeRequest) (LaunchExchangeResponse, error)
+}
@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Login brute-force protection. Failed logins are counted per account (the
+// e-mail, whether it exists or not) and per client IP. Past a few failures each
+// new attempt must wait a growing delay; past the limit the key is locked for a
+// while, each lockout longer than the previous one, until a successful login or
+// an admin unlock.
+
+// Prefixes of the keys tracked by the login attempt store
+const (
+	LoginAttemptKeyAccount = "account:"
+	LoginAttemptKeyIP      = "ip:"
+	LoginAttemptKeyGuest   = "guest:" // guests created by an IP, counted like failures
+)
+
+// LoginAttempt is the failure counter of one key. It is the model of the
+// database store and the value returned by every store.
+type LoginAttempt struct {
+	gorm.Model
+	Key           string    `gorm:"size:320;uniqueIndex;not null"`
+	Failures      int       `gorm:"not null;default:0"` // failures in the current window
+	Lockouts      int       `gorm:"not null;default:0"` // lockouts since the last success, drives their length
+	WindowStart   time.Time `gorm:"not null"`
+	LastFailureAt time.Time `gorm:"not null;Index"`
+	NextAttemptAt *time.Time
+	LockedUntil   *time.Time
+}
+
+// LoginThrottlePolicy holds the limits, read from the environment
+type LoginThrottlePolicy struct {
+	MaxAccountFailures int
+	MaxIPFailures      int
+	FailureWindow      time.Duration
+	LockoutDuration    time.Duration
+	MaxGuestsPerIPHour int
+}
+
+// LoginThrottledError is returned while a key is delayed or locked. It wraps
+// ErrTooManyLoginAttempts or ErrAccountLocked.
+type LoginThrottledError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string { return e.Err.Error() }
+func (e *LoginThrottledError) Unwrap() error { return e.Err }
+
+type LoginLockoutStatus struct {
+	UserID        uint   `json:"user_id"`
+	Email         string `json:"email"`
+	Locked        bool   `json:"locked"`
+	Failures      int    `json:"failures"`
+	Lockouts      int    `json:"lockouts"`
+	LockedUntil   string `json:"locked_until"`
+	NextAttemptAt string `json:"next_attempt_at"`
+}
+
+// LoginAttemptStore keeps the counters. The in-memory store suits a single
+// instance; clustered deployments use the database store so every instance
+// sees the same counters.
+type LoginAttemptStore interface {
+	// Get returns the counter of key, a zero LoginAttempt when there is none
+	Get(ctx context.Context, key string) (LoginAttempt, error)
+	// RegisterFailure atomically counts a failure; failures before windowStart are forgotten
+	RegisterFailure(ctx context.Context, key string, at time.Time, windowStart time.Time) (LoginAttempt, error)
+	// Delay refuses attempts of key until the given time
+	Delay(ctx context.Context, key string, until time.Time) error
+	// Lock refuses attempts of key until the given time, counts the lockout and clears the failures
+	Lock(ctx context.Context, key string, until time.Time) error
+	// Reset forgets key
+	Reset(ctx context.Context, key string) error
+	// Prune forgets the keys without failure since before that are not locked
+	Prune(ctx context.Context, before time.Time) (int64, error)
+}
+
+type LoginAttemptUsecase interface {
+	// Check refuses an attempt while the account or the IP is delayed or locked
+	Check(ctx context.Context, email string, client ClientInfo) error
+	// RegisterFailure counts a failed attempt and logs the lockout it causes; user is nil when the e-mail is unknown
+	RegisterFailure(ctx context.Context, email string, user *User, client ClientInfo) error
+	RegisterSuccess(ctx context.Context, email string) error
+	// RegisterGuest counts a guest created from the client IP, refused with ErrTooManyGuests past the hourly limit
+	RegisterGuest(ctx context.Context, client ClientInfo) error
+	GetStatus(ctx context.Context, userID uint) (LoginLockoutStatus, error)
+	Unlock(ctx context.Context, userID uint, client ClientInfo) error
+	UnlockIP(ctx context.Context, ipAddress string) error
+	Prune(ctx context.Context) (int64, error)
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	MailStatusPending = "pending"
+	MailStatusSent    = "sent"
+	MailStatusFailed  = "failed"
+)
+
+// Mail templates available in internal/mailer/templates
+const (
+	MailTemplatePasswordReset           = "password_reset"
+	MailTemplatePasswordChanged         = "password_changed"
+	MailTemplateInvitation              = "invitation"
+	MailTemplateSubscriptionExpiring    = "subscription_expiring"
+	MailTemplateSubscriptionExpired     = "subscription_expired"
+	MailTemplateSubscriptionDeactivated = "subscription_deactivated"
+)
+
+// MailMessage is a rendered e-mail ready to be delivered
+type MailMessage struct {
+	To       []string
+	Subject  string
+	TextBody string
+	HTMLBody string
+}
+
+// Mailer delivers e-mails. The driver is selected by MAIL_DRIVER (see bootstrap.NewMailer).
+type Mailer interface {
+	Send(ctx context.Context, message *MailMessage) error
+}
+
+// MailOutbox is an e-mail waiting to be delivered by the mail outbox worker.
+// Request handlers only insert rows here, so they never block on SMTP. The
+// bodies carry the password reset and invitation links in clear: they are
+// blanked once the e-mail is sent or given up, only the envelope is kept.
+type MailOutbox struct {
+	gorm.Model
+	To            string    `gorm:"size:1024;not null"` // comma separated
+	Template      string    `gorm:"size:100"`
+	Locale        string    `gorm:"size:10"`
+	Subject       string    `gorm:"size:255;not null"`
+	TextBody      string    `gorm:"type:text"`
+	HTMLBody      string    `gorm:"type:text"`
+	Status        string    `gorm:"size:20;not null;default:'pending';Index"` // pending, sent, failed
+	Attempts      int       `gorm:"not null;default:0"`
+	NextAttemptAt time.Time `gorm:"not null;Index"`
+	LastError     string    `gorm:"size:1024"`
+	SentAt        *time.Time
+}
+
+type MailOutboxRepository interface {
+	Create(ctx context.Context, mail *MailOutbox) error
+	FetchDue(ctx context.Context, now time.Time, limit int) ([]MailOutbox, error)
+	Claim(ctx context.Context, mailID uint, now time.Time, leaseUntil time.Time) (bool, error)
+	MarkSent(ctx context.Context, mailID uint, sentAt time.Time) error
+	MarkRetry(ctx context.Context, mailID uint, attempts int, nextAttemptAt time.Time, lastError string) error
+	MarkFailed(ctx context.Context, mailID uint, attempts int, lastError string) error
+}
+
+type MailOutboxUsecase interface {
+	// Enqueue renders the template in the given locale and stores it for delivery
+	Enqueue(ctx context.Context, to []string, template string, locale string, data any) error
+	// DeliverPending sends the due e-mails, scheduling retries with backoff on failure
+	DeliverPending(ctx context.Context) (delivered int, err error)
+}
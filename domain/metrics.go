@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// MetricsUsecase materializes the organization and user metrics and the usage rollups.
+// The Record methods are called as the events happen; their failures are logged and
+// never fail the event, Recompute and ReconcileUsageRollups repair any drift from the
+// source tables.
+type MetricsUsecase interface {
+	RecordLogin(ctx context.Context, userID uint, ipAddress string)
+	// RecordSession counts a usage session as it opens
+	RecordSession(ctx context.Context, log UserServiceLog)
+	// RecordUsage credits usage to a session, as done to its usage log
+	RecordUsage(ctx context.Context, log UserServiceLog, duration time.Duration)
+	RecordReport(ctx context.Context, organizationID uint)
+	// Recompute rebuilds every metric, for backfills (see cmd/recomputemetrics)
+	Recompute(ctx context.Context) (organizations int, users int, err error)
+	// ReconcileUsageRollups rebuilds the usage rollups of the sessions started since the
+	// start of the (UTC) day of since; the zero time rebuilds everything
+	ReconcileUsageRollups(ctx context.Context, since time.Time) (int, error)
+	GetOrganizationMetrics(ctx context.Context, organizationID uint) (PublicOrganizationMetrics, error)
+	GetUserMetrics(ctx context.Context, userID uint) (PublicUserMetrics, error)
+}
@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ONE TO ONE WITH USER
+
+// UserMFA holds the TOTP factor of a user. The secret is encrypted at rest
+// (MFA_ENCRYPTION_KEY) and only becomes active once a first code is verified.
+type UserMFA struct {
+	gorm.Model
+	UserID          uint   `gorm:"not null;uniqueIndex"`
+	SecretEncrypted string `gorm:"size:255;not null"`
+	IsEnabled       bool   `gorm:"not null;default:false"`
+	EnabledAt       *time.Time
+	LastUsedStep    int64 `gorm:"not null;default:0"` // last accepted TOTP step, a code cannot be used twice
+}
+
+// MANY TO ONE WITH USER
+
+// MFARecoveryCode is a single-use backup code. Only its SHA-256 hash is stored.
+type MFARecoveryCode struct {
+	gorm.Model
+	UserID   uint   `gorm:"not null;Index"`
+	CodeHash string `gorm:"size:64;not null;Index"`
+	UsedAt   *time.Time
+}
+
+type MFACodeRequest struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recoveryCode"`
+}
+
+type MFALoginRequest struct {
+	MFAToken     string `json:"mfaToken" binding:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recoveryCode"`
+}
+
+type MFAEnrollLoginRequest struct {
+	MFAToken string `json:"mfaToken" binding:"required"`
+}
+
+// MFAChallengeResponse is returned by /login instead of LoginResponse when a second factor is needed.
+// With EnrollmentRequired the organization requires MFA and the user must enroll first (/login/mfa/enroll).
+type MFAChallengeResponse struct {
+	MFARequired        bool   `json:"mfaRequired"`
+	EnrollmentRequired bool   `json:"enrollmentRequired"`
+	MFAToken           string `json:"mfaToken"`
+	ExpiresIn          int    `json:"expiresIn"`
+}
+
+// MFALoginResponse completes a two-step login. RecoveryCodes is only set when the login finished an enrollment.
+type MFALoginResponse struct {
+	AccessToken   string   `json:"accessToken"`
+	RefreshToken  string   `json:"refreshToken"`
+	RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+}
+
+type MFAEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type MFARecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type MFAStatus struct {
+	Enabled                bool `json:"enabled"`
+	RequiredByOrganization bool `json:"required_by_organization"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+type MFARepository interface {
+	GetByUserID(ctx context.Context, userID uint) (UserMFA, error)
+	Save(ctx context.Context, mfa *UserMFA) error
+	Enable(ctx context.Context, userID uint, enabledAt time.Time, step int64) error
+	UseStep(ctx context.Context, userID uint, step int64) error
+	DeleteByUserID(ctx context.Context, userID uint) error
+	ReplaceRecoveryCodes(ctx context.Context, userID uint, codeHashes []string) error
+	UseRecoveryCode(ctx context.Context, userID uint, codeHash string, usedAt time.Time) error
+	CountRecoveryCodes(ctx context.Context, userID uint) (int, error)
+}
+
+type MFAUsecase interface {
+	GetStatus(ctx context.Context, userID uint) (MFAStatus, error)
+	Enroll(ctx context.Context, userID uint) (*MFAEnrollment, error)
+	Activate(ctx context.Context, userID uint, code string) (*MFARecoveryCodes, error)
+	// Verify checks a TOTP code or, when code is empty, a recovery code
+	Verify(ctx context.Context, userID uint, code string, recoveryCode string) error
+	Disable(ctx context.Context, userID uint, code string, recoveryCode string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) (*MFARecoveryCodes, error)
+	// Reset removes the factor without a code (administrators, lost device)
+	Reset(ctx context.Context, userID uint) error
+}
@@ -0,0 +1,214 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// The core is the OpenID Connect provider of the platform microservices: each
+// service launched from the hub registers OAuth clients and signs its users in
+// with the authorization code flow (PKCE required). ID tokens are signed by the
+// key ring, so services verify them with /.well-known/jwks.json.
+
+// Scopes a client may request on /authorize
+const (
+	OAuthScopeOpenID       = "openid"
+	OAuthScopeProfile      = "profile"
+	OAuthScopeEmail        = "email"
+	OAuthScopeOrganization = "organization"
+)
+
+// OAuthAvailableScopes lists every scope of the authorization endpoint
+var OAuthAvailableScopes = []string{
+	OAuthScopeOpenID,
+	OAuthScopeProfile,
+	OAuthScopeEmail,
+	OAuthScopeOrganization,
+}
+
+const GrantTypeAuthorizationCode = "authorization_code"
+
+// MANY TO ONE WITH SERVICE
+
+// OAuthClient is an application of a service allowed to sign users in. First-party
+// clients skip the consent screen. Public clients (mobile, single page) have no
+// secret and rely on PKCE only.
+type OAuthClient struct {
+	gorm.Model
+	ServiceID        uint     `gorm:"not null;Index"`
+	Service          Service  `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Name             string   `gorm:"size:255;not null"`
+	ClientID         string   `gorm:"size:255;uniqueIndex;not null"`
+	ClientSecretHash string   `gorm:"size:255"` // empty for public clients
+	RedirectURIs     []string `gorm:"serializer:json"`
+	FirstParty       bool     `gorm:"not null;default:false"`
+	LastUsedAt       *time.Time
+}
+
+// OAuthAuthorizationCode is a code issued by /authorize, stored as a SHA-256 hash and redeemable once
+type OAuthAuthorizationCode struct {
+	gorm.Model
+	CodeHash      string    `gorm:"size:64;uniqueIndex;not null"`
+	OAuthClientID uint      `gorm:"not null;Index"`
+	UserID        uint      `gorm:"not null;Index"`
+	RedirectURI   string    `gorm:"size:1024;not null"`
+	Scope         string    `gorm:"size:255;not null"`
+	Nonce         string    `gorm:"size:255"`
+	CodeChallenge string    `gorm:"size:255;not null"`
+	ExpiresAt     time.Time `gorm:"not null;Index"`
+	UsedAt        *time.Time
+}
+
+// OAuthConsent remembers the scopes a user granted to a third-party client
+type OAuthConsent struct {
+	gorm.Model
+	UserID        uint   `gorm:"not null;uniqueIndex:idx_oauth_consent_user_client"`
+	OAuthClientID uint   `gorm:"not null;uniqueIndex:idx_oauth_consent_user_client"`
+	Scope         string `gorm:"size:255;not null"`
+}
+
+type CreateOAuthClient struct {
+	ServiceID    uint     `json:"service_id" binding:"required"`
+	Name         string   `json:"name" binding:"required"`
+	RedirectURIs []string `json:"redirect_uris" binding:"required,min=1,dive,url"`
+	FirstParty   bool     `json:"first_party"`
+	Public       bool     `json:"public"` // no client secret, PKCE only
+}
+
+type UpdateOAuthClient struct {
+	Name         string   `json:"name" binding:"required"`
+	RedirectURIs []string `json:"redirect_uris" binding:"required,min=1,dive,url"`
+	FirstParty   bool     `json:"first_party"`
+}
+
+type PublicOAuthClient struct {
+	ID           uint     `json:"id"`
+	ServiceID    uint     `json:"service_id"`
+	ServiceName  string   `json:"service_name"`
+	Name         string   `json:"name"`
+	ClientID     string   `json:"client_id"`
+	RedirectURIs []string `json:"redirect_uris"`
+	FirstParty   bool     `json:"first_party"`
+	Public       bool     `json:"public"`
+	CreatedAt    string   `json:"created_at"`
+	LastUsedAt   string   `json:"last_used_at"`
+}
+
+// OAuthClientCredentials is returned only once, when the client is created or its
+// secret is rotated. ClientSecret is empty for public clients.
+type OAuthClientCredentials struct {
+	OAuthClient  PublicOAuthClient `json:"oauth_client"`
+	ClientID     string            `json:"client_id"`
+	ClientSecret string            `json:"client_secret,omitempty"`
+}
+
+// AuthorizeRequest follows RFC 6749 section 4.1.1 and RFC 7636 (PKCE)
+type AuthorizeRequest struct {
+	ResponseType        string `form:"response_type" json:"response_type"`
+	ClientID            string `form:"client_id" json:"client_id" binding:"required"`
+	RedirectURI         string `form:"redirect_uri" json:"redirect_uri" binding:"required"`
+	Scope               string `form:"scope" json:"scope"`
+	State               string `form:"state" json:"state"`
+	Nonce               string `form:"nonce" json:"nonce"`
+	CodeChallenge       string `form:"code_challenge" json:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method"`
+}
+
+// ConsentRequest answers the consent screen of a third-party client
+type ConsentRequest struct {
+	AuthorizeRequest
+	Approve *bool `json:"approve" binding:"required"`
+}
+
+// AuthorizeResponse tells the hub what to do with the browser: either go to
+// RedirectTo (code or error for the client) or ask the user for consent first
+type AuthorizeResponse struct {
+	RedirectTo      string             `json:"redirect_to,omitempty"`
+	ConsentRequired bool               `json:"consent_required"`
+	Client          *PublicOAuthClient `json:"client,omitempty"`
+	Scopes          []string           `json:"scopes,omitempty"`
+}
+
+// UserInfoResponse is the answer of /userinfo (OpenID Connect Core 1.0, 5.3), filtered by the granted scopes
+type UserInfoResponse struct {
+	Subject            string `json:"sub"`
+	Name               string `json:"name,omitempty"`
+	Email              string `json:"email,omitempty"`
+	EmailVerified      *bool  `json:"email_verified,omitempty"`
+	UserRoleID         uint   `json:"user_role_id,omitempty"`
+	OrganizationID     uint   `json:"organization_id,omitempty"`
+	OrganizationName   string `json:"organization_name,omitempty"`
+	OrganizationRoleID uint   `json:"organization_role_id,omitempty"`
+}
+
+// OpenIDConfiguration is the discovery document (OpenID Connect Discovery 1.0)
+type OpenIDConfiguration struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+// OAuthRedirectError is an error of the authorization request that is reported to the
+// client through its redirect URI (RFC 6749 section 4.1.2.1)
+type OAuthRedirectError struct {
+	Code        string
+	Description string
+}
+
+func (e *OAuthRedirectError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+type OAuthClientRepository interface {
+	Create(ctx context.Context, client *OAuthClient) error
+	Fetch(ctx context.Context) ([]OAuthClient, error)
+	GetByID(ctx context.Context, id uint) (OAuthClient, error)
+	GetByClientID(ctx context.Context, clientID string) (OAuthClient, error)
+	Update(ctx context.Context, client *OAuthClient) error
+	UpdateSecret(ctx context.Context, oauthClientID uint, clientSecretHash string) error
+	TouchLastUsed(ctx context.Context, oauthClientID uint, usedAt time.Time) error
+	Delete(ctx context.Context, oauthClientID uint) error
+}
+
+type OAuthAuthorizationCodeRepository interface {
+	Create(ctx context.Context, code *OAuthAuthorizationCode) error
+	// Consume marks the code used and returns it. It fails with ErrInvalidGrant when
+	// the code is unknown, issued to another client, already used or expired.
+	Consume(ctx context.Context, codeHash string, oauthClientID uint, at time.Time) (OAuthAuthorizationCode, error)
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+type OAuthConsentRepository interface {
+	Get(ctx context.Context, userID uint, oauthClientID uint) (OAuthConsent, error)
+	// Save creates or replaces the consent of the user to the client
+	Save(ctx context.Context, consent *OAuthConsent) error
+}
+
+type OAuthClientUsecase interface {
+	Create(ctx context.Context, client *CreateOAuthClient) (OAuthClientCredentials, error)
+	Fetch(ctx context.Context) ([]PublicOAuthClient, error)
+	Update(ctx context.Context, oauthClientID uint, client *UpdateOAuthClient) (PublicOAuthClient, error)
+	RotateSecret(ctx context.Context, oauthClientID uint) (OAuthClientCredentials, error)
+	Delete(ctx context.Context, oauthClientID uint) error
+}
+
+type OAuthUsecase interface {
+	Authorize(ctx context.Context, userID uint, request *AuthorizeRequest) (AuthorizeResponse, error)
+	Consent(ctx context.Context, userID uint, request *ConsentRequest) (AuthorizeResponse, error)
+	// ExchangeCode implements the authorization_code grant of /token
+	ExchangeCode(ctx context.Context, request *TokenRequest) (TokenResponse, error)
+	UserInfo(ctx context.Context, accessToken string) (UserInfoResponse, error)
+	Discovery() OpenIDConfiguration
+}
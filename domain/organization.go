@@ -12,6 +12,7 @@ type Organization struct {
 	Nickname           string                   `gorm:"size:255"`
 	LogoUrl            string                   `gorm:"size:255"`
 	RoleID             uint                     `gorm:"not null"`
+	RequireMFA         bool                     `gorm:"not null;default:false"`
 	Role               OrganizationRole         `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 	Users              []User                   `gorm:"foreignKey:OrganizationID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 	Subscription       OrganizationSubscription `gorm:"foreignKey:OrganizationID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
@@ -20,15 +21,62 @@ type Organization struct {
 }
 
 type CreateOrganization struct {
-	Name               string `json:"name" binding:"required"`
+	Name               string `json:"name" binding:"required,max=255"`
+	Nickname           string `json:"nickname" binding:"max=255"`
+	LogoUrl            string `json:"logo_url" binding:"omitempty,max=255,url"`
 	OrganizationRoleID uint   `json:"organization_role_id" binding:"required,number"`
 }
 
+// UpdateOrganization replaces the profile of an organization (PUT). The role is
+// kept when omitted and only platform admins may change it.
+type UpdateOrganization struct {
+	Name               string `json:"name" binding:"required,max=255"`
+	Nickname           string `json:"nickname" binding:"max=255"`
+	LogoUrl            string `json:"logo_url" binding:"omitempty,max=255,url"`
+	OrganizationRoleID *uint  `json:"organization_role_id"`
+}
+
+// PatchOrganization changes only the fields sent (PATCH); an empty nickname or logo clears it
+type PatchOrganization struct {
+	Name               *string `json:"name" binding:"omitempty,min=1,max=255"`
+	Nickname           *string `json:"nickname" binding:"omitempty,max=255"`
+	LogoUrl            *string `json:"logo_url" binding:"omitempty,max=255,url|len=0"`
+	OrganizationRoleID *uint   `json:"organization_role_id"`
+}
+
+// AsPatch returns the patch setting every field of the update
+func (u UpdateOrganization) AsPatch() PatchOrganization {
+	return PatchOrganization{
+		Name:               &u.Name,
+		Nickname:           &u.Nickname,
+		LogoUrl:            &u.LogoUrl,
+		OrganizationRoleID: u.OrganizationRoleID,
+	}
+}
+
 type PublicOrganization struct {
-	ID       uint   `json:"id"`
-	Name     string `json:"name"`
-	Nickname string `json:"nickname"`
-	LogoUrl  string `json:"logo_url"`
+	ID         uint   `json:"id"`
+	Name       string `json:"name"`
+	Nickname   string `json:"nickname"`
+	LogoUrl    string `json:"logo_url"`
+	RequireMFA bool   `json:"require_mfa"`
+	DeletedAt  string `json:"deleted_at,omitempty"` // set for archived organizations only
+}
+
+// OrganizationDetails is the full view of an organization for its admins and the platform admins
+type OrganizationDetails struct {
+	PublicOrganization
+	RoleID             uint                     `json:"organization_role_id"`
+	RoleName           string                   `json:"organization_role_name"`
+	UserCount          int                      `json:"user_count"`
+	SubscribedServices []PublicService          `json:"subscribed_services"`
+	Subscription       PublicSubscriptionStatus `json:"subscription"`
+	CreatedAt          string                   `json:"created_at"`
+	UpdatedAt          string                   `json:"updated_at"`
+}
+
+type OrganizationMFAPolicy struct {
+	RequireMFA *bool `json:"require_mfa" binding:"required"`
 }
 
 type OrganizationRepository interface {
@@ -38,16 +86,27 @@ type OrganizationRepository interface {
 	GetByName(ctx context.Context, name string) (Organization, error)
 	GetUsers(ctx context.Context, id uint) ([]User, error)
 	GetSubscribedServices(ctx context.Context, id uint) ([]PublicService, error)
+	// FetchArchived returns the soft-deleted organizations
+	FetchArchived(ctx context.Context) ([]Organization, error)
+	// NameTaken reports whether another organization, archived ones included, uses the name
+	NameTaken(ctx context.Context, name string, exceptID uint) (bool, error)
+	// Update saves the name, nickname, logo and role of the organization
 	Update(ctx context.Context, organizationID uint, organization *Organization) error
+	SetRequireMFA(ctx context.Context, organizationID uint, requireMFA bool) error
 	Delete(ctx context.Context, organizationID uint) error
+	// Restore brings back a soft-deleted organization; ErrNotFound when none is archived with the ID
+	Restore(ctx context.Context, organizationID uint) error
 }
 
 type OrganizationUsecase interface {
 	Create(ctx context.Context, organization *Organization) error
-	Fetch(ctx context.Context) ([]PublicOrganization, error)
-	GetByIdentifier(ctx context.Context, identifier string) (PublicOrganization, error)
+	Fetch(ctx context.Context, archived bool) ([]PublicOrganization, error)
+	GetByIdentifier(ctx context.Context, identifier string) (OrganizationDetails, error)
 	GetUsers(ctx context.Context, id uint) ([]PublicUser, error)
 	GetSubscribedServices(ctx context.Context, id uint) ([]PublicService, error)
-	Update(ctx context.Context, organizationID uint, organization *Organization) error
+	// Update applies the fields of the patch; a role change fails with ErrForbidden unless allowRoleChange
+	Update(ctx context.Context, organizationID uint, patch *PatchOrganization, allowRoleChange bool) (OrganizationDetails, error)
+	SetMFAPolicy(ctx context.Context, organizationID uint, requireMFA bool) (PublicOrganization, error)
 	Delete(ctx context.Context, organizationID uint) error
+	Restore(ctx context.Context, organizationID uint) (OrganizationDetails, error)
 }
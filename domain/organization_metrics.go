@@ -1,36 +1,48 @@
 package domain
 
 import (
+	"context"
+	"time"
+
 	"gorm.io/gorm"
 )
 
 // ONE TO ONE WITH ORGANIZATION
 
+// OrganizationMetrics is kept up to date as the members use the services and the
+// services record reports (see MetricsUsecase). The user and service counts change
+// in many places, they are refreshed when the metrics are read.
 type OrganizationMetrics struct {
 	gorm.Model
 	OrganizationID           uint   `gorm:"not null;uniqueIndex"`
-	TotalServices            int    `gorm:"default:0"`
-	TotalUsers               int    `gorm:"default:0"`
-	TotalReports             int    `gorm:"default:0"`
-	TotalReportsCurrentMonth int    `gorm:"default:0"`
-	LastReportDate           string `gorm:"size:255"`
-	NextReportDate           string `gorm:"size:255"`
+	TotalServices            int    `gorm:"not null;default:0"`
+	TotalUsers               int    `gorm:"not null;default:0"` // members, guests excluded
+	TotalReports             int    `gorm:"not null;default:0"`
+	TotalReportsCurrentMonth int    `gorm:"not null;default:0"`
+	ReportsMonth             string `gorm:"size:7"` // month (2006-01) counted by TotalReportsCurrentMonth
+	LastReportDate           *time.Time
+	TotalUsageDuration       time.Duration `gorm:"not null;default:0"` // time.Duration (nanoseconds), exposed in seconds
 }
 
-type OrganizationMetricsRepository interface {
-	Create(organizationMetrics *OrganizationMetrics) error
-	Fetch() ([]OrganizationMetrics, error)
-	GetByID(id uint) (OrganizationMetrics, error)
-	GetByOrganizationID(organizationID uint) (OrganizationMetrics, error)
-	Update(organizationMetricsID uint, organizationMetrics *OrganizationMetrics) error
-	Delete(organizationMetricsID uint) error
+type PublicOrganizationMetrics struct {
+	OrganizationID           uint   `json:"organization_id"`
+	TotalServices            int    `json:"total_services"`
+	TotalUsers               int    `json:"total_users"`
+	TotalReports             int    `json:"total_reports"`
+	TotalReportsCurrentMonth int    `json:"total_reports_current_month"`
+	LastReportDate           string `json:"last_report_date"`
+	TotalUsageDuration       int64  `json:"total_usage_duration"` // seconds
+	UpdatedAt                string `json:"updated_at"`
 }
 
-type OrganizationMetricsUsecase interface {
-	Create(organizationMetrics *OrganizationMetrics) error
-	Fetch() ([]OrganizationMetrics, error)
-	GetByID(id uint) (OrganizationMetrics, error)
-	GetByOrganizationID(organizationID uint) (OrganizationMetrics, error)
-	Update(organizationMetricsID uint, organizationMetrics *OrganizationMetrics) error
-	Delete(organizationMetricsID uint) error
+type OrganizationMetricsRepository interface {
+	// GetByOrganizationID returns the metrics of the organization, zero values when nothing was recorded yet
+	GetByOrganizationID(ctx context.Context, organizationID uint) (OrganizationMetrics, error)
+	// AddUsage credits usage of a user to the metrics of the organization of the user
+	AddUsage(ctx context.Context, userID uint, duration time.Duration) error
+	AddReport(ctx context.Context, organizationID uint, at time.Time) error
+	// RefreshCounts counts the members and the subscribed services of the organization again
+	RefreshCounts(ctx context.Context, organizationID uint) error
+	// Recompute rebuilds the metrics of every organization from the source tables
+	Recompute(ctx context.Context) (int, error)
 }
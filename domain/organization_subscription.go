@@ -2,12 +2,34 @@ package domain
 
 import (
 	"context"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 // ONE TO ONE WITH ORGANIZATION
 
+// Billing periods of a subscription
+const (
+	SubscriptionPeriodMonthly   = "monthly"
+	SubscriptionPeriodQuarterly = "quarterly"
+	SubscriptionPeriodYearly    = "yearly"
+)
+
+// Subscription status of an organization
+const (
+	SubscriptionStatusNone     = "none"     // no subscription, nothing is limited
+	SubscriptionStatusPending  = "pending"  // active but not started yet
+	SubscriptionStatusActive   = "active"   // active and within its dates
+	SubscriptionStatusInactive = "inactive" // deactivated by a platform admin
+	SubscriptionStatusGrace    = "grace"    // past its end date, services still available until the grace period ends
+	SubscriptionStatusExpired  = "expired"  // past its end date and grace period
+)
+
+// OrganizationSubscription is the plan of an organization. Zero limits mean unlimited;
+// SubscriptionReportsLimit counts the reports of each calendar month. The subscription
+// jobs warn before EndsAt, renew the auto-renewed ones for another period and deactivate
+// the others once GraceEndsAt is over.
 type OrganizationSubscription struct {
 	gorm.Model
 	Active                   bool    `gorm:"default:false"`
@@ -16,8 +38,121 @@ type OrganizationSubscription struct {
 	SubscriptionPeriod       string  `gorm:"not null"`
 	SubscriptionUsersLimit   int     `gorm:"not null"`
 	SubscriptionReportsLimit int     `gorm:"not null"`
-	SubscriptionInitDate     string  `gorm:"not null"`
-	SubscriptionEndDate      string  `gorm:"not null"`
+	StartsAt                 time.Time
+	EndsAt                   time.Time `gorm:"Index"`
+	GraceEndsAt              *time.Time
+	AutoRenew                bool `gorm:"not null;default:false"`
+	ExpiryWarnedAt           *time.Time
+	ExpiryNotifiedAt         *time.Time
+}
+
+// Status summarizes the subscription at now; an organization without one is SubscriptionStatusNone
+func (s OrganizationSubscription) Status(now time.Time) string {
+	switch {
+	case s.ID == 0:
+		return SubscriptionStatusNone
+	case !now.Before(s.EndsAt) && (s.GraceEndsAt == nil || !now.Before(*s.GraceEndsAt)):
+		return SubscriptionStatusExpired
+	case !s.Active:
+		return SubscriptionStatusInactive
+	case !now.Before(s.EndsAt):
+		return SubscriptionStatusGrace
+	case now.Before(s.StartsAt):
+		return SubscriptionStatusPending
+	}
+	return SubscriptionStatusActive
+}
+
+// CheckAccess tells whether the members of the organization may use the services at now.
+// Organizations without a subscription or in their grace period are not limited.
+func (s OrganizationSubscription) CheckAccess(now time.Time) error {
+	switch s.Status(now) {
+	case SubscriptionStatusExpired:
+		return ErrSubscriptionExpired
+	case SubscriptionStatusInactive, SubscriptionStatusPending:
+		return ErrSubscriptionInactive
+	}
+	return nil
+}
+
+// SubscriptionRequest creates or replaces the subscription of an organization
+type SubscriptionRequest struct {
+	Active       bool      `json:"active"`
+	Value        float64   `json:"value" binding:"gte=0"`
+	Period       string    `json:"period" binding:"required,oneof=monthly quarterly yearly"`
+	UsersLimit   int       `json:"users_limit" binding:"gte=0"`   // 0 for unlimited
+	ReportsLimit int       `json:"reports_limit" binding:"gte=0"` // per calendar month, 0 for unlimited
+	StartsAt     time.Time `json:"starts_at" binding:"required"`
+	EndsAt       time.Time `json:"ends_at" binding:"required,gtfield=StartsAt"`
+	AutoRenew    bool      `json:"auto_renew"` // renewed for another period when it ends
+}
+
+// SubscriptionPeriodEnd returns the end of the nth billing period of a subscription started
+// at startsAt. Every end keeps the day of startsAt, clamped to the last day of shorter
+// months, so a subscription started on the 31st ends on each month end without drifting.
+func SubscriptionPeriodEnd(startsAt time.Time, period string, n int) time.Time {
+	months := 1
+	switch period {
+	case SubscriptionPeriodQuarterly:
+		months = 3
+	case SubscriptionPeriodYearly:
+		months = 12
+	}
+	year, month, day := startsAt.Date()
+	hour, minute, second := startsAt.Clock()
+	firstOfMonth := time.Date(year, month+time.Month(n*months), 1, hour, minute, second, startsAt.Nanosecond(), startsAt.Location())
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	return firstOfMonth.AddDate(0, 0, min(day, lastDay)-1)
+}
+
+// MANY TO ONE WITH ORGANIZATION SUBSCRIPTION
+
+// SubscriptionRenewal records a billing period added to an auto-renewed subscription
+type SubscriptionRenewal struct {
+	gorm.Model
+	OrganizationSubscriptionID uint      `gorm:"not null;Index"`
+	OrganizationID             uint      `gorm:"not null;Index"`
+	Period                     string    `gorm:"not null"`
+	Value                      float64   `gorm:"not null"`
+	StartsAt                   time.Time `gorm:"not null"`
+	EndsAt                     time.Time `gorm:"not null"`
+}
+
+type PublicSubscriptionRenewal struct {
+	ID        uint    `json:"id"`
+	Period    string  `json:"period"`
+	Value     float64 `json:"value"`
+	StartsAt  string  `json:"starts_at"`
+	EndsAt    string  `json:"ends_at"`
+	CreatedAt string  `json:"created_at"`
+}
+
+type PublicSubscription struct {
+	ID             uint    `json:"id"`
+	OrganizationID uint    `json:"organization_id"`
+	Active         bool    `json:"active"`
+	Status         string  `json:"status"`
+	Value          float64 `json:"value"`
+	Period         string  `json:"period"`
+	UsersLimit     int     `json:"users_limit"`
+	UsersUsed      int     `json:"users_used"`
+	ReportsLimit   int     `json:"reports_limit"`
+	ReportsUsed    int64   `json:"reports_used"` // this calendar month
+	StartsAt       string  `json:"starts_at"`
+	EndsAt         string  `json:"ends_at"`
+	GraceEndsAt    string  `json:"grace_ends_at"`
+	AutoRenew      bool    `json:"auto_renew"`
+	CreatedAt      string  `json:"created_at"`
+	UpdatedAt      string  `json:"updated_at"`
+}
+
+// PublicSubscriptionStatus is the summary of the subscription shown with the organization
+type PublicSubscriptionStatus struct {
+	Status       string `json:"status"`
+	UsersLimit   int    `json:"users_limit"`
+	ReportsLimit int    `json:"reports_limit"`
+	StartsAt     string `json:"starts_at"`
+	EndsAt       string `json:"ends_at"`
 }
 
 type OrganizationSubscriptionRepository interface {
@@ -26,14 +161,44 @@ type OrganizationSubscriptionRepository interface {
 	GetByID(ctx context.Context, id uint) (OrganizationSubscription, error)
 	GetByOrganizationID(ctx context.Context, organizationID uint) (OrganizationSubscription, error)
 	Update(ctx context.Context, organizationSubscriptionID uint, organizationSubscription *OrganizationSubscription) error
+	SetActive(ctx context.Context, organizationSubscriptionID uint, active bool) error
+	// Delete removes the subscription for good so the organization can subscribe again
 	Delete(ctx context.Context, organizationSubscriptionID uint) error
+	// FetchExpiring returns the active subscriptions ending before the given time that
+	// will not renew and were not warned yet
+	FetchExpiring(ctx context.Context, now time.Time, before time.Time) ([]OrganizationSubscription, error)
+	// FetchInGrace returns the active subscriptions that ended, will not renew, are
+	// still in their grace period and whose organization was not notified yet
+	FetchInGrace(ctx context.Context, now time.Time) ([]OrganizationSubscription, error)
+	// FetchGraceOver returns the active subscriptions that ended, will not renew and are past their grace period
+	FetchGraceOver(ctx context.Context, now time.Time) ([]OrganizationSubscription, error)
+	// FetchDueRenewal returns the active auto-renewed subscriptions that ended
+	FetchDueRenewal(ctx context.Context, now time.Time) ([]OrganizationSubscription, error)
+	MarkExpiryWarned(ctx context.Context, organizationSubscriptionID uint, at time.Time) error
+	MarkExpiryNotified(ctx context.Context, organizationSubscriptionID uint, at time.Time) error
+	// Renew records the renewals and moves the end of the subscription accordingly, in one transaction
+	Renew(ctx context.Context, organizationSubscription *OrganizationSubscription, renewals []SubscriptionRenewal) error
+	FetchRenewals(ctx context.Context, organizationSubscriptionID uint) ([]SubscriptionRenewal, error)
 }
 
 type OrganizationSubscriptionUsecase interface {
-	Create(ctx context.Context, organizationSubscription *OrganizationSubscription) error
-	Fetch(ctx context.Context) ([]OrganizationSubscription, error)
-	GetByID(ctx context.Context, id uint) (OrganizationSubscription, error)
-	GetByOrganizationID(ctx context.Context, organizationID uint) (OrganizationSubscription, error)
-	Update(ctx context.Context, organizationSubscriptionID uint, organizationSubscription *OrganizationSubscription) error
-	Delete(ctx context.Context, organizationSubscriptionID uint) error
+	Create(ctx context.Context, organizationID uint, request *SubscriptionRequest) (PublicSubscription, error)
+	GetByOrganizationID(ctx context.Context, organizationID uint) (PublicSubscription, error)
+	Update(ctx context.Context, organizationID uint, request *SubscriptionRequest) (PublicSubscription, error)
+	// SetActive activates or deactivates the subscription; expiry follows its end date
+	SetActive(ctx context.Context, organizationID uint, active bool) (PublicSubscription, error)
+	Delete(ctx context.Context, organizationID uint) error
+	FetchRenewals(ctx context.Context, organizationID uint) ([]PublicSubscriptionRenewal, error)
+}
+
+// SubscriptionLifecycleUsecase holds the scheduled subscription jobs. Each returns how many subscriptions it handled.
+type SubscriptionLifecycleUsecase interface {
+	// SendExpiryWarnings e-mails the managers of the organizations whose subscription ends soon
+	SendExpiryWarnings(ctx context.Context) (int, error)
+	// NotifyGracePeriods e-mails the managers of the organizations whose subscription ended and entered its grace period
+	NotifyGracePeriods(ctx context.Context) (int, error)
+	// DeactivateExpired deactivates the subscriptions past their grace period
+	DeactivateExpired(ctx context.Context) (int, error)
+	// RenewDue adds billing periods to the auto-renewed subscriptions that ended
+	RenewDue(ctx context.Context) (int, error)
 }
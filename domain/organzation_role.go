@@ -8,6 +8,13 @@ import (
 
 // ONE TO MANY WITH ORGANIZATION
 
+// Organization role IDs as created by bootstrap/seeds/roles.go
+const (
+	OrganizationRoleAdmin    uint = 1
+	OrganizationRoleHospital uint = 2
+	OrganizationRoleGuest    uint = 3
+)
+
 type OrganizationRole struct {
 	gorm.Model
 	RoleName      string         `gorm:"size:255;uniqueIndex;not null"`
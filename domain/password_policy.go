@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// PasswordPolicy is what a new password must satisfy. MinCharacterClasses counts
+// how many of lowercase, uppercase, digits and symbols it must mix; the last
+// HistorySize passwords of the user cannot be reused. Hashes made with another
+// BcryptCost are upgraded on the next successful login. With ChangeWeakOnLogin a
+// user whose current password breaks the policy must change it to sign in.
+type PasswordPolicy struct {
+	MinLength           int
+	MinCharacterClasses int
+	HistorySize         int
+	BcryptCost          int
+	ChangeWeakOnLogin   bool
+}
+
+// PasswordPolicyError is returned when a new password is refused. It wraps
+// ErrPasswordTooShort, ErrPasswordTooLong, ErrPasswordTooSimple,
+// ErrPasswordTooCommon or ErrPasswordReused; Requirement tells what is expected.
+type PasswordPolicyError struct {
+	Err         error
+	Requirement string
+}
+
+func (e *PasswordPolicyError) Error() string { return e.Err.Error() + ": " + e.Requirement }
+func (e *PasswordPolicyError) Unwrap() error { return e.Err }
+
+// MANY TO ONE WITH USER
+
+// PasswordHistory keeps the hashes of the last passwords of a user so they are not reused
+type PasswordHistory struct {
+	gorm.Model
+	UserID       uint   `gorm:"not null;Index"`
+	PasswordHash string `gorm:"size:255;not null"`
+}
+
+// PasswordChangeChallengeResponse is returned by /login and /login/mfa instead of the tokens when
+// the user must change their password first. ResetToken is a single-use token for /reset-password.
+// RecoveryCodes is only set when the login finished an MFA enrollment.
+type PasswordChangeChallengeResponse struct {
+	PasswordChangeRequired bool     `json:"passwordChangeRequired"`
+	ResetToken             string   `json:"resetToken"`
+	ExpiresIn              int      `json:"expiresIn"`
+	RecoveryCodes          []string `json:"recoveryCodes,omitempty"`
+}
+
+type PasswordHistoryRepository interface {
+	Create(ctx context.Context, history *PasswordHistory) error
+	// FetchRecentByUserID returns the last hashes of a user, most recent first
+	FetchRecentByUserID(ctx context.Context, userID uint, limit int) ([]PasswordHistory, error)
+	// Prune keeps only the most recent entries of a user
+	Prune(ctx context.Context, userID uint, keep int) error
+}
+
+type PasswordUsecase interface {
+	// Hash checks a new password against the policy (and, for an existing user, against
+	// their recent passwords) and hashes it with the configured cost
+	Hash(ctx context.Context, user *User, rawPassword string) (string, error)
+	// Record adds the hash of a password just set to the history of the user
+	Record(ctx context.Context, userID uint, hashedPassword string) error
+	// Save makes the hash the current password of the user, which ends a forced change
+	Save(ctx context.Context, userID uint, hashedPassword string) error
+	// Verify checks the password at login, upgrading its hash and flagging a weak one for change
+	Verify(ctx context.Context, user *User, rawPassword string) error
+	// VerifyUnknown takes the time of Verify for a login without a user and always fails
+	// with ErrUserPasswordNotMatch
+	VerifyUnknown(rawPassword string) error
+}
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MANY TO ONE WITH USER
+
+// PasswordResetToken is a single-use, time-limited token sent by e-mail to prove
+// ownership of an account. Only its SHA-256 hash is stored.
+type PasswordResetToken struct {
+	gorm.Model
+	UserID    uint      `gorm:"not null;Index"`
+	TokenHash string    `gorm:"size:64;uniqueIndex;not null"`
+	IPAddress string    `gorm:"size:255"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"newPassword" binding:"required"`
+}
+
+type PasswordResetTokenRepository interface {
+	Create(ctx context.Context, resetToken *PasswordResetToken) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (PasswordResetToken, error)
+	MarkUsed(ctx context.Context, resetTokenID uint, usedAt time.Time) error
+	InvalidateByUserID(ctx context.Context, userID uint, at time.Time) error
+}
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MANY TO ONE WITH USER
+
+// RefreshToken is an opaque, single-use refresh token. Only its SHA-256 hash is
+// stored. Every login starts a new family; each rotation marks the presented
+// token as used and issues a child in the same family. Presenting a used token
+// again means it was stolen, so the whole family is revoked.
+type RefreshToken struct {
+	gorm.Model
+	UserID    uint      `gorm:"not null;Index"`
+	TokenHash string    `gorm:"size:64;uniqueIndex;not null"`
+	FamilyID  string    `gorm:"size:64;not null;Index"`
+	ParentID  *uint     `gorm:"Index"`
+	UserAgent string    `gorm:"size:512"`
+	IPAddress string    `gorm:"size:255"`
+	IssuedAt  time.Time `gorm:"not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, refreshToken *RefreshToken) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (RefreshToken, error)
+	MarkUsed(ctx context.Context, refreshTokenID uint, usedAt time.Time) error
+	RevokeFamily(ctx context.Context, familyID string, revokedAt time.Time) error
+	RevokeByUserID(ctx context.Context, userID uint, revokedAt time.Time) error
+}
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Reports are produced by the services, not by the core: before producing one, the
+// backend of a service records it here against the reports limit of the organization.
+
+// MANY TO ONE WITH ORGANIZATION
+// MANY TO ONE WITH SERVICE
+
+// ServiceReport is a report a service produced for a user
+type ServiceReport struct {
+	gorm.Model
+	OrganizationID   uint `gorm:"not null;Index"`
+	ServiceID        uint `gorm:"not null;Index"`
+	UserID           uint `gorm:"not null;Index"`
+	UserServiceLogID uint `gorm:"Index"`
+}
+
+// ReportRequest is sent by the backend of a service with the usage log of the user
+// it produces a report for (see LaunchExchangeResponse.LogID)
+type ReportRequest struct {
+	LogID        uint   `form:"log_id" json:"log_id" binding:"required"`
+	ClientID     string `form:"client_id" json:"client_id"`
+	ClientSecret string `form:"client_secret" json:"client_secret"`
+}
+
+// ReportQuota is the reports usage of the organization this calendar month
+type ReportQuota struct {
+	ReportID     uint  `json:"report_id"`
+	ReportsUsed  int64 `json:"reports_used"`
+	ReportsLimit int   `json:"reports_limit"` // 0 for unlimited
+	Remaining    int64 `json:"remaining"`     // -1 when unlimited
+}
+
+type ServiceReportRepository interface {
+	Create(ctx context.Context, report *ServiceReport) error
+	CountByOrganizationSince(ctx context.Context, organizationID uint, since time.Time) (int64, error)
+}
+
+type ReportUsecase interface {
+	// Record checks the subscription of the organization of the user and counts a new report
+	Record(ctx context.Context, request *ReportRequest) (ReportQuota, error)
+}
+
+// MonthStart is the beginning of the calendar month of t, when the reports limit resets
+func MonthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
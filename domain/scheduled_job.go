@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Status of the last run of a scheduled job
+const (
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+)
+
+// ScheduledJob is a job run by the scheduler on a cron expression (UTC). Every
+// replica runs the scheduler; the row is the lease that lets a single replica
+// run each occurrence, and it keeps the outcome of the last run.
+type ScheduledJob struct {
+	gorm.Model
+	Name           string    `gorm:"size:100;uniqueIndex;not null"`
+	Schedule       string    `gorm:"size:100;not null"`
+	NextRunAt      time.Time `gorm:"not null"`
+	LeaseOwner     string    `gorm:"size:255"`
+	LeaseUntil     *time.Time
+	LastStartedAt  *time.Time
+	LastFinishedAt *time.Time
+	LastStatus     string `gorm:"size:20"` // running, succeeded, failed
+	LastResult     string `gorm:"size:1024"`
+	LastError      string `gorm:"size:1024"`
+}
+
+type PublicScheduledJob struct {
+	Name           string `json:"name"`
+	Schedule       string `json:"schedule"`
+	NextRunAt      string `json:"next_run_at"`
+	Running        bool   `json:"running"`
+	RunningOn      string `json:"running_on,omitempty"`
+	LastStartedAt  string `json:"last_started_at"`
+	LastFinishedAt string `json:"last_finished_at"`
+	LastDurationMs int64  `json:"last_duration_ms"`
+	LastStatus     string `json:"last_status"`
+	LastResult     string `json:"last_result"`
+	LastError      string `json:"last_error"`
+}
+
+type ScheduledJobRepository interface {
+	// Register creates the job, or updates its schedule and next run when the schedule changed.
+	// A job that missed runs while no replica was up keeps its past next run and runs at once.
+	Register(ctx context.Context, name string, schedule string, nextRunAt time.Time) error
+	Fetch(ctx context.Context) ([]ScheduledJob, error)
+	// Acquire takes the lease of a due job and moves its next run to nextRunAt. Only one
+	// replica can win the update; a lease left by a dead replica is taken over once expired.
+	Acquire(ctx context.Context, name string, owner string, now time.Time, leaseUntil time.Time, nextRunAt time.Time) (bool, error)
+	// Finish records the outcome of the run and releases the lease
+	Finish(ctx context.Context, name string, owner string, finishedAt time.Time, status string, result string, runError string) error
+}
+
+type ScheduledJobUsecase interface {
+	Fetch(ctx context.Context) ([]PublicScheduledJob, error)
+}
@@ -6,6 +6,14 @@ import (
 	"gorm.io/gorm"
 )
 
+// Values of Service.Status. Offline services and services under maintenance
+// cannot be launched; any other status (empty included) counts as online.
+const (
+	ServiceStatusOnline      = "Online"
+	ServiceStatusOffline     = "Offline"
+	ServiceStatusMaintenance = "Maintenance"
+)
+
 // MANY TO MANY WITH ORGANIZATION
 
 type Service struct {
@@ -28,6 +36,11 @@ type Service struct {
 	Organization  []Organization `gorm:"many2many:organization_services;" json:"-"`
 }
 
+// IsActive reports whether the service can be launched
+func (s Service) IsActive() bool {
+	return s.Status != ServiceStatusOffline && s.Status != ServiceStatusMaintenance
+}
+
 type PublicService struct {
 	ID            uint    `json:"id"`
 	MarketingName string  `json:"marketing_name"`
@@ -72,12 +85,17 @@ type MarketingService struct {
 }
 
 type UseService struct {
-	Service PublicService `json:"service"`
-	LogID   uint          `json:"log_id"`
+	Service     PublicService `json:"service"`
+	LogID       uint          `json:"log_id"`
+	LaunchToken string        `json:"launch_token"`
+	LaunchURL   string        `json:"launch_url"` // AppUrl carrying the launch token, ready to redirect to
+	ExpiresIn   int           `json:"expires_in"` // seconds left to redeem the launch token
 }
 
+// Heartbeat keeps a usage session open. Duration is deprecated and ignored: the
+// server computes the usage from the time between heartbeats.
 type Heartbeat struct {
-	LogID    uint `json:"log_id"`
+	LogID    uint `json:"log_id" binding:"required"`
 	Duration int  `json:"duration"`
 }
 
@@ -99,8 +117,9 @@ type ServiceUsageStats struct {
 }
 
 type TimeSeriesDataPoint struct {
-	Date     string                       `json:"date"`
-	Services map[string]TimeSeriesService `json:"services"` // key: service_name
+	Date     string                       `json:"date"`     // label of the bucket in the requested time zone: 2006-01-02 15:00 (hour), 2006-01-02 (day, week starting on Monday) or 2006-01 (month)
+	Start    string                       `json:"start"`    // start of the bucket, RFC 3339 with the offset of the time zone
+	Services map[string]TimeSeriesService `json:"services"` // key: service_name, every service of the range, zero when unused in the bucket
 }
 
 type TimeSeriesService struct {
@@ -127,6 +146,7 @@ type ServiceRepository interface {
 	GetMarketing(ctx context.Context) ([]Service, error)
 	SetAvailabilityToOrganization(ctx context.Context, serviceID uint, organizationID uint) error
 	RemoveAvailabilityFromOrganization(ctx context.Context, serviceID uint, organizationID uint) error
+	IsAvailableToOrganization(ctx context.Context, serviceID uint, organizationID uint) (bool, error)
 	Update(ctx context.Context, serviceID uint, service *Service) error
 	Delete(ctx context.Context, serviceID uint) error
 }
@@ -139,8 +159,11 @@ type ServiceUsecase interface {
 	GetMarketing(ctx context.Context) ([]MarketingService, error)
 	SetAvailabilityToOrganization(ctx context.Context, serviceID uint, organizationID uint) error
 	RemoveAvailabilityFromOrganization(ctx context.Context, serviceID uint, organizationID uint) error
-	Use(ctx context.Context, userID uint, serviceID uint) (UseService, uint, error)
-	Heartbeat(ctx context.Context, logID uint, duration int) error
+	// Use opens a usage session of the service from a login session
+	Use(ctx context.Context, userID uint, sessionID uint, serviceID uint) (UseService, uint, error)
+	Heartbeat(ctx context.Context, userID uint, logID uint) error
+	// Close ends a usage session of the user
+	Close(ctx context.Context, userID uint, logID uint) error
 	Update(ctx context.Context, serviceID uint, service *Service) error
 	Delete(ctx context.Context, serviceID uint) error
 }
@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Machine identities used by the other platform microservices. They
+// authenticate with the client_credentials grant on /token and receive
+// short-lived tokens carrying their scopes.
+
+// Scopes that can be granted to a service account
+const (
+	ScopeUsersRead           = "users:read"
+	ScopeUsersWrite          = "users:write"
+	ScopeOrganizationsRead   = "organizations:read"
+	ScopeOrganizationsWrite  = "organizations:write"
+	ScopeServicesRead        = "services:read"
+	ScopeServicesWrite       = "services:write"
+	ScopeStatisticsRead      = "statistics:read"
+	ScopeContactIntentsRead  = "contact-intents:read"
+	ScopeContactIntentsWrite = "contact-intents:write"
+	ScopeTokensIntrospect    = "tokens:introspect"
+)
+
+// AvailableScopes lists every scope a service account may hold
+var AvailableScopes = []string{
+	ScopeUsersRead,
+	ScopeUsersWrite,
+	ScopeOrganizationsRead,
+	ScopeOrganizationsWrite,
+	ScopeServicesRead,
+	ScopeServicesWrite,
+	ScopeStatisticsRead,
+	ScopeContactIntentsRead,
+	ScopeContactIntentsWrite,
+	ScopeTokensIntrospect,
+}
+
+type ServiceAccount struct {
+	gorm.Model
+	Name             string `gorm:"size:255;uniqueIndex;not null"`
+	Description      string `gorm:"size:255"`
+	ClientID         string `gorm:"size:255;uniqueIndex;not null"`
+	ClientSecretHash string `gorm:"size:255;not null"`
+	Scopes           string `gorm:"size:1024"`          // space delimited, as in the OAuth "scope" parameter
+	SecretVersion    int    `gorm:"not null;default:1"` // raised by each rotation, the tokens of older versions are refused
+	LastUsedAt       *time.Time
+}
+
+type CreateServiceAccount struct {
+	Name        string   `json:"name" binding:"required"`
+	Description string   `json:"description"`
+	Scopes      []string `json:"scopes" binding:"required,min=1"`
+}
+
+type PublicServiceAccount struct {
+	ID          uint     `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	ClientID    string   `json:"client_id"`
+	Scopes      []string `json:"scopes"`
+	CreatedAt   string   `json:"created_at"`
+	LastUsedAt  string   `json:"last_used_at"`
+}
+
+// ServiceAccountCredentials is returned only once, when the account is created
+// or its secret is rotated. The secret is never stored in plain text.
+type ServiceAccountCredentials struct {
+	ServiceAccount PublicServiceAccount `json:"service_account"`
+	ClientID       string               `json:"client_id"`
+	ClientSecret   string               `json:"client_secret"`
+}
+
+// TokenRequest follows RFC 6749 section 4.4 (client credentials grant) and
+// section 4.1.3 with RFC 7636 (authorization code grant with PKCE)
+type TokenRequest struct {
+	GrantType    string `form:"grant_type" json:"grant_type" binding:"required"`
+	ClientID     string `form:"client_id" json:"client_id"`
+	ClientSecret string `form:"client_secret" json:"client_secret"`
+	Scope        string `form:"scope" json:"scope"`
+	Code         string `form:"code" json:"code"`
+	RedirectURI  string `form:"redirect_uri" json:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier" json:"code_verifier"`
+}
+
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
+	IDToken     string `json:"id_token,omitempty"`
+}
+
+// OAuthErrorResponse follows RFC 6749 section 5.2
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+type ServiceAccountRepository interface {
+	Create(ctx context.Context, serviceAccount *ServiceAccount) error
+	Fetch(ctx context.Context) ([]ServiceAccount, error)
+	GetByID(ctx context.Context, id uint) (ServiceAccount, error)
+	GetByClientID(ctx context.Context, clientID string) (ServiceAccount, error)
+	// UpdateSecret replaces the hash of the client secret and raises the secret version
+	UpdateSecret(ctx context.Context, serviceAccountID uint, clientSecretHash string) error
+	TouchLastUsed(ctx context.Context, serviceAccountID uint, usedAt time.Time) error
+	Delete(ctx context.Context, serviceAccountID uint) error
+}
+
+type ServiceAccountUsecase interface {
+	Create(ctx context.Context, serviceAccount *CreateServiceAccount) (ServiceAccountCredentials, error)
+	Fetch(ctx context.Context) ([]PublicServiceAccount, error)
+	RotateSecret(ctx context.Context, serviceAccountID uint) (ServiceAccountCredentials, error)
+	Delete(ctx context.Context, serviceAccountID uint) error
+	// Validate refuses the tokens of a deleted service account or of a rotated secret with ErrServiceAccountRevoked
+	Validate(ctx context.Context, serviceAccountID uint, secretVersion int) error
+	IssueToken(ctx context.Context, request *TokenRequest, tokenSecret string, tokenExpiry int) (TokenResponse, error)
+}
@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MANY TO ONE WITH USER
+
+// Session is a signed-in device. Every login starts one together with a refresh
+// token family (FamilyID); the access tokens of the session carry its ID in the
+// sid claim and are refused once it is revoked.
+type Session struct {
+	gorm.Model
+	UserID     uint      `gorm:"not null;Index"`
+	FamilyID   string    `gorm:"size:64;not null;uniqueIndex"`
+	UserAgent  string    `gorm:"size:512"`
+	IPAddress  string    `gorm:"size:255"`
+	LastSeenAt time.Time `gorm:"not null"`
+	ExpiresAt  time.Time `gorm:"not null"`
+	RevokedAt  *time.Time
+}
+
+type PublicSession struct {
+	ID         uint   `json:"id"`
+	UserAgent  string `json:"user_agent"`
+	IPAddress  string `json:"ip_address"`
+	CreatedAt  string `json:"created_at"`
+	LastSeenAt string `json:"last_seen_at"`
+	ExpiresAt  string `json:"expires_at"`
+	Current    bool   `json:"current"`
+}
+
+type SessionRepository interface {
+	Create(ctx context.Context, session *Session) error
+	GetByID(ctx context.Context, id uint) (Session, error)
+	GetByFamilyID(ctx context.Context, familyID string) (Session, error)
+	// FetchActiveByUserID returns the sessions of a user neither revoked nor expired at the given time
+	FetchActiveByUserID(ctx context.Context, userID uint, at time.Time) ([]Session, error)
+	// Touch records the last time the session was used
+	Touch(ctx context.Context, sessionID uint, lastSeenAt time.Time) error
+	// Extend records a refresh: the device data may change and the session lasts until expiresAt
+	Extend(ctx context.Context, sessionID uint, client ClientInfo, lastSeenAt time.Time, expiresAt time.Time) error
+	// Revoke revokes a session and its refresh token family
+	Revoke(ctx context.Context, sessionID uint, revokedAt time.Time) error
+	RevokeByFamilyID(ctx context.Context, familyID string, revokedAt time.Time) error
+	RevokeByUserID(ctx context.Context, userID uint, revokedAt time.Time) error
+}
+
+type SessionUsecase interface {
+	// FetchByUserID lists the active sessions of a user, flagging currentSessionID
+	FetchByUserID(ctx context.Context, userID uint, currentSessionID uint) ([]PublicSession, error)
+	// Revoke signs a device of the user out
+	Revoke(ctx context.Context, userID uint, sessionID uint, client ClientInfo) error
+	// Validate checks that the session of an access token is still active and records its use
+	Validate(ctx context.Context, sessionID uint) error
+}
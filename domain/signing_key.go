@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"gorm.io/gorm"
+)
+
+// Algorithms supported for signing user access tokens
+const (
+	SigningAlgorithmRS256 = "RS256"
+	SigningAlgorithmEdDSA = "EdDSA"
+)
+
+// SigningKey is a key pair of the access token key ring. Only one key is active
+// (used to sign); retired keys keep verifying the tokens they signed until those
+// expire, so a rotation never logs anybody out. The private key is a PKCS#8 PEM
+// encrypted with SIGNING_KEY_ENCRYPTION_KEY.
+type SigningKey struct {
+	gorm.Model
+	KID                 string `gorm:"size:64;uniqueIndex;not null"`
+	Algorithm           string `gorm:"size:10;not null"`
+	PrivateKeyEncrypted string `gorm:"type:text;not null"`
+	PublicKeyPEM        string `gorm:"type:text;not null"`
+	IsActive            bool   `gorm:"not null;default:false;Index"`
+	RetiredAt           *time.Time
+}
+
+type RotateSigningKey struct {
+	Algorithm string `json:"algorithm" binding:"omitempty,oneof=RS256 EdDSA"`
+}
+
+type PublicSigningKey struct {
+	KID       string `json:"kid"`
+	Algorithm string `json:"algorithm"`
+	IsActive  bool   `json:"is_active"`
+	CreatedAt string `json:"created_at"`
+	RetiredAt string `json:"retired_at"`
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`   // RSA modulus
+	E   string `json:"e,omitempty"`   // RSA exponent
+	Crv string `json:"crv,omitempty"` // OKP curve
+	X   string `json:"x,omitempty"`   // OKP public key
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+type SigningKeyRepository interface {
+	Fetch(ctx context.Context) ([]SigningKey, error)
+	// FetchVerifiable returns the active key and the keys retired after retiredAfter
+	FetchVerifiable(ctx context.Context, retiredAfter time.Time) ([]SigningKey, error)
+	// Activate stores a new key as the active one and retires the previous active key
+	Activate(ctx context.Context, signingKey *SigningKey, at time.Time) error
+}
+
+// KeyRing signs user access tokens with the active key and resolves the key
+// that verifies a token from its kid header
+type KeyRing interface {
+	Sign(claims jwt.Claims) (string, error)
+	VerificationKey(token *jwt.Token) (interface{}, error) // jwt.Keyfunc
+	JWKS(ctx context.Context) (JWKS, error)
+	Reload(ctx context.Context) error
+}
+
+type SigningKeyUsecase interface {
+	Fetch(ctx context.Context) ([]PublicSigningKey, error)
+	Rotate(ctx context.Context, algorithm string, encryptionKey string) (PublicSigningKey, error)
+	GetJWKS(ctx context.Context) (JWKS, error)
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Granularity of the usage rollups (hour, day) and of the usage statistics (all)
+const (
+	UsageGranularityHour  = "hour"
+	UsageGranularityDay   = "day"
+	UsageGranularityWeek  = "week" // starting on Monday
+	UsageGranularityMonth = "month"
+)
+
+// MANY TO ONE WITH ORGANIZATION
+// MANY TO ONE WITH SERVICE
+// MANY TO ONE WITH USER
+
+// UsageHourlyRollup sums the usage sessions of a user in a service started within an
+// hour (UTC). The rollups are kept up to date as the sessions open and their heartbeats
+// are credited, and rebuilt from the usage logs by a periodic job to repair any drift.
+type UsageHourlyRollup struct {
+	gorm.Model
+	BucketStart    time.Time     `gorm:"not null;uniqueIndex:idx_usage_hourly_rollup_key"` // start of the hour, UTC
+	OrganizationID uint          `gorm:"not null;uniqueIndex:idx_usage_hourly_rollup_key;Index"`
+	ServiceID      uint          `gorm:"not null;uniqueIndex:idx_usage_hourly_rollup_key"`
+	UserID         uint          `gorm:"not null;uniqueIndex:idx_usage_hourly_rollup_key"`
+	Sessions       int64         `gorm:"not null;default:0"` // sessions started in the hour
+	Duration       time.Duration `gorm:"not null;default:0"` // credited to those sessions, time.Duration (nanoseconds)
+}
+
+// UsageDailyRollup is UsageHourlyRollup by day (UTC)
+type UsageDailyRollup struct {
+	gorm.Model
+	BucketStart    time.Time     `gorm:"not null;uniqueIndex:idx_usage_daily_rollup_key"` // start of the day, UTC
+	OrganizationID uint          `gorm:"not null;uniqueIndex:idx_usage_daily_rollup_key;Index"`
+	ServiceID      uint          `gorm:"not null;uniqueIndex:idx_usage_daily_rollup_key"`
+	UserID         uint          `gorm:"not null;uniqueIndex:idx_usage_daily_rollup_key"`
+	Sessions       int64         `gorm:"not null;default:0"` // sessions started in the day
+	Duration       time.Duration `gorm:"not null;default:0"` // credited to those sessions, time.Duration (nanoseconds)
+}
+
+// UsageTotal is the usage of a service summed by user or by bucket, the other one being zero
+type UsageTotal struct {
+	BucketStart time.Time
+	ServiceID   uint
+	ServiceName string
+	UserID      uint
+	Sessions    int64
+	Duration    time.Duration
+}
+
+type UsageRollupRepository interface {
+	// AddSession counts a session of the user in the hour and the day it started
+	AddSession(ctx context.Context, userID uint, serviceID uint, startedAt time.Time) error
+	// AddUsage credits usage to a session of the user in the hour and the day it started
+	AddUsage(ctx context.Context, userID uint, serviceID uint, startedAt time.Time, duration time.Duration) error
+	// Reconcile rebuilds the rollups of the sessions started since the start of the (UTC) day
+	// of since from the usage logs; the zero time rebuilds everything
+	Reconcile(ctx context.Context, since time.Time) (int, error)
+	// FetchTotals sums the rollups of granularity within [from, to), by service and user and
+	// by service and bucket; zero bounds are open
+	FetchTotals(ctx context.Context, granularity string, organizationID *uint, from time.Time, to time.Time) (byUser []UsageTotal, byBucket []UsageTotal, err error)
+}
@@ -10,26 +10,28 @@ import (
 
 type User struct {
 	gorm.Model
-	Name           string           `gorm:"size:255"`
-	Email          string           `gorm:"size:255;uniqueIndex;not null"`
-	Password       string           `gorm:"size:255;not null"`
-	OrganizationID uint             `gorm:"not nul;Index"`
-	Organization   Organization     `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE"` // Relationship to Organization
-	RoleID         uint             `gorm:"not null;Index"`
-	Role           UserRole         `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE"` // Relationship to UserRole
-	Bio            UserBio          `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Metrics        UserMetrics      `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Configs        UserConfig       `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Logs           []UserLog        `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	ServiceLogs    []UserServiceLog `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Name               string           `gorm:"size:255"`
+	Email              string           `gorm:"size:255;uniqueIndex;not null"`
+	Password           string           `gorm:"size:255;not null"`
+	MustChangePassword bool             `gorm:"not null;default:false"` // Change the password on the next login
+	OrganizationID     uint             `gorm:"not nul;Index"`
+	Organization       Organization     `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE"` // Relationship to Organization
+	RoleID             uint             `gorm:"not null;Index"`
+	Role               UserRole         `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE"` // Relationship to UserRole
+	Bio                UserBio          `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Metrics            UserMetrics      `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Configs            UserConfig       `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Logs               []UserLog        `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	ServiceLogs        []UserServiceLog `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 }
 
 type CreateUser struct {
-	Name           string `json:"name" binding:"required"`
-	Email          string `json:"email" binding:"required,email"`
-	Password       string `json:"password" binding:"required"`
-	OrganizationID uint   `json:"organization_id" binding:"required"`
-	RoleID         uint   `json:"role" binding:"required"`
+	Name               string `json:"name" binding:"required"`
+	Email              string `json:"email" binding:"required,email"`
+	Password           string `json:"password" binding:"required"`
+	OrganizationID     uint   `json:"organization_id" binding:"required"`
+	RoleID             uint   `json:"role" binding:"required"`
+	MustChangePassword bool   `json:"must_change_password"` // Replace the initial password on first login
 }
 
 type PublicUser struct {
@@ -51,6 +53,9 @@ type UserRepository interface {
 	GetByID(ctx context.Context, id uint) (User, error)
 	GetByEmail(ctx context.Context, email string) (User, error)
 	Update(ctx context.Context, userID uint, user *User) error
+	// UpdatePassword sets the password hash and the forced change flag, false included
+	UpdatePassword(ctx context.Context, userID uint, hashedPassword string, mustChangePassword bool) error
+	RequirePasswordChange(ctx context.Context, userID uint) error
 	Archive(ctx context.Context, userID uint) error
 	Unarchive(ctx context.Context, userID uint) error
 }
@@ -60,6 +65,7 @@ type UserUsecase interface {
 	Fetch(ctx context.Context) ([]PublicUser, error)
 	GetByIdentifier(ctx context.Context, identifier string) (PublicUser, error)
 	Update(ctx context.Context, userID uint, user *User) error
+	RequirePasswordChange(ctx context.Context, userID uint) error
 	Archive(ctx context.Context, userID uint) error
 	Unarchive(ctx context.Context, userID uint) error
 }
@@ -2,36 +2,42 @@ package domain
 
 import (
 	"context"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 // ONE TO ONE WITH USER
 
+// UserMetrics is kept up to date on each login and usage heartbeat (see MetricsUsecase).
+// FavoriteServiceID is the service the user spent the most time in.
 type UserMetrics struct {
 	gorm.Model
 	UserID             uint   `gorm:"not null;uniqueIndex"`
 	FavoriteServiceID  uint   `gorm:""`
 	LastIP             string `gorm:"size:255"`
-	LastLogin          string `gorm:"size:255"`
-	TotalLogins        int    `gorm:"size:255"`
-	TotalUsageDuration int    `gorm:"size:255"`
+	LastLogin          *time.Time
+	TotalLogins        int           `gorm:"not null;default:0"`
+	TotalUsageDuration time.Duration `gorm:"not null;default:0"` // time.Duration (nanoseconds), exposed in seconds
 }
 
-type UserMetricsRepository interface {
-	Create(ctx context.Context, userMetrics *UserMetrics) error
-	Fetch(ctx context.Context) ([]UserMetrics, error)
-	GetByID(ctx context.Context, id uint) (UserMetrics, error)
-	GetByUserID(ctx context.Context, userID uint) (UserMetrics, error)
-	Update(ctx context.Context, userMetricsID uint, userMetrics *UserMetrics) error
-	Delete(ctx context.Context, userMetricsID uint) error
+type PublicUserMetrics struct {
+	UserID             uint   `json:"user_id"`
+	OrganizationID     uint   `json:"organization_id"`
+	FavoriteServiceID  uint   `json:"favorite_service_id"`
+	LastIP             string `json:"last_ip"`
+	LastLogin          string `json:"last_login"`
+	TotalLogins        int    `json:"total_logins"`
+	TotalUsageDuration int64  `json:"total_usage_duration"` // seconds
+	UpdatedAt          string `json:"updated_at"`
 }
 
-type UserMetricsUsecase interface {
-	Create(ctx context.Context, userMetrics *UserMetrics) error
-	Fetch(ctx context.Context) ([]UserMetrics, error)
-	GetByID(ctx context.Context, id uint) (UserMetrics, error)
+type UserMetricsRepository interface {
+	// GetByUserID returns the metrics of the user, zero values when nothing was recorded yet
 	GetByUserID(ctx context.Context, userID uint) (UserMetrics, error)
-	Update(ctx context.Context, userMetricsID uint, userMetrics *UserMetrics) error
-	Delete(ctx context.Context, userMetricsID uint) error
+	AddLogin(ctx context.Context, userID uint, ipAddress string, at time.Time) error
+	// AddUsage credits usage to the user and elects its favorite service again
+	AddUsage(ctx context.Context, userID uint, duration time.Duration) error
+	// Recompute rebuilds the metrics of every user from the user logs and the usage logs
+	Recompute(ctx context.Context) (int, error)
 }
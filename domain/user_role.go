@@ -9,6 +9,14 @@ import (
 // ONE TO MANY WITH USER
 // Admin, Manager, User, Guest
 
+// User role IDs as created by bootstrap/seeds/roles.go
+const (
+	UserRoleAdmin   uint = 1
+	UserRoleManager uint = 2
+	UserRoleUser    uint = 3
+	UserRoleGuest   uint = 4
+)
+
 type UserRole struct {
 	gorm.Model
 	RoleName string `gorm:"size:255;uniqueIndex;not null"`
@@ -8,21 +8,58 @@ import (
 	"gorm.io/gorm"
 )
 
+// End reasons of a usage session
+const (
+	UsageEndReasonClosed  = "closed"  // closed by the service or the hub
+	UsageEndReasonTimeout = "timeout" // heartbeats stopped, closed by the reaper
+	UsageEndReasonLogout  = "logout"  // the login session it was opened from ended
+)
+
 // MANY TO ONE WITH USER
 // MANY TO ONE WITH SERVICE
 
+// UserServiceLog is a usage session of a service. It starts when the user opens
+// the service from the hub and ends when it is closed, when the user logs out or
+// when its heartbeats stop. Duration is computed by the server: each heartbeat
+// credits the time elapsed since the previous one, capped by the heartbeat
+// timeout, so clients cannot inflate it.
 type UserServiceLog struct {
 	gorm.Model
-	UserID    uint          `gorm:"not null;Index"`
-	ServiceID uint          `gorm:"not null;Index"`
-	Duration  time.Duration `gorm:"default:0"` // Duration in seconds;
+	UserID          uint          `gorm:"not null;Index"`
+	ServiceID       uint          `gorm:"not null;Index"`
+	SessionID       uint          `gorm:"Index"`     // domain.Session the service was opened from, 0 when unknown
+	Duration        time.Duration `gorm:"default:0"` // time.Duration (nanoseconds), exposed in seconds
+	StartedAt       time.Time
+	LastHeartbeatAt time.Time  `gorm:"Index"`
+	EndedAt         *time.Time `gorm:"Index"`
+	EndReason       string     `gorm:"size:20"`
 }
 
 type PublicUserServiceLog struct {
-	ID        uint `json:"id"`
-	UserID    uint `json:"user_id"`
-	ServiceID uint `json:"service_id"`
-	Duration  int  `json:"duration"`
+	ID              uint   `json:"id"`
+	UserID          uint   `json:"user_id"`
+	ServiceID       uint   `json:"service_id"`
+	Duration        int    `json:"duration"`
+	StartedAt       string `json:"started_at"`
+	LastHeartbeatAt string `json:"last_heartbeat_at"`
+	EndedAt         string `json:"ended_at"`
+	EndReason       string `json:"end_reason"`
+}
+
+// UsageSessionRequest identifies the usage session a service is reporting about
+type UsageSessionRequest struct {
+	LogID uint `json:"log_id" binding:"required"`
+}
+
+// UsageStatisticsQuery filters the usage statistics and shapes their time series. The
+// dates are read in the time zone, UTC by default, and the series is bucketed by day
+// unless another granularity is asked.
+type UsageStatisticsQuery struct {
+	OrganizationID *uint  `form:"organization_id"`
+	StartDate      string `form:"start_date"` // 2006-01-02 or RFC 3339
+	EndDate        string `form:"end_date"`   // 2006-01-02, included, or RFC 3339, excluded
+	Timezone       string `form:"tz"`         // IANA name, e.g. America/Sao_Paulo
+	Granularity    string `form:"granularity" binding:"omitempty,oneof=hour day week month"`
 }
 
 type UserServiceLogRepository interface {
@@ -31,14 +68,31 @@ type UserServiceLogRepository interface {
 	GetByID(ctx context.Context, id uint) (UserServiceLog, error)
 	GetByUserID(ctx context.Context, userID uint) (UserServiceLog, error)
 	GetByServiceID(ctx context.Context, serviceID uint) (UserServiceLog, error)
-	UpdateDuration(ctx context.Context, UserServiceLogID uint, duration int) error
+	// RecordHeartbeat credits the usage since the previous heartbeat and moves the last
+	// heartbeat to at. It does nothing, and returns false, when the session ended or
+	// another heartbeat was recorded since previous.
+	RecordHeartbeat(ctx context.Context, UserServiceLogID uint, previous time.Time, at time.Time, credit time.Duration) (bool, error)
+	// End closes an open session at endedAt, crediting its last interval; it fails with
+	// ErrUsageSessionEnded when the session was already closed
+	End(ctx context.Context, UserServiceLogID uint, endedAt time.Time, credit time.Duration, reason string) error
+	// EndBySessionID closes the open sessions opened from a login session at their last heartbeat
+	EndBySessionID(ctx context.Context, sessionID uint, reason string) (int64, error)
+	// EndByUserID closes the open sessions of a user at their last heartbeat
+	EndByUserID(ctx context.Context, userID uint, reason string) (int64, error)
+	// EndStale closes, at their last heartbeat, the open sessions silent since before
+	EndStale(ctx context.Context, before time.Time) (int64, error)
 	Delete(ctx context.Context, UserServiceLogID uint) error
-	GetUsageStatistics(ctx context.Context, organizationID *uint, startDate *string, endDate *string) (UsageStatistics, error)
+	// FetchUsageTotals sums the sessions started within [from, to) like the rollups, by hour;
+	// the statistics read it for the current day, not rolled up for good yet
+	FetchUsageTotals(ctx context.Context, organizationID *uint, from time.Time, to time.Time) (byUser []UsageTotal, byHour []UsageTotal, err error)
+	FetchRecentActivity(ctx context.Context, organizationID *uint, from time.Time, to time.Time, limit int) ([]RecentActivityItem, error)
+	CountOrganizationUsers(ctx context.Context, organizationID uint) (int64, error)
 }
 
 type UserServiceLogUsecase interface {
 	Fetch(ctx context.Context) ([]PublicUserServiceLog, error)
 	GetByIdentifier(ctx context.Context, identifier string) (PublicUserServiceLog, error)
 	Delete(ctx context.Context, UserServiceLogID uint) error
-	GetUsageStatistics(ctx context.Context, organizationID *uint, startDate *string, endDate *string) (UsageStatistics, error)
+	// GetUsageStatistics reads the usage rollups, and the usage logs for the current day
+	GetUsageStatistics(ctx context.Context, query *UsageStatisticsQuery) (UsageStatistics, error)
 }
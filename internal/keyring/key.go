@@ -0,0 +1,172 @@
+package keyring
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal/tokenutil"
+	jwt "github.com/golang-jwt/jwt/v4"
+)
+
+const rsaKeyBits = 2048
+
+// key is a decoded signing key, ready to sign and verify
+type key struct {
+	record     domain.SigningKey
+	method     jwt.SigningMethod
+	privateKey crypto.Signer
+	publicKey  crypto.PublicKey
+}
+
+// GenerateKey creates a new key pair for the algorithm. The private key is
+// stored encrypted with encryptionKey and the kid is the RFC 7638 thumbprint
+// of the public key.
+func GenerateKey(algorithm string, encryptionKey string) (*domain.SigningKey, error) {
+	var privateKey crypto.Signer
+	var err error
+	switch algorithm {
+	case domain.SigningAlgorithmRS256:
+		privateKey, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case domain.SigningAlgorithmEdDSA:
+		_, privateKey, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm: %s", algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(privateKey.Public())
+	if err != nil {
+		return nil, err
+	}
+	privateKeyEncrypted, err := tokenutil.EncryptSecret(string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER})), encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	jwk, err := toJWK(privateKey.Public(), algorithm, "")
+	if err != nil {
+		return nil, err
+	}
+	kid, err := thumbprint(jwk)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.SigningKey{
+		KID:                 kid,
+		Algorithm:           algorithm,
+		PrivateKeyEncrypted: privateKeyEncrypted,
+		PublicKeyPEM:        string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})),
+	}, nil
+}
+
+// decodeKey decrypts and parses a stored signing key
+func decodeKey(record domain.SigningKey, encryptionKey string) (*key, error) {
+	privatePEM, err := tokenutil.DecryptSecret(record.PrivateKeyEncrypted, encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode([]byte(privatePEM))
+	if block == nil {
+		return nil, fmt.Errorf("invalid private key pem")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type")
+	}
+
+	var method jwt.SigningMethod
+	switch record.Algorithm {
+	case domain.SigningAlgorithmRS256:
+		if _, ok := privateKey.(*rsa.PrivateKey); !ok {
+			return nil, fmt.Errorf("key %s is not an RSA key", record.KID)
+		}
+		method = jwt.SigningMethodRS256
+	case domain.SigningAlgorithmEdDSA:
+		if _, ok := privateKey.(ed25519.PrivateKey); !ok {
+			return nil, fmt.Errorf("key %s is not an Ed25519 key", record.KID)
+		}
+		method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm: %s", record.Algorithm)
+	}
+
+	return &key{
+		record:     record,
+		method:     method,
+		privateKey: privateKey,
+		publicKey:  privateKey.Public(),
+	}, nil
+}
+
+func toJWK(publicKey crypto.PublicKey, algorithm string, kid string) (domain.JWK, error) {
+	switch pub := publicKey.(type) {
+	case *rsa.PublicKey:
+		return domain.JWK{
+			Kty: "RSA",
+			Use: "sig",
+			Kid: kid,
+			Alg: algorithm,
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}, nil
+	case ed25519.PublicKey:
+		return domain.JWK{
+			Kty: "OKP",
+			Use: "sig",
+			Kid: kid,
+			Alg: algorithm,
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(pub),
+		}, nil
+	}
+	return domain.JWK{}, fmt.Errorf("unsupported public key type")
+}
+
+// thumbprint computes the RFC 7638 JWK thumbprint: the SHA-256 of the required
+// members in lexicographic order, without whitespace
+func thumbprint(jwk domain.JWK) (string, error) {
+	var members interface{}
+	switch jwk.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	case "OKP":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	default:
+		return "", fmt.Errorf("unsupported key type: %s", jwk.Kty)
+	}
+	canonical, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,155 @@
+package keyring
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	jwt "github.com/golang-jwt/jwt/v4"
+)
+
+// missReloadInterval limits how often an unknown kid triggers a reload, so
+// forged kids cannot hammer the database
+const missReloadInterval = 10 * time.Second
+
+type keyRing struct {
+	repository    domain.SigningKeyRepository
+	encryptionKey string
+	verifyWindow  time.Duration
+
+	mu           sync.RWMutex
+	active       *key
+	keys         []*key // active first, then retired keys newest first
+	lastMissLoad time.Time
+}
+
+// New returns a key ring backed by the signing key repository. Retired keys
+// keep verifying tokens for verifyWindow (the access token lifetime), so the
+// tokens they signed live out their normal expiry. Call Reload before use.
+func New(repository domain.SigningKeyRepository, encryptionKey string, verifyWindow time.Duration) domain.KeyRing {
+	return &keyRing{
+		repository:    repository,
+		encryptionKey: encryptionKey,
+		verifyWindow:  verifyWindow,
+	}
+}
+
+// Reload reads the verifiable keys from the database
+func (k *keyRing) Reload(ctx context.Context) error {
+	records, err := k.repository.FetchVerifiable(ctx, time.Now().Add(-k.verifyWindow))
+	if err != nil {
+		return err
+	}
+
+	var active *key
+	keys := make([]*key, 0, len(records))
+	for _, record := range records {
+		decoded, err := decodeKey(record, k.encryptionKey)
+		if err != nil {
+			// a key that cannot be decoded (e.g. wrong encryption key) is left out
+			log.Printf("Skipping signing key %s: %v", record.KID, err)
+			continue
+		}
+		if record.IsActive && active == nil {
+			active = decoded
+			keys = append([]*key{decoded}, keys...)
+			continue
+		}
+		keys = append(keys, decoded)
+	}
+
+	k.mu.Lock()
+	k.active = active
+	k.keys = keys
+	k.mu.Unlock()
+	return nil
+}
+
+// Sign signs the claims with the active key, setting its kid in the header
+func (k *keyRing) Sign(claims jwt.Claims) (string, error) {
+	k.mu.RLock()
+	active := k.active
+	k.mu.RUnlock()
+	if active == nil {
+		return "", fmt.Errorf("no active signing key")
+	}
+
+	token := jwt.NewWithClaims(active.method, claims)
+	token.Header["kid"] = active.record.KID
+	return token.SignedString(active.privateKey)
+}
+
+// VerificationKey is a jwt.Keyfunc resolving the public key named by the kid header
+func (k *keyRing) VerificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, fmt.Errorf("token without kid")
+	}
+
+	verificationKey := k.find(kid)
+	if verificationKey == nil && k.allowMissReload() {
+		// the key may have been rotated by another instance
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := k.Reload(ctx); err != nil {
+			log.Printf("Failed to reload signing keys: %v", err)
+		}
+		verificationKey = k.find(kid)
+	}
+	if verificationKey == nil {
+		return nil, fmt.Errorf("unknown signing key: %s", kid)
+	}
+	if token.Method.Alg() != verificationKey.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return verificationKey.publicKey, nil
+}
+
+// JWKS returns the public keys that currently verify tokens
+func (k *keyRing) JWKS(ctx context.Context) (domain.JWKS, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	jwks := domain.JWKS{Keys: []domain.JWK{}}
+	for _, current := range k.keys {
+		if k.expired(current) {
+			continue
+		}
+		jwk, err := toJWK(current.publicKey, current.record.Algorithm, current.record.KID)
+		if err != nil {
+			return domain.JWKS{}, err
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks, nil
+}
+
+func (k *keyRing) find(kid string) *key {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	for _, current := range k.keys {
+		if current.record.KID == kid && !k.expired(current) {
+			return current
+		}
+	}
+	return nil
+}
+
+// expired tells whether a retired key is past its verification window
+func (k *keyRing) expired(current *key) bool {
+	retiredAt := current.record.RetiredAt
+	return retiredAt != nil && time.Since(*retiredAt) > k.verifyWindow
+}
+
+func (k *keyRing) allowMissReload() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if time.Since(k.lastMissLoad) < missReloadInterval {
+		return false
+	}
+	k.lastMissLoad = time.Now()
+	return true
+}
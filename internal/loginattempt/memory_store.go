@@ -0,0 +1,99 @@
+package loginattempt
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+)
+
+// memoryStore keeps the login attempt counters in the process. The counters are
+// lost on restart and not shared between instances: use the database store when
+// running more than one instance.
+type memoryStore struct {
+	mu       sync.Mutex
+	attempts map[string]*domain.LoginAttempt
+}
+
+func NewMemoryStore() domain.LoginAttemptStore {
+	return &memoryStore{
+		attempts: make(map[string]*domain.LoginAttempt),
+	}
+}
+
+func (s *memoryStore) Get(ctx context.Context, key string) (domain.LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if attempt, ok := s.attempts[key]; ok {
+		return *attempt, nil
+	}
+	return domain.LoginAttempt{Key: key}, nil
+}
+
+func (s *memoryStore) RegisterFailure(ctx context.Context, key string, at time.Time, windowStart time.Time) (domain.LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt, ok := s.attempts[key]
+	if !ok {
+		attempt = &domain.LoginAttempt{Key: key, WindowStart: at}
+		attempt.CreatedAt = at
+		s.attempts[key] = attempt
+	}
+	if attempt.WindowStart.Before(windowStart) {
+		attempt.Failures = 0
+		attempt.WindowStart = at
+	}
+	attempt.Failures++
+	attempt.LastFailureAt = at
+	attempt.UpdatedAt = at
+	return *attempt, nil
+}
+
+func (s *memoryStore) Delay(ctx context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if attempt, ok := s.attempts[key]; ok {
+		attempt.NextAttemptAt = &until
+	}
+	return nil
+}
+
+func (s *memoryStore) Lock(ctx context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if attempt, ok := s.attempts[key]; ok {
+		attempt.LockedUntil = &until
+		attempt.Lockouts++
+		attempt.Failures = 0
+		attempt.NextAttemptAt = nil
+	}
+	return nil
+}
+
+func (s *memoryStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.attempts, key)
+	return nil
+}
+
+func (s *memoryStore) Prune(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var pruned int64
+	for key, attempt := range s.attempts {
+		if attempt.LastFailureAt.Before(before) && (attempt.LockedUntil == nil || attempt.LockedUntil.Before(now)) {
+			delete(s.attempts, key)
+			pruned++
+		}
+	}
+	return pruned, nil
+}
@@ -0,0 +1,44 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal/tokenutil"
+)
+
+// fileMailer writes each e-mail as an .eml file in a directory (local development)
+type fileMailer struct {
+	from string
+	dir  string
+}
+
+func NewFileMailer(from string, dir string) domain.Mailer {
+	return &fileMailer{
+		from: from,
+		dir:  dir,
+	}
+}
+
+func (m *fileMailer) Send(ctx context.Context, message *domain.MailMessage) error {
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return err
+	}
+
+	content, err := buildMIMEMessage(m.from, message)
+	if err != nil {
+		return err
+	}
+
+	suffix, err := tokenutil.GenerateOpaqueToken(6)
+	if err != nil {
+		return err
+	}
+	fileName := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102-150405"), suffix)
+
+	return os.WriteFile(filepath.Join(m.dir, fileName), content, 0o644)
+}
@@ -0,0 +1,27 @@
+package mailer
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+)
+
+// logMailer writes the envelope of the e-mails to stdout through the application log instead of
+// sending them (local development). The body is left out, it holds single-use links (password
+// reset, invitation); the file mailer keeps it.
+type logMailer struct {
+	from string
+}
+
+func NewLogMailer(from string) domain.Mailer {
+	return &logMailer{
+		from: from,
+	}
+}
+
+func (m *logMailer) Send(ctx context.Context, message *domain.MailMessage) error {
+	log.Printf("[Mailer] From: %s | To: %s | Subject: %s", m.from, strings.Join(message.To, ", "), message.Subject)
+	return nil
+}
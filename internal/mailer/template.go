@@ -0,0 +1,107 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+)
+
+// DefaultLocale is used when the requested locale has no templates
+const DefaultLocale = "pt-BR"
+
+// SupportedLocales lists the template folders under templates/
+var SupportedLocales = []string{"pt-BR", "en"}
+
+// Each template has two files per locale:
+//   - <name>.txt (text/template): defines a "subject" block; the rest is the plain text body
+//   - <name>.html (html/template): defines a "content" block rendered inside templates/layout.html
+//
+//go:embed templates
+var templateFS embed.FS
+
+type compiledTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+var (
+	templateCache   = map[string]*compiledTemplate{}
+	templateCacheMu sync.Mutex
+)
+
+// ResolveLocale picks the best supported locale from an Accept-Language value
+// (e.g. "en-US,en;q=0.9,pt;q=0.8"), falling back to DefaultLocale.
+func ResolveLocale(acceptLanguage string) string {
+	for _, entry := range strings.Split(acceptLanguage, ",") {
+		tag := strings.TrimSpace(strings.SplitN(entry, ";", 2)[0])
+		if tag == "" {
+			continue
+		}
+		for _, locale := range SupportedLocales {
+			if strings.EqualFold(tag, locale) {
+				return locale
+			}
+		}
+		language := strings.SplitN(tag, "-", 2)[0]
+		for _, locale := range SupportedLocales {
+			if strings.EqualFold(language, strings.SplitN(locale, "-", 2)[0]) {
+				return locale
+			}
+		}
+	}
+	return DefaultLocale
+}
+
+// Render executes the named template in the given locale. The returned message has no recipients.
+func Render(name string, locale string, data any) (*domain.MailMessage, error) {
+	tmpl, err := loadTemplate(name, ResolveLocale(locale))
+	if err != nil {
+		return nil, err
+	}
+
+	var subject, textBody, htmlBody bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, err
+	}
+	if err := tmpl.text.Execute(&textBody, data); err != nil {
+		return nil, err
+	}
+	if err := tmpl.html.ExecuteTemplate(&htmlBody, "layout", data); err != nil {
+		return nil, err
+	}
+
+	return &domain.MailMessage{
+		Subject:  strings.TrimSpace(subject.String()),
+		TextBody: strings.TrimSpace(textBody.String()) + "\n",
+		HTMLBody: htmlBody.String(),
+	}, nil
+}
+
+func loadTemplate(name string, locale string) (*compiledTemplate, error) {
+	key := locale + "/" + name
+
+	templateCacheMu.Lock()
+	defer templateCacheMu.Unlock()
+
+	if tmpl, ok := templateCache[key]; ok {
+		return tmpl, nil
+	}
+
+	text, err := texttemplate.ParseFS(templateFS, "templates/"+key+".txt")
+	if err != nil {
+		return nil, err
+	}
+	html, err := htmltemplate.ParseFS(templateFS, "templates/layout.html", "templates/"+key+".html")
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl := &compiledTemplate{text: text, html: html}
+	templateCache[key] = tmpl
+	return tmpl, nil
+}
@@ -0,0 +1,172 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v4"
+)
+
+// IDTokenClaims are the claims of a verified ID token. Raw keeps every claim so
+// that custom ones (roles, groups, ...) can be read.
+type IDTokenClaims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Raw           jwt.MapClaims
+}
+
+// jsonWebKey is a public key of the provider JWKS (RSA or EC)
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// VerifyIDToken checks the signature of an ID token against the provider keys, then its
+// issuer, audience, expiry and nonce (OpenID Connect Core 1.0, 3.1.3.7)
+func (c *Client) VerifyIDToken(ctx context.Context, metadata ProviderMetadata, rawIDToken string, clientID string, nonce string) (IDTokenClaims, error) {
+	var keySet jsonWebKeySet
+	if err := c.getJSON(ctx, metadata.JWKSURI, &keySet); err != nil {
+		return IDTokenClaims{}, err
+	}
+
+	parser := jwt.Parser{ValidMethods: []string{"RS256", "RS384", "RS512", "PS256", "ES256", "ES384", "ES512"}}
+	claims := jwt.MapClaims{}
+	_, err := parser.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return keySet.publicKey(kid)
+	})
+	if err != nil {
+		return IDTokenClaims{}, err
+	}
+
+	if issuer, _ := claims["iss"].(string); issuer != metadata.Issuer {
+		return IDTokenClaims{}, fmt.Errorf("unexpected issuer %q", issuer)
+	}
+	if !claims.VerifyAudience(clientID, true) {
+		return IDTokenClaims{}, errors.New("id token not issued for this client")
+	}
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return IDTokenClaims{}, errors.New("id token expired")
+	}
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce != nonce {
+		return IDTokenClaims{}, errors.New("id token nonce mismatch")
+	}
+
+	idClaims := IDTokenClaims{Raw: claims}
+	idClaims.Subject, _ = claims["sub"].(string)
+	idClaims.Email, _ = claims["email"].(string)
+	idClaims.Name, _ = claims["name"].(string)
+	// some providers send email_verified as a string
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		idClaims.EmailVerified = verified
+	case string:
+		idClaims.EmailVerified = verified == "true"
+	}
+	if idClaims.Subject == "" {
+		return IDTokenClaims{}, errors.New("id token without subject")
+	}
+	return idClaims, nil
+}
+
+// StringValues reads a claim holding either a string or a list of strings
+func (c IDTokenClaims) StringValues(name string) []string {
+	switch value := c.Raw[name].(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// publicKey returns the signing key named kid; without kid the set must hold a single signing key
+func (s jsonWebKeySet) publicKey(kid string) (crypto.PublicKey, error) {
+	var candidates []jsonWebKey
+	for _, key := range s.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		if kid == "" || key.Kid == kid {
+			candidates = append(candidates, key)
+		}
+	}
+	if len(candidates) != 1 {
+		return nil, fmt.Errorf("no unique signing key for kid %q", kid)
+	}
+	return candidates[0].publicKey()
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve: %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("ec key is not on its curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type: %s", k.Kty)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
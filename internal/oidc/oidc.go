@@ -0,0 +1,248 @@
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/internal/tokenutil"
+)
+
+const maxResponseBytes = 1 << 20
+
+// ErrForbiddenURL is returned for provider urls that are not https or reach a private,
+// loopback or link-local address
+var ErrForbiddenURL = errors.New("identity provider urls must be https urls of public hosts")
+
+// Client talks to the identity providers. Their issuer is set by organization admins and
+// fetched from public routes, so unless private networks are allowed (development) it
+// only reaches https urls of public addresses, checked again on every dial.
+type Client struct {
+	httpClient           *http.Client
+	allowPrivateNetworks bool
+}
+
+func NewClient(allowPrivateNetworks bool) *Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	if !allowPrivateNetworks {
+		dialer.Control = refusePrivateAddress
+	}
+	client := &Client{allowPrivateNetworks: allowPrivateNetworks}
+	client.httpClient = &http.Client{
+		Timeout: 10 * time.Second,
+		// no proxy: the dialed address must be the one of the provider
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+			ForceAttemptHTTP2:   true,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			return client.checkURL(req.URL)
+		},
+	}
+	return client
+}
+
+// CheckIssuer refuses issuers the client would not fetch: not https, or resolving to a
+// private, loopback or link-local address
+func (c *Client) CheckIssuer(ctx context.Context, issuer string) error {
+	target, err := url.Parse(issuer)
+	if err != nil || target.Hostname() == "" {
+		return ErrForbiddenURL
+	}
+	if err := c.checkURL(target); err != nil {
+		return err
+	}
+	if c.allowPrivateNetworks {
+		return nil
+	}
+	addresses, err := net.DefaultResolver.LookupNetIP(ctx, "ip", target.Hostname())
+	if err != nil {
+		return err
+	}
+	for _, address := range addresses {
+		if isPrivateAddress(address) {
+			return ErrForbiddenURL
+		}
+	}
+	return nil
+}
+
+// ProviderMetadata is the part of the discovery document (OpenID Connect Discovery 1.0) the relying party needs
+type ProviderMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// TokenResponse is the answer of the token endpoint to an authorization code grant
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// Discover fetches the discovery document of the issuer and checks that it belongs to it
+func (c *Client) Discover(ctx context.Context, issuer string) (ProviderMetadata, error) {
+	var metadata ProviderMetadata
+	wellKnown := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	if err := c.getJSON(ctx, wellKnown, &metadata); err != nil {
+		return metadata, err
+	}
+	if strings.TrimSuffix(metadata.Issuer, "/") != strings.TrimSuffix(issuer, "/") {
+		return metadata, fmt.Errorf("discovery issuer %q does not match %q", metadata.Issuer, issuer)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return metadata, errors.New("incomplete discovery document")
+	}
+	return metadata, nil
+}
+
+// AuthorizationURL builds the authorization request of the code flow with a S256 PKCE challenge
+func AuthorizationURL(metadata ProviderMetadata, clientID string, redirectURI string, scopes []string, state string, nonce string, codeVerifier string) (string, error) {
+	endpoint, err := url.Parse(metadata.AuthorizationEndpoint)
+	if err != nil {
+		return "", err
+	}
+	query := endpoint.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", clientID)
+	query.Set("redirect_uri", redirectURI)
+	query.Set("scope", strings.Join(scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", CodeChallenge(codeVerifier))
+	query.Set("code_challenge_method", "S256")
+	endpoint.RawQuery = query.Encode()
+	return endpoint.String(), nil
+}
+
+// Exchange redeems an authorization code at the token endpoint (client_secret_basic)
+func (c *Client) Exchange(ctx context.Context, metadata ProviderMetadata, clientID string, clientSecret string, code string, redirectURI string, codeVerifier string) (TokenResponse, error) {
+	var tokens TokenResponse
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {codeVerifier},
+	}
+	if clientSecret == "" {
+		// public client: identified by its id, proven by the PKCE verifier
+		form.Set("client_id", clientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return tokens, err
+	}
+	if err := c.checkURL(req.URL); err != nil {
+		return tokens, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if clientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return tokens, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return tokens, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		// the body may echo the code or the client credentials, keep it out of the logs
+		return tokens, fmt.Errorf("token endpoint answered %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(body, &tokens); err != nil {
+		return tokens, err
+	}
+	if tokens.IDToken == "" {
+		return tokens, errors.New("token response without id_token")
+	}
+	return tokens, nil
+}
+
+// NewCodeVerifier returns a random PKCE code verifier (RFC 7636, 43 characters)
+func NewCodeVerifier() (string, error) {
+	return tokenutil.GenerateOpaqueToken(32)
+}
+
+// CodeChallenge derives the S256 challenge of a code verifier
+func CodeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func (c *Client) getJSON(ctx context.Context, target string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	if err := c.checkURL(req.URL); err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s answered %d", target, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(v)
+}
+
+func (c *Client) checkURL(target *url.URL) error {
+	switch {
+	case target.Scheme == "https":
+		return nil
+	case target.Scheme == "http" && c.allowPrivateNetworks:
+		return nil
+	default:
+		return ErrForbiddenURL
+	}
+}
+
+// refusePrivateAddress is the dialer control of the client: the check runs on the
+// resolved address, so a host cannot be pointed at a private network after it was saved
+func refusePrivateAddress(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if isPrivateAddress(ip) {
+		return ErrForbiddenURL
+	}
+	return nil
+}
+
+func isPrivateAddress(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified()
+}
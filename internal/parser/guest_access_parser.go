@@ -0,0 +1,19 @@
+package parser
+
+import (
+	"github.com/gabrielfmcoelho/platform-core/domain"
+)
+
+// Parse OrganizationGuestAccess to PublicGuestAccess
+func ToPublicGuestAccess(guestAccess domain.OrganizationGuestAccess) domain.PublicGuestAccess {
+	services := make([]domain.PublicService, 0, len(guestAccess.AllowedServices))
+	for _, s := range guestAccess.AllowedServices {
+		services = append(services, ToPublicService(s))
+	}
+	return domain.PublicGuestAccess{
+		OrganizationID:  guestAccess.OrganizationID,
+		Enabled:         guestAccess.Enabled,
+		SessionMinute:   guestAccess.SessionMinute,
+		AllowedServices: services,
+	}
+}
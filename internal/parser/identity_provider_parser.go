@@ -0,0 +1,26 @@
+package parser
+
+import (
+	"github.com/gabrielfmcoelho/platform-core/domain"
+)
+
+// Parse OrganizationIdentityProvider to PublicIdentityProvider, the client secret never leaves the server
+func ToPublicIdentityProvider(identityProvider domain.OrganizationIdentityProvider, redirectURL string) domain.PublicIdentityProvider {
+	roleMapping := identityProvider.RoleMapping
+	if roleMapping == nil {
+		roleMapping = map[string]uint{}
+	}
+	return domain.PublicIdentityProvider{
+		OrganizationID:    identityProvider.OrganizationID,
+		Enabled:           identityProvider.Enabled,
+		Issuer:            identityProvider.Issuer,
+		ClientID:          identityProvider.ClientID,
+		HasClientSecret:   identityProvider.ClientSecret != "",
+		Scopes:            identityProvider.Scopes,
+		RoleClaim:         identityProvider.RoleClaim,
+		RoleMapping:       roleMapping,
+		DefaultRoleID:     identityProvider.DefaultRoleID,
+		AllowProvisioning: identityProvider.AllowProvisioning,
+		RedirectURL:       redirectURL,
+	}
+}
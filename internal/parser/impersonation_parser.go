@@ -0,0 +1,24 @@
+package parser
+
+import (
+	"github.com/gabrielfmcoelho/platform-core/domain"
+)
+
+// Parse Impersonation to PublicImpersonation
+func ToPublicImpersonation(impersonation domain.Impersonation) domain.PublicImpersonation {
+	endedAt := ""
+	if impersonation.EndedAt != nil {
+		endedAt = impersonation.EndedAt.Format("2006-01-02 15:04:05")
+	}
+
+	return domain.PublicImpersonation{
+		ID:               impersonation.ID,
+		UserID:           impersonation.UserID,
+		ImpersonatorID:   impersonation.ImpersonatorID,
+		ImpersonatorName: impersonation.Impersonator.Name,
+		Reason:           impersonation.Reason,
+		StartedAt:        impersonation.CreatedAt.Format("2006-01-02 15:04:05"),
+		ExpiresAt:        impersonation.ExpiresAt.Format("2006-01-02 15:04:05"),
+		EndedAt:          endedAt,
+	}
+}
@@ -0,0 +1,38 @@
+package parser
+
+import (
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+)
+
+// Parse Invitation to PublicInvitation
+func ToPublicInvitation(invitation domain.Invitation) domain.PublicInvitation {
+	return domain.PublicInvitation{
+		ID:             invitation.ID,
+		OrganizationID: invitation.OrganizationID,
+		Email:          invitation.Email,
+		Name:           invitation.Name,
+		RoleID:         invitation.RoleID,
+		InvitedByID:    invitation.InvitedByID,
+		Status:         InvitationStatus(invitation, time.Now()),
+		SendCount:      invitation.SendCount,
+		LastSentAt:     invitation.LastSentAt.Format("2006-01-02 15:04:05"),
+		ExpiresAt:      invitation.ExpiresAt.Format("2006-01-02 15:04:05"),
+		CreatedAt:      invitation.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// InvitationStatus tells whether an invitation is pending, expired, accepted or revoked at the given time
+func InvitationStatus(invitation domain.Invitation, at time.Time) string {
+	switch {
+	case invitation.AcceptedAt != nil:
+		return domain.InvitationStatusAccepted
+	case invitation.RevokedAt != nil:
+		return domain.InvitationStatusRevoked
+	case at.After(invitation.ExpiresAt):
+		return domain.InvitationStatusExpired
+	default:
+		return domain.InvitationStatusPending
+	}
+}
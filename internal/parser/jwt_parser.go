@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"slices"
+	"strconv"
 	"time"
 
 	"github.com/gabrielfmcoelho/platform-core/domain"
@@ -22,17 +24,125 @@ func ToJwtCustomClaims(u *domain.User, expireTime time.Time) *domain.JwtCustomCl
 	}
 }
 
-// parse User to JWTCustomRefreshClaims
-func ToJwtCustomRefreshClaims(u *domain.User, expireTime time.Time) *domain.JwtCustomRefreshClaims {
-	return &domain.JwtCustomRefreshClaims{
-		UserID:             u.ID,
-		UserRoleID:         u.RoleID,
-		OrganizationID:     u.OrganizationID,
-		OrganizationRoleID: u.Organization.RoleID,
-		OrganizationName:   u.Organization.Name,
+// parse ServiceAccount to JwtServiceAccountClaims
+func ToJwtServiceAccountClaims(sa *domain.ServiceAccount, scope string, issuedAt time.Time, expireTime time.Time) *domain.JwtServiceAccountClaims {
+	return &domain.JwtServiceAccountClaims{
+		ServiceAccountID: sa.ID,
+		ClientID:         sa.ClientID,
+		Scope:            scope,
+		TokenUse:         domain.TokenUseService,
+		SecretVersion:    sa.SecretVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
-			Subject:   u.Email,
+			Subject:   sa.ClientID,
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(expireTime),
+		},
+	}
+}
+
+// build the claims of an MFA challenge token for a user
+func ToJwtMFAChallengeClaims(userID uint, enrollment bool, issuedAt time.Time, expireTime time.Time) *domain.JwtMFAChallengeClaims {
+	return &domain.JwtMFAChallengeClaims{
+		TokenUse:   domain.TokenUseMFAChallenge,
+		Enrollment: enrollment,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatUint(uint64(userID), 10),
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(expireTime),
+		},
+	}
+}
+
+// build the claims of the token sent in an invitation e-mail
+func ToJwtInvitationClaims(invitation *domain.Invitation, nonce string, issuedAt time.Time) *domain.JwtInvitationClaims {
+	return &domain.JwtInvitationClaims{
+		TokenUse: domain.TokenUseInvitation,
+		Email:    invitation.Email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatUint(uint64(invitation.ID), 10),
+			ID:        nonce,
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(invitation.ExpiresAt),
+		},
+	}
+}
+
+// build the claims of the access token issued to an OAuth client for a user
+func ToJwtOAuthAccessClaims(userID uint, clientID string, scope string, issuer string, issuedAt time.Time, expireTime time.Time) *domain.JwtOAuthAccessClaims {
+	return &domain.JwtOAuthAccessClaims{
+		TokenUse: domain.TokenUseOAuthAccess,
+		ClientID: clientID,
+		Scope:    scope,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   strconv.FormatUint(uint64(userID), 10),
+			Audience:  jwt.ClaimStrings{clientID},
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(expireTime),
+		},
+	}
+}
+
+// build the claims of an ID token, only with the claims of the granted scopes
+func ToJwtIDTokenClaims(u *domain.User, clientID string, scopes []string, nonce string, issuer string, issuedAt time.Time, expireTime time.Time) *domain.JwtIDTokenClaims {
+	claims := &domain.JwtIDTokenClaims{
+		Nonce: nonce,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   strconv.FormatUint(uint64(u.ID), 10),
+			Audience:  jwt.ClaimStrings{clientID},
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
 			ExpiresAt: jwt.NewNumericDate(expireTime),
 		},
 	}
+	userInfo := ToUserInfoResponse(u, scopes)
+	claims.Name = userInfo.Name
+	claims.Email = userInfo.Email
+	claims.EmailVerified = userInfo.EmailVerified
+	claims.UserRoleID = userInfo.UserRoleID
+	claims.OrganizationID = userInfo.OrganizationID
+	claims.OrganizationName = userInfo.OrganizationName
+	claims.OrganizationRoleID = userInfo.OrganizationRoleID
+	return claims
+}
+
+// parse User to UserInfoResponse, only with the claims of the granted scopes
+func ToUserInfoResponse(u *domain.User, scopes []string) domain.UserInfoResponse {
+	userInfo := domain.UserInfoResponse{
+		Subject: strconv.FormatUint(uint64(u.ID), 10),
+	}
+	if slices.Contains(scopes, domain.OAuthScopeProfile) {
+		userInfo.Name = u.Name
+	}
+	if slices.Contains(scopes, domain.OAuthScopeEmail) {
+		// accounts are created by admins, invitations or an identity provider
+		verified := true
+		userInfo.Email = u.Email
+		userInfo.EmailVerified = &verified
+	}
+	if slices.Contains(scopes, domain.OAuthScopeOrganization) {
+		userInfo.UserRoleID = u.RoleID
+		userInfo.OrganizationID = u.OrganizationID
+		userInfo.OrganizationName = u.Organization.Name
+		userInfo.OrganizationRoleID = u.Organization.RoleID
+	}
+	return userInfo
+}
+
+// build the claims of a launch token, whose only audience is the launched service
+func ToJwtLaunchClaims(launch *domain.ServiceLaunch, nonce string, issuer string, issuedAt time.Time) *domain.JwtLaunchClaims {
+	return &domain.JwtLaunchClaims{
+		TokenUse:       domain.TokenUseLaunch,
+		OrganizationID: launch.OrganizationID,
+		ServiceID:      launch.ServiceID,
+		LogID:          launch.UserServiceLogID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   strconv.FormatUint(uint64(launch.UserID), 10),
+			Audience:  jwt.ClaimStrings{domain.LaunchAudience(launch.ServiceID)},
+			ID:        nonce,
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(launch.ExpiresAt),
+		},
+	}
 }
@@ -0,0 +1,48 @@
+package parser
+
+import (
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+)
+
+// Parse OrganizationMetrics to PublicOrganizationMetrics; the monthly count of reports is zero
+// until the first report of the month of now
+func ToPublicOrganizationMetrics(metrics domain.OrganizationMetrics, now time.Time) domain.PublicOrganizationMetrics {
+	publicMetrics := domain.PublicOrganizationMetrics{
+		OrganizationID:     metrics.OrganizationID,
+		TotalServices:      metrics.TotalServices,
+		TotalUsers:         metrics.TotalUsers,
+		TotalReports:       metrics.TotalReports,
+		TotalUsageDuration: int64(metrics.TotalUsageDuration.Seconds()),
+	}
+	if metrics.ReportsMonth == now.Format("2006-01") {
+		publicMetrics.TotalReportsCurrentMonth = metrics.TotalReportsCurrentMonth
+	}
+	if metrics.LastReportDate != nil {
+		publicMetrics.LastReportDate = metrics.LastReportDate.Format("2006-01-02 15:04:05")
+	}
+	if !metrics.UpdatedAt.IsZero() {
+		publicMetrics.UpdatedAt = metrics.UpdatedAt.Format("2006-01-02 15:04:05")
+	}
+	return publicMetrics
+}
+
+// Parse UserMetrics to PublicUserMetrics
+func ToPublicUserMetrics(metrics domain.UserMetrics, organizationID uint) domain.PublicUserMetrics {
+	publicMetrics := domain.PublicUserMetrics{
+		UserID:             metrics.UserID,
+		OrganizationID:     organizationID,
+		FavoriteServiceID:  metrics.FavoriteServiceID,
+		LastIP:             metrics.LastIP,
+		TotalLogins:        metrics.TotalLogins,
+		TotalUsageDuration: int64(metrics.TotalUsageDuration.Seconds()),
+	}
+	if metrics.LastLogin != nil {
+		publicMetrics.LastLogin = metrics.LastLogin.Format("2006-01-02 15:04:05")
+	}
+	if !metrics.UpdatedAt.IsZero() {
+		publicMetrics.UpdatedAt = metrics.UpdatedAt.Format("2006-01-02 15:04:05")
+	}
+	return publicMetrics
+}
@@ -0,0 +1,30 @@
+package parser
+
+import (
+	"github.com/gabrielfmcoelho/platform-core/domain"
+)
+
+// Parse OAuthClient to PublicOAuthClient
+func ToPublicOAuthClient(client domain.OAuthClient) domain.PublicOAuthClient {
+	lastUsedAt := ""
+	if client.LastUsedAt != nil {
+		lastUsedAt = client.LastUsedAt.Format("2006-01-02 15:04:05")
+	}
+	redirectURIs := client.RedirectURIs
+	if redirectURIs == nil {
+		redirectURIs = []string{}
+	}
+
+	return domain.PublicOAuthClient{
+		ID:           client.ID,
+		ServiceID:    client.ServiceID,
+		ServiceName:  client.Service.Name,
+		Name:         client.Name,
+		ClientID:     client.ClientID,
+		RedirectURIs: redirectURIs,
+		FirstParty:   client.FirstParty,
+		Public:       client.ClientSecretHash == "",
+		CreatedAt:    client.CreatedAt.Format("2006-01-02 15:04:05"),
+		LastUsedAt:   lastUsedAt,
+	}
+}
@@ -1,15 +1,54 @@
 package parser
 
 import (
+	"time"
+
 	"github.com/gabrielfmcoelho/platform-core/domain"
 )
 
 // Parse Organization to PublicOrganization
 func ToPublicOrganization(org domain.Organization) domain.PublicOrganization {
+	deletedAt := ""
+	if org.DeletedAt.Valid {
+		deletedAt = org.DeletedAt.Time.Format("2006-01-02 15:04:05")
+	}
 	return domain.PublicOrganization{
-		ID:       org.ID,
-		Name:     org.Name,
-		Nickname: org.Nickname,
-		LogoUrl:  org.LogoUrl,
+		ID:         org.ID,
+		Name:       org.Name,
+		Nickname:   org.Nickname,
+		LogoUrl:    org.LogoUrl,
+		RequireMFA: org.RequireMFA,
+		DeletedAt:  deletedAt,
+	}
+}
+
+// Parse Organization (with its role, users, services and subscription preloaded) to OrganizationDetails
+func ToOrganizationDetails(org domain.Organization) domain.OrganizationDetails {
+	services := make([]domain.PublicService, 0, len(org.SubscribedServices))
+	for _, service := range org.SubscribedServices {
+		services = append(services, ToPublicService(service))
+	}
+
+	return domain.OrganizationDetails{
+		PublicOrganization: ToPublicOrganization(org),
+		RoleID:             org.RoleID,
+		RoleName:           org.Role.RoleName,
+		UserCount:          len(org.Users),
+		SubscribedServices: services,
+		Subscription:       ToPublicSubscriptionStatus(org.Subscription, time.Now()),
+		CreatedAt:          org.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:          org.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// Parse OrganizationSubscription to PublicSubscriptionStatus
+func ToPublicSubscriptionStatus(subscription domain.OrganizationSubscription, now time.Time) domain.PublicSubscriptionStatus {
+	status := domain.PublicSubscriptionStatus{Status: subscription.Status(now)}
+	if subscription.ID != 0 {
+		status.UsersLimit = subscription.SubscriptionUsersLimit
+		status.ReportsLimit = subscription.SubscriptionReportsLimit
+		status.StartsAt = subscription.StartsAt.Format("2006-01-02 15:04:05")
+		status.EndsAt = subscription.EndsAt.Format("2006-01-02 15:04:05")
 	}
+	return status
 }
@@ -0,0 +1,61 @@
+package parser
+
+import (
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+)
+
+// Parse OrganizationSubscription to PublicSubscription, with the seats and reports used
+func ToPublicSubscription(subscription domain.OrganizationSubscription, now time.Time, usersUsed int, reportsUsed int64) domain.PublicSubscription {
+	graceEndsAt := ""
+	if subscription.GraceEndsAt != nil {
+		graceEndsAt = subscription.GraceEndsAt.Format("2006-01-02 15:04:05")
+	}
+
+	return domain.PublicSubscription{
+		ID:             subscription.ID,
+		OrganizationID: subscription.OrganizationID,
+		Active:         subscription.Active,
+		Status:         subscription.Status(now),
+		Value:          subscription.SubscriptionValue,
+		Period:         subscription.SubscriptionPeriod,
+		UsersLimit:     subscription.SubscriptionUsersLimit,
+		UsersUsed:      usersUsed,
+		ReportsLimit:   subscription.SubscriptionReportsLimit,
+		ReportsUsed:    reportsUsed,
+		StartsAt:       subscription.StartsAt.Format("2006-01-02 15:04:05"),
+		EndsAt:         subscription.EndsAt.Format("2006-01-02 15:04:05"),
+		GraceEndsAt:    graceEndsAt,
+		AutoRenew:      subscription.AutoRenew,
+		CreatedAt:      subscription.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:      subscription.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// Parse SubscriptionRequest to OrganizationSubscription
+func ToOrganizationSubscription(organizationID uint, request *domain.SubscriptionRequest) domain.OrganizationSubscription {
+	return domain.OrganizationSubscription{
+		Active:                   request.Active,
+		OrganizationID:           organizationID,
+		SubscriptionValue:        request.Value,
+		SubscriptionPeriod:       request.Period,
+		SubscriptionUsersLimit:   request.UsersLimit,
+		SubscriptionReportsLimit: request.ReportsLimit,
+		StartsAt:                 request.StartsAt,
+		EndsAt:                   request.EndsAt,
+		AutoRenew:                request.AutoRenew,
+	}
+}
+
+// Parse SubscriptionRenewal to PublicSubscriptionRenewal
+func ToPublicSubscriptionRenewal(renewal domain.SubscriptionRenewal) domain.PublicSubscriptionRenewal {
+	return domain.PublicSubscriptionRenewal{
+		ID:        renewal.ID,
+		Period:    renewal.Period,
+		Value:     renewal.Value,
+		StartsAt:  renewal.StartsAt.Format("2006-01-02 15:04:05"),
+		EndsAt:    renewal.EndsAt.Format("2006-01-02 15:04:05"),
+		CreatedAt: renewal.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
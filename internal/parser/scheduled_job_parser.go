@@ -0,0 +1,33 @@
+package parser
+
+import (
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+)
+
+// Parse ScheduledJob to PublicScheduledJob; the job is running while a replica holds its lease
+func ToPublicScheduledJob(job domain.ScheduledJob, now time.Time) domain.PublicScheduledJob {
+	publicJob := domain.PublicScheduledJob{
+		Name:       job.Name,
+		Schedule:   job.Schedule,
+		NextRunAt:  job.NextRunAt.Format("2006-01-02 15:04:05"),
+		LastStatus: job.LastStatus,
+		LastResult: job.LastResult,
+		LastError:  job.LastError,
+	}
+	if job.LeaseUntil != nil && job.LeaseUntil.After(now) {
+		publicJob.Running = true
+		publicJob.RunningOn = job.LeaseOwner
+	}
+	if job.LastStartedAt != nil {
+		publicJob.LastStartedAt = job.LastStartedAt.Format("2006-01-02 15:04:05")
+	}
+	if job.LastFinishedAt != nil {
+		publicJob.LastFinishedAt = job.LastFinishedAt.Format("2006-01-02 15:04:05")
+		if job.LastStartedAt != nil && !job.LastFinishedAt.Before(*job.LastStartedAt) {
+			publicJob.LastDurationMs = job.LastFinishedAt.Sub(*job.LastStartedAt).Milliseconds()
+		}
+	}
+	return publicJob
+}
@@ -0,0 +1,25 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+)
+
+// Parse ServiceAccount to PublicServiceAccount
+func ToPublicServiceAccount(sa domain.ServiceAccount) domain.PublicServiceAccount {
+	lastUsedAt := ""
+	if sa.LastUsedAt != nil {
+		lastUsedAt = sa.LastUsedAt.Format("2006-01-02 15:04:05")
+	}
+
+	return domain.PublicServiceAccount{
+		ID:          sa.ID,
+		Name:        sa.Name,
+		Description: sa.Description,
+		ClientID:    sa.ClientID,
+		Scopes:      strings.Fields(sa.Scopes),
+		CreatedAt:   sa.CreatedAt.Format("2006-01-02 15:04:05"),
+		LastUsedAt:  lastUsedAt,
+	}
+}
@@ -0,0 +1,18 @@
+package parser
+
+import (
+	"github.com/gabrielfmcoelho/platform-core/domain"
+)
+
+// Parse Session to PublicSession
+func ToPublicSession(session domain.Session, current bool) domain.PublicSession {
+	return domain.PublicSession{
+		ID:         session.ID,
+		UserAgent:  session.UserAgent,
+		IPAddress:  session.IPAddress,
+		CreatedAt:  session.CreatedAt.Format("2006-01-02 15:04:05"),
+		LastSeenAt: session.LastSeenAt.Format("2006-01-02 15:04:05"),
+		ExpiresAt:  session.ExpiresAt.Format("2006-01-02 15:04:05"),
+		Current:    current,
+	}
+}
@@ -0,0 +1,19 @@
+package parser
+
+import "github.com/gabrielfmcoelho/platform-core/domain"
+
+// Parse SigningKey to PublicSigningKey (never exposes the private key)
+func ToPublicSigningKey(sk domain.SigningKey) domain.PublicSigningKey {
+	retiredAt := ""
+	if sk.RetiredAt != nil {
+		retiredAt = sk.RetiredAt.Format("2006-01-02 15:04:05")
+	}
+
+	return domain.PublicSigningKey{
+		KID:       sk.KID,
+		Algorithm: sk.Algorithm,
+		IsActive:  sk.IsActive,
+		CreatedAt: sk.CreatedAt.Format("2006-01-02 15:04:05"),
+		RetiredAt: retiredAt,
+	}
+}
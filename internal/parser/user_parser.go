@@ -29,10 +29,11 @@ func ToPublicUser(u domain.User) domain.PublicUser {
 // Parse CreateUser to User
 func ToUser(cu *domain.CreateUser) *domain.User {
 	return &domain.User{
-		Name:           cu.Name,
-		Email:          cu.Email,
-		Password:       cu.Password,
-		OrganizationID: cu.OrganizationID,
-		RoleID:         cu.RoleID,
+		Name:               cu.Name,
+		Email:              cu.Email,
+		Password:           cu.Password,
+		OrganizationID:     cu.OrganizationID,
+		RoleID:             cu.RoleID,
+		MustChangePassword: cu.MustChangePassword,
 	}
 }
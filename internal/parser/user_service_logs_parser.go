@@ -7,10 +7,18 @@ import (
 
 // Parse UserServiceLog to PublicUserServiceLog
 func ToPublicUserServiceLog(log domain.UserServiceLog) domain.PublicUserServiceLog {
+	endedAt := ""
+	if log.EndedAt != nil {
+		endedAt = log.EndedAt.Format("2006-01-02 15:04:05")
+	}
 	return domain.PublicUserServiceLog{
-		ID:        log.ID,
-		UserID:    log.UserID,
-		ServiceID: log.ServiceID,
-		Duration:  internal.ToSeconds(log.Duration),
+		ID:              log.ID,
+		UserID:          log.UserID,
+		ServiceID:       log.ServiceID,
+		Duration:        internal.ToSeconds(log.Duration),
+		StartedAt:       log.StartedAt.Format("2006-01-02 15:04:05"),
+		LastHeartbeatAt: log.LastHeartbeatAt.Format("2006-01-02 15:04:05"),
+		EndedAt:         endedAt,
+		EndReason:       log.EndReason,
 	}
 }
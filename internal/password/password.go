@@ -5,6 +5,10 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Unusable is stored as the password of the users that never sign in with one (guests).
+// It is not a bcrypt hash, so VerifyPassword refuses every password against it.
+const Unusable = "!unusable"
+
 // HashPassword hashes the raw password
 func HashPassword(rawPassword string) (string, error) {
 	hash, err := bcrypt.GenerateFromPassword([]byte(rawPassword), bcrypt.DefaultCost)
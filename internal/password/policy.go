@@ -0,0 +1,103 @@
+package password
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// bcrypt ignores everything past 72 bytes, longer passwords are refused instead of truncated
+const maxPasswordBytes = 72
+
+//go:embed common_passwords.txt
+var commonPasswordList string
+
+var commonPasswords = func() map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, line := range strings.Split(commonPasswordList, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			set[line] = struct{}{}
+		}
+	}
+	return set
+}()
+
+// Validate checks a raw password against the policy. Reuse is checked by the caller,
+// it needs the password history.
+func Validate(policy domain.PasswordPolicy, rawPassword string) error {
+	if utf8.RuneCountInString(rawPassword) < policy.MinLength {
+		return &domain.PasswordPolicyError{
+			Err:         domain.ErrPasswordTooShort,
+			Requirement: fmt.Sprintf("use at least %d characters", policy.MinLength),
+		}
+	}
+	if len(rawPassword) > maxPasswordBytes {
+		return &domain.PasswordPolicyError{
+			Err:         domain.ErrPasswordTooLong,
+			Requirement: fmt.Sprintf("use at most %d bytes", maxPasswordBytes),
+		}
+	}
+	if characterClasses(rawPassword) < policy.MinCharacterClasses {
+		return &domain.PasswordPolicyError{
+			Err:         domain.ErrPasswordTooSimple,
+			Requirement: fmt.Sprintf("mix at least %d of lowercase letters, uppercase letters, digits and symbols", policy.MinCharacterClasses),
+		}
+	}
+	if IsCommon(rawPassword) {
+		return &domain.PasswordPolicyError{
+			Err:         domain.ErrPasswordTooCommon,
+			Requirement: "choose a password that is not on the list of common passwords",
+		}
+	}
+	return nil
+}
+
+// IsCommon reports whether the password, or the password without its trailing digits
+// and symbols ("Flamengo2024!"), is on the bundled list of common passwords
+func IsCommon(rawPassword string) bool {
+	lowered := strings.ToLower(rawPassword)
+	if _, found := commonPasswords[lowered]; found {
+		return true
+	}
+	base := strings.TrimRightFunc(lowered, func(r rune) bool { return !unicode.IsLetter(r) })
+	_, found := commonPasswords[base]
+	return found
+}
+
+// characterClasses counts how many of lowercase, uppercase, digits and symbols the password uses
+func characterClasses(rawPassword string) int {
+	var lower, upper, digit, symbol int
+	for _, r := range rawPassword {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+	return lower + upper + digit + symbol
+}
+
+// HashPasswordWithCost hashes the raw password with the given bcrypt cost
+func HashPasswordWithCost(rawPassword string, cost int) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(rawPassword), cost)
+	if err != nil {
+		return "", domain.ErrInternalServerError
+	}
+	return string(hash), nil
+}
+
+// NeedsRehash reports whether the hash was made with another cost than the given one
+func NeedsRehash(hashedPassword string, cost int) bool {
+	hashCost, err := bcrypt.Cost([]byte(hashedPassword))
+	return err == nil && hashCost != cost
+}
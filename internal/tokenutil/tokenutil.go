@@ -2,6 +2,8 @@ package tokenutil
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gabrielfmcoelho/platform-core/domain"
@@ -9,96 +11,171 @@ import (
 	jwt "github.com/golang-jwt/jwt/v4"
 )
 
-func CreateAccessToken(user *domain.User, secret string, expiry int) (accessToken string, err error) {
-	nowTime := time.Now()
-	expireTime := nowTime.Add(time.Hour * time.Duration(expiry))
+// CreateAccessToken issues a user access token signed by the active key of the key ring
+func CreateAccessToken(user *domain.User, keyRing domain.KeyRing, expiry int) (accessToken string, err error) {
+	return CreateAccessTokenUntil(user, 0, keyRing, time.Now().Add(time.Hour*time.Duration(expiry)))
+}
+
+// CreateAccessTokenUntil issues a user access token of the session expiring at the given time
+func CreateAccessTokenUntil(user *domain.User, sessionID uint, keyRing domain.KeyRing, expireTime time.Time) (accessToken string, err error) {
 	claims := parser.ToJwtCustomClaims(user, expireTime)
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	t, err := token.SignedString([]byte(secret))
+	claims.IssuedAt = jwt.NewNumericDate(time.Now())
+	claims.SessionID = sessionID
+	return keyRing.Sign(claims)
+}
+
+// CreateImpersonationToken issues an access token of the user carrying the impersonator in the act claim
+func CreateImpersonationToken(user *domain.User, impersonator *domain.User, impersonationID uint, keyRing domain.KeyRing, expireTime time.Time) (accessToken string, err error) {
+	claims := parser.ToJwtCustomClaims(user, expireTime)
+	claims.IssuedAt = jwt.NewNumericDate(time.Now())
+	claims.ID = strconv.FormatUint(uint64(impersonationID), 10)
+	claims.Act = &domain.ActorClaims{
+		Subject: impersonator.Email,
+		UserID:  impersonator.ID,
+	}
+	return keyRing.Sign(claims)
+}
+
+// ExtractClaimsFromToken verifies a user access token against the key ring
+func ExtractClaimsFromToken(requestToken string, keyRing domain.KeyRing) (*domain.JwtCustomClaims, error) {
+	claims := &domain.JwtCustomClaims{}
+	token, err := jwt.ParseWithClaims(requestToken, claims, keyRing.VerificationKey)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	return t, err
+	// a token without user (e.g. a service account token) is never a user token
+	if !token.Valid || claims.UserID == 0 {
+		return nil, fmt.Errorf("invalid token")
+	}
+	return claims, nil
 }
 
-func CreateRefreshToken(user *domain.User, secret string, expiry int) (refreshToken string, err error) {
+func CreateServiceAccountToken(serviceAccount *domain.ServiceAccount, scope string, secret string, expiry int) (accessToken string, expiresIn int, err error) {
 	nowTime := time.Now()
 	expireTime := nowTime.Add(time.Hour * time.Duration(expiry))
-	claimsRefresh := parser.ToJwtCustomRefreshClaims(user, expireTime)
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claimsRefresh)
-	rt, err := token.SignedString([]byte(secret))
+	claims := parser.ToJwtServiceAccountClaims(serviceAccount, scope, nowTime, expireTime)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	t, err := token.SignedString([]byte(secret))
 	if err != nil {
-		return "", err
+		return "", 0, err
 	}
-	return rt, err
+	return t, int(expireTime.Sub(nowTime).Seconds()), nil
 }
 
-func IsAuthorized(requestToken string, secret string) (bool, error) {
-	_, err := jwt.Parse(requestToken, func(token *jwt.Token) (interface{}, error) {
+func ExtractServiceAccountClaimsFromToken(requestToken string, secret string) (*domain.JwtServiceAccountClaims, error) {
+	claims := &domain.JwtServiceAccountClaims{}
+	token, err := jwt.ParseWithClaims(requestToken, claims, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 		return []byte(secret), nil
 	})
 	if err != nil {
-		return false, err
+		return nil, err
+	}
+	if !token.Valid || claims.TokenUse != domain.TokenUseService {
+		return nil, fmt.Errorf("invalid service account token")
+	}
+	return claims, nil
+}
+
+func CreateMFAChallengeToken(userID uint, enrollment bool, secret string, expiryMinute int) (challengeToken string, expiresIn int, err error) {
+	nowTime := time.Now()
+	expireTime := nowTime.Add(time.Minute * time.Duration(expiryMinute))
+	claims := parser.ToJwtMFAChallengeClaims(userID, enrollment, nowTime, expireTime)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	t, err := token.SignedString([]byte(secret))
+	if err != nil {
+		return "", 0, err
 	}
-	return true, nil
+	return t, int(expireTime.Sub(nowTime).Seconds()), nil
 }
 
-func ExtractIDFromToken(requestToken string, secret string) (int, error) {
-	token, err := jwt.Parse(requestToken, func(token *jwt.Token) (interface{}, error) {
+func ExtractMFAChallengeClaimsFromToken(requestToken string, secret string) (*domain.JwtMFAChallengeClaims, error) {
+	claims := &domain.JwtMFAChallengeClaims{}
+	token, err := jwt.ParseWithClaims(requestToken, claims, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 		return []byte(secret), nil
 	})
-
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok && !token.Valid {
-		return 0, fmt.Errorf("invalid token")
+	if !token.Valid || claims.TokenUse != domain.TokenUseMFAChallenge {
+		return nil, fmt.Errorf("invalid mfa challenge token")
 	}
-
-	return int(claims["user_id"].(float64)), nil
+	return claims, nil
 }
 
-func SkipTokenValidation(requestToken string) (bool, error) {
-	token, _, err := new(jwt.Parser).ParseUnverified(requestToken, jwt.MapClaims{})
-	if err != nil {
-		return false, err
-	}
-	// if token has claim "admin" and it is true, return the true
-	if token.Claims.(jwt.MapClaims)["apiAdmin"] == true {
-		return true, nil
-	}
-	return false, nil
+// CreateInvitationToken signs the token of an invitation link, it expires with the invitation
+func CreateInvitationToken(invitation *domain.Invitation, nonce string, secret string) (invitationToken string, err error) {
+	claims := parser.ToJwtInvitationClaims(invitation, nonce, time.Now())
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(secret))
 }
 
-func ValidateRefreshToken(refreshToken string, secret string) (uint, error) {
-	token, err := jwt.Parse(refreshToken, func(token *jwt.Token) (interface{}, error) {
+func ExtractInvitationClaimsFromToken(requestToken string, secret string) (*domain.JwtInvitationClaims, error) {
+	claims := &domain.JwtInvitationClaims{}
+	token, err := jwt.ParseWithClaims(requestToken, claims, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 		return []byte(secret), nil
 	})
-
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+	if !token.Valid || claims.TokenUse != domain.TokenUseInvitation {
+		return nil, fmt.Errorf("invalid invitation token")
 	}
+	return claims, nil
+}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok || !token.Valid {
-		return 0, fmt.Errorf("invalid refresh token")
+// CreateOAuthTokens issues the access token and the ID token of an authorization code
+// grant, both signed by the active key of the key ring
+func CreateOAuthTokens(user *domain.User, clientID string, scope string, nonce string, issuer string, keyRing domain.KeyRing, expiryMinute int) (accessToken string, idToken string, expiresIn int, err error) {
+	nowTime := time.Now()
+	expireTime := nowTime.Add(time.Minute * time.Duration(expiryMinute))
+	accessToken, err = keyRing.Sign(parser.ToJwtOAuthAccessClaims(user.ID, clientID, scope, issuer, nowTime, expireTime))
+	if err != nil {
+		return "", "", 0, err
 	}
+	idToken, err = keyRing.Sign(parser.ToJwtIDTokenClaims(user, clientID, strings.Fields(scope), nonce, issuer, nowTime, expireTime))
+	if err != nil {
+		return "", "", 0, err
+	}
+	return accessToken, idToken, int(expireTime.Sub(nowTime).Seconds()), nil
+}
 
-	// Extract user_id from claims
-	userID, ok := claims["user_id"].(float64)
-	if !ok {
-		return 0, fmt.Errorf("user_id not found in refresh token")
+// ExtractOAuthAccessClaimsFromToken verifies an access token issued to an OAuth client
+func ExtractOAuthAccessClaimsFromToken(requestToken string, keyRing domain.KeyRing, issuer string) (*domain.JwtOAuthAccessClaims, error) {
+	claims := &domain.JwtOAuthAccessClaims{}
+	token, err := jwt.ParseWithClaims(requestToken, claims, keyRing.VerificationKey)
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid || claims.TokenUse != domain.TokenUseOAuthAccess || claims.Issuer != issuer {
+		return nil, fmt.Errorf("invalid token")
 	}
+	return claims, nil
+}
 
-	return uint(userID), nil
+// CreateLaunchToken signs the launch token of a service with the active key of the key ring
+func CreateLaunchToken(launch *domain.ServiceLaunch, nonce string, issuer string, keyRing domain.KeyRing) (launchToken string, err error) {
+	return keyRing.Sign(parser.ToJwtLaunchClaims(launch, nonce, issuer, time.Now()))
+}
+
+// ExtractLaunchClaimsFromToken verifies a launch token issued for the given service
+func ExtractLaunchClaimsFromToken(requestToken string, keyRing domain.KeyRing, issuer string, serviceID uint) (*domain.JwtLaunchClaims, error) {
+	claims := &domain.JwtLaunchClaims{}
+	token, err := jwt.ParseWithClaims(requestToken, claims, keyRing.VerificationKey)
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid || claims.TokenUse != domain.TokenUseLaunch || claims.Issuer != issuer ||
+		!claims.VerifyAudience(domain.LaunchAudience(serviceID), true) || claims.ServiceID != serviceID {
+		return nil, fmt.Errorf("invalid token")
+	}
+	return claims, nil
 }
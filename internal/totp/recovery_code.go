@@ -0,0 +1,40 @@
+package totp
+
+import (
+	"crypto/rand"
+	"math/big"
+	"strings"
+)
+
+// unambiguous characters only (no 0/o, 1/l/i)
+const recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// RecoveryCodeCount is how many recovery codes a user gets on enrollment
+const RecoveryCodeCount = 10
+
+// GenerateRecoveryCode returns a random code formatted as xxxxx-xxxxx
+func GenerateRecoveryCode() (string, error) {
+	var builder strings.Builder
+	max := big.NewInt(int64(len(recoveryCodeAlphabet)))
+	for i := 0; i < 10; i++ {
+		if i == 5 {
+			builder.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		builder.WriteByte(recoveryCodeAlphabet[n.Int64()])
+	}
+	return builder.String(), nil
+}
+
+// NormalizeRecoveryCode makes user input comparable to the generated code (case, spaces, missing dash)
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.Join(strings.Fields(code), ""))
+	code = strings.ReplaceAll(code, "-", "")
+	if len(code) == 10 {
+		return code[:5] + "-" + code[5:]
+	}
+	return code
+}
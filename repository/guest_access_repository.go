@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"gorm.io/gorm"
+)
+
+type guestAccessRepository struct {
+	db *gorm.DB
+}
+
+func NewGuestAccessRepository(db *gorm.DB) domain.GuestAccessRepository {
+	return &guestAccessRepository{
+		db: db,
+	}
+}
+
+// GetByOrganizationID returns the guest access configuration of an organization
+func (r *guestAccessRepository) GetByOrganizationID(ctx context.Context, organizationID uint) (domain.OrganizationGuestAccess, error) {
+	var guestAccess domain.OrganizationGuestAccess
+	if err := r.db.WithContext(ctx).
+		Preload("AllowedServices").
+		Where("organization_id = ?", organizationID).
+		First(&guestAccess).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return guestAccess, domain.ErrNotFound
+		}
+		return guestAccess, domain.ErrDataBaseInternalError
+	}
+	return guestAccess, nil
+}
+
+// GetDefault returns the first enabled guest access of a Guest organization,
+// used when the visitor does not say which organization it visits
+func (r *guestAccessRepository) GetDefault(ctx context.Context) (domain.OrganizationGuestAccess, error) {
+	var guestAccess domain.OrganizationGuestAccess
+	if err := r.db.WithContext(ctx).
+		Preload("AllowedServices").
+		Joins("JOIN organizations ON organizations.id = organization_guest_accesses.organization_id AND organizations.deleted_at IS NULL").
+		Where("organization_guest_accesses.enabled = ? AND organizations.role_id = ?", true, domain.OrganizationRoleGuest).
+		Order("organization_guest_accesses.organization_id").
+		First(&guestAccess).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return guestAccess, domain.ErrNotFound
+		}
+		return guestAccess, domain.ErrDataBaseInternalError
+	}
+	return guestAccess, nil
+}
+
+// Save creates or updates the configuration of the organization and replaces its allowed services
+func (r *guestAccessRepository) Save(ctx context.Context, guestAccess *domain.OrganizationGuestAccess, serviceIDs []uint) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var stored domain.OrganizationGuestAccess
+		err := tx.Where("organization_id = ?", guestAccess.OrganizationID).First(&stored).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err := tx.Omit("AllowedServices").Create(guestAccess).Error; err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		default:
+			guestAccess.ID = stored.ID
+			guestAccess.CreatedAt = stored.CreatedAt
+			if err := tx.Model(&stored).Updates(map[string]interface{}{
+				"enabled":        guestAccess.Enabled,
+				"session_minute": guestAccess.SessionMinute,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		services := []domain.Service{}
+		if len(serviceIDs) > 0 {
+			if err := tx.Where("id IN ?", serviceIDs).Find(&services).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(guestAccess).Association("AllowedServices").Replace(services); err != nil {
+			return err
+		}
+		guestAccess.AllowedServices = services
+		return nil
+	})
+	if err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
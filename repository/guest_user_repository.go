@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"gorm.io/gorm"
+)
+
+type guestUserRepository struct {
+	db *gorm.DB
+}
+
+func NewGuestUserRepository(db *gorm.DB) domain.GuestUserRepository {
+	return &guestUserRepository{
+		db: db,
+	}
+}
+
+// Create inserts the ephemeral user and its guest record in one transaction
+func (r *guestUserRepository) Create(ctx context.Context, user *domain.User, guestUser *domain.GuestUser) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		guestUser.UserID = user.ID
+		return tx.Create(guestUser).Error
+	})
+	if err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// GetByUserID returns the guest record of a user
+func (r *guestUserRepository) GetByUserID(ctx context.Context, userID uint) (domain.GuestUser, error) {
+	var guestUser domain.GuestUser
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&guestUser).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return guestUser, domain.ErrNotFound
+		}
+		return guestUser, domain.ErrDataBaseInternalError
+	}
+	return guestUser, nil
+}
+
+// userScopedModels are the tables holding rows of a user in a user_id column, deleted
+// with an expired guest; a new table keyed by user is added here. Service reports are
+// left out on purpose: they still count towards the monthly reports limit of the
+// organization. The bio and config tables are only deleted from where they exist, they
+// are not migrated.
+var userScopedModels = []interface{}{
+	&domain.UserLog{},
+	&domain.UserServiceLog{},
+	&domain.UsageHourlyRollup{},
+	&domain.UsageDailyRollup{},
+	&domain.UserMetrics{},
+	&domain.ServiceLaunch{},
+	&domain.RefreshToken{},
+	&domain.Session{},
+	&domain.PasswordResetToken{},
+	&domain.PasswordHistory{},
+	&domain.UserMFA{},
+	&domain.MFARecoveryCode{},
+	&domain.Impersonation{},
+	&domain.UserIdentity{},
+	&domain.OAuthAuthorizationCode{},
+	&domain.OAuthConsent{},
+	&domain.UserBio{},
+	&domain.UserServiceConfig{},
+	&domain.UserConfig{},
+	&domain.GuestUser{},
+}
+
+// DeleteExpired hard-deletes the guests expired before the given time, their user
+// rows and their rows in the userScopedModels tables. Their usage rollups go too, so
+// their usage leaves the statistics at once rather than at the next reconciliation
+// of its days; their reports stay. It returns the number of guests removed.
+func (r *guestUserRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	var deleted int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var userIDs []uint
+		if err := tx.Model(&domain.GuestUser{}).
+			Where("expires_at < ?", before).
+			Pluck("user_id", &userIDs).Error; err != nil {
+			return err
+		}
+		if len(userIDs) == 0 {
+			return nil
+		}
+
+		for _, model := range userScopedModels {
+			if !tx.Migrator().HasTable(model) {
+				continue
+			}
+			if err := tx.Unscoped().Where("user_id IN ?", userIDs).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		result := tx.Unscoped().Where("id IN ?", userIDs).Delete(&domain.User{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted = result.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return 0, domain.ErrDataBaseInternalError
+	}
+	return deleted, nil
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"gorm.io/gorm"
+)
+
+type identityProviderRepository struct {
+	db *gorm.DB
+}
+
+func NewIdentityProviderRepository(db *gorm.DB) domain.IdentityProviderRepository {
+	return &identityProviderRepository{
+		db: db,
+	}
+}
+
+// GetByOrganizationID returns the identity provider configured for an organization
+func (r *identityProviderRepository) GetByOrganizationID(ctx context.Context, organizationID uint) (domain.OrganizationIdentityProvider, error) {
+	var identityProvider domain.OrganizationIdentityProvider
+	if err := r.db.WithContext(ctx).Where("organization_id = ?", organizationID).First(&identityProvider).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return identityProvider, domain.ErrNotFound
+		}
+		return identityProvider, domain.ErrDataBaseInternalError
+	}
+	return identityProvider, nil
+}
+
+// Save creates or updates the identity provider of the organization
+func (r *identityProviderRepository) Save(ctx context.Context, identityProvider *domain.OrganizationIdentityProvider) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var stored domain.OrganizationIdentityProvider
+		err := tx.Where("organization_id = ?", identityProvider.OrganizationID).First(&stored).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return tx.Create(identityProvider).Error
+		case err != nil:
+			return err
+		}
+		identityProvider.ID = stored.ID
+		identityProvider.CreatedAt = stored.CreatedAt
+		// Save writes every column, false and empty values included
+		return tx.Save(identityProvider).Error
+	})
+	if err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"gorm.io/gorm"
+)
+
+type impersonationRepository struct {
+	db *gorm.DB
+}
+
+func NewImpersonationRepository(db *gorm.DB) domain.ImpersonationRepository {
+	return &impersonationRepository{
+		db: db,
+	}
+}
+
+// Create inserts a new impersonation record
+func (r *impersonationRepository) Create(ctx context.Context, impersonation *domain.Impersonation) error {
+	if err := r.db.WithContext(ctx).Omit("Impersonator").Create(impersonation).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// GetByID returns an impersonation record
+func (r *impersonationRepository) GetByID(ctx context.Context, id uint) (domain.Impersonation, error) {
+	var impersonation domain.Impersonation
+	if err := r.db.WithContext(ctx).First(&impersonation, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return impersonation, domain.ErrNotFound
+		}
+		return impersonation, domain.ErrDataBaseInternalError
+	}
+	return impersonation, nil
+}
+
+// FetchByUserID returns the impersonations of a user, most recent first
+func (r *impersonationRepository) FetchByUserID(ctx context.Context, userID uint) ([]domain.Impersonation, error) {
+	var impersonations []domain.Impersonation
+	if err := r.db.WithContext(ctx).
+		Preload("Impersonator", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&impersonations).Error; err != nil {
+		return nil, domain.ErrDataBaseInternalError
+	}
+	return impersonations, nil
+}
+
+// End closes an impersonation that is still running
+func (r *impersonationRepository) End(ctx context.Context, impersonationID uint, endedAt time.Time) error {
+	result := r.db.WithContext(ctx).
+		Model(&domain.Impersonation{}).
+		Where("id = ? AND ended_at IS NULL", impersonationID).
+		Update("ended_at", endedAt)
+	if result.Error != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrImpersonationEnded
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"gorm.io/gorm"
+)
+
+type invitationRepository struct {
+	db *gorm.DB
+}
+
+func NewInvitationRepository(db *gorm.DB) domain.InvitationRepository {
+	return &invitationRepository{
+		db: db,
+	}
+}
+
+// Create inserts a new invitation
+func (r *invitationRepository) Create(ctx context.Context, invitation *domain.Invitation) error {
+	if err := r.db.WithContext(ctx).Omit("Organization").Create(invitation).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// GetByID returns an invitation with its organization
+func (r *invitationRepository) GetByID(ctx context.Context, id uint) (domain.Invitation, error) {
+	var invitation domain.Invitation
+	if err := r.db.WithContext(ctx).Preload("Organization").First(&invitation, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return invitation, domain.ErrNotFound
+		}
+		return invitation, domain.ErrDataBaseInternalError
+	}
+	return invitation, nil
+}
+
+// FetchOpenByOrganization returns the invitations of an organization that were neither accepted nor revoked
+func (r *invitationRepository) FetchOpenByOrganization(ctx context.Context, organizationID uint) ([]domain.Invitation, error) {
+	var invitations []domain.Invitation
+	if err := r.db.WithContext(ctx).
+		Where("organization_id = ? AND accepted_at IS NULL AND revoked_at IS NULL", organizationID).
+		Order("created_at DESC").
+		Find(&invitations).Error; err != nil {
+		return nil, domain.ErrDataBaseInternalError
+	}
+	return invitations, nil
+}
+
+// GetOpenByEmail returns the open invitation of an e-mail to an organization
+func (r *invitationRepository) GetOpenByEmail(ctx context.Context, organizationID uint, email string) (domain.Invitation, error) {
+	var invitation domain.Invitation
+	if err := r.db.WithContext(ctx).
+		Where("organization_id = ? AND email = ? AND accepted_at IS NULL AND revoked_at IS NULL", organizationID, email).
+		First(&invitation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return invitation, domain.ErrNotFound
+		}
+		return invitation, domain.ErrDataBaseInternalError
+	}
+	return invitation, nil
+}
+
+// Renew replaces the nonce of an open invitation and pushes its expiry, invalidating the previous links
+func (r *invitationRepository) Renew(ctx context.Context, invitationID uint, nonceHash string, sentAt time.Time, expiresAt time.Time) error {
+	result := r.db.WithContext(ctx).
+		Model(&domain.Invitation{}).
+		Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", invitationID).
+		Updates(map[string]interface{}{
+			"nonce_hash":   nonceHash,
+			"send_count":   gorm.Expr("send_count + 1"),
+			"last_sent_at": sentAt,
+			"expires_at":   expiresAt,
+		})
+	if result.Error != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrInvalidInvitation
+	}
+	return nil
+}
+
+// Revoke cancels an open invitation
+func (r *invitationRepository) Revoke(ctx context.Context, invitationID uint, revokedAt time.Time) error {
+	result := r.db.WithContext(ctx).
+		Model(&domain.Invitation{}).
+		Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", invitationID).
+		Update("revoked_at", revokedAt)
+	if result.Error != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrInvalidInvitation
+	}
+	return nil
+}
+
+// Accept consumes the invitation and creates the user. The invitation is only
+// consumed while still open, so two concurrent acceptances cannot both win. An
+// e-mail taken meanwhile (e.g. the invitee signed up another way) rolls back with
+// ErrUserAlreadyExists.
+func (r *invitationRepository) Accept(ctx context.Context, invitationID uint, user *domain.User, acceptedAt time.Time) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.Invitation{}).
+			Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", invitationID).
+			Update("accepted_at", acceptedAt)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrInvalidInvitation
+		}
+		if err := tx.Create(user).Error; err != nil {
+			if isUniqueViolation(tx, err) {
+				return domain.ErrUserAlreadyExists
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidInvitation) || errors.Is(err, domain.ErrUserAlreadyExists) {
+			return err
+		}
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// isUniqueViolation reports whether err is the violation of a unique index, whatever the database
+func isUniqueViolation(db *gorm.DB, err error) bool {
+	if translator, ok := db.Dialector.(gorm.ErrorTranslator); ok {
+		err = translator.Translate(err)
+	}
+	return errors.Is(err, gorm.ErrDuplicatedKey)
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type loginAttemptRepository struct {
+	db *gorm.DB
+}
+
+// NewLoginAttemptRepository is the database login attempt store, shared by every instance
+func NewLoginAttemptRepository(db *gorm.DB) domain.LoginAttemptStore {
+	return &loginAttemptRepository{
+		db: db,
+	}
+}
+
+// Get returns the counter of a key, a zero LoginAttempt when there is none
+func (r *loginAttemptRepository) Get(ctx context.Context, key string) (domain.LoginAttempt, error) {
+	var attempt domain.LoginAttempt
+	if err := r.db.WithContext(ctx).Where("key = ?", key).First(&attempt).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.LoginAttempt{Key: key}, nil
+		}
+		return attempt, domain.ErrDataBaseInternalError
+	}
+	return attempt, nil
+}
+
+// RegisterFailure counts a failure with a single upsert, so concurrent attempts
+// on several instances are all counted
+func (r *loginAttemptRepository) RegisterFailure(ctx context.Context, key string, at time.Time, windowStart time.Time) (domain.LoginAttempt, error) {
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "key"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"failures":        gorm.Expr("CASE WHEN login_attempts.window_start < ? THEN 1 ELSE login_attempts.failures + 1 END", windowStart),
+			"window_start":    gorm.Expr("CASE WHEN login_attempts.window_start < ? THEN ? ELSE login_attempts.window_start END", windowStart, at),
+			"last_failure_at": at,
+			"updated_at":      at,
+		}),
+	}).Create(&domain.LoginAttempt{
+		Key:           key,
+		Failures:      1,
+		WindowStart:   at,
+		LastFailureAt: at,
+	}).Error
+	if err != nil {
+		return domain.LoginAttempt{}, domain.ErrDataBaseInternalError
+	}
+	return r.Get(ctx, key)
+}
+
+// Delay refuses attempts of a key until the given time
+func (r *loginAttemptRepository) Delay(ctx context.Context, key string, until time.Time) error {
+	if err := r.db.WithContext(ctx).
+		Model(&domain.LoginAttempt{}).
+		Where("key = ?", key).
+		Update("next_attempt_at", until).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// Lock locks a key until the given time, counting the lockout and clearing the failures
+func (r *loginAttemptRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	if err := r.db.WithContext(ctx).
+		Model(&domain.LoginAttempt{}).
+		Where("key = ?", key).
+		Updates(map[string]interface{}{
+			"locked_until":    until,
+			"lockouts":        gorm.Expr("lockouts + 1"),
+			"failures":        0,
+			"next_attempt_at": nil,
+		}).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// Reset forgets a key
+func (r *loginAttemptRepository) Reset(ctx context.Context, key string) error {
+	if err := r.db.WithContext(ctx).Unscoped().Where("key = ?", key).Delete(&domain.LoginAttempt{}).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// Prune forgets the keys without failure since before that are not locked
+func (r *loginAttemptRepository) Prune(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Unscoped().
+		Where("last_failure_at < ? AND (locked_until IS NULL OR locked_until < ?)", before, time.Now()).
+		Delete(&domain.LoginAttempt{})
+	if result.Error != nil {
+		return 0, domain.ErrDataBaseInternalError
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"gorm.io/gorm"
+)
+
+type mailOutboxRepository struct {
+	db *gorm.DB
+}
+
+func NewMailOutboxRepository(db *gorm.DB) domain.MailOutboxRepository {
+	return &mailOutboxRepository{
+		db: db,
+	}
+}
+
+// Create adds an e-mail to the outbox
+func (r *mailOutboxRepository) Create(ctx context.Context, mail *domain.MailOutbox) error {
+	if err := r.db.WithContext(ctx).Create(mail).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// FetchDue returns pending e-mails whose next attempt is due, oldest first
+func (r *mailOutboxRepository) FetchDue(ctx context.Context, now time.Time, limit int) ([]domain.MailOutbox, error) {
+	var mails []domain.MailOutbox
+	if err := r.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", domain.MailStatusPending, now).
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		Find(&mails).Error; err != nil {
+		return nil, domain.ErrDataBaseInternalError
+	}
+	return mails, nil
+}
+
+// Claim pushes the next attempt of a due e-mail to leaseUntil. Only one worker
+// instance can win the update, and if it dies the e-mail becomes due again.
+func (r *mailOutboxRepository) Claim(ctx context.Context, mailID uint, now time.Time, leaseUntil time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&domain.MailOutbox{}).
+		Where("id = ? AND status = ? AND next_attempt_at <= ?", mailID, domain.MailStatusPending, now).
+		Update("next_attempt_at", leaseUntil)
+	if result.Error != nil {
+		return false, domain.ErrDataBaseInternalError
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// MarkSent flags an e-mail as delivered and drops its bodies, which may carry single-use links
+func (r *mailOutboxRepository) MarkSent(ctx context.Context, mailID uint, sentAt time.Time) error {
+	if err := r.db.WithContext(ctx).
+		Model(&domain.MailOutbox{}).
+		Where("id = ?", mailID).
+		Updates(map[string]interface{}{
+			"status":     domain.MailStatusSent,
+			"sent_at":    sentAt,
+			"last_error": "",
+			"text_body":  "",
+			"html_body":  "",
+		}).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// MarkRetry records a failed attempt and schedules the next one
+func (r *mailOutboxRepository) MarkRetry(ctx context.Context, mailID uint, attempts int, nextAttemptAt time.Time, lastError string) error {
+	if err := r.db.WithContext(ctx).
+		Model(&domain.MailOutbox{}).
+		Where("id = ?", mailID).
+		Updates(map[string]interface{}{
+			"attempts":        attempts,
+			"next_attempt_at": nextAttemptAt,
+			"last_error":      lastError,
+		}).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// MarkFailed gives up on an e-mail after the last attempt and drops its bodies
+func (r *mailOutboxRepository) MarkFailed(ctx context.Context, mailID uint, attempts int, lastError string) error {
+	if err := r.db.WithContext(ctx).
+		Model(&domain.MailOutbox{}).
+		Where("id = ?", mailID).
+		Updates(map[string]interface{}{
+			"status":     domain.MailStatusFailed,
+			"attempts":   attempts,
+			"last_error": lastError,
+			"text_body":  "",
+			"html_body":  "",
+		}).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"gorm.io/gorm"
+)
+
+type mfaRepository struct {
+	db *gorm.DB
+}
+
+func NewMFARepository(db *gorm.DB) domain.MFARepository {
+	return &mfaRepository{
+		db: db,
+	}
+}
+
+// GetByUserID returns the TOTP factor of a user
+func (r *mfaRepository) GetByUserID(ctx context.Context, userID uint) (domain.UserMFA, error) {
+	var mfa domain.UserMFA
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&mfa).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return mfa, domain.ErrNotFound
+		}
+		return mfa, domain.ErrDataBaseInternalError
+	}
+	return mfa, nil
+}
+
+// Save replaces the TOTP factor of a user (a new enrollment discards the previous secret)
+func (r *mfaRepository) Save(ctx context.Context, mfa *domain.UserMFA) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", mfa.UserID).Delete(&domain.UserMFA{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(mfa).Error
+	})
+	if err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// Enable activates the factor, remembering the step of the code used to confirm it
+func (r *mfaRepository) Enable(ctx context.Context, userID uint, enabledAt time.Time, step int64) error {
+	if err := r.db.WithContext(ctx).
+		Model(&domain.UserMFA{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]interface{}{
+			"is_enabled":     true,
+			"enabled_at":     enabledAt,
+			"last_used_step": step,
+		}).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// UseStep records an accepted TOTP step. It fails with ErrInvalidMFACode when the
+// step is not newer than the last one, so a code cannot be replayed.
+func (r *mfaRepository) UseStep(ctx context.Context, userID uint, step int64) error {
+	result := r.db.WithContext(ctx).
+		Model(&domain.UserMFA{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrInvalidMFACode
+	}
+	return nil
+}
+
+// DeleteByUserID removes the factor and the recovery codes of a user
+func (r *mfaRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&domain.MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("user_id = ?", userID).Delete(&domain.UserMFA{}).Error
+	})
+	if err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodes discards the current recovery codes of a user and stores the new ones
+func (r *mfaRepository) ReplaceRecoveryCodes(ctx context.Context, userID uint, codeHashes []string) error {
+	codes := make([]domain.MFARecoveryCode, 0, len(codeHashes))
+	for _, codeHash := range codeHashes {
+		codes = append(codes, domain.MFARecoveryCode{UserID: userID, CodeHash: codeHash})
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&domain.MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&codes).Error
+	})
+	if err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// UseRecoveryCode consumes an unused recovery code, failing with ErrInvalidMFACode otherwise
+func (r *mfaRepository) UseRecoveryCode(ctx context.Context, userID uint, codeHash string, usedAt time.Time) error {
+	result := r.db.WithContext(ctx).
+		Model(&domain.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", usedAt)
+	if result.Error != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrInvalidMFACode
+	}
+	return nil
+}
+
+// CountRecoveryCodes returns how many unused recovery codes a user has left
+func (r *mfaRepository) CountRecoveryCodes(ctx context.Context, userID uint) (int, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).
+		Model(&domain.MFARecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error; err != nil {
+		return 0, domain.ErrDataBaseInternalError
+	}
+	return int(count), nil
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"gorm.io/gorm"
+)
+
+type oauthAuthorizationCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewOAuthAuthorizationCodeRepository(db *gorm.DB) domain.OAuthAuthorizationCodeRepository {
+	return &oauthAuthorizationCodeRepository{
+		db: db,
+	}
+}
+
+// Create inserts a new authorization code
+func (r *oauthAuthorizationCodeRepository) Create(ctx context.Context, code *domain.OAuthAuthorizationCode) error {
+	if err := r.db.WithContext(ctx).Create(code).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// Consume marks an unused, unexpired code of the client as used. The conditional update
+// makes sure the same code cannot be redeemed twice, even concurrently; the code of
+// another client is left alone.
+func (r *oauthAuthorizationCodeRepository) Consume(ctx context.Context, codeHash string, oauthClientID uint, at time.Time) (domain.OAuthAuthorizationCode, error) {
+	var code domain.OAuthAuthorizationCode
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("code_hash = ? AND o_auth_client_id = ?", codeHash, oauthClientID).First(&code).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&domain.OAuthAuthorizationCode{}).
+			Where("id = ? AND used_at IS NULL AND expires_at > ?", code.ID, at).
+			Update("used_at", at)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrInvalidGrant
+		}
+		code.UsedAt = &at
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, domain.ErrInvalidGrant) {
+			return code, domain.ErrInvalidGrant
+		}
+		return code, domain.ErrDataBaseInternalError
+	}
+	return code, nil
+}
+
+// DeleteExpired hard-deletes the codes expired before the given time
+func (r *oauthAuthorizationCodeRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Unscoped().Where("expires_at < ?", before).Delete(&domain.OAuthAuthorizationCode{})
+	if result.Error != nil {
+		return 0, domain.ErrDataBaseInternalError
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"gorm.io/gorm"
+)
+
+type oauthClientRepository struct {
+	db *gorm.DB
+}
+
+func NewOAuthClientRepository(db *gorm.DB) domain.OAuthClientRepository {
+	return &oauthClientRepository{
+		db: db,
+	}
+}
+
+// Create inserts a new OAuth client
+func (r *oauthClientRepository) Create(ctx context.Context, client *domain.OAuthClient) error {
+	if err := r.db.WithContext(ctx).Omit("Service").Create(client).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// Fetch returns all active (not deleted) OAuth clients with their service
+func (r *oauthClientRepository) Fetch(ctx context.Context) ([]domain.OAuthClient, error) {
+	var clients []domain.OAuthClient
+	if err := r.db.WithContext(ctx).Preload("Service").Order("created_at DESC").Find(&clients).Error; err != nil {
+		return nil, domain.ErrDataBaseInternalError
+	}
+	return clients, nil
+}
+
+// GetByID returns an OAuth client with its service
+func (r *oauthClientRepository) GetByID(ctx context.Context, id uint) (domain.OAuthClient, error) {
+	var client domain.OAuthClient
+	if err := r.db.WithContext(ctx).Preload("Service").First(&client, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return client, domain.ErrNotFound
+		}
+		return client, domain.ErrDataBaseInternalError
+	}
+	return client, nil
+}
+
+// GetByClientID returns an OAuth client by its client ID, with its service
+func (r *oauthClientRepository) GetByClientID(ctx context.Context, clientID string) (domain.OAuthClient, error) {
+	var client domain.OAuthClient
+	if err := r.db.WithContext(ctx).Preload("Service").Where("client_id = ?", clientID).First(&client).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return client, domain.ErrNotFound
+		}
+		return client, domain.ErrDataBaseInternalError
+	}
+	return client, nil
+}
+
+// Update saves the name, redirect URIs and first-party flag of a client
+func (r *oauthClientRepository) Update(ctx context.Context, client *domain.OAuthClient) error {
+	result := r.db.WithContext(ctx).
+		Model(&domain.OAuthClient{}).
+		Where("id = ?", client.ID).
+		Select("name", "redirect_uris", "first_party").
+		Updates(client)
+	if result.Error != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// UpdateSecret replaces the stored hash of the client secret
+func (r *oauthClientRepository) UpdateSecret(ctx context.Context, oauthClientID uint, clientSecretHash string) error {
+	result := r.db.WithContext(ctx).
+		Model(&domain.OAuthClient{}).
+		Where("id = ?", oauthClientID).
+		Update("client_secret_hash", clientSecretHash)
+	if result.Error != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// TouchLastUsed records the last time the client redeemed a code
+func (r *oauthClientRepository) TouchLastUsed(ctx context.Context, oauthClientID uint, usedAt time.Time) error {
+	if err := r.db.WithContext(ctx).
+		Model(&domain.OAuthClient{}).
+		Where("id = ?", oauthClientID).
+		Update("last_used_at", usedAt).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// Delete soft deletes an OAuth client, which revokes its credentials
+func (r *oauthClientRepository) Delete(ctx context.Context, oauthClientID uint) error {
+	result := r.db.WithContext(ctx).Delete(&domain.OAuthClient{}, oauthClientID)
+	if result.Error != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
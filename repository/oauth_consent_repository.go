@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"gorm.io/gorm"
+)
+
+type oauthConsentRepository struct {
+	db *gorm.DB
+}
+
+func NewOAuthConsentRepository(db *gorm.DB) domain.OAuthConsentRepository {
+	return &oauthConsentRepository{
+		db: db,
+	}
+}
+
+// Get returns the consent of a user to a client
+func (r *oauthConsentRepository) Get(ctx context.Context, userID uint, oauthClientID uint) (domain.OAuthConsent, error) {
+	var consent domain.OAuthConsent
+	if err := r.db.WithContext(ctx).
+		Where(&domain.OAuthConsent{UserID: userID, OAuthClientID: oauthClientID}).
+		First(&consent).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return consent, domain.ErrNotFound
+		}
+		return consent, domain.ErrDataBaseInternalError
+	}
+	return consent, nil
+}
+
+// Save creates or replaces the consent of a user to a client
+func (r *oauthConsentRepository) Save(ctx context.Context, consent *domain.OAuthConsent) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var stored domain.OAuthConsent
+		err := tx.Where(&domain.OAuthConsent{UserID: consent.UserID, OAuthClientID: consent.OAuthClientID}).First(&stored).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return tx.Create(consent).Error
+		case err != nil:
+			return err
+		}
+		consent.ID = stored.ID
+		return tx.Model(&stored).Update("scope", consent.Scope).Error
+	})
+	if err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type organizationMetricsRepository struct {
+	db *gorm.DB
+}
+
+func NewOrganizationMetricsRepository(db *gorm.DB) domain.OrganizationMetricsRepository {
+	return &organizationMetricsRepository{
+		db: db,
+	}
+}
+
+func (r *organizationMetricsRepository) GetByOrganizationID(ctx context.Context, organizationID uint) (domain.OrganizationMetrics, error) {
+	var metrics domain.OrganizationMetrics
+	err := r.db.WithContext(ctx).Where("organization_id = ?", organizationID).First(&metrics).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.OrganizationMetrics{OrganizationID: organizationID}, nil
+	}
+	if err != nil {
+		return metrics, domain.ErrDataBaseInternalError
+	}
+	return metrics, nil
+}
+
+// AddUsage credits usage to the organization the user belongs to
+func (r *organizationMetricsRepository) AddUsage(ctx context.Context, userID uint, duration time.Duration) error {
+	var organizationIDs []uint
+	if err := r.db.WithContext(ctx).Model(&domain.User{}).Where("id = ?", userID).Pluck("organization_id", &organizationIDs).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	if len(organizationIDs) == 0 {
+		return domain.ErrNotFound
+	}
+
+	if err := r.ensure(ctx, organizationIDs[0]); err != nil {
+		return err
+	}
+	if err := r.db.WithContext(ctx).
+		Model(&domain.OrganizationMetrics{}).
+		Where("organization_id = ?", organizationIDs[0]).
+		Update("total_usage_duration", gorm.Expr("total_usage_duration + ?", int64(duration))).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// AddReport counts a report; the monthly count starts over with the first report of a month
+func (r *organizationMetricsRepository) AddReport(ctx context.Context, organizationID uint, at time.Time) error {
+	if err := r.ensure(ctx, organizationID); err != nil {
+		return err
+	}
+	month := at.Format("2006-01")
+	if err := r.db.WithContext(ctx).
+		Model(&domain.OrganizationMetrics{}).
+		Where("organization_id = ?", organizationID).
+		Updates(map[string]interface{}{
+			"total_reports":               gorm.Expr("total_reports + 1"),
+			"total_reports_current_month": gorm.Expr("CASE WHEN reports_month = ? THEN total_reports_current_month + 1 ELSE 1 END", month),
+			"reports_month":               month,
+			"last_report_date":            at,
+		}).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+func (r *organizationMetricsRepository) RefreshCounts(ctx context.Context, organizationID uint) error {
+	var users, services int64
+	if err := r.countUsers(ctx).Where("organization_id = ?", organizationID).Count(&users).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	if err := r.countServices(ctx).Where("organization_services.organization_id = ?", organizationID).Count(&services).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+
+	if err := r.ensure(ctx, organizationID); err != nil {
+		return err
+	}
+	if err := r.db.WithContext(ctx).
+		Model(&domain.OrganizationMetrics{}).
+		Where("organization_id = ?", organizationID).
+		Updates(map[string]interface{}{
+			"total_users":    users,
+			"total_services": services,
+		}).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// Recompute rebuilds the metrics of every organization with a handful of grouped queries
+// and overwrites the stored rows. Events recorded while it runs may be counted twice or
+// missed until the next run.
+func (r *organizationMetricsRepository) Recompute(ctx context.Context) (int, error) {
+	var organizationIDs []uint
+	if err := r.db.WithContext(ctx).Model(&domain.Organization{}).Order("id").Pluck("id", &organizationIDs).Error; err != nil {
+		return 0, domain.ErrDataBaseInternalError
+	}
+
+	metrics := make(map[uint]*domain.OrganizationMetrics, len(organizationIDs))
+	for _, organizationID := range organizationIDs {
+		metrics[organizationID] = &domain.OrganizationMetrics{OrganizationID: organizationID}
+	}
+
+	type count struct {
+		OrganizationID uint
+		Total          int64
+		LastID         uint
+	}
+
+	var users []count
+	if err := r.countUsers(ctx).Select("organization_id, COUNT(*) AS total").Group("organization_id").Scan(&users).Error; err != nil {
+		return 0, domain.ErrDataBaseInternalError
+	}
+	for _, c := range users {
+		if m, ok := metrics[c.OrganizationID]; ok {
+			m.TotalUsers = int(c.Total)
+		}
+	}
+
+	var services []count
+	if err := r.countServices(ctx).Select("organization_services.organization_id, COUNT(*) AS total").Group("organization_services.organization_id").Scan(&services).Error; err != nil {
+		return 0, domain.ErrDataBaseInternalError
+	}
+	for _, c := range services {
+		if m, ok := metrics[c.OrganizationID]; ok {
+			m.TotalServices = int(c.Total)
+		}
+	}
+
+	var usages []count
+	if err := r.db.WithContext(ctx).
+		Model(&domain.UserServiceLog{}).
+		Select("users.organization_id, SUM(user_service_logs.duration) AS total").
+		Joins("JOIN users ON users.id = user_service_logs.user_id").
+		Group("users.organization_id").
+		Scan(&usages).Error; err != nil {
+		return 0, domain.ErrDataBaseInternalError
+	}
+	for _, c := range usages {
+		if m, ok := metrics[c.OrganizationID]; ok {
+			m.TotalUsageDuration = time.Duration(c.Total)
+		}
+	}
+
+	// reports, the last one being the latest report
+	var reports []count
+	if err := r.db.WithContext(ctx).
+		Model(&domain.ServiceReport{}).
+		Select("organization_id, COUNT(*) AS total, MAX(id) AS last_id").
+		Group("organization_id").
+		Scan(&reports).Error; err != nil {
+		return 0, domain.ErrDataBaseInternalError
+	}
+	lastReportIDs := make([]uint, 0, len(reports))
+	for _, c := range reports {
+		if m, ok := metrics[c.OrganizationID]; ok {
+			m.TotalReports = int(c.Total)
+			lastReportIDs = append(lastReportIDs, c.LastID)
+		}
+	}
+	for _, ids := range chunk(lastReportIDs, 500) {
+		var lastReports []domain.ServiceReport
+		if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&lastReports).Error; err != nil {
+			return 0, domain.ErrDataBaseInternalError
+		}
+		for _, report := range lastReports {
+			createdAt := report.CreatedAt
+			metrics[report.OrganizationID].LastReportDate = &createdAt
+		}
+	}
+
+	now := time.Now()
+	var monthReports []count
+	if err := r.db.WithContext(ctx).
+		Model(&domain.ServiceReport{}).
+		Select("organization_id, COUNT(*) AS total").
+		Where("created_at >= ?", domain.MonthStart(now)).
+		Group("organization_id").
+		Scan(&monthReports).Error; err != nil {
+		return 0, domain.ErrDataBaseInternalError
+	}
+	for _, m := range metrics {
+		m.ReportsMonth = now.Format("2006-01")
+	}
+	for _, c := range monthReports {
+		if m, ok := metrics[c.OrganizationID]; ok {
+			m.TotalReportsCurrentMonth = int(c.Total)
+		}
+	}
+
+	rows := make([]domain.OrganizationMetrics, 0, len(organizationIDs))
+	for _, organizationID := range organizationIDs {
+		rows = append(rows, *metrics[organizationID])
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "organization_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"updated_at", "total_services", "total_users", "total_reports", "total_reports_current_month", "reports_month", "last_report_date", "total_usage_duration"}),
+		}).
+		CreateInBatches(&rows, 500).Error; err != nil {
+		return 0, domain.ErrDataBaseInternalError
+	}
+	return len(rows), nil
+}
+
+// countUsers selects the members of organizations, guests excluded
+func (r *organizationMetricsRepository) countUsers(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Model(&domain.User{}).Where("role_id <> ?", domain.UserRoleGuest)
+}
+
+// countServices selects the services organizations subscribed to
+func (r *organizationMetricsRepository) countServices(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).
+		Table("organization_services").
+		Joins("JOIN services ON services.id = organization_services.service_id AND services.deleted_at IS NULL")
+}
+
+// ensure creates the metrics of the organization on its first event
+func (r *organizationMetricsRepository) ensure(ctx context.Context, organizationID uint) error {
+	metrics := domain.OrganizationMetrics{OrganizationID: organizationID}
+	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&metrics).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
@@ -44,6 +44,7 @@ func (r *organizationRepository) GetByID(ctx context.Context, id uint) (domain.O
 		Preload("Role").
 		Preload("Users").
 		Preload("SubscribedServices").
+		Preload("Subscription").
 		First(&org, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return org, domain.ErrNotFound
@@ -107,6 +108,29 @@ func (r *organizationRepository) GetSubscribedServices(ctx context.Context, orga
 	return publicServices, nil
 }
 
+// FetchArchived retorna as Organizações removidas (soft delete)
+func (r *organizationRepository) FetchArchived(ctx context.Context) ([]domain.Organization, error) {
+	var orgs []domain.Organization
+	if err := r.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Find(&orgs).Error; err != nil {
+		return nil, domain.ErrDataBaseInternalError
+	}
+	return orgs, nil
+}
+
+// NameTaken verifica se outra Organização, inclusive removida, já usa o nome (índice único)
+func (r *organizationRepository) NameTaken(ctx context.Context, name string, exceptID uint) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Unscoped().
+		Model(&domain.Organization{}).
+		Where("name = ? AND id <> ?", name, exceptID).
+		Count(&count).Error; err != nil {
+		return false, domain.ErrDataBaseInternalError
+	}
+	return count > 0, nil
+}
+
 // Update atualiza dados de uma Organização
 func (r *organizationRepository) Update(ctx context.Context, organizationID uint, data *domain.Organization) error {
 	// Checa se existe a org
@@ -117,13 +141,29 @@ func (r *organizationRepository) Update(ctx context.Context, organizationID uint
 		return err
 	}
 
-	// Atualiza
+	// Atualiza (inclusive campos vazios, para limpar apelido e logo)
 	if err := r.db.WithContext(ctx).
 		Model(&domain.Organization{}).
 		Where("id = ?", organizationID).
+		Select("name", "nickname", "logo_url", "role_id").
 		Updates(data).
 		Error; err != nil {
-		return err
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// SetRequireMFA liga/desliga a exigência de MFA para os usuários da Organização
+func (r *organizationRepository) SetRequireMFA(ctx context.Context, organizationID uint, requireMFA bool) error {
+	result := r.db.WithContext(ctx).
+		Model(&domain.Organization{}).
+		Where("id = ?", organizationID).
+		Update("require_mfa", requireMFA)
+	if result.Error != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotFound
 	}
 	return nil
 }
@@ -135,3 +175,18 @@ func (r *organizationRepository) Delete(ctx context.Context, organizationID uint
 	}
 	return nil
 }
+
+// Restore remove o soft delete para reativar uma Organização
+func (r *organizationRepository) Restore(ctx context.Context, organizationID uint) error {
+	result := r.db.WithContext(ctx).Unscoped().
+		Model(&domain.Organization{}).
+		Where("id = ? AND deleted_at IS NOT NULL", organizationID).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"gorm.io/gorm"
+)
+
+type organizationSubscriptionRepository struct {
+	db *gorm.DB
+}
+
+func NewOrganizationSubscriptionRepository(db *gorm.DB) domain.OrganizationSubscriptionRepository {
+	return &organizationSubscriptionRepository{
+		db: db,
+	}
+}
+
+// Create inserts a new subscription
+func (r *organizationSubscriptionRepository) Create(ctx context.Context, subscription *domain.OrganizationSubscription) error {
+	if err := r.db.WithContext(ctx).Create(subscription).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// Fetch returns every subscription
+func (r *organizationSubscriptionRepository) Fetch(ctx context.Context) ([]domain.OrganizationSubscription, error) {
+	var subscriptions []domain.OrganizationSubscription
+	if err := r.db.WithContext(ctx).Order("id").Find(&subscriptions).Error; err != nil {
+		return nil, domain.ErrDataBaseInternalError
+	}
+	return subscriptions, nil
+}
+
+// GetByID returns a subscription by its ID
+func (r *organizationSubscriptionRepository) GetByID(ctx context.Context, id uint) (domain.OrganizationSubscription, error) {
+	var subscription domain.OrganizationSubscription
+	if err := r.db.WithContext(ctx).First(&subscription, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return subscription, domain.ErrNotFound
+		}
+		return subscription, domain.ErrDataBaseInternalError
+	}
+	return subscription, nil
+}
+
+// GetByOrganizationID returns the subscription of an organization
+func (r *organizationSubscriptionRepository) GetByOrganizationID(ctx context.Context, organizationID uint) (domain.OrganizationSubscription, error) {
+	var subscription domain.OrganizationSubscription
+	if err := r.db.WithContext(ctx).Where("organization_id = ?", organizationID).First(&subscription).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return subscription, domain.ErrNotFound
+		}
+		return subscription, domain.ErrDataBaseInternalError
+	}
+	return subscription, nil
+}
+
+// Update saves the plan and the dates of a subscription, zero values included. The
+// expiry notifications are saved too, so new dates can be warned about again.
+func (r *organizationSubscriptionRepository) Update(ctx context.Context, subscriptionID uint, subscription *domain.OrganizationSubscription) error {
+	if err := r.db.WithContext(ctx).Model(&domain.OrganizationSubscription{}).
+		Where("id = ?", subscriptionID).
+		Select("active", "subscription_value", "subscription_period", "subscription_users_limit", "subscription_reports_limit", "starts_at", "ends_at", "grace_ends_at", "auto_renew", "expiry_warned_at", "expiry_notified_at").
+		Updates(subscription).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// SetActive activates or deactivates a subscription
+func (r *organizationSubscriptionRepository) SetActive(ctx context.Context, subscriptionID uint, active bool) error {
+	if err := r.db.WithContext(ctx).Model(&domain.OrganizationSubscription{}).
+		Where("id = ?", subscriptionID).
+		Update("active", active).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// Delete removes a subscription (hard delete, organization_id is unique)
+func (r *organizationSubscriptionRepository) Delete(ctx context.Context, subscriptionID uint) error {
+	if err := r.db.WithContext(ctx).Unscoped().Delete(&domain.OrganizationSubscription{}, subscriptionID).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// FetchExpiring returns the subscriptions to warn about their end
+func (r *organizationSubscriptionRepository) FetchExpiring(ctx context.Context, now time.Time, before time.Time) ([]domain.OrganizationSubscription, error) {
+	var subscriptions []domain.OrganizationSubscription
+	if err := r.db.WithContext(ctx).
+		Where("active = ? AND auto_renew = ? AND expiry_warned_at IS NULL", true, false).
+		Where("ends_at > ? AND ends_at <= ?", now, before).
+		Order("id").
+		Find(&subscriptions).Error; err != nil {
+		return nil, domain.ErrDataBaseInternalError
+	}
+	return subscriptions, nil
+}
+
+// FetchInGrace returns the ended subscriptions to notify about their grace period
+func (r *organizationSubscriptionRepository) FetchInGrace(ctx context.Context, now time.Time) ([]domain.OrganizationSubscription, error) {
+	var subscriptions []domain.OrganizationSubscription
+	if err := r.db.WithContext(ctx).
+		Where("active = ? AND auto_renew = ? AND expiry_notified_at IS NULL", true, false).
+		Where("ends_at <= ? AND grace_ends_at > ?", now, now).
+		Order("id").
+		Find(&subscriptions).Error; err != nil {
+		return nil, domain.ErrDataBaseInternalError
+	}
+	return subscriptions, nil
+}
+
+// FetchGraceOver returns the ended subscriptions to deactivate
+func (r *organizationSubscriptionRepository) FetchGraceOver(ctx context.Context, now time.Time) ([]domain.OrganizationSubscription, error) {
+	var subscriptions []domain.OrganizationSubscription
+	if err := r.db.WithContext(ctx).
+		Where("active = ? AND auto_renew = ?", true, false).
+		Where("ends_at <= ? AND (grace_ends_at IS NULL OR grace_ends_at <= ?)", now, now).
+		Order("id").
+		Find(&subscriptions).Error; err != nil {
+		return nil, domain.ErrDataBaseInternalError
+	}
+	return subscriptions, nil
+}
+
+// FetchDueRenewal returns the ended subscriptions to renew
+func (r *organizationSubscriptionRepository) FetchDueRenewal(ctx context.Context, now time.Time) ([]domain.OrganizationSubscription, error) {
+	var subscriptions []domain.OrganizationSubscription
+	if err := r.db.WithContext(ctx).
+		Where("active = ? AND auto_renew = ? AND ends_at <= ?", true, true, now).
+		Order("id").
+		Find(&subscriptions).Error; err != nil {
+		return nil, domain.ErrDataBaseInternalError
+	}
+	return subscriptions, nil
+}
+
+// MarkExpiryWarned records that the organization was warned about the end of its subscription
+func (r *organizationSubscriptionRepository) MarkExpiryWarned(ctx context.Context, subscriptionID uint, at time.Time) error {
+	if err := r.db.WithContext(ctx).Model(&domain.OrganizationSubscription{}).
+		Where("id = ?", subscriptionID).
+		Update("expiry_warned_at", at).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// MarkExpiryNotified records that the organization was told its subscription ended
+func (r *organizationSubscriptionRepository) MarkExpiryNotified(ctx context.Context, subscriptionID uint, at time.Time) error {
+	if err := r.db.WithContext(ctx).Model(&domain.OrganizationSubscription{}).
+		Where("id = ?", subscriptionID).
+		Update("expiry_notified_at", at).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// Renew inserts the renewals and saves the new end of the subscription. The end it
+// had when it was read is checked, so a renewal cannot be recorded twice.
+func (r *organizationSubscriptionRepository) Renew(ctx context.Context, subscription *domain.OrganizationSubscription, renewals []domain.SubscriptionRenewal) error {
+	previousEndsAt := renewals[0].StartsAt
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.OrganizationSubscription{}).
+			Where("id = ? AND ends_at = ?", subscription.ID, previousEndsAt).
+			Updates(map[string]interface{}{
+				"ends_at":            subscription.EndsAt,
+				"grace_ends_at":      subscription.GraceEndsAt,
+				"expiry_warned_at":   nil,
+				"expiry_notified_at": nil,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrSubscriptionChanged
+		}
+		return tx.Create(&renewals).Error
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrSubscriptionChanged) {
+			return domain.ErrSubscriptionChanged
+		}
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// FetchRenewals returns the renewals of a subscription, latest first
+func (r *organizationSubscriptionRepository) FetchRenewals(ctx context.Context, subscriptionID uint) ([]domain.SubscriptionRenewal, error) {
+	var renewals []domain.SubscriptionRenewal
+	if err := r.db.WithContext(ctx).
+		Where("organization_subscription_id = ?", subscriptionID).
+		Order("starts_at DESC").
+		Find(&renewals).Error; err != nil {
+		return nil, domain.ErrDataBaseInternalError
+	}
+	return renewals, nil
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"gorm.io/gorm"
+)
+
+type passwordHistoryRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordHistoryRepository(db *gorm.DB) domain.PasswordHistoryRepository {
+	return &passwordHistoryRepository{
+		db: db,
+	}
+}
+
+// Create inserts a new password history record
+func (r *passwordHistoryRepository) Create(ctx context.Context, history *domain.PasswordHistory) error {
+	if err := r.db.WithContext(ctx).Create(history).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// FetchRecentByUserID returns the last password hashes of a user, most recent first
+func (r *passwordHistoryRepository) FetchRecentByUserID(ctx context.Context, userID uint, limit int) ([]domain.PasswordHistory, error) {
+	var history []domain.PasswordHistory
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("id DESC").
+		Limit(limit).
+		Find(&history).Error; err != nil {
+		return nil, domain.ErrDataBaseInternalError
+	}
+	return history, nil
+}
+
+// Prune hard-deletes the history of a user past its most recent entries
+func (r *passwordHistoryRepository) Prune(ctx context.Context, userID uint, keep int) error {
+	var keptIDs []uint
+	if err := r.db.WithContext(ctx).
+		Model(&domain.PasswordHistory{}).
+		Where("user_id = ?", userID).
+		Order("id DESC").
+		Limit(keep).
+		Pluck("id", &keptIDs).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	if len(keptIDs) == 0 {
+		return nil
+	}
+
+	if err := r.db.WithContext(ctx).
+		Unscoped().
+		Where("user_id = ? AND id NOT IN ?", userID, keptIDs).
+		Delete(&domain.PasswordHistory{}).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
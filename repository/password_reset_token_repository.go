@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"gorm.io/gorm"
+)
+
+type passwordResetTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordResetTokenRepository(db *gorm.DB) domain.PasswordResetTokenRepository {
+	return &passwordResetTokenRepository{
+		db: db,
+	}
+}
+
+// Create inserts a new password reset token record
+func (r *passwordResetTokenRepository) Create(ctx context.Context, resetToken *domain.PasswordResetToken) error {
+	if err := r.db.WithContext(ctx).Create(resetToken).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// GetByTokenHash returns the password reset token matching the given hash
+func (r *passwordResetTokenRepository) GetByTokenHash(ctx context.Context, tokenHash string) (domain.PasswordResetToken, error) {
+	var resetToken domain.PasswordResetToken
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&resetToken).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return resetToken, domain.ErrNotFound
+		}
+		return resetToken, domain.ErrDataBaseInternalError
+	}
+	return resetToken, nil
+}
+
+// MarkUsed consumes a token. The update only succeeds while the token is still
+// unused, so the same link cannot reset the password twice.
+func (r *passwordResetTokenRepository) MarkUsed(ctx context.Context, resetTokenID uint, usedAt time.Time) error {
+	result := r.db.WithContext(ctx).
+		Model(&domain.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", resetTokenID).
+		Update("used_at", usedAt)
+	if result.Error != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrInvalidResetToken
+	}
+	return nil
+}
+
+// InvalidateByUserID consumes every outstanding token of a user
+func (r *passwordResetTokenRepository) InvalidateByUserID(ctx context.Context, userID uint, at time.Time) error {
+	if err := r.db.WithContext(ctx).
+		Model(&domain.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", at).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"gorm.io/gorm"
+)
+
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewRefreshTokenRepository(db *gorm.DB) domain.RefreshTokenRepository {
+	return &refreshTokenRepository{
+		db: db,
+	}
+}
+
+// Create inserts a new refresh token record
+func (r *refreshTokenRepository) Create(ctx context.Context, refreshToken *domain.RefreshToken) error {
+	if err := r.db.WithContext(ctx).Create(refreshToken).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// GetByTokenHash returns the refresh token matching the given hash
+func (r *refreshTokenRepository) GetByTokenHash(ctx context.Context, tokenHash string) (domain.RefreshToken, error) {
+	var refreshToken domain.RefreshToken
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&refreshToken).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return refreshToken, domain.ErrNotFound
+		}
+		return refreshToken, domain.ErrDataBaseInternalError
+	}
+	return refreshToken, nil
+}
+
+// MarkUsed flags a refresh token as consumed. The update only succeeds while the
+// token is still unused, so two concurrent rotations cannot both win: the loser
+// gets ErrRefreshTokenReused.
+func (r *refreshTokenRepository) MarkUsed(ctx context.Context, refreshTokenID uint, usedAt time.Time) error {
+	result := r.db.WithContext(ctx).
+		Model(&domain.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", refreshTokenID).
+		Update("used_at", usedAt)
+	if result.Error != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrRefreshTokenReused
+	}
+	return nil
+}
+
+// RevokeFamily revokes every token of a family that is not revoked yet
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, familyID string, revokedAt time.Time) error {
+	if err := r.db.WithContext(ctx).
+		Model(&domain.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", revokedAt).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// RevokeByUserID revokes every token of a user (sign out everywhere)
+func (r *refreshTokenRepository) RevokeByUserID(ctx context.Context, userID uint, revokedAt time.Time) error {
+	if err := r.db.WithContext(ctx).
+		Model(&domain.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", revokedAt).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type scheduledJobRepository struct {
+	db *gorm.DB
+}
+
+func NewScheduledJobRepository(db *gorm.DB) domain.ScheduledJobRepository {
+	return &scheduledJobRepository{
+		db: db,
+	}
+}
+
+// Register creates the job when no replica did yet, and follows a change of its schedule
+func (r *scheduledJobRepository) Register(ctx context.Context, name string, schedule string, nextRunAt time.Time) error {
+	job := domain.ScheduledJob{
+		Name:      name,
+		Schedule:  schedule,
+		NextRunAt: nextRunAt,
+	}
+	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&job).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+
+	if err := r.db.WithContext(ctx).
+		Model(&domain.ScheduledJob{}).
+		Where("name = ? AND schedule <> ?", name, schedule).
+		Updates(map[string]interface{}{
+			"schedule":    schedule,
+			"next_run_at": nextRunAt,
+		}).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// Fetch returns every registered job
+func (r *scheduledJobRepository) Fetch(ctx context.Context) ([]domain.ScheduledJob, error) {
+	var jobs []domain.ScheduledJob
+	if err := r.db.WithContext(ctx).Order("name").Find(&jobs).Error; err != nil {
+		return nil, domain.ErrDataBaseInternalError
+	}
+	return jobs, nil
+}
+
+// Acquire leases a due job to owner. The conditions on next_run_at and lease_until
+// make the update a compare-and-swap, so a single replica runs each occurrence.
+func (r *scheduledJobRepository) Acquire(ctx context.Context, name string, owner string, now time.Time, leaseUntil time.Time, nextRunAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&domain.ScheduledJob{}).
+		Where("name = ? AND next_run_at <= ? AND (lease_until IS NULL OR lease_until < ?)", name, now, now).
+		Updates(map[string]interface{}{
+			"next_run_at":     nextRunAt,
+			"lease_owner":     owner,
+			"lease_until":     leaseUntil,
+			"last_started_at": now,
+			"last_status":     domain.JobStatusRunning,
+		})
+	if result.Error != nil {
+		return false, domain.ErrDataBaseInternalError
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Finish stores the outcome of a run, unless the lease was taken over in the meantime
+func (r *scheduledJobRepository) Finish(ctx context.Context, name string, owner string, finishedAt time.Time, status string, result string, runError string) error {
+	update := r.db.WithContext(ctx).
+		Model(&domain.ScheduledJob{}).
+		Where("name = ? AND lease_owner = ?", name, owner).
+		Updates(map[string]interface{}{
+			"lease_owner":      "",
+			"lease_until":      nil,
+			"last_finished_at": finishedAt,
+			"last_status":      status,
+			"last_result":      result,
+			"last_error":       runError,
+		})
+	if update.Error != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	if update.RowsAffected == 0 {
+		return domain.ErrJobLeaseLost
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"gorm.io/gorm"
+)
+
+type serviceAccountRepository struct {
+	db *gorm.DB
+}
+
+func NewServiceAccountRepository(db *gorm.DB) domain.ServiceAccountRepository {
+	return &serviceAccountRepository{
+		db: db,
+	}
+}
+
+// Create inserts a new service account in the database
+func (r *serviceAccountRepository) Create(ctx context.Context, serviceAccount *domain.ServiceAccount) error {
+	if err := r.db.WithContext(ctx).Create(serviceAccount).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// Fetch returns all active (not deleted) service accounts
+func (r *serviceAccountRepository) Fetch(ctx context.Context) ([]domain.ServiceAccount, error) {
+	var serviceAccounts []domain.ServiceAccount
+	if err := r.db.WithContext(ctx).Order("created_at DESC").Find(&serviceAccounts).Error; err != nil {
+		return nil, domain.ErrDataBaseInternalError
+	}
+	return serviceAccounts, nil
+}
+
+// GetByID returns a service account by its ID
+func (r *serviceAccountRepository) GetByID(ctx context.Context, id uint) (domain.ServiceAccount, error) {
+	var serviceAccount domain.ServiceAccount
+	if err := r.db.WithContext(ctx).First(&serviceAccount, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return serviceAccount, domain.ErrNotFound
+		}
+		return serviceAccount, domain.ErrDataBaseInternalError
+	}
+	return serviceAccount, nil
+}
+
+// GetByClientID returns a service account by its client ID
+func (r *serviceAccountRepository) GetByClientID(ctx context.Context, clientID string) (domain.ServiceAccount, error) {
+	var serviceAccount domain.ServiceAccount
+	if err := r.db.WithContext(ctx).Where("client_id = ?", clientID).First(&serviceAccount).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return serviceAccount, domain.ErrNotFound
+		}
+		return serviceAccount, domain.ErrDataBaseInternalError
+	}
+	return serviceAccount, nil
+}
+
+// UpdateSecret replaces the stored hash of the client secret and raises the secret
+// version, which revokes the tokens issued with the previous secret
+func (r *serviceAccountRepository) UpdateSecret(ctx context.Context, serviceAccountID uint, clientSecretHash string) error {
+	result := r.db.WithContext(ctx).
+		Model(&domain.ServiceAccount{}).
+		Where("id = ?", serviceAccountID).
+		Updates(map[string]interface{}{
+			"client_secret_hash": clientSecretHash,
+			"secret_version":     gorm.Expr("secret_version + 1"),
+		})
+	if result.Error != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// TouchLastUsed records the last time the service account obtained a token
+func (r *serviceAccountRepository) TouchLastUsed(ctx context.Context, serviceAccountID uint, usedAt time.Time) error {
+	if err := r.db.WithContext(ctx).
+		Model(&domain.ServiceAccount{}).
+		Where("id = ?", serviceAccountID).
+		Update("last_used_at", usedAt).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// Delete soft deletes a service account, which revokes its credentials
+func (r *serviceAccountRepository) Delete(ctx context.Context, serviceAccountID uint) error {
+	result := r.db.WithContext(ctx).Delete(&domain.ServiceAccount{}, serviceAccountID)
+	if result.Error != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"gorm.io/gorm"
+)
+
+type serviceLaunchRepository struct {
+	db *gorm.DB
+}
+
+func NewServiceLaunchRepository(db *gorm.DB) domain.ServiceLaunchRepository {
+	return &serviceLaunchRepository{
+		db: db,
+	}
+}
+
+// Create inserts a new launch
+func (r *serviceLaunchRepository) Create(ctx context.Context, launch *domain.ServiceLaunch) error {
+	if err := r.db.WithContext(ctx).Create(launch).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// Consume marks an unused, unexpired launch as used. The conditional update makes
+// sure two exchanges racing with the same token cannot both succeed.
+func (r *serviceLaunchRepository) Consume(ctx context.Context, nonceHash string, at time.Time) (domain.ServiceLaunch, error) {
+	var launch domain.ServiceLaunch
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("nonce_hash = ?", nonceHash).First(&launch).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&domain.ServiceLaunch{}).
+			Where("id = ? AND used_at IS NULL AND expires_at > ?", launch.ID, at).
+			Update("used_at", at)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrInvalidLaunchToken
+		}
+		launch.UsedAt = &at
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, domain.ErrInvalidLaunchToken) {
+			return launch, domain.ErrInvalidLaunchToken
+		}
+		return launch, domain.ErrDataBaseInternalError
+	}
+	return launch, nil
+}
+
+// DeleteExpired hard-deletes the launches expired before the given time
+func (r *serviceLaunchRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Unscoped().Where("expires_at < ?", before).Delete(&domain.ServiceLaunch{})
+	if result.Error != nil {
+		return 0, domain.ErrDataBaseInternalError
+	}
+	return result.RowsAffected, nil
+}
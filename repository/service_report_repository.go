@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"gorm.io/gorm"
+)
+
+type serviceReportRepository struct {
+	db *gorm.DB
+}
+
+func NewServiceReportRepository(db *gorm.DB) domain.ServiceReportRepository {
+	return &serviceReportRepository{
+		db: db,
+	}
+}
+
+// Create inserts a new report
+func (r *serviceReportRepository) Create(ctx context.Context, report *domain.ServiceReport) error {
+	if err := r.db.WithContext(ctx).Create(report).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// CountByOrganizationSince counts the reports of the organization created since the given time
+func (r *serviceReportRepository) CountByOrganizationSince(ctx context.Context, organizationID uint, since time.Time) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&domain.ServiceReport{}).
+		Where("organization_id = ? AND created_at >= ?", organizationID, since).
+		Count(&count).Error; err != nil {
+		return 0, domain.ErrDataBaseInternalError
+	}
+	return count, nil
+}
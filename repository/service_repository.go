@@ -112,6 +112,18 @@ func (r *serviceRepository) SetAvailabilityToOrganization(ctx context.Context, s
 	return nil
 }
 
+// IsAvailableToOrganization reports whether the organization subscribes to the service
+func (r *serviceRepository) IsAvailableToOrganization(ctx context.Context, serviceID uint, organizationID uint) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).
+		Table("organization_services").
+		Where("service_id = ? AND organization_id = ?", serviceID, organizationID).
+		Count(&count).Error; err != nil {
+		return false, domain.ErrDataBaseInternalError
+	}
+	return count > 0, nil
+}
+
 // RemoveAvailabilityFromOrganization remove o vínculo do service com uma organização
 func (r *serviceRepository) RemoveAvailabilityFromOrganization(ctx context.Context, serviceID uint, organizationID uint) error {
 	var service domain.Service
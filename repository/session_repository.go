@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"gorm.io/gorm"
+)
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) domain.SessionRepository {
+	return &sessionRepository{
+		db: db,
+	}
+}
+
+// Create inserts a new session record
+func (r *sessionRepository) Create(ctx context.Context, session *domain.Session) error {
+	if err := r.db.WithContext(ctx).Create(session).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// GetByID returns a session record
+func (r *sessionRepository) GetByID(ctx context.Context, id uint) (domain.Session, error) {
+	var session domain.Session
+	if err := r.db.WithContext(ctx).First(&session, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return session, domain.ErrNotFound
+		}
+		return session, domain.ErrDataBaseInternalError
+	}
+	return session, nil
+}
+
+// GetByFamilyID returns the session of a refresh token family
+func (r *sessionRepository) GetByFamilyID(ctx context.Context, familyID string) (domain.Session, error) {
+	var session domain.Session
+	if err := r.db.WithContext(ctx).Where("family_id = ?", familyID).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return session, domain.ErrNotFound
+		}
+		return session, domain.ErrDataBaseInternalError
+	}
+	return session, nil
+}
+
+// FetchActiveByUserID returns the active sessions of a user, most recently seen first
+func (r *sessionRepository) FetchActiveByUserID(ctx context.Context, userID uint, at time.Time) ([]domain.Session, error) {
+	var sessions []domain.Session
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, at).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, domain.ErrDataBaseInternalError
+	}
+	return sessions, nil
+}
+
+// Touch updates the last seen time of a session
+func (r *sessionRepository) Touch(ctx context.Context, sessionID uint, lastSeenAt time.Time) error {
+	if err := r.db.WithContext(ctx).
+		Model(&domain.Session{}).
+		Where("id = ?", sessionID).
+		Update("last_seen_at", lastSeenAt).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// Extend updates the device data, last seen and expiry of a session on refresh
+func (r *sessionRepository) Extend(ctx context.Context, sessionID uint, client domain.ClientInfo, lastSeenAt time.Time, expiresAt time.Time) error {
+	if err := r.db.WithContext(ctx).
+		Model(&domain.Session{}).
+		Where("id = ?", sessionID).
+		Updates(map[string]interface{}{
+			"user_agent":   client.UserAgent,
+			"ip_address":   client.IPAddress,
+			"last_seen_at": lastSeenAt,
+			"expires_at":   expiresAt,
+		}).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// Revoke revokes a session and every token of its refresh family in one transaction
+func (r *sessionRepository) Revoke(ctx context.Context, sessionID uint, revokedAt time.Time) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var session domain.Session
+		if err := tx.First(&session, sessionID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&domain.Session{}).
+			Where("id = ? AND revoked_at IS NULL", session.ID).
+			Update("revoked_at", revokedAt).Error; err != nil {
+			return err
+		}
+		return tx.Model(&domain.RefreshToken{}).
+			Where("family_id = ? AND revoked_at IS NULL", session.FamilyID).
+			Update("revoked_at", revokedAt).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrNotFound
+		}
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// RevokeByFamilyID revokes the session of a refresh token family
+func (r *sessionRepository) RevokeByFamilyID(ctx context.Context, familyID string, revokedAt time.Time) error {
+	if err := r.db.WithContext(ctx).
+		Model(&domain.Session{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", revokedAt).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// RevokeByUserID revokes every session of a user (sign out everywhere)
+func (r *sessionRepository) RevokeByUserID(ctx context.Context, userID uint, revokedAt time.Time) error {
+	if err := r.db.WithContext(ctx).
+		Model(&domain.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", revokedAt).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
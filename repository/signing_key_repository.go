@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"gorm.io/gorm"
+)
+
+type signingKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewSigningKeyRepository(db *gorm.DB) domain.SigningKeyRepository {
+	return &signingKeyRepository{
+		db: db,
+	}
+}
+
+// Fetch returns every signing key, newest first
+func (r *signingKeyRepository) Fetch(ctx context.Context) ([]domain.SigningKey, error) {
+	var signingKeys []domain.SigningKey
+	if err := r.db.WithContext(ctx).Order("created_at DESC").Find(&signingKeys).Error; err != nil {
+		return nil, domain.ErrDataBaseInternalError
+	}
+	return signingKeys, nil
+}
+
+// FetchVerifiable returns the keys that still verify tokens: the active one and those retired after retiredAfter
+func (r *signingKeyRepository) FetchVerifiable(ctx context.Context, retiredAfter time.Time) ([]domain.SigningKey, error) {
+	var signingKeys []domain.SigningKey
+	if err := r.db.WithContext(ctx).
+		Where("is_active = ? OR retired_at > ?", true, retiredAfter).
+		Order("created_at DESC").
+		Find(&signingKeys).Error; err != nil {
+		return nil, domain.ErrDataBaseInternalError
+	}
+	return signingKeys, nil
+}
+
+// Activate retires the current active key and stores the new one as active, atomically
+func (r *signingKeyRepository) Activate(ctx context.Context, signingKey *domain.SigningKey, at time.Time) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.SigningKey{}).
+			Where("is_active = ?", true).
+			Updates(map[string]interface{}{"is_active": false, "retired_at": at}).Error; err != nil {
+			return err
+		}
+		signingKey.IsActive = true
+		return tx.Create(signingKey).Error
+	})
+	if err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"gorm.io/gorm"
+)
+
+type ssoLoginStateRepository struct {
+	db *gorm.DB
+}
+
+func NewSSOLoginStateRepository(db *gorm.DB) domain.SSOLoginStateRepository {
+	return &ssoLoginStateRepository{
+		db: db,
+	}
+}
+
+// Create inserts a new login state
+func (r *ssoLoginStateRepository) Create(ctx context.Context, state *domain.SSOLoginState) error {
+	if err := r.db.WithContext(ctx).Create(state).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// Consume marks an unused, unexpired state as used. The conditional update makes
+// sure two callbacks racing with the same state cannot both succeed.
+func (r *ssoLoginStateRepository) Consume(ctx context.Context, stateHash string, at time.Time) (domain.SSOLoginState, error) {
+	var state domain.SSOLoginState
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("state_hash = ?", stateHash).First(&state).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&domain.SSOLoginState{}).
+			Where("id = ? AND used_at IS NULL AND expires_at > ?", state.ID, at).
+			Update("used_at", at)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrInvalidSSOState
+		}
+		state.UsedAt = &at
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, domain.ErrInvalidSSOState) {
+			return state, domain.ErrInvalidSSOState
+		}
+		return state, domain.ErrDataBaseInternalError
+	}
+	return state, nil
+}
+
+// DeleteExpired hard-deletes the states expired before the given time
+func (r *ssoLoginStateRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Unscoped().Where("expires_at < ?", before).Delete(&domain.SSOLoginState{})
+	if result.Error != nil {
+		return 0, domain.ErrDataBaseInternalError
+	}
+	return result.RowsAffected, nil
+}
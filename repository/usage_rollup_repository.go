@@ -0,0 +1,290 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type usageRollupRepository struct {
+	db *gorm.DB
+}
+
+func NewUsageRollupRepository(db *gorm.DB) domain.UsageRollupRepository {
+	return &usageRollupRepository{
+		db: db,
+	}
+}
+
+// usageKey identifies a rollup; the bucket is kept as a Unix time so equal instants match
+type usageKey struct {
+	bucket         int64
+	organizationID uint
+	serviceID      uint
+	userID         uint
+}
+
+type usageSums struct {
+	sessions int64
+	duration time.Duration
+}
+
+// usageSession is a usage log as summed into the rollups
+type usageSession struct {
+	UserID         uint
+	ServiceID      uint
+	OrganizationID uint
+	StartedAt      time.Time
+	Duration       time.Duration
+}
+
+func (r *usageRollupRepository) AddSession(ctx context.Context, userID uint, serviceID uint, startedAt time.Time) error {
+	return r.add(ctx, userID, serviceID, startedAt, map[string]interface{}{
+		"sessions": gorm.Expr("sessions + 1"),
+	})
+}
+
+func (r *usageRollupRepository) AddUsage(ctx context.Context, userID uint, serviceID uint, startedAt time.Time, duration time.Duration) error {
+	return r.add(ctx, userID, serviceID, startedAt, map[string]interface{}{
+		"duration": gorm.Expr("duration + ?", int64(duration)),
+	})
+}
+
+// Reconcile sums the usage logs again and replaces the rollups of their buckets in a
+// transaction. Sessions credited while it runs may be missed until the next run.
+func (r *usageRollupRepository) Reconcile(ctx context.Context, since time.Time) (int, error) {
+	if !since.IsZero() {
+		since = usageBucket(since, domain.UsageGranularityDay)
+	}
+
+	hourly := make(map[usageKey]*usageSums)
+	daily := make(map[usageKey]*usageSums)
+	err := eachUsageSession(r.db.WithContext(ctx).Where("user_service_logs.started_at >= ?", since), func(session usageSession) {
+		sumSession(hourly, domain.UsageGranularityHour, session)
+		sumSession(daily, domain.UsageGranularityDay, session)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	hourlyRollups := make([]domain.UsageHourlyRollup, 0, len(hourly))
+	for key, sums := range hourly {
+		hourlyRollups = append(hourlyRollups, domain.UsageHourlyRollup{
+			BucketStart:    time.Unix(key.bucket, 0).UTC(),
+			OrganizationID: key.organizationID,
+			ServiceID:      key.serviceID,
+			UserID:         key.userID,
+			Sessions:       sums.sessions,
+			Duration:       sums.duration,
+		})
+	}
+	dailyRollups := make([]domain.UsageDailyRollup, 0, len(daily))
+	for key, sums := range daily {
+		dailyRollups = append(dailyRollups, domain.UsageDailyRollup{
+			BucketStart:    time.Unix(key.bucket, 0).UTC(),
+			OrganizationID: key.organizationID,
+			ServiceID:      key.serviceID,
+			UserID:         key.userID,
+			Sessions:       sums.sessions,
+			Duration:       sums.duration,
+		})
+	}
+
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("bucket_start >= ?", since).Delete(&domain.UsageHourlyRollup{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("bucket_start >= ?", since).Delete(&domain.UsageDailyRollup{}).Error; err != nil {
+			return err
+		}
+		if len(hourlyRollups) > 0 {
+			if err := tx.CreateInBatches(&hourlyRollups, 500).Error; err != nil {
+				return err
+			}
+		}
+		if len(dailyRollups) > 0 {
+			if err := tx.CreateInBatches(&dailyRollups, 500).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, domain.ErrDataBaseInternalError
+	}
+	return len(hourlyRollups) + len(dailyRollups), nil
+}
+
+func (r *usageRollupRepository) FetchTotals(ctx context.Context, granularity string, organizationID *uint, from time.Time, to time.Time) ([]domain.UsageTotal, []domain.UsageTotal, error) {
+	query := func() *gorm.DB {
+		var model interface{} = &domain.UsageDailyRollup{}
+		if granularity == domain.UsageGranularityHour {
+			model = &domain.UsageHourlyRollup{}
+		}
+		query := r.db.WithContext(ctx).Model(model)
+		if organizationID != nil {
+			query = query.Where("organization_id = ?", *organizationID)
+		}
+		if !from.IsZero() {
+			query = query.Where("bucket_start >= ?", from.UTC())
+		}
+		if !to.IsZero() {
+			query = query.Where("bucket_start < ?", to.UTC())
+		}
+		return query
+	}
+
+	var byUser []domain.UsageTotal
+	if err := query().
+		Select("service_id, user_id, SUM(sessions) AS sessions, SUM(duration) AS duration").
+		Group("service_id, user_id").
+		Order("service_id, user_id").
+		Scan(&byUser).Error; err != nil {
+		return nil, nil, domain.ErrDataBaseInternalError
+	}
+
+	var byBucket []domain.UsageTotal
+	if err := query().
+		Select("bucket_start, service_id, SUM(sessions) AS sessions, SUM(duration) AS duration").
+		Group("bucket_start, service_id").
+		Order("bucket_start, service_id").
+		Scan(&byBucket).Error; err != nil {
+		return nil, nil, domain.ErrDataBaseInternalError
+	}
+
+	if err := nameServices(r.db.WithContext(ctx), byUser, byBucket); err != nil {
+		return nil, nil, err
+	}
+	return byUser, byBucket, nil
+}
+
+// add applies updates to the hourly and the daily rollups of a session, creating them first
+func (r *usageRollupRepository) add(ctx context.Context, userID uint, serviceID uint, startedAt time.Time, updates map[string]interface{}) error {
+	var organizationIDs []uint
+	if err := r.db.WithContext(ctx).Model(&domain.User{}).Where("id = ?", userID).Pluck("organization_id", &organizationIDs).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	if len(organizationIDs) == 0 {
+		return domain.ErrNotFound
+	}
+
+	hourly := domain.UsageHourlyRollup{
+		BucketStart:    usageBucket(startedAt, domain.UsageGranularityHour),
+		OrganizationID: organizationIDs[0],
+		ServiceID:      serviceID,
+		UserID:         userID,
+	}
+	daily := domain.UsageDailyRollup{
+		BucketStart:    usageBucket(startedAt, domain.UsageGranularityDay),
+		OrganizationID: organizationIDs[0],
+		ServiceID:      serviceID,
+		UserID:         userID,
+	}
+	for _, rollup := range []interface{}{&hourly, &daily} {
+		if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(rollup).Error; err != nil {
+			return domain.ErrDataBaseInternalError
+		}
+	}
+
+	if err := r.db.WithContext(ctx).
+		Model(&domain.UsageHourlyRollup{}).
+		Where("bucket_start = ? AND organization_id = ? AND service_id = ? AND user_id = ?", hourly.BucketStart, hourly.OrganizationID, serviceID, userID).
+		Updates(updates).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	if err := r.db.WithContext(ctx).
+		Model(&domain.UsageDailyRollup{}).
+		Where("bucket_start = ? AND organization_id = ? AND service_id = ? AND user_id = ?", daily.BucketStart, daily.OrganizationID, serviceID, userID).
+		Updates(updates).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// usageBucket is the start of the UTC hour or day of t
+func usageBucket(t time.Time, granularity string) time.Time {
+	t = t.UTC()
+	if granularity == domain.UsageGranularityHour {
+		return t.Truncate(time.Hour)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// sumSession adds a session to the sums of its bucket
+func sumSession(sums map[usageKey]*usageSums, granularity string, session usageSession) {
+	addSession(sums, usageKey{
+		bucket:         usageBucket(session.StartedAt, granularity).Unix(),
+		organizationID: session.OrganizationID,
+		serviceID:      session.ServiceID,
+		userID:         session.UserID,
+	}, session)
+}
+
+func addSession(sums map[usageKey]*usageSums, key usageKey, session usageSession) {
+	if sums[key] == nil {
+		sums[key] = &usageSums{}
+	}
+	sums[key].sessions++
+	sums[key].duration += session.Duration
+}
+
+// eachUsageSession streams the usage logs selected by query with the organization of their
+// user, archived users included
+func eachUsageSession(query *gorm.DB, handle func(session usageSession)) error {
+	rows, err := query.
+		Model(&domain.UserServiceLog{}).
+		Select("user_service_logs.user_id, user_service_logs.service_id, users.organization_id, user_service_logs.started_at, user_service_logs.duration").
+		Joins("JOIN users ON users.id = user_service_logs.user_id").
+		Rows()
+	if err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var session usageSession
+		if err := query.ScanRows(rows, &session); err != nil {
+			return domain.ErrDataBaseInternalError
+		}
+		handle(session)
+	}
+	if rows.Err() != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// nameServices fills the service names of the totals, archived services included
+func nameServices(db *gorm.DB, totals ...[]domain.UsageTotal) error {
+	var serviceIDs []uint
+	seen := make(map[uint]bool)
+	for _, list := range totals {
+		for _, total := range list {
+			if !seen[total.ServiceID] {
+				seen[total.ServiceID] = true
+				serviceIDs = append(serviceIDs, total.ServiceID)
+			}
+		}
+	}
+	if len(serviceIDs) == 0 {
+		return nil
+	}
+
+	var services []domain.Service
+	if err := db.Unscoped().Select("id, name").Where("id IN ?", serviceIDs).Find(&services).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	names := make(map[uint]string, len(services))
+	for _, service := range services {
+		names[service.ID] = service.Name
+	}
+	for _, list := range totals {
+		for i := range list {
+			list[i].ServiceName = names[list[i].ServiceID]
+		}
+	}
+	return nil
+}
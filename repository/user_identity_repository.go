@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"gorm.io/gorm"
+)
+
+type userIdentityRepository struct {
+	db *gorm.DB
+}
+
+func NewUserIdentityRepository(db *gorm.DB) domain.UserIdentityRepository {
+	return &userIdentityRepository{
+		db: db,
+	}
+}
+
+// GetBySubject returns the identity of a provider subject
+func (r *userIdentityRepository) GetBySubject(ctx context.Context, identityProviderID uint, subject string) (domain.UserIdentity, error) {
+	var identity domain.UserIdentity
+	if err := r.db.WithContext(ctx).
+		Where("identity_provider_id = ? AND subject = ?", identityProviderID, subject).
+		First(&identity).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return identity, domain.ErrNotFound
+		}
+		return identity, domain.ErrDataBaseInternalError
+	}
+	return identity, nil
+}
+
+// Create links an existing user to a provider subject
+func (r *userIdentityRepository) Create(ctx context.Context, identity *domain.UserIdentity) error {
+	if err := r.db.WithContext(ctx).Omit("User").Create(identity).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// CreateWithUser inserts a provisioned user and its identity in one transaction
+func (r *userIdentityRepository) CreateWithUser(ctx context.Context, user *domain.User, identity *domain.UserIdentity) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		identity.UserID = user.ID
+		return tx.Omit("User").Create(identity).Error
+	})
+	if err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type userMetricsRepository struct {
+	db *gorm.DB
+}
+
+func NewUserMetricsRepository(db *gorm.DB) domain.UserMetricsRepository {
+	return &userMetricsRepository{
+		db: db,
+	}
+}
+
+func (r *userMetricsRepository) GetByUserID(ctx context.Context, userID uint) (domain.UserMetrics, error) {
+	var metrics domain.UserMetrics
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&metrics).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.UserMetrics{UserID: userID}, nil
+	}
+	if err != nil {
+		return metrics, domain.ErrDataBaseInternalError
+	}
+	return metrics, nil
+}
+
+// AddLogin counts a login and keeps it as the last one
+func (r *userMetricsRepository) AddLogin(ctx context.Context, userID uint, ipAddress string, at time.Time) error {
+	if err := r.ensure(ctx, userID); err != nil {
+		return err
+	}
+	if err := r.db.WithContext(ctx).
+		Model(&domain.UserMetrics{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]interface{}{
+			"total_logins": gorm.Expr("total_logins + 1"),
+			"last_ip":      ipAddress,
+			"last_login":   at,
+		}).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// AddUsage credits usage to the user. The favorite service is elected again from the
+// daily usage rollups, a row per day and service used, which already hold the credit.
+func (r *userMetricsRepository) AddUsage(ctx context.Context, userID uint, duration time.Duration) error {
+	if err := r.ensure(ctx, userID); err != nil {
+		return err
+	}
+
+	var favorite []uint
+	if err := r.db.WithContext(ctx).
+		Model(&domain.UsageDailyRollup{}).
+		Where("user_id = ?", userID).
+		Group("service_id").
+		Order("SUM(duration) DESC, service_id").
+		Limit(1).
+		Pluck("service_id", &favorite).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+
+	updates := map[string]interface{}{
+		"total_usage_duration": gorm.Expr("total_usage_duration + ?", int64(duration)),
+	}
+	if len(favorite) == 1 {
+		updates["favorite_service_id"] = favorite[0]
+	}
+	if err := r.db.WithContext(ctx).
+		Model(&domain.UserMetrics{}).
+		Where("user_id = ?", userID).
+		Updates(updates).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// Recompute rebuilds the metrics of every user with a handful of grouped queries and
+// overwrites the stored rows. Events recorded while it runs may be counted twice or
+// missed until the next run.
+func (r *userMetricsRepository) Recompute(ctx context.Context) (int, error) {
+	var userIDs []uint
+	if err := r.db.WithContext(ctx).Model(&domain.User{}).Order("id").Pluck("id", &userIDs).Error; err != nil {
+		return 0, domain.ErrDataBaseInternalError
+	}
+
+	metrics := make(map[uint]*domain.UserMetrics, len(userIDs))
+	for _, userID := range userIDs {
+		metrics[userID] = &domain.UserMetrics{UserID: userID}
+	}
+
+	// logins, the last one being the latest log
+	var logins []struct {
+		UserID uint
+		Total  int
+		LastID uint
+	}
+	if err := r.db.WithContext(ctx).
+		Model(&domain.UserLog{}).
+		Select("user_id, COUNT(*) AS total, MAX(id) AS last_id").
+		Where("action = ?", "login").
+		Group("user_id").
+		Scan(&logins).Error; err != nil {
+		return 0, domain.ErrDataBaseInternalError
+	}
+	lastLoginIDs := make([]uint, 0, len(logins))
+	for _, login := range logins {
+		if m, ok := metrics[login.UserID]; ok {
+			m.TotalLogins = login.Total
+			lastLoginIDs = append(lastLoginIDs, login.LastID)
+		}
+	}
+	for _, ids := range chunk(lastLoginIDs, 500) {
+		var lastLogins []domain.UserLog
+		if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&lastLogins).Error; err != nil {
+			return 0, domain.ErrDataBaseInternalError
+		}
+		for _, login := range lastLogins {
+			createdAt := login.CreatedAt
+			metrics[login.UserID].LastLogin = &createdAt
+			metrics[login.UserID].LastIP = login.IPAddress
+		}
+	}
+
+	// usage per service, the favorite being the most used (the lowest ID on a tie)
+	var usages []struct {
+		UserID    uint
+		ServiceID uint
+		Total     int64
+	}
+	if err := r.db.WithContext(ctx).
+		Model(&domain.UserServiceLog{}).
+		Select("user_id, service_id, SUM(duration) AS total").
+		Group("user_id, service_id").
+		Order("user_id, service_id").
+		Scan(&usages).Error; err != nil {
+		return 0, domain.ErrDataBaseInternalError
+	}
+	favoriteUsage := make(map[uint]time.Duration)
+	for _, usage := range usages {
+		m, ok := metrics[usage.UserID]
+		if !ok {
+			continue
+		}
+		total := time.Duration(usage.Total)
+		m.TotalUsageDuration += total
+		if m.FavoriteServiceID == 0 || total > favoriteUsage[usage.UserID] {
+			m.FavoriteServiceID = usage.ServiceID
+			favoriteUsage[usage.UserID] = total
+		}
+	}
+
+	rows := make([]domain.UserMetrics, 0, len(userIDs))
+	for _, userID := range userIDs {
+		rows = append(rows, *metrics[userID])
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"updated_at", "favorite_service_id", "last_ip", "last_login", "total_logins", "total_usage_duration"}),
+		}).
+		CreateInBatches(&rows, 500).Error; err != nil {
+		return 0, domain.ErrDataBaseInternalError
+	}
+	return len(rows), nil
+}
+
+// ensure creates the metrics of the user on its first event
+func (r *userMetricsRepository) ensure(ctx context.Context, userID uint) error {
+	metrics := domain.UserMetrics{UserID: userID}
+	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&metrics).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// chunk splits ids so IN lists stay within the limits of the database
+func chunk(ids []uint, size int) [][]uint {
+	var chunks [][]uint
+	for len(ids) > size {
+		chunks = append(chunks, ids[:size])
+		ids = ids[size:]
+	}
+	if len(ids) > 0 {
+		chunks = append(chunks, ids)
+	}
+	return chunks
+}
@@ -65,7 +65,7 @@ func (r *userRepository) GetByID(ctx context.Context, id uint) (domain.User, err
 	var user domain.User
 	if err := r.db.WithContext(ctx).
 		Preload("Role").
-		Preload("Organization").
+		Preload("Organization.Subscription").
 		First(&user, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return user, domain.ErrNotFound
@@ -87,6 +87,35 @@ func (r *userRepository) Update(ctx context.Context, userID uint, userData *doma
 	return nil
 }
 
+// UpdatePassword sets the password hash and the forced change flag of a user
+func (r *userRepository) UpdatePassword(ctx context.Context, userID uint, hashedPassword string, mustChangePassword bool) error {
+	if err := r.db.WithContext(ctx).
+		Model(&domain.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"password":             hashedPassword,
+			"must_change_password": mustChangePassword,
+		}).Error; err != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// RequirePasswordChange makes the user change their password on the next login
+func (r *userRepository) RequirePasswordChange(ctx context.Context, userID uint) error {
+	result := r.db.WithContext(ctx).
+		Model(&domain.User{}).
+		Where("id = ?", userID).
+		Update("must_change_password", true)
+	if result.Error != nil {
+		return domain.ErrDataBaseInternalError
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
 // Archive marca um usuário como arquivado (soft delete)
 func (r *userRepository) Archive(ctx context.Context, userID uint) error {
 	// Alterando apenas o campo IsArchived
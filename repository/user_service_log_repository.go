@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/gabrielfmcoelho/platform-core/domain"
 	"gorm.io/gorm"
@@ -72,155 +73,119 @@ func (r *userServiceLogRepository) GetByServiceID(ctx context.Context, serviceID
 	return log, nil
 }
 
-// Update updates an existing UserServiceLog, add duration to the existing duration
-// duration parameter is in seconds, but we store as nanoseconds (time.Duration)
-func (r *userServiceLogRepository) UpdateDuration(ctx context.Context, userServiceLogID uint, duration int) error {
-	// Convert seconds to nanoseconds (time.Duration stores nanoseconds)
-	durationNanoseconds := int64(duration) * 1000000000
-	if err := r.db.WithContext(ctx).Model(&domain.UserServiceLog{}).
-		Where("id = ?", userServiceLogID).
-		Update("duration", gorm.Expr("duration + ?", durationNanoseconds)).Error; err != nil {
-		return domain.ErrDataBaseInternalError
+// RecordHeartbeat credits the usage since the previous heartbeat of an open session.
+// The update is conditioned on the previous heartbeat so two concurrent heartbeats
+// cannot credit the same interval twice.
+func (r *userServiceLogRepository) RecordHeartbeat(ctx context.Context, userServiceLogID uint, previous time.Time, at time.Time, credit time.Duration) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&domain.UserServiceLog{}).
+		Where("id = ? AND ended_at IS NULL AND last_heartbeat_at = ?", userServiceLogID, previous).
+		Updates(map[string]interface{}{
+			"duration":          gorm.Expr("duration + ?", int64(credit)),
+			"last_heartbeat_at": at,
+		})
+	if result.Error != nil {
+		return false, domain.ErrDataBaseInternalError
 	}
-	return nil
+	return result.RowsAffected == 1, nil
 }
 
-// Delete removes a UserServiceLog by its ID (hard delete)
-func (r *userServiceLogRepository) Delete(ctx context.Context, userServiceLogID uint) error {
-	if err := r.db.WithContext(ctx).Delete(&domain.UserServiceLog{}, userServiceLogID).Error; err != nil {
+// End closes an open session, crediting its last interval
+func (r *userServiceLogRepository) End(ctx context.Context, userServiceLogID uint, endedAt time.Time, credit time.Duration, reason string) error {
+	result := r.db.WithContext(ctx).Model(&domain.UserServiceLog{}).
+		Where("id = ? AND ended_at IS NULL", userServiceLogID).
+		Updates(map[string]interface{}{
+			"duration":          gorm.Expr("duration + ?", int64(credit)),
+			"last_heartbeat_at": endedAt,
+			"ended_at":          endedAt,
+			"end_reason":        reason,
+		})
+	if result.Error != nil {
 		return domain.ErrDataBaseInternalError
 	}
+	if result.RowsAffected == 0 {
+		return domain.ErrUsageSessionEnded
+	}
 	return nil
 }
 
-// GetUsageStatistics returns aggregated usage statistics for admin dashboard
-func (r *userServiceLogRepository) GetUsageStatistics(ctx context.Context, organizationID *uint, startDate *string, endDate *string) (domain.UsageStatistics, error) {
-	var stats domain.UsageStatistics
-
-	// Build base query with optional filters
-	baseQuery := r.db.WithContext(ctx).Model(&domain.UserServiceLog{})
-
-	// Apply organization filter if provided
-	if organizationID != nil {
-		baseQuery = baseQuery.
-			Joins("INNER JOIN users ON users.id = user_service_logs.user_id").
-			Where("users.organization_id = ?", *organizationID)
-	}
+// EndBySessionID closes the open sessions opened from a login session at their last heartbeat
+func (r *userServiceLogRepository) EndBySessionID(ctx context.Context, sessionID uint, reason string) (int64, error) {
+	return r.endOpen(ctx, r.db.Where("session_id = ?", sessionID), reason)
+}
 
-	// Apply date range filter if provided
-	if startDate != nil && *startDate != "" {
-		baseQuery = baseQuery.Where("user_service_logs.created_at >= ?", *startDate)
-	}
-	if endDate != nil && *endDate != "" {
-		baseQuery = baseQuery.Where("user_service_logs.created_at <= ?", *endDate)
-	}
+// EndByUserID closes the open sessions of a user at their last heartbeat
+func (r *userServiceLogRepository) EndByUserID(ctx context.Context, userID uint, reason string) (int64, error) {
+	return r.endOpen(ctx, r.db.Where("user_id = ?", userID), reason)
+}
 
-	// Get total unique users (with activity)
-	var totalUsers int64
-	if err := baseQuery.
-		Distinct("user_service_logs.user_id").
-		Count(&totalUsers).Error; err != nil {
-		return stats, domain.ErrDataBaseInternalError
-	}
-	stats.TotalUsers = int(totalUsers)
+// EndStale closes the open sessions without heartbeat since before; the time after
+// their last heartbeat is not counted
+func (r *userServiceLogRepository) EndStale(ctx context.Context, before time.Time) (int64, error) {
+	return r.endOpen(ctx, r.db.Where("last_heartbeat_at < ?", before), domain.UsageEndReasonTimeout)
+}
 
-	// Get total organization users (if organization filter is applied)
-	if organizationID != nil {
-		var totalOrgUsers int64
-		if err := r.db.WithContext(ctx).
-			Model(&domain.User{}).
-			Where("organization_id = ?", *organizationID).
-			Count(&totalOrgUsers).Error; err != nil {
-			return stats, domain.ErrDataBaseInternalError
-		}
-		stats.TotalOrgUsers = int(totalOrgUsers)
-	} else {
-		stats.TotalOrgUsers = 0
+func (r *userServiceLogRepository) endOpen(ctx context.Context, scope *gorm.DB, reason string) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&domain.UserServiceLog{}).
+		Where(scope).
+		Where("ended_at IS NULL").
+		Updates(map[string]interface{}{
+			"ended_at":   gorm.Expr("last_heartbeat_at"),
+			"end_reason": reason,
+		})
+	if result.Error != nil {
+		return 0, domain.ErrDataBaseInternalError
 	}
+	return result.RowsAffected, nil
+}
 
-	// Get total duration (sum of all durations in nanoseconds, convert to seconds)
-	type TotalDurationResult struct {
-		TotalNanoseconds int64
+// Delete removes a UserServiceLog by its ID (hard delete)
+func (r *userServiceLogRepository) Delete(ctx context.Context, userServiceLogID uint) error {
+	if err := r.db.WithContext(ctx).Delete(&domain.UserServiceLog{}, userServiceLogID).Error; err != nil {
+		return domain.ErrDataBaseInternalError
 	}
-	var durationResult TotalDurationResult
+	return nil
+}
 
-	// Rebuild query for duration with same filters
-	durationQuery := r.db.WithContext(ctx).Model(&domain.UserServiceLog{})
+// FetchUsageTotals sums the usage sessions started within [from, to) by service and user
+// and by service and hour (UTC), like the rollups; zero bounds are open
+func (r *userServiceLogRepository) FetchUsageTotals(ctx context.Context, organizationID *uint, from time.Time, to time.Time) ([]domain.UsageTotal, []domain.UsageTotal, error) {
+	query := r.db.WithContext(ctx).Where("user_service_logs.deleted_at IS NULL")
 	if organizationID != nil {
-		durationQuery = durationQuery.
-			Joins("INNER JOIN users ON users.id = user_service_logs.user_id").
-			Where("users.organization_id = ?", *organizationID)
+		query = query.Where("users.organization_id = ?", *organizationID)
 	}
-	if startDate != nil && *startDate != "" {
-		durationQuery = durationQuery.Where("user_service_logs.created_at >= ?", *startDate)
+	if !from.IsZero() {
+		query = query.Where("user_service_logs.started_at >= ?", from.UTC())
 	}
-	if endDate != nil && *endDate != "" {
-		durationQuery = durationQuery.Where("user_service_logs.created_at <= ?", *endDate)
+	if !to.IsZero() {
+		query = query.Where("user_service_logs.started_at < ?", to.UTC())
 	}
 
-	if err := durationQuery.
-		Select("COALESCE(SUM(user_service_logs.duration), 0) as total_nanoseconds").
-		Scan(&durationResult).Error; err != nil {
-		return stats, domain.ErrDataBaseInternalError
+	byUserSums := make(map[usageKey]*usageSums)
+	byHourSums := make(map[usageKey]*usageSums)
+	if err := eachUsageSession(query, func(session usageSession) {
+		addSession(byUserSums, usageKey{serviceID: session.ServiceID, userID: session.UserID}, session)
+		addSession(byHourSums, usageKey{bucket: usageBucket(session.StartedAt, domain.UsageGranularityHour).Unix(), serviceID: session.ServiceID}, session)
+	}); err != nil {
+		return nil, nil, err
 	}
-	stats.TotalDuration = int(durationResult.TotalNanoseconds / 1000000000) // Convert nanoseconds to seconds
 
-	// Get per-service statistics
-	type ServiceStatsRow struct {
-		ServiceID        uint
-		ServiceName      string
-		TotalUsers       int
-		TotalNanoseconds int64
+	byUser := make([]domain.UsageTotal, 0, len(byUserSums))
+	for key, sums := range byUserSums {
+		byUser = append(byUser, domain.UsageTotal{ServiceID: key.serviceID, UserID: key.userID, Sessions: sums.sessions, Duration: sums.duration})
 	}
-	var serviceRows []ServiceStatsRow
-
-	// Build service stats query with filters
-	serviceStatsQuery := r.db.WithContext(ctx).
-		Table("user_service_logs").
-		Select(`
-			user_service_logs.service_id,
-			services.name as service_name,
-			COUNT(DISTINCT user_service_logs.user_id) as total_users,
-			COALESCE(SUM(user_service_logs.duration), 0) as total_nanoseconds
-		`).
-		Joins("LEFT JOIN services ON services.id = user_service_logs.service_id").
-		Where("user_service_logs.deleted_at IS NULL")
-
-	if organizationID != nil {
-		serviceStatsQuery = serviceStatsQuery.
-			Joins("INNER JOIN users ON users.id = user_service_logs.user_id").
-			Where("users.organization_id = ?", *organizationID)
-	}
-	if startDate != nil && *startDate != "" {
-		serviceStatsQuery = serviceStatsQuery.Where("user_service_logs.created_at >= ?", *startDate)
-	}
-	if endDate != nil && *endDate != "" {
-		serviceStatsQuery = serviceStatsQuery.Where("user_service_logs.created_at <= ?", *endDate)
+	byHour := make([]domain.UsageTotal, 0, len(byHourSums))
+	for key, sums := range byHourSums {
+		byHour = append(byHour, domain.UsageTotal{BucketStart: time.Unix(key.bucket, 0).UTC(), ServiceID: key.serviceID, Sessions: sums.sessions, Duration: sums.duration})
 	}
 
-	if err := serviceStatsQuery.
-		Group("user_service_logs.service_id, services.name").
-		Scan(&serviceRows).Error; err != nil {
-		return stats, domain.ErrDataBaseInternalError
-	}
-
-	stats.ServiceStats = make([]domain.ServiceUsageStats, 0)
-	for _, row := range serviceRows {
-		totalSeconds := int(row.TotalNanoseconds / 1000000000) // Convert nanoseconds to seconds
-		avgDuration := 0.0
-		if row.TotalUsers > 0 {
-			avgDuration = float64(totalSeconds) / float64(row.TotalUsers)
-		}
-		stats.ServiceStats = append(stats.ServiceStats, domain.ServiceUsageStats{
-			ServiceID:    row.ServiceID,
-			ServiceName:  row.ServiceName,
-			TotalUsers:   row.TotalUsers,
-			TotalSeconds: totalSeconds,
-			AvgDuration:  avgDuration,
-		})
+	if err := nameServices(r.db.WithContext(ctx), byUser, byHour); err != nil {
+		return nil, nil, err
 	}
+	return byUser, byHour, nil
+}
 
-	// Get recent activity (last 10 entries)
+// FetchRecentActivity returns the latest usage sessions started within [from, to); zero bounds are open
+func (r *userServiceLogRepository) FetchRecentActivity(ctx context.Context, organizationID *uint, from time.Time, to time.Time, limit int) ([]domain.RecentActivityItem, error) {
 	type RecentActivityRow struct {
 		ID          uint
 		UserID      uint
@@ -228,11 +193,10 @@ func (r *userServiceLogRepository) GetUsageStatistics(ctx context.Context, organ
 		ServiceID   uint
 		ServiceName string
 		Duration    int64
-		CreatedAt   string
+		StartedAt   time.Time
 	}
 	var activityRows []RecentActivityRow
 
-	// Build recent activity query with filters
 	activityQuery := r.db.WithContext(ctx).
 		Table("user_service_logs").
 		Select(`
@@ -241,8 +205,8 @@ func (r *userServiceLogRepository) GetUsageStatistics(ctx context.Context, organ
 			users.email as user_email,
 			user_service_logs.service_id,
 			services.name as service_name,
-			user_service_logs.duration / 1000000000 as duration,
-			user_service_logs.created_at
+			user_service_logs.duration,
+			user_service_logs.started_at
 		`).
 		Joins("LEFT JOIN users ON users.id = user_service_logs.user_id").
 		Joins("LEFT JOIN services ON services.id = user_service_logs.service_id").
@@ -251,93 +215,43 @@ func (r *userServiceLogRepository) GetUsageStatistics(ctx context.Context, organ
 	if organizationID != nil {
 		activityQuery = activityQuery.Where("users.organization_id = ?", *organizationID)
 	}
-	if startDate != nil && *startDate != "" {
-		activityQuery = activityQuery.Where("user_service_logs.created_at >= ?", *startDate)
+	if !from.IsZero() {
+		activityQuery = activityQuery.Where("user_service_logs.started_at >= ?", from.UTC())
 	}
-	if endDate != nil && *endDate != "" {
-		activityQuery = activityQuery.Where("user_service_logs.created_at <= ?", *endDate)
+	if !to.IsZero() {
+		activityQuery = activityQuery.Where("user_service_logs.started_at < ?", to.UTC())
 	}
 
 	if err := activityQuery.
-		Order("user_service_logs.created_at DESC").
-		Limit(10).
+		Order("user_service_logs.started_at DESC, user_service_logs.id DESC").
+		Limit(limit).
 		Scan(&activityRows).Error; err != nil {
-		return stats, domain.ErrDataBaseInternalError
+		return nil, domain.ErrDataBaseInternalError
 	}
 
-	stats.RecentActivity = make([]domain.RecentActivityItem, 0)
+	activity := make([]domain.RecentActivityItem, 0, len(activityRows))
 	for _, row := range activityRows {
-		stats.RecentActivity = append(stats.RecentActivity, domain.RecentActivityItem{
+		activity = append(activity, domain.RecentActivityItem{
 			ID:          row.ID,
 			UserID:      row.UserID,
 			UserEmail:   row.UserEmail,
 			ServiceID:   row.ServiceID,
 			ServiceName: row.ServiceName,
-			Duration:    int(row.Duration),
-			CreatedAt:   row.CreatedAt,
+			Duration:    int(time.Duration(row.Duration).Seconds()),
+			CreatedAt:   row.StartedAt.Format("2006-01-02 15:04:05"),
 		})
 	}
+	return activity, nil
+}
 
-	// Get time-series data (usage by day and service)
-	type TimeSeriesRow struct {
-		Date         string
-		ServiceName  string
-		TotalSeconds int64
-		AccessCount  int
-	}
-	var timeSeriesRows []TimeSeriesRow
-
-	// Build time-series query with filters
-	timeSeriesQuery := r.db.WithContext(ctx).
-		Table("user_service_logs").
-		Select(`
-			DATE(user_service_logs.created_at) as date,
-			services.name as service_name,
-			FLOOR(COALESCE(SUM(user_service_logs.duration), 0) / 1000000000) as total_seconds,
-			COUNT(*) as access_count
-		`).
-		Joins("LEFT JOIN services ON services.id = user_service_logs.service_id").
-		Where("user_service_logs.deleted_at IS NULL")
-
-	if organizationID != nil {
-		timeSeriesQuery = timeSeriesQuery.
-			Joins("INNER JOIN users ON users.id = user_service_logs.user_id").
-			Where("users.organization_id = ?", *organizationID)
-	}
-	if startDate != nil && *startDate != "" {
-		timeSeriesQuery = timeSeriesQuery.Where("user_service_logs.created_at >= ?", *startDate)
-	}
-	if endDate != nil && *endDate != "" {
-		timeSeriesQuery = timeSeriesQuery.Where("user_service_logs.created_at <= ?", *endDate)
-	}
-
-	if err := timeSeriesQuery.
-		Group("DATE(user_service_logs.created_at), services.name").
-		Order("date ASC").
-		Scan(&timeSeriesRows).Error; err != nil {
-		return stats, domain.ErrDataBaseInternalError
-	}
-
-	// Transform rows into time-series data structure
-	dateMap := make(map[string]map[string]domain.TimeSeriesService)
-	for _, row := range timeSeriesRows {
-		if _, exists := dateMap[row.Date]; !exists {
-			dateMap[row.Date] = make(map[string]domain.TimeSeriesService)
-		}
-		dateMap[row.Date][row.ServiceName] = domain.TimeSeriesService{
-			TotalSeconds: int(row.TotalSeconds),
-			AccessCount:  row.AccessCount,
-		}
-	}
-
-	// Convert map to array
-	stats.TimeSeriesData = make([]domain.TimeSeriesDataPoint, 0)
-	for date, services := range dateMap {
-		stats.TimeSeriesData = append(stats.TimeSeriesData, domain.TimeSeriesDataPoint{
-			Date:     date,
-			Services: services,
-		})
+// CountOrganizationUsers counts the users of the organization
+func (r *userServiceLogRepository) CountOrganizationUsers(ctx context.Context, organizationID uint) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).
+		Model(&domain.User{}).
+		Where("organization_id = ?", organizationID).
+		Count(&count).Error; err != nil {
+		return 0, domain.ErrDataBaseInternalError
 	}
-
-	return stats, nil
+	return count, nil
 }
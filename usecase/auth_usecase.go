@@ -3,63 +3,252 @@ package usecase
 import (
 	"context"
 	"errors"
+	"log"
+	"net/url"
 	"time"
 
 	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal"
 	"github.com/gabrielfmcoelho/platform-core/internal/password"
 	"github.com/gabrielfmcoelho/platform-core/internal/tokenutil"
 )
 
 type AuthUsecase struct {
-	userRepository    domain.UserRepository
-	userLogRepository domain.UserLogRepository
-	contextTimeout    time.Duration
+	userRepository         domain.UserRepository
+	userLogRepository      domain.UserLogRepository
+	refreshTokenRepository domain.RefreshTokenRepository
+	sessionRepository      domain.SessionRepository
+	resetTokenRepository   domain.PasswordResetTokenRepository
+	passwordUsecase        domain.PasswordUsecase
+	mailOutboxUsecase      domain.MailOutboxUsecase
+	mfaUsecase             domain.MFAUsecase
+	loginAttemptUsecase    domain.LoginAttemptUsecase
+	guestAccessRepository  domain.GuestAccessRepository
+	guestUserRepository    domain.GuestUserRepository
+	usageLogRepository     domain.UserServiceLogRepository
+	metricsUsecase         domain.MetricsUsecase
+	keyRing                domain.KeyRing
+	contextTimeout         time.Duration
 }
 
-func NewAuthUsecase(userRepository domain.UserRepository, userLogRepository domain.UserLogRepository, timeout time.Duration) *AuthUsecase {
+func NewAuthUsecase(userRepository domain.UserRepository, userLogRepository domain.UserLogRepository, refreshTokenRepository domain.RefreshTokenRepository, sessionRepository domain.SessionRepository, resetTokenRepository domain.PasswordResetTokenRepository, passwordUsecase domain.PasswordUsecase, mailOutboxUsecase domain.MailOutboxUsecase, mfaUsecase domain.MFAUsecase, loginAttemptUsecase domain.LoginAttemptUsecase, guestAccessRepository domain.GuestAccessRepository, guestUserRepository domain.GuestUserRepository, usageLogRepository domain.UserServiceLogRepository, metricsUsecase domain.MetricsUsecase, keyRing domain.KeyRing, timeout time.Duration) *AuthUsecase {
 	return &AuthUsecase{
-		userRepository:    userRepository,
-		userLogRepository: userLogRepository,
-		contextTimeout:    timeout,
+		userRepository:         userRepository,
+		userLogRepository:      userLogRepository,
+		refreshTokenRepository: refreshTokenRepository,
+		sessionRepository:      sessionRepository,
+		resetTokenRepository:   resetTokenRepository,
+		passwordUsecase:        passwordUsecase,
+		mailOutboxUsecase:      mailOutboxUsecase,
+		mfaUsecase:             mfaUsecase,
+		loginAttemptUsecase:    loginAttemptUsecase,
+		guestAccessRepository:  guestAccessRepository,
+		guestUserRepository:    guestUserRepository,
+		usageLogRepository:     usageLogRepository,
+		metricsUsecase:         metricsUsecase,
+		keyRing:                keyRing,
+		contextTimeout:         timeout,
 	}
 }
 
-func (au *AuthUsecase) LoginUserByEmail(c context.Context, email string, rawPassword string, accessSecret string, accessExpiry int, refreshSecret string, refreshExpiry int) (loginResponse *domain.LoginResponse, err error) {
+// LoginUserByEmail checks the password. When the user has MFA enabled, or the organization
+// requires it, no session is created yet: a short-lived challenge token is returned instead
+// and the login is completed by LoginWithMFA. Once every factor is checked, a user who must
+// change their password gets a single-use reset token and nothing else.
+func (au *AuthUsecase) LoginUserByEmail(c context.Context, email string, rawPassword string, client domain.ClientInfo, accessSecret string, accessExpiry int, refreshExpiry int, mfaChallengeExpiry int, resetExpiry int) (loginResponse *domain.LoginResponse, mfaChallenge *domain.MFAChallengeResponse, passwordChange *domain.PasswordChangeChallengeResponse, err error) {
 	ctx, cancel := context.WithTimeout(c, au.contextTimeout) // This creates a new context with a timeout and a cancel function, which should be called at the end of the function to release resources
 	defer cancel()
 
+	// refuse the attempt while the account or the IP is delayed or locked
+	if err := au.loginAttemptUsecase.Check(ctx, email, client); err != nil {
+		return nil, nil, nil, err
+	}
+
 	user, err := au.userRepository.GetByEmail(ctx, email)
-	// if the user is not found, return an error with a message
+	// an unknown e-mail is refused like a wrong password, in the same time
 	if err != nil {
 		if !errors.Is(err, domain.ErrUserEmailNotFound) {
-			return nil, domain.ErrInternalServerError
+			return nil, nil, nil, domain.ErrInternalServerError
 		}
-		return nil, err
+		err = au.passwordUsecase.VerifyUnknown(rawPassword)
+		au.registerLoginFailure(ctx, email, nil, client)
+		return nil, nil, nil, err
+	}
+
+	// verify if the password is match (weak or outdated hashes are dealt with here)
+	err = au.passwordUsecase.Verify(ctx, &user, rawPassword)
+	if err != nil {
+		// LOG INTO USER LOG
+		au.userLogRepository.Create(ctx, &domain.UserLog{
+			UserID:    user.ID,
+			IPAddress: client.IPAddress,
+			Action:    "login_failed",
+		})
+		au.registerLoginFailure(ctx, email, &user, client)
+		return nil, nil, nil, err
+	}
+
+	// second factor, before anything lets the password be changed
+	mfaStatus, err := au.mfaUsecase.GetStatus(ctx, user.ID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if mfaStatus.Enabled || mfaStatus.RequiredByOrganization {
+		enrollment := !mfaStatus.Enabled
+		mfaToken, expiresIn, err := tokenutil.CreateMFAChallengeToken(user.ID, enrollment, accessSecret, mfaChallengeExpiry)
+		if err != nil {
+			return nil, nil, nil, domain.ErrInternalServerError
+		}
+		return nil, &domain.MFAChallengeResponse{
+			MFARequired:        true,
+			EnrollmentRequired: enrollment,
+			MFAToken:           mfaToken,
+			ExpiresIn:          expiresIn,
+		}, nil, nil
 	}
 
-	// verify if the password is match
-	err = password.VerifyPassword(user.Password, rawPassword)
+	if user.MustChangePassword {
+		passwordChange, err = au.requirePasswordChange(ctx, user.ID, client, resetExpiry)
+		return nil, nil, passwordChange, err
+	}
+
+	loginResponse, err = au.completeLogin(ctx, &user, client, accessExpiry, refreshExpiry)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return loginResponse, nil, nil, nil
+}
+
+// requirePasswordChange returns the reset token of a forced password change, the user signs
+// in again with the new password. Only called once every factor of the login is checked.
+func (au *AuthUsecase) requirePasswordChange(ctx context.Context, userID uint, client domain.ClientInfo, resetExpiry int) (*domain.PasswordChangeChallengeResponse, error) {
+	resetToken, err := au.createResetToken(ctx, userID, client, resetExpiry)
 	if err != nil {
 		return nil, err
 	}
 
-	// create access token
-	accessToken, err := au.CreateAccessToken(&user, accessSecret, accessExpiry)
+	// LOG INTO USER LOG
+	au.userLogRepository.Create(ctx, &domain.UserLog{
+		UserID:    userID,
+		IPAddress: client.IPAddress,
+		Action:    "password_change_required",
+	})
+	return &domain.PasswordChangeChallengeResponse{
+		PasswordChangeRequired: true,
+		ResetToken:             resetToken,
+		ExpiresIn:              resetExpiry * 60,
+	}, nil
+}
+
+// LoginWithMFA completes a login started by LoginUserByEmail with a TOTP or recovery code.
+// For an enrollment challenge the code confirms the factor set up by EnrollMFAForLogin. A
+// user who must change their password gets a reset token instead of the session.
+func (au *AuthUsecase) LoginWithMFA(c context.Context, mfaToken string, code string, recoveryCode string, client domain.ClientInfo, accessSecret string, accessExpiry int, refreshExpiry int, resetExpiry int) (loginResponse *domain.MFALoginResponse, passwordChange *domain.PasswordChangeChallengeResponse, err error) {
+	ctx, cancel := context.WithTimeout(c, au.contextTimeout)
+	defer cancel()
+
+	claims, err := tokenutil.ExtractMFAChallengeClaimsFromToken(mfaToken, accessSecret)
+	if err != nil {
+		return nil, nil, domain.ErrInvalidMFAToken
+	}
+	userID, err := internal.ParseUint(claims.Subject)
+	if err != nil {
+		return nil, nil, domain.ErrInvalidMFAToken
+	}
+
+	user, err := au.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return nil, nil, domain.ErrInvalidMFAToken
+	}
+
+	// codes are as guessable as passwords, they share the account counters
+	if err := au.loginAttemptUsecase.Check(ctx, user.Email, client); err != nil {
+		return nil, nil, err
+	}
+
+	var recoveryCodes *domain.MFARecoveryCodes
+	if claims.Enrollment {
+		recoveryCodes, err = au.mfaUsecase.Activate(ctx, user.ID, code)
+	} else {
+		err = au.mfaUsecase.Verify(ctx, user.ID, code, recoveryCode)
+	}
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidMFACode) {
+			au.userLogRepository.Create(ctx, &domain.UserLog{
+				UserID:    user.ID,
+				IPAddress: client.IPAddress,
+				Action:    "mfa_failed",
+			})
+			au.registerLoginFailure(ctx, user.Email, &user, client)
+		}
+		return nil, nil, err
+	}
+
+	if user.MustChangePassword {
+		passwordChange, err = au.requirePasswordChange(ctx, user.ID, client, resetExpiry)
+		if passwordChange != nil && recoveryCodes != nil {
+			passwordChange.RecoveryCodes = recoveryCodes.RecoveryCodes
+		}
+		return nil, passwordChange, err
+	}
+
+	tokens, err := au.completeLogin(ctx, &user, client, accessExpiry, refreshExpiry)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	loginResponse = &domain.MFALoginResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}
+	if recoveryCodes != nil {
+		loginResponse.RecoveryCodes = recoveryCodes.RecoveryCodes
+	}
+	return loginResponse, nil, nil
+}
+
+// EnrollMFAForLogin starts the TOTP enrollment of a user whose organization requires MFA
+func (au *AuthUsecase) EnrollMFAForLogin(c context.Context, mfaToken string, accessSecret string) (enrollment *domain.MFAEnrollment, err error) {
+	claims, err := tokenutil.ExtractMFAChallengeClaimsFromToken(mfaToken, accessSecret)
+	if err != nil || !claims.Enrollment {
+		return nil, domain.ErrInvalidMFAToken
+	}
+	userID, err := internal.ParseUint(claims.Subject)
+	if err != nil {
+		return nil, domain.ErrInvalidMFAToken
+	}
+
+	return au.mfaUsecase.Enroll(c, userID)
+}
+
+// completeLogin issues the tokens of a new session and logs the login
+func (au *AuthUsecase) completeLogin(ctx context.Context, user *domain.User, client domain.ClientInfo, accessExpiry int, refreshExpiry int) (*domain.LoginResponse, error) {
+	nowTime := time.Now()
+
+	// create the session and its refresh token (starts a new token family)
+	session, refreshToken, err := au.startSession(ctx, user.ID, client, nowTime.Add(time.Hour*time.Duration(refreshExpiry)))
 	if err != nil {
 		return nil, err
 	}
 
-	// create refresh token
-	refreshToken, err := au.CreateRefreshToken(&user, refreshSecret, refreshExpiry)
+	// create access token
+	accessToken, err := tokenutil.CreateAccessTokenUntil(user, session.ID, au.keyRing, nowTime.Add(time.Hour*time.Duration(accessExpiry)))
 	if err != nil {
 		return nil, err
 	}
 
 	// LOG INTO USER LOG
 	au.userLogRepository.Create(ctx, &domain.UserLog{
-		UserID: user.ID,
-		Action: "login",
+		UserID:    user.ID,
+		IPAddress: client.IPAddress,
+		Action:    "login",
 	})
+	au.metricsUsecase.RecordLogin(ctx, user.ID, client.IPAddress)
+
+	// a complete login (password and second factor) clears the failed attempts
+	if err := au.loginAttemptUsecase.RegisterSuccess(ctx, user.Email); err != nil {
+		log.Printf("Failed to reset login attempts of user %d: %v", user.ID, err)
+	}
 
 	// return the login response
 	return &domain.LoginResponse{
@@ -68,37 +257,103 @@ func (au *AuthUsecase) LoginUserByEmail(c context.Context, email string, rawPass
 	}, nil
 }
 
-func (au *AuthUsecase) LoginGuestUser(c context.Context, accessSecret string, accessExpiry int, refreshSecret string, refreshExpiry int) (loginResponse *domain.LoginResponse, err error) {
+// LoginExternalUser signs in a user already authenticated by an identity provider (single
+// sign-on). The provider is trusted with the credentials and the second factor.
+func (au *AuthUsecase) LoginExternalUser(c context.Context, user *domain.User, client domain.ClientInfo, accessExpiry int, refreshExpiry int) (loginResponse *domain.LoginResponse, err error) {
 	ctx, cancel := context.WithTimeout(c, au.contextTimeout) // This creates a new context with a timeout and a cancel function, which should be called at the end of the function to release resources
 	defer cancel()
 
-	//log.Println("Requesting IP")
-	//incomingIP := ctx.Value("ip").(string) DOES NOT WORK
-	//log.Println("Incoming IP:", incomingIP)
+	return au.completeLogin(ctx, user, client, accessExpiry, refreshExpiry)
+}
 
-	user, err := au.userRepository.GetByID(ctx, 4)
+// registerLoginFailure counts a failed attempt; a failing store must not change the answer of the login
+func (au *AuthUsecase) registerLoginFailure(ctx context.Context, email string, user *domain.User, client domain.ClientInfo) {
+	if err := au.loginAttemptUsecase.RegisterFailure(ctx, email, user, client); err != nil {
+		log.Printf("Failed to register login failure: %v", err)
+	}
+}
 
+// LoginGuestUser creates an ephemeral guest user for the visitor and signs it in. Without
+// an organization the first Guest organization open to guests is used. The session never
+// outlives the guest, which is removed with its data once expired.
+func (au *AuthUsecase) LoginGuestUser(c context.Context, organizationID uint, client domain.ClientInfo, accessExpiry int) (loginResponse *domain.LoginResponse, err error) {
+	ctx, cancel := context.WithTimeout(c, au.contextTimeout) // This creates a new context with a timeout and a cancel function, which should be called at the end of the function to release resources
+	defer cancel()
+
+	var guestAccess domain.OrganizationGuestAccess
+	if organizationID == 0 {
+		guestAccess, err = au.guestAccessRepository.GetDefault(ctx)
+	} else {
+		guestAccess, err = au.guestAccessRepository.GetByOrganizationID(ctx, organizationID)
+	}
 	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, domain.ErrGuestAccessDisabled
+		}
+		return nil, domain.ErrInternalServerError
+	}
+	if !guestAccess.Enabled {
+		return nil, domain.ErrGuestAccessDisabled
+	}
+
+	// every guest is a new user: an IP only creates so many per hour
+	if err := au.loginAttemptUsecase.RegisterGuest(ctx, client); err != nil {
 		return nil, err
 	}
 
-	// create access token
-	accessToken, err := au.CreateAccessToken(&user, accessSecret, accessExpiry)
+	// a fresh identity per visitor; it has no password, it only lives through its tokens
+	handle, err := tokenutil.GenerateOpaqueToken(9)
+	if err != nil {
+		return nil, domain.ErrInternalServerError
+	}
+
+	expiresAt := time.Now().Add(time.Minute * time.Duration(guestAccess.SessionMinute))
+	user := domain.User{
+		Name:           "Convidado",
+		Email:          "guest-" + handle + "@guest.invalid",
+		Password:       password.Unusable,
+		OrganizationID: guestAccess.OrganizationID,
+		RoleID:         domain.UserRoleGuest,
+	}
+	err = au.guestUserRepository.Create(ctx, &user, &domain.GuestUser{
+		OrganizationID: guestAccess.OrganizationID,
+		IPAddress:      client.IPAddress,
+		UserAgent:      client.UserAgent,
+		ExpiresAt:      expiresAt,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// create refresh token
-	refreshToken, err := au.CreateRefreshToken(&user, refreshSecret, accessExpiry)
+	// reload with the organization, the token claims need its role
+	user, err = au.userRepository.GetByID(ctx, user.ID)
+	if err != nil {
+		return nil, domain.ErrInternalServerError
+	}
+
+	// create the session and its refresh token, both end with the guest
+	session, refreshToken, err := au.startSession(ctx, user.ID, client, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// create access token
+	accessExpireTime := time.Now().Add(time.Hour * time.Duration(accessExpiry))
+	if accessExpireTime.After(expiresAt) {
+		accessExpireTime = expiresAt
+	}
+	accessToken, err := tokenutil.CreateAccessTokenUntil(&user, session.ID, au.keyRing, accessExpireTime)
 	if err != nil {
 		return nil, err
 	}
 
 	// LOG INTO USER LOG
 	au.userLogRepository.Create(ctx, &domain.UserLog{
-		UserID: user.ID,
-		Action: "login",
+		UserID:    user.ID,
+		IPAddress: client.IPAddress,
+		Action:    "login",
 	})
+	au.metricsUsecase.RecordLogin(ctx, user.ID, client.IPAddress)
 
 	return &domain.LoginResponse{
 		AccessToken:  accessToken,
@@ -106,65 +361,225 @@ func (au *AuthUsecase) LoginGuestUser(c context.Context, accessSecret string, ac
 	}, nil
 }
 
-func (au *AuthUsecase) CreateAccessToken(user *domain.User, accessSecret string, expiry int) (accessToken string, err error) {
-	return tokenutil.CreateAccessToken(user, accessSecret, expiry)
+func (au *AuthUsecase) CreateAccessToken(user *domain.User, expiry int) (accessToken string, err error) {
+	return tokenutil.CreateAccessToken(user, au.keyRing, expiry)
+}
+
+// CreateRefreshToken starts a new session and returns the first refresh token of its family
+func (au *AuthUsecase) CreateRefreshToken(ctx context.Context, user *domain.User, client domain.ClientInfo, expiry int) (refreshToken string, err error) {
+	_, refreshToken, err = au.startSession(ctx, user.ID, client, time.Now().Add(time.Hour*time.Duration(expiry)))
+	return refreshToken, err
 }
 
-func (au *AuthUsecase) CreateRefreshToken(user *domain.User, refreshSecret string, expiry int) (refreshToken string, err error) {
-	return tokenutil.CreateRefreshToken(user, refreshSecret, expiry)
+// startSession records the device signing in and issues the first token of its refresh family
+func (au *AuthUsecase) startSession(ctx context.Context, userID uint, client domain.ClientInfo, expiresAt time.Time) (domain.Session, string, error) {
+	familyID, err := tokenutil.GenerateOpaqueToken(16)
+	if err != nil {
+		return domain.Session{}, "", domain.ErrInternalServerError
+	}
+
+	session := domain.Session{
+		UserID:     userID,
+		FamilyID:   familyID,
+		UserAgent:  client.UserAgent,
+		IPAddress:  client.IPAddress,
+		LastSeenAt: time.Now(),
+		ExpiresAt:  expiresAt,
+	}
+	if err := au.sessionRepository.Create(ctx, &session); err != nil {
+		return domain.Session{}, "", err
+	}
+
+	refreshToken, err := au.issueRefreshToken(ctx, userID, familyID, nil, client, expiresAt)
+	if err != nil {
+		return domain.Session{}, "", err
+	}
+	return session, refreshToken, nil
 }
 
-func (au *AuthUsecase) RefreshToken(c context.Context, refreshToken string, refreshSecret string, accessSecret string, accessExpiry int, refreshExpiry int) (refreshResponse *domain.RefreshTokenResponse, err error) {
+// issueRefreshToken generates an opaque token and persists only its hash
+func (au *AuthUsecase) issueRefreshToken(ctx context.Context, userID uint, familyID string, parentID *uint, client domain.ClientInfo, expiresAt time.Time) (string, error) {
+	rawToken, err := tokenutil.GenerateOpaqueToken(32)
+	if err != nil {
+		return "", domain.ErrInternalServerError
+	}
+
+	nowTime := time.Now()
+	err = au.refreshTokenRepository.Create(ctx, &domain.RefreshToken{
+		UserID:    userID,
+		TokenHash: tokenutil.HashOpaqueToken(rawToken),
+		FamilyID:  familyID,
+		ParentID:  parentID,
+		UserAgent: client.UserAgent,
+		IPAddress: client.IPAddress,
+		IssuedAt:  nowTime,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return "", err
+	}
+	return rawToken, nil
+}
+
+// RefreshToken rotates a refresh token: the presented token is consumed and a child of
+// the same family is returned. Presenting an already used token revokes the whole family.
+func (au *AuthUsecase) RefreshToken(c context.Context, refreshToken string, client domain.ClientInfo, accessExpiry int, refreshExpiry int) (refreshResponse *domain.RefreshTokenResponse, err error) {
 	ctx, cancel := context.WithTimeout(c, au.contextTimeout)
 	defer cancel()
 
-	// Validate the refresh token and extract user ID
-	userID, err := tokenutil.ValidateRefreshToken(refreshToken, refreshSecret)
+	stored, err := au.refreshTokenRepository.GetByTokenHash(ctx, tokenutil.HashOpaqueToken(refreshToken))
 	if err != nil {
-		return nil, errors.New("invalid or expired refresh token")
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, domain.ErrInvalidRefreshToken
+		}
+		return nil, domain.ErrInternalServerError
+	}
+
+	nowTime := time.Now()
+	if stored.RevokedAt != nil || nowTime.After(stored.ExpiresAt) {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	// Consume the token; losing this race or finding it already used means it was replayed,
+	// a failing database does not
+	reused := stored.UsedAt != nil
+	if !reused {
+		if err := au.refreshTokenRepository.MarkUsed(ctx, stored.ID, nowTime); err != nil {
+			if !errors.Is(err, domain.ErrRefreshTokenReused) {
+				return nil, domain.ErrInternalServerError
+			}
+			reused = true
+		}
+	}
+	if reused {
+		au.refreshTokenRepository.RevokeFamily(ctx, stored.FamilyID, nowTime)
+		au.sessionRepository.RevokeByFamilyID(ctx, stored.FamilyID, nowTime)
+		au.userLogRepository.Create(ctx, &domain.UserLog{
+			UserID:    stored.UserID,
+			IPAddress: client.IPAddress,
+			Action:    "refresh_token_reuse",
+		})
+		return nil, domain.ErrRefreshTokenReused
 	}
 
 	// Get user from database
-	user, err := au.userRepository.GetByID(ctx, userID)
+	user, err := au.userRepository.GetByID(ctx, stored.UserID)
 	if err != nil {
-		return nil, errors.New("user not found")
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	// families started before sessions existed get one on their first rotation
+	session, err := au.sessionRepository.GetByFamilyID(ctx, stored.FamilyID)
+	if errors.Is(err, domain.ErrNotFound) {
+		session = domain.Session{
+			UserID:     stored.UserID,
+			FamilyID:   stored.FamilyID,
+			UserAgent:  client.UserAgent,
+			IPAddress:  client.IPAddress,
+			LastSeenAt: nowTime,
+			ExpiresAt:  stored.ExpiresAt,
+		}
+		err = au.sessionRepository.Create(ctx, &session)
+	}
+	if err != nil {
+		return nil, domain.ErrInternalServerError
+	}
+	if session.RevokedAt != nil {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	// guest sessions end with the guest, rotating never extends them
+	accessExpireTime := nowTime.Add(time.Hour * time.Duration(accessExpiry))
+	refreshExpireTime := nowTime.Add(time.Hour * time.Duration(refreshExpiry))
+	if user.RoleID == domain.UserRoleGuest {
+		refreshExpireTime = stored.ExpiresAt
+		if accessExpireTime.After(stored.ExpiresAt) {
+			accessExpireTime = stored.ExpiresAt
+		}
 	}
 
 	// Create new access token
-	newAccessToken, err := au.CreateAccessToken(&user, accessSecret, accessExpiry)
+	newAccessToken, err := tokenutil.CreateAccessTokenUntil(&user, session.ID, au.keyRing, accessExpireTime)
 	if err != nil {
 		return nil, err
 	}
 
-	// Create new refresh token
-	newRefreshToken, err := au.CreateRefreshToken(&user, refreshSecret, refreshExpiry)
+	// Create new refresh token in the same family
+	newRefreshToken, err := au.issueRefreshToken(ctx, user.ID, stored.FamilyID, &stored.ID, client, refreshExpireTime)
 	if err != nil {
 		return nil, err
 	}
 
+	// the session follows the device and lives as long as its newest refresh token
+	if err := au.sessionRepository.Extend(ctx, session.ID, client, nowTime, refreshExpireTime); err != nil {
+		return nil, err
+	}
+
 	return &domain.RefreshTokenResponse{
 		AccessToken:  newAccessToken,
 		RefreshToken: newRefreshToken,
 	}, nil
 }
 
-func (au *AuthUsecase) ForgotPassword(c context.Context, email string) (err error) {
-	//ctx, cancel := context.WithTimeout(c, au.contextTimeout)
-	//defer cancel()
+// Logout revokes the token family of the given refresh token (the current device)
+func (au *AuthUsecase) Logout(c context.Context, refreshToken string, client domain.ClientInfo) (err error) {
+	ctx, cancel := context.WithTimeout(c, au.contextTimeout)
+	defer cancel()
 
-	//user, err := au.userRepository.GetByEmail(ctx, email)
-	//if err != nil {
-	//	if !errors.Is(err, domain.ErrUserEmailNotFound) {
-	//		return domain.ErrInternalServerError
-	//	}
-	//	return err
-	//}
+	stored, err := au.refreshTokenRepository.GetByTokenHash(ctx, tokenutil.HashOpaqueToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.ErrInvalidRefreshToken
+		}
+		return domain.ErrInternalServerError
+	}
 
-	// send email with the reset password link
+	nowTime := time.Now()
+	if err := au.refreshTokenRepository.RevokeFamily(ctx, stored.FamilyID, nowTime); err != nil {
+		return err
+	}
+	if err := au.sessionRepository.RevokeByFamilyID(ctx, stored.FamilyID, nowTime); err != nil {
+		return err
+	}
+
+	// the services opened from this device stop counting usage; left open, the reaper closes them
+	if session, err := au.sessionRepository.GetByFamilyID(ctx, stored.FamilyID); err == nil {
+		au.usageLogRepository.EndBySessionID(ctx, session.ID, domain.UsageEndReasonLogout)
+	}
+
+	au.userLogRepository.Create(ctx, &domain.UserLog{
+		UserID:    stored.UserID,
+		IPAddress: client.IPAddress,
+		Action:    "logout",
+	})
+	return nil
+}
+
+// LogoutAll revokes every session and refresh token of the user (sign out everywhere)
+func (au *AuthUsecase) LogoutAll(c context.Context, userID uint, client domain.ClientInfo) (err error) {
+	ctx, cancel := context.WithTimeout(c, au.contextTimeout)
+	defer cancel()
+
+	nowTime := time.Now()
+	if err := au.refreshTokenRepository.RevokeByUserID(ctx, userID, nowTime); err != nil {
+		return err
+	}
+	if err := au.sessionRepository.RevokeByUserID(ctx, userID, nowTime); err != nil {
+		return err
+	}
+	au.usageLogRepository.EndByUserID(ctx, userID, domain.UsageEndReasonLogout)
+
+	au.userLogRepository.Create(ctx, &domain.UserLog{
+		UserID:    userID,
+		IPAddress: client.IPAddress,
+		Action:    "logout_all",
+	})
 	return nil
 }
 
-func (au *AuthUsecase) ResetPassword(c context.Context, email string, newRawPassword string) (err error) {
+// ForgotPassword e-mails a single-use reset link to the account owner. Unknown e-mails
+// are ignored silently so the endpoint cannot be used to discover accounts.
+func (au *AuthUsecase) ForgotPassword(c context.Context, email string, client domain.ClientInfo, resetURL string, resetExpiry int) (err error) {
 	ctx, cancel := context.WithTimeout(c, au.contextTimeout)
 	defer cancel()
 
@@ -173,20 +588,123 @@ func (au *AuthUsecase) ResetPassword(c context.Context, email string, newRawPass
 		if !errors.Is(err, domain.ErrUserEmailNotFound) {
 			return domain.ErrInternalServerError
 		}
-		return err
+		return nil
 	}
 
-	// update user password
-	user.Password, err = password.HashPassword(newRawPassword)
+	rawToken, err := au.createResetToken(ctx, user.ID, client, resetExpiry)
 	if err != nil {
 		return err
 	}
 
-	err = au.userRepository.Update(ctx, user.ID, &user)
+	// send email with the reset password link
+	err = au.mailOutboxUsecase.Enqueue(ctx, []string{user.Email}, domain.MailTemplatePasswordReset, client.Locale, map[string]any{
+		"Name":          user.Name,
+		"ExpiryMinutes": resetExpiry,
+		"ResetLink":     resetURL + "?token=" + url.QueryEscape(rawToken),
+	})
 	if err != nil {
+		log.Printf("Failed to queue password reset e-mail to user %d: %v", user.ID, err)
+		return domain.ErrInternalServerError
+	}
+
+	// LOG INTO USER LOG
+	au.userLogRepository.Create(ctx, &domain.UserLog{
+		UserID:    user.ID,
+		IPAddress: client.IPAddress,
+		Action:    "password_reset_requested",
+	})
+	return nil
+}
+
+// createResetToken issues a single-use reset token; only the latest one of a user is valid
+func (au *AuthUsecase) createResetToken(ctx context.Context, userID uint, client domain.ClientInfo, resetExpiry int) (string, error) {
+	nowTime := time.Now()
+	if err := au.resetTokenRepository.InvalidateByUserID(ctx, userID, nowTime); err != nil {
+		return "", err
+	}
+
+	rawToken, err := tokenutil.GenerateOpaqueToken(32)
+	if err != nil {
+		return "", domain.ErrInternalServerError
+	}
+
+	err = au.resetTokenRepository.Create(ctx, &domain.PasswordResetToken{
+		UserID:    userID,
+		TokenHash: tokenutil.HashOpaqueToken(rawToken),
+		IPAddress: client.IPAddress,
+		ExpiresAt: nowTime.Add(time.Minute * time.Duration(resetExpiry)),
+	})
+	if err != nil {
+		return "", err
+	}
+	return rawToken, nil
+}
+
+// ResetPassword consumes a reset token, sets the new password (which must satisfy the
+// password policy) and signs the user out everywhere. It also completes a forced change.
+func (au *AuthUsecase) ResetPassword(c context.Context, resetToken string, newRawPassword string, client domain.ClientInfo) (err error) {
+	ctx, cancel := context.WithTimeout(c, au.contextTimeout)
+	defer cancel()
+
+	stored, err := au.resetTokenRepository.GetByTokenHash(ctx, tokenutil.HashOpaqueToken(resetToken))
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.ErrInvalidResetToken
+		}
+		return domain.ErrInternalServerError
+	}
+
+	nowTime := time.Now()
+	if stored.UsedAt != nil || nowTime.After(stored.ExpiresAt) {
+		return domain.ErrInvalidResetToken
+	}
+
+	user, err := au.userRepository.GetByID(ctx, stored.UserID)
+	if err != nil {
+		return domain.ErrInvalidResetToken
+	}
+
+	hashedPassword, err := au.passwordUsecase.Hash(ctx, &user, newRawPassword)
+	if err != nil {
+		return err
+	}
+
+	// consume the token before changing anything so it cannot be replayed concurrently
+	if err := au.resetTokenRepository.MarkUsed(ctx, stored.ID, nowTime); err != nil {
+		return err
+	}
+
+	// update user password
+	if err := au.passwordUsecase.Save(ctx, user.ID, hashedPassword); err != nil {
+		return err
+	}
+
+	// invalidate existing sessions and any other outstanding reset link
+	if err := au.refreshTokenRepository.RevokeByUserID(ctx, user.ID, nowTime); err != nil {
+		return err
+	}
+	if err := au.sessionRepository.RevokeByUserID(ctx, user.ID, nowTime); err != nil {
+		return err
+	}
+	au.usageLogRepository.EndByUserID(ctx, user.ID, domain.UsageEndReasonLogout)
+	if err := au.resetTokenRepository.InvalidateByUserID(ctx, user.ID, nowTime); err != nil {
 		return err
 	}
 
+	// LOG INTO USER LOG
+	au.userLogRepository.Create(ctx, &domain.UserLog{
+		UserID:    user.ID,
+		IPAddress: client.IPAddress,
+		Action:    "password_reset",
+	})
+
 	// send email with the password reset confirmation
+	err = au.mailOutboxUsecase.Enqueue(ctx, []string{user.Email}, domain.MailTemplatePasswordChanged, client.Locale, map[string]any{
+		"Name":      user.Name,
+		"ChangedAt": nowTime.Format("2006-01-02 15:04:05"),
+	})
+	if err != nil {
+		log.Printf("Failed to queue password change confirmation to user %d: %v", user.ID, err)
+	}
 	return nil
 }
@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal/parser"
+)
+
+type guestAccessUsecase struct {
+	guestAccessRepository  domain.GuestAccessRepository
+	guestUserRepository    domain.GuestUserRepository
+	organizationRepository domain.OrganizationRepository
+	defaultSessionMinute   int
+	contextTimeout         time.Duration
+}
+
+func NewGuestAccessUsecase(guestAccessRepository domain.GuestAccessRepository, guestUserRepository domain.GuestUserRepository, organizationRepository domain.OrganizationRepository, defaultSessionMinute int, timeout time.Duration) domain.GuestAccessUsecase {
+	return &guestAccessUsecase{
+		guestAccessRepository:  guestAccessRepository,
+		guestUserRepository:    guestUserRepository,
+		organizationRepository: organizationRepository,
+		defaultSessionMinute:   defaultSessionMinute,
+		contextTimeout:         timeout,
+	}
+}
+
+// Get returns the guest access of an organization; organizations never configured are disabled
+func (gu *guestAccessUsecase) Get(c context.Context, organizationID uint) (domain.PublicGuestAccess, error) {
+	ctx, cancel := context.WithTimeout(c, gu.contextTimeout)
+	defer cancel()
+
+	if _, err := gu.organizationRepository.GetByID(ctx, organizationID); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.PublicGuestAccess{}, domain.ErrNotFound
+		}
+		return domain.PublicGuestAccess{}, domain.ErrInternalServerError
+	}
+
+	guestAccess, err := gu.guestAccessRepository.GetByOrganizationID(ctx, organizationID)
+	if err != nil {
+		if !errors.Is(err, domain.ErrNotFound) {
+			return domain.PublicGuestAccess{}, err
+		}
+		guestAccess = domain.OrganizationGuestAccess{
+			OrganizationID: organizationID,
+			SessionMinute:  gu.defaultSessionMinute,
+		}
+	}
+	return parser.ToPublicGuestAccess(guestAccess), nil
+}
+
+// Configure enables or disables the guest access of an organization. Guests can
+// only be given services the organization itself is subscribed to.
+func (gu *guestAccessUsecase) Configure(c context.Context, organizationID uint, config *domain.GuestAccessConfig) (domain.PublicGuestAccess, error) {
+	ctx, cancel := context.WithTimeout(c, gu.contextTimeout)
+	defer cancel()
+
+	organization, err := gu.organizationRepository.GetByID(ctx, organizationID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.PublicGuestAccess{}, domain.ErrNotFound
+		}
+		return domain.PublicGuestAccess{}, domain.ErrInternalServerError
+	}
+
+	subscribed := make([]uint, 0, len(organization.SubscribedServices))
+	for _, service := range organization.SubscribedServices {
+		subscribed = append(subscribed, service.ID)
+	}
+	for _, serviceID := range config.ServiceIDs {
+		if !slices.Contains(subscribed, serviceID) {
+			return domain.PublicGuestAccess{}, domain.ErrServiceNotSubscribed
+		}
+	}
+
+	// an omitted session length keeps the current one
+	sessionMinute := config.SessionMinute
+	if sessionMinute == 0 {
+		current, err := gu.guestAccessRepository.GetByOrganizationID(ctx, organizationID)
+		switch {
+		case err == nil:
+			sessionMinute = current.SessionMinute
+		case errors.Is(err, domain.ErrNotFound):
+			sessionMinute = gu.defaultSessionMinute
+		default:
+			return domain.PublicGuestAccess{}, err
+		}
+	}
+
+	guestAccess := domain.OrganizationGuestAccess{
+		OrganizationID: organizationID,
+		Enabled:        *config.Enabled,
+		SessionMinute:  sessionMinute,
+	}
+	if err := gu.guestAccessRepository.Save(ctx, &guestAccess, config.ServiceIDs); err != nil {
+		return domain.PublicGuestAccess{}, err
+	}
+	return parser.ToPublicGuestAccess(guestAccess), nil
+}
+
+// CleanupExpired removes the guest users whose session is over, with their data
+func (gu *guestAccessUsecase) CleanupExpired(c context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(c, gu.contextTimeout)
+	defer cancel()
+
+	return gu.guestUserRepository.DeleteExpired(ctx, time.Now())
+}
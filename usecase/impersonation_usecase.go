@@ -0,0 +1,143 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal/parser"
+	"github.com/gabrielfmcoelho/platform-core/internal/tokenutil"
+)
+
+type impersonationUsecase struct {
+	impersonationRepository domain.ImpersonationRepository
+	userRepository          domain.UserRepository
+	userLogRepository       domain.UserLogRepository
+	keyRing                 domain.KeyRing
+	expiryMinute            int
+	contextTimeout          time.Duration
+}
+
+func NewImpersonationUsecase(impersonationRepository domain.ImpersonationRepository, userRepository domain.UserRepository, userLogRepository domain.UserLogRepository, keyRing domain.KeyRing, expiryMinute int, timeout time.Duration) domain.ImpersonationUsecase {
+	return &impersonationUsecase{
+		impersonationRepository: impersonationRepository,
+		userRepository:          userRepository,
+		userLogRepository:       userLogRepository,
+		keyRing:                 keyRing,
+		expiryMinute:            expiryMinute,
+		contextTimeout:          timeout,
+	}
+}
+
+// Start lets a platform admin act as a user. The short-lived access token returned has
+// no refresh token; the start is logged on the user so they can see support accessed it.
+func (iu *impersonationUsecase) Start(c context.Context, impersonatorID uint, userID uint, reason string, client domain.ClientInfo) (*domain.ImpersonationResponse, error) {
+	ctx, cancel := context.WithTimeout(c, iu.contextTimeout)
+	defer cancel()
+
+	impersonator, err := iu.userRepository.GetByID(ctx, impersonatorID)
+	if err != nil {
+		return nil, domain.ErrUnauthorized
+	}
+
+	user, err := iu.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, domain.ErrInternalServerError
+	}
+
+	// platform admins (the impersonator included) are never impersonated
+	if user.ID == impersonator.ID ||
+		(user.RoleID == domain.UserRoleAdmin && user.Organization.RoleID == domain.OrganizationRoleAdmin) {
+		return nil, domain.ErrCannotImpersonate
+	}
+
+	nowTime := time.Now()
+	impersonation := domain.Impersonation{
+		ImpersonatorID: impersonator.ID,
+		UserID:         user.ID,
+		Reason:         reason,
+		IPAddress:      client.IPAddress,
+		ExpiresAt:      nowTime.Add(time.Minute * time.Duration(iu.expiryMinute)),
+	}
+	if err := iu.impersonationRepository.Create(ctx, &impersonation); err != nil {
+		return nil, err
+	}
+
+	accessToken, err := tokenutil.CreateImpersonationToken(&user, &impersonator, impersonation.ID, iu.keyRing, impersonation.ExpiresAt)
+	if err != nil {
+		return nil, domain.ErrInternalServerError
+	}
+
+	// LOG INTO USER LOG
+	iu.userLogRepository.Create(ctx, &domain.UserLog{
+		UserID:    user.ID,
+		IPAddress: client.IPAddress,
+		Action:    "impersonation_started",
+	})
+
+	return &domain.ImpersonationResponse{
+		AccessToken:     accessToken,
+		ExpiresIn:       int(impersonation.ExpiresAt.Sub(nowTime).Seconds()),
+		ImpersonationID: impersonation.ID,
+		User:            parser.ToPublicUser(user),
+	}, nil
+}
+
+// Stop ends an impersonation, its token is refused from now on
+func (iu *impersonationUsecase) Stop(c context.Context, impersonationID uint, client domain.ClientInfo) error {
+	ctx, cancel := context.WithTimeout(c, iu.contextTimeout)
+	defer cancel()
+
+	impersonation, err := iu.impersonationRepository.GetByID(ctx, impersonationID)
+	if err != nil {
+		return err
+	}
+
+	if err := iu.impersonationRepository.End(ctx, impersonation.ID, time.Now()); err != nil {
+		return err
+	}
+
+	// LOG INTO USER LOG
+	iu.userLogRepository.Create(ctx, &domain.UserLog{
+		UserID:    impersonation.UserID,
+		IPAddress: client.IPAddress,
+		Action:    "impersonation_stopped",
+	})
+	return nil
+}
+
+// IsActive reports whether an impersonation was neither stopped nor expired
+func (iu *impersonationUsecase) IsActive(c context.Context, impersonationID uint) (bool, error) {
+	ctx, cancel := context.WithTimeout(c, iu.contextTimeout)
+	defer cancel()
+
+	impersonation, err := iu.impersonationRepository.GetByID(ctx, impersonationID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return impersonation.EndedAt == nil && time.Now().Before(impersonation.ExpiresAt), nil
+}
+
+// FetchByUserID lists who accessed the account of a user, and when
+func (iu *impersonationUsecase) FetchByUserID(c context.Context, userID uint) ([]domain.PublicImpersonation, error) {
+	ctx, cancel := context.WithTimeout(c, iu.contextTimeout)
+	defer cancel()
+
+	impersonations, err := iu.impersonationRepository.FetchByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	publicImpersonations := make([]domain.PublicImpersonation, 0, len(impersonations))
+	for _, impersonation := range impersonations {
+		publicImpersonations = append(publicImpersonations, parser.ToPublicImpersonation(impersonation))
+	}
+	return publicImpersonations, nil
+}
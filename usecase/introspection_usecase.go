@@ -0,0 +1,244 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal/parser"
+	"github.com/gabrielfmcoelho/platform-core/internal/tokenutil"
+	jwt "github.com/golang-jwt/jwt/v4"
+)
+
+type introspectionUsecase struct {
+	userRepository           domain.UserRepository
+	organizationRepository   domain.OrganizationRepository
+	refreshTokenRepository   domain.RefreshTokenRepository
+	serviceAccountRepository domain.ServiceAccountRepository
+	impersonationRepository  domain.ImpersonationRepository
+	sessionRepository        domain.SessionRepository
+	guestAccessRepository    domain.GuestAccessRepository
+	keyRing                  domain.KeyRing
+	contextTimeout           time.Duration
+}
+
+func NewIntrospectionUsecase(userRepository domain.UserRepository, organizationRepository domain.OrganizationRepository, refreshTokenRepository domain.RefreshTokenRepository, serviceAccountRepository domain.ServiceAccountRepository, impersonationRepository domain.ImpersonationRepository, sessionRepository domain.SessionRepository, guestAccessRepository domain.GuestAccessRepository, keyRing domain.KeyRing, timeout time.Duration) domain.IntrospectionUsecase {
+	return &introspectionUsecase{
+		userRepository:           userRepository,
+		organizationRepository:   organizationRepository,
+		refreshTokenRepository:   refreshTokenRepository,
+		serviceAccountRepository: serviceAccountRepository,
+		impersonationRepository:  impersonationRepository,
+		sessionRepository:        sessionRepository,
+		guestAccessRepository:    guestAccessRepository,
+		keyRing:                  keyRing,
+		contextTimeout:           timeout,
+	}
+}
+
+// Introspect tells whether a token is active and who it belongs to. Access and
+// service account tokens are JWTs; refresh tokens are opaque and looked up by hash.
+// Besides the signature and expiry, a token is only active while its user (or
+// service account, still with the secret the token was issued with) exists and is
+// not archived, an access token while its session is not revoked and an
+// impersonation token while its impersonation is running.
+func (iu *introspectionUsecase) Introspect(c context.Context, request *domain.IntrospectionRequest, serviceAccountSecret string) (domain.IntrospectionResponse, error) {
+	ctx, cancel := context.WithTimeout(c, iu.contextTimeout)
+	defer cancel()
+
+	inactive := domain.IntrospectionResponse{Active: false}
+
+	// the hint only changes the lookup order (RFC 7662 section 2.1)
+	isJWT := strings.Count(request.Token, ".") == 2
+	if !isJWT || request.TokenTypeHint == domain.TokenTypeHintRefreshToken {
+		response, err := iu.introspectRefreshToken(ctx, request.Token)
+		if err != nil || response.Active || !isJWT {
+			return response, err
+		}
+	}
+
+	if claims, err := tokenutil.ExtractClaimsFromToken(request.Token, iu.keyRing); err == nil {
+		user, err := iu.userRepository.GetByID(ctx, claims.UserID)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return inactive, nil
+			}
+			return inactive, domain.ErrInternalServerError
+		}
+		if claims.SessionID != 0 {
+			active, err := iu.sessionActive(ctx, claims.SessionID)
+			if err != nil || !active {
+				return inactive, err
+			}
+		}
+		if claims.Act != nil {
+			active, err := iu.impersonationActive(ctx, claims.ID)
+			if err != nil || !active {
+				return inactive, err
+			}
+		}
+		return domain.IntrospectionResponse{
+			Active:             true,
+			TokenUse:           domain.TokenUseAccess,
+			TokenType:          "Bearer",
+			Username:           user.Email,
+			Sub:                claims.Subject,
+			Exp:                numericDateUnix(claims.ExpiresAt),
+			Iat:                numericDateUnix(claims.IssuedAt),
+			UserID:             user.ID,
+			UserRoleID:         user.RoleID,
+			OrganizationID:     user.OrganizationID,
+			OrganizationRoleID: user.Organization.RoleID,
+			Act:                claims.Act,
+		}, nil
+	}
+
+	if claims, err := tokenutil.ExtractServiceAccountClaimsFromToken(request.Token, serviceAccountSecret); err == nil {
+		serviceAccount, err := iu.serviceAccountRepository.GetByClientID(ctx, claims.ClientID)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return inactive, nil
+			}
+			return inactive, domain.ErrInternalServerError
+		}
+		if serviceAccount.SecretVersion != claims.SecretVersion {
+			return inactive, nil
+		}
+		return domain.IntrospectionResponse{
+			Active:    true,
+			TokenUse:  domain.TokenUseService,
+			TokenType: "Bearer",
+			Scope:     claims.Scope,
+			ClientID:  claims.ClientID,
+			Sub:       claims.Subject,
+			Exp:       numericDateUnix(claims.ExpiresAt),
+			Iat:       numericDateUnix(claims.IssuedAt),
+		}, nil
+	}
+
+	return inactive, nil
+}
+
+// introspectRefreshToken reports an unused, unrevoked and unexpired refresh token as active
+func (iu *introspectionUsecase) introspectRefreshToken(ctx context.Context, token string) (domain.IntrospectionResponse, error) {
+	inactive := domain.IntrospectionResponse{Active: false}
+
+	stored, err := iu.refreshTokenRepository.GetByTokenHash(ctx, tokenutil.HashOpaqueToken(token))
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return inactive, nil
+		}
+		return inactive, domain.ErrInternalServerError
+	}
+	if stored.UsedAt != nil || stored.RevokedAt != nil || time.Now().After(stored.ExpiresAt) {
+		return inactive, nil
+	}
+
+	user, err := iu.userRepository.GetByID(ctx, stored.UserID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return inactive, nil
+		}
+		return inactive, domain.ErrInternalServerError
+	}
+	return domain.IntrospectionResponse{
+		Active:             true,
+		TokenUse:           domain.TokenUseRefresh,
+		Username:           user.Email,
+		Sub:                user.Email,
+		Exp:                stored.ExpiresAt.Unix(),
+		Iat:                stored.IssuedAt.Unix(),
+		UserID:             user.ID,
+		UserRoleID:         user.RoleID,
+		OrganizationID:     user.OrganizationID,
+		OrganizationRoleID: user.Organization.RoleID,
+	}, nil
+}
+
+// GetUserInfo returns the user, its organization and roles, and the services the user can launch right now:
+// the active services of the organization, none while its subscription lapses, and for guests only the opened ones
+func (iu *introspectionUsecase) GetUserInfo(c context.Context, userID uint) (domain.UserInfo, error) {
+	ctx, cancel := context.WithTimeout(c, iu.contextTimeout)
+	defer cancel()
+
+	user, err := iu.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return domain.UserInfo{}, err
+	}
+	organization, err := iu.organizationRepository.GetByID(ctx, user.OrganizationID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.UserInfo{}, domain.ErrNotFound
+		}
+		return domain.UserInfo{}, domain.ErrDataBaseInternalError
+	}
+
+	// the same entitlements serviceUsecase.Use checks before a launch
+	platformAdmin := user.RoleID == domain.UserRoleAdmin && organization.RoleID == domain.OrganizationRoleAdmin
+	subscribed := platformAdmin || organization.Subscription.CheckAccess(time.Now()) == nil
+	var guestAccess domain.OrganizationGuestAccess
+	if user.RoleID == domain.UserRoleGuest {
+		guestAccess, err = iu.guestAccessRepository.GetByOrganizationID(ctx, user.OrganizationID)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return domain.UserInfo{}, domain.ErrInternalServerError
+		}
+	}
+
+	services := make([]domain.PublicService, 0, len(organization.SubscribedServices))
+	for _, service := range organization.SubscribedServices {
+		if !subscribed || !service.IsActive() {
+			continue
+		}
+		if user.RoleID == domain.UserRoleGuest && !guestAccess.Allows(service.ID) {
+			continue
+		}
+		services = append(services, parser.ToPublicService(service))
+	}
+
+	return domain.UserInfo{
+		User:             parser.ToPublicUser(user),
+		UserRole:         parser.ToPublicUserRole(user.Role),
+		Organization:     parser.ToPublicOrganization(organization),
+		OrganizationRole: parser.ToPublicOrganizationRole(organization.Role),
+		Services:         services,
+	}, nil
+}
+
+// numericDateUnix returns the seconds of an optional JWT date (0 when absent, omitted in the response)
+func numericDateUnix(date *jwt.NumericDate) int64 {
+	if date == nil {
+		return 0
+	}
+	return date.Unix()
+}
+
+// impersonationActive reports whether the impersonation named by the jti of a token is still running
+func (iu *introspectionUsecase) impersonationActive(ctx context.Context, jti string) (bool, error) {
+	impersonationID, err := strconv.ParseUint(jti, 10, 0)
+	if err != nil {
+		return false, nil
+	}
+	impersonation, err := iu.impersonationRepository.GetByID(ctx, uint(impersonationID))
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return false, nil
+		}
+		return false, domain.ErrInternalServerError
+	}
+	return impersonation.EndedAt == nil && time.Now().Before(impersonation.ExpiresAt), nil
+}
+
+// sessionActive reports whether the session of an access token is neither revoked nor expired
+func (iu *introspectionUsecase) sessionActive(ctx context.Context, sessionID uint) (bool, error) {
+	session, err := iu.sessionRepository.GetByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return false, nil
+		}
+		return false, domain.ErrInternalServerError
+	}
+	return session.RevokedAt == nil && time.Now().Before(session.ExpiresAt), nil
+}
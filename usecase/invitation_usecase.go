@@ -0,0 +1,305 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal"
+	"github.com/gabrielfmcoelho/platform-core/internal/parser"
+	"github.com/gabrielfmcoelho/platform-core/internal/tokenutil"
+)
+
+type invitationUsecase struct {
+	invitationRepository   domain.InvitationRepository
+	userRepository         domain.UserRepository
+	organizationRepository domain.OrganizationRepository
+	userLogRepository      domain.UserLogRepository
+	mailOutboxUsecase      domain.MailOutboxUsecase
+	passwordUsecase        domain.PasswordUsecase
+	secret                 string
+	invitationURL          string
+	expiryHour             int
+	contextTimeout         time.Duration
+}
+
+func NewInvitationUsecase(invitationRepository domain.InvitationRepository, userRepository domain.UserRepository, organizationRepository domain.OrganizationRepository, userLogRepository domain.UserLogRepository, mailOutboxUsecase domain.MailOutboxUsecase, passwordUsecase domain.PasswordUsecase, secret string, invitationURL string, expiryHour int, timeout time.Duration) domain.InvitationUsecase {
+	return &invitationUsecase{
+		invitationRepository:   invitationRepository,
+		userRepository:         userRepository,
+		organizationRepository: organizationRepository,
+		userLogRepository:      userLogRepository,
+		mailOutboxUsecase:      mailOutboxUsecase,
+		passwordUsecase:        passwordUsecase,
+		secret:                 secret,
+		invitationURL:          invitationURL,
+		expiryHour:             expiryHour,
+		contextTimeout:         timeout,
+	}
+}
+
+// Invite records an invitation to the organization and e-mails its link
+func (iu *invitationUsecase) Invite(c context.Context, organizationID uint, invitedByID uint, createInvitation *domain.CreateInvitation, client domain.ClientInfo) (domain.PublicInvitation, error) {
+	ctx, cancel := context.WithTimeout(c, iu.contextTimeout)
+	defer cancel()
+
+	email := strings.ToLower(strings.TrimSpace(createInvitation.Email))
+
+	organization, err := iu.organizationRepository.GetByID(ctx, organizationID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.PublicInvitation{}, domain.ErrNotFound
+		}
+		return domain.PublicInvitation{}, domain.ErrInternalServerError
+	}
+	if usersLimitReached(organization) {
+		return domain.PublicInvitation{}, domain.ErrUsersLimitReached
+	}
+
+	if _, err := iu.userRepository.GetByEmail(ctx, email); err == nil {
+		return domain.PublicInvitation{}, domain.ErrUserAlreadyExists
+	} else if !errors.Is(err, domain.ErrUserEmailNotFound) {
+		return domain.PublicInvitation{}, domain.ErrInternalServerError
+	}
+
+	if _, err := iu.invitationRepository.GetOpenByEmail(ctx, organizationID, email); err == nil {
+		return domain.PublicInvitation{}, domain.ErrInvitationPending
+	} else if !errors.Is(err, domain.ErrNotFound) {
+		return domain.PublicInvitation{}, err
+	}
+
+	nonce, err := tokenutil.GenerateOpaqueToken(32)
+	if err != nil {
+		return domain.PublicInvitation{}, domain.ErrInternalServerError
+	}
+
+	nowTime := time.Now()
+	invitation := domain.Invitation{
+		OrganizationID: organizationID,
+		Email:          email,
+		Name:           createInvitation.Name,
+		RoleID:         createInvitation.RoleID,
+		InvitedByID:    invitedByID,
+		NonceHash:      tokenutil.HashOpaqueToken(nonce),
+		SendCount:      1,
+		LastSentAt:     nowTime,
+		ExpiresAt:      nowTime.Add(time.Hour * time.Duration(iu.expiryHour)),
+	}
+	if err := iu.invitationRepository.Create(ctx, &invitation); err != nil {
+		return domain.PublicInvitation{}, err
+	}
+	invitation.Organization = organization
+
+	if err := iu.send(ctx, &invitation, nonce, client); err != nil {
+		return domain.PublicInvitation{}, err
+	}
+
+	// LOG INTO USER LOG
+	if invitedByID != 0 {
+		iu.userLogRepository.Create(ctx, &domain.UserLog{
+			UserID:    invitedByID,
+			IPAddress: client.IPAddress,
+			Action:    "invitation_sent",
+		})
+	}
+
+	return parser.ToPublicInvitation(invitation), nil
+}
+
+// FetchByOrganization lists the invitations still waiting for an answer (pending or expired)
+func (iu *invitationUsecase) FetchByOrganization(c context.Context, organizationID uint) ([]domain.PublicInvitation, error) {
+	ctx, cancel := context.WithTimeout(c, iu.contextTimeout)
+	defer cancel()
+
+	invitations, err := iu.invitationRepository.FetchOpenByOrganization(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	publicInvitations := make([]domain.PublicInvitation, 0, len(invitations))
+	for _, invitation := range invitations {
+		publicInvitations = append(publicInvitations, parser.ToPublicInvitation(invitation))
+	}
+	return publicInvitations, nil
+}
+
+// Resend e-mails a new link and restarts the expiry; the links sent before stop working
+func (iu *invitationUsecase) Resend(c context.Context, organizationID uint, invitationID uint, client domain.ClientInfo) (domain.PublicInvitation, error) {
+	ctx, cancel := context.WithTimeout(c, iu.contextTimeout)
+	defer cancel()
+
+	invitation, err := iu.getOpen(ctx, organizationID, invitationID)
+	if err != nil {
+		return domain.PublicInvitation{}, err
+	}
+
+	nonce, err := tokenutil.GenerateOpaqueToken(32)
+	if err != nil {
+		return domain.PublicInvitation{}, domain.ErrInternalServerError
+	}
+
+	nowTime := time.Now()
+	invitation.NonceHash = tokenutil.HashOpaqueToken(nonce)
+	invitation.SendCount++
+	invitation.LastSentAt = nowTime
+	invitation.ExpiresAt = nowTime.Add(time.Hour * time.Duration(iu.expiryHour))
+	if err := iu.invitationRepository.Renew(ctx, invitation.ID, invitation.NonceHash, invitation.LastSentAt, invitation.ExpiresAt); err != nil {
+		return domain.PublicInvitation{}, err
+	}
+
+	if err := iu.send(ctx, &invitation, nonce, client); err != nil {
+		return domain.PublicInvitation{}, err
+	}
+	return parser.ToPublicInvitation(invitation), nil
+}
+
+// Revoke cancels an invitation, its link stops working
+func (iu *invitationUsecase) Revoke(c context.Context, organizationID uint, invitationID uint) error {
+	ctx, cancel := context.WithTimeout(c, iu.contextTimeout)
+	defer cancel()
+
+	if _, err := iu.getOpen(ctx, organizationID, invitationID); err != nil {
+		return err
+	}
+	return iu.invitationRepository.Revoke(ctx, invitationID, time.Now())
+}
+
+// Accept creates the account of the invitee with the password they chose
+func (iu *invitationUsecase) Accept(c context.Context, request *domain.AcceptInvitationRequest, client domain.ClientInfo) error {
+	ctx, cancel := context.WithTimeout(c, iu.contextTimeout)
+	defer cancel()
+
+	claims, err := tokenutil.ExtractInvitationClaimsFromToken(request.Token, iu.secret)
+	if err != nil {
+		return domain.ErrInvalidInvitation
+	}
+	invitationID, err := internal.ParseUint(claims.Subject)
+	if err != nil {
+		return domain.ErrInvalidInvitation
+	}
+
+	invitation, err := iu.invitationRepository.GetByID(ctx, invitationID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.ErrInvalidInvitation
+		}
+		return domain.ErrInternalServerError
+	}
+
+	// only the latest link of an open invitation is accepted
+	nowTime := time.Now()
+	if invitation.NonceHash != tokenutil.HashOpaqueToken(claims.ID) ||
+		parser.InvitationStatus(invitation, nowTime) != domain.InvitationStatusPending {
+		return domain.ErrInvalidInvitation
+	}
+
+	if _, err := iu.userRepository.GetByEmail(ctx, invitation.Email); err == nil {
+		return domain.ErrUserAlreadyExists
+	} else if !errors.Is(err, domain.ErrUserEmailNotFound) {
+		return domain.ErrInternalServerError
+	}
+
+	// the new member takes a seat of the subscription
+	organization, err := iu.organizationRepository.GetByID(ctx, invitation.OrganizationID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.ErrInvalidInvitation
+		}
+		return domain.ErrInternalServerError
+	}
+	if usersLimitReached(organization) {
+		return domain.ErrUsersLimitReached
+	}
+
+	hashedPassword, err := iu.passwordUsecase.Hash(ctx, nil, request.Password)
+	if err != nil {
+		return err
+	}
+
+	name := request.Name
+	if name == "" {
+		name = invitation.Name
+	}
+	user := domain.User{
+		Name:           name,
+		Email:          invitation.Email,
+		Password:       hashedPassword,
+		OrganizationID: invitation.OrganizationID,
+		RoleID:         invitation.RoleID,
+	}
+	if err := iu.invitationRepository.Accept(ctx, invitation.ID, &user, nowTime); err != nil {
+		return err
+	}
+	if err := iu.passwordUsecase.Record(ctx, user.ID, hashedPassword); err != nil {
+		log.Printf("Failed to record the password history of user %d: %v", user.ID, err)
+	}
+
+	// LOG INTO USER LOG
+	iu.userLogRepository.Create(ctx, &domain.UserLog{
+		UserID:    user.ID,
+		IPAddress: client.IPAddress,
+		Action:    "invitation_accepted",
+	})
+	return nil
+}
+
+// getOpen returns an invitation of the organization that can still be resent or revoked
+func (iu *invitationUsecase) getOpen(ctx context.Context, organizationID uint, invitationID uint) (domain.Invitation, error) {
+	invitation, err := iu.invitationRepository.GetByID(ctx, invitationID)
+	if err != nil {
+		return invitation, err
+	}
+	if invitation.OrganizationID != organizationID {
+		return invitation, domain.ErrNotFound
+	}
+	if invitation.AcceptedAt != nil || invitation.RevokedAt != nil {
+		return invitation, domain.ErrInvalidInvitation
+	}
+	return invitation, nil
+}
+
+// send queues the invitation e-mail with a freshly signed link
+func (iu *invitationUsecase) send(ctx context.Context, invitation *domain.Invitation, nonce string, client domain.ClientInfo) error {
+	token, err := tokenutil.CreateInvitationToken(invitation, nonce, iu.secret)
+	if err != nil {
+		return domain.ErrInternalServerError
+	}
+
+	err = iu.mailOutboxUsecase.Enqueue(ctx, []string{invitation.Email}, domain.MailTemplateInvitation, client.Locale, map[string]any{
+		"Name":             invitation.Name,
+		"OrganizationName": invitation.Organization.Name,
+		"ExpiryHours":      iu.expiryHour,
+		"InvitationLink":   iu.invitationURL + "?token=" + url.QueryEscape(token),
+	})
+	if err != nil {
+		log.Printf("Failed to queue invitation e-mail %d: %v", invitation.ID, err)
+		return domain.ErrInternalServerError
+	}
+	return nil
+}
+
+// usersLimitReached reports whether the organization has no seat left for another member.
+// Guests do not take seats; organizations without a subscription limit are not limited.
+func usersLimitReached(organization domain.Organization) bool {
+	limit := organization.Subscription.SubscriptionUsersLimit
+	if organization.Subscription.ID == 0 || limit <= 0 {
+		return false
+	}
+
+	return countMembers(organization) >= limit
+}
+
+// countMembers counts the users of the organization taking a seat, guests excluded
+func countMembers(organization domain.Organization) int {
+	members := 0
+	for _, user := range organization.Users {
+		if user.RoleID != domain.UserRoleGuest {
+			members++
+		}
+	}
+	return members
+}
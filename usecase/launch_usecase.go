@@ -0,0 +1,122 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal/parser"
+	"github.com/gabrielfmcoelho/platform-core/internal/tokenutil"
+)
+
+type launchUsecase struct {
+	serviceLaunchRepository domain.ServiceLaunchRepository
+	userRepository          domain.UserRepository
+	oauthClientRepository   domain.OAuthClientRepository
+	keyRing                 domain.KeyRing
+	issuer                  string
+	expirySecond            int
+	contextTimeout          time.Duration
+}
+
+func NewLaunchUsecase(serviceLaunchRepository domain.ServiceLaunchRepository, userRepository domain.UserRepository, oauthClientRepository domain.OAuthClientRepository, keyRing domain.KeyRing, issuer string, expirySecond int, timeout time.Duration) domain.LaunchUsecase {
+	return &launchUsecase{
+		serviceLaunchRepository: serviceLaunchRepository,
+		userRepository:          userRepository,
+		oauthClientRepository:   oauthClientRepository,
+		keyRing:                 keyRing,
+		issuer:                  issuer,
+		expirySecond:            expirySecond,
+		contextTimeout:          timeout,
+	}
+}
+
+// Issue records a new launch for the usage log and adds its token to the URL of the application
+func (lu *launchUsecase) Issue(c context.Context, userID uint, useService *domain.UseService) error {
+	ctx, cancel := context.WithTimeout(c, lu.contextTimeout)
+	defer cancel()
+
+	user, err := lu.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.ErrNotFound
+		}
+		return domain.ErrInternalServerError
+	}
+
+	nonce, err := tokenutil.GenerateOpaqueToken(32)
+	if err != nil {
+		return domain.ErrInternalServerError
+	}
+
+	launch := domain.ServiceLaunch{
+		NonceHash:        tokenutil.HashOpaqueToken(nonce),
+		UserID:           user.ID,
+		OrganizationID:   user.OrganizationID,
+		ServiceID:        useService.Service.ID,
+		UserServiceLogID: useService.LogID,
+		ExpiresAt:        time.Now().Add(time.Second * time.Duration(lu.expirySecond)),
+	}
+	if err := lu.serviceLaunchRepository.Create(ctx, &launch); err != nil {
+		return err
+	}
+
+	launchToken, err := tokenutil.CreateLaunchToken(&launch, nonce, lu.issuer, lu.keyRing)
+	if err != nil {
+		return domain.ErrInternalServerError
+	}
+
+	useService.LaunchToken = launchToken
+	useService.LaunchURL = redirectWith(useService.Service.AppUrl, url.Values{"launch_token": {launchToken}}, "")
+	useService.ExpiresIn = lu.expirySecond
+	return nil
+}
+
+// Exchange redeems a launch token for the backend of the service it was issued to.
+// The caller authenticates with a confidential OAuth client of that service, so a
+// token leaked to another service is useless there.
+func (lu *launchUsecase) Exchange(c context.Context, request *domain.LaunchExchangeRequest) (domain.LaunchExchangeResponse, error) {
+	ctx, cancel := context.WithTimeout(c, lu.contextTimeout)
+	defer cancel()
+
+	var response domain.LaunchExchangeResponse
+
+	client, err := authenticateOAuthClient(ctx, lu.oauthClientRepository, request.ClientID, request.ClientSecret)
+	if err != nil {
+		return response, err
+	}
+	if client.ClientSecretHash == "" {
+		// a public client runs in the browser, it cannot vouch for the service
+		return response, domain.ErrInvalidClient
+	}
+
+	claims, err := tokenutil.ExtractLaunchClaimsFromToken(request.LaunchToken, lu.keyRing, lu.issuer, client.ServiceID)
+	if err != nil {
+		return response, domain.ErrInvalidLaunchToken
+	}
+
+	launch, err := lu.serviceLaunchRepository.Consume(ctx, tokenutil.HashOpaqueToken(claims.ID), time.Now())
+	if err != nil {
+		return response, err
+	}
+
+	user, err := lu.userRepository.GetByID(ctx, launch.UserID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			// archived since the launch
+			return response, domain.ErrInvalidLaunchToken
+		}
+		return response, domain.ErrInternalServerError
+	}
+
+	// Not critical for the exchange, only informative
+	lu.oauthClientRepository.TouchLastUsed(ctx, client.ID, time.Now())
+
+	return domain.LaunchExchangeResponse{
+		UserInfoResponse: parser.ToUserInfoResponse(&user, domain.OAuthAvailableScopes),
+		ServiceID:        launch.ServiceID,
+		LogID:            launch.UserServiceLogID,
+	}, nil
+}
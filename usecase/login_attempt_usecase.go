@@ -0,0 +1,253 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+)
+
+const (
+	loginFreeFailures = 2                // failures of an account answered without delay (an IP gets half its limit)
+	loginBaseDelay    = time.Second      // delay after the first failure past the free ones, doubled at each new failure
+	loginMaxDelay     = 30 * time.Second // longest delay between two attempts
+	loginMaxLockout   = 24 * time.Hour   // longest lockout, however many came before
+	guestWindow       = time.Hour        // window of the guests created per IP
+)
+
+type loginAttemptUsecase struct {
+	loginAttemptStore domain.LoginAttemptStore
+	userRepository    domain.UserRepository
+	userLogRepository domain.UserLogRepository
+	policy            domain.LoginThrottlePolicy
+	contextTimeout    time.Duration
+}
+
+func NewLoginAttemptUsecase(loginAttemptStore domain.LoginAttemptStore, userRepository domain.UserRepository, userLogRepository domain.UserLogRepository, policy domain.LoginThrottlePolicy, timeout time.Duration) domain.LoginAttemptUsecase {
+	return &loginAttemptUsecase{
+		loginAttemptStore: loginAttemptStore,
+		userRepository:    userRepository,
+		userLogRepository: userLogRepository,
+		policy:            policy,
+		contextTimeout:    timeout,
+	}
+}
+
+// Check refuses the attempt while the account or the client IP is locked or delayed
+func (lu *loginAttemptUsecase) Check(c context.Context, email string, client domain.ClientInfo) error {
+	ctx, cancel := context.WithTimeout(c, lu.contextTimeout)
+	defer cancel()
+
+	now := time.Now()
+	for _, key := range []string{accountKey(email), ipKey(client.IPAddress)} {
+		attempt, err := lu.loginAttemptStore.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		if attempt.LockedUntil != nil && attempt.LockedUntil.After(now) {
+			lockErr := domain.ErrTooManyLoginAttempts
+			if strings.HasPrefix(key, domain.LoginAttemptKeyAccount) {
+				lockErr = domain.ErrAccountLocked
+			}
+			return &domain.LoginThrottledError{Err: lockErr, RetryAfter: attempt.LockedUntil.Sub(now)}
+		}
+		if attempt.NextAttemptAt != nil && attempt.NextAttemptAt.After(now) {
+			return &domain.LoginThrottledError{Err: domain.ErrTooManyLoginAttempts, RetryAfter: attempt.NextAttemptAt.Sub(now)}
+		}
+	}
+	return nil
+}
+
+// RegisterFailure counts a failed attempt against the account and the IP, then
+// delays or locks them. The e-mail is counted even when no user has it, so the
+// answers do not tell which accounts exist.
+func (lu *loginAttemptUsecase) RegisterFailure(c context.Context, email string, user *domain.User, client domain.ClientInfo) error {
+	ctx, cancel := context.WithTimeout(c, lu.contextTimeout)
+	defer cancel()
+
+	now := time.Now()
+	windowStart := now.Add(-lu.policy.FailureWindow)
+
+	account, err := lu.loginAttemptStore.RegisterFailure(ctx, accountKey(email), now, windowStart)
+	if err != nil {
+		return err
+	}
+	locked, err := lu.throttle(ctx, account, loginFreeFailures, lu.policy.MaxAccountFailures, now)
+	if err != nil {
+		return err
+	}
+	if locked && user != nil {
+		// LOG INTO USER LOG
+		lu.userLogRepository.Create(ctx, &domain.UserLog{
+			UserID:    user.ID,
+			IPAddress: client.IPAddress,
+			Action:    "account_locked",
+		})
+	}
+
+	ip, err := lu.loginAttemptStore.RegisterFailure(ctx, ipKey(client.IPAddress), now, windowStart)
+	if err != nil {
+		return err
+	}
+	// an IP may be shared by a whole office, it is delayed later than an account
+	locked, err = lu.throttle(ctx, ip, lu.policy.MaxIPFailures/2, lu.policy.MaxIPFailures, now)
+	if err != nil {
+		return err
+	}
+	if locked {
+		log.Printf("Login locked for IP %s after %d failed attempts", client.IPAddress, ip.Failures)
+	}
+	return nil
+}
+
+// throttle locks the key once it reaches maxFailures, otherwise delays its next attempt past freeFailures
+func (lu *loginAttemptUsecase) throttle(ctx context.Context, attempt domain.LoginAttempt, freeFailures int, maxFailures int, now time.Time) (locked bool, err error) {
+	if attempt.Failures >= maxFailures {
+		return true, lu.loginAttemptStore.Lock(ctx, attempt.Key, now.Add(lu.lockoutDuration(attempt.Lockouts)))
+	}
+	if delay := failureDelay(attempt.Failures, freeFailures); delay > 0 {
+		return false, lu.loginAttemptStore.Delay(ctx, attempt.Key, now.Add(delay))
+	}
+	return false, nil
+}
+
+// RegisterGuest counts a guest created from the client IP. Past MaxGuestsPerIPHour the
+// IP cannot create guests until the end of the hour started by its first one.
+func (lu *loginAttemptUsecase) RegisterGuest(c context.Context, client domain.ClientInfo) error {
+	ctx, cancel := context.WithTimeout(c, lu.contextTimeout)
+	defer cancel()
+
+	now := time.Now()
+	key := guestKey(client.IPAddress)
+	attempt, err := lu.loginAttemptStore.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	if attempt.LockedUntil != nil && attempt.LockedUntil.After(now) {
+		return &domain.LoginThrottledError{Err: domain.ErrTooManyGuests, RetryAfter: attempt.LockedUntil.Sub(now)}
+	}
+
+	attempt, err = lu.loginAttemptStore.RegisterFailure(ctx, key, now, now.Add(-guestWindow))
+	if err != nil {
+		return err
+	}
+	if attempt.Failures >= lu.policy.MaxGuestsPerIPHour {
+		log.Printf("Guest logins refused for IP %s after %d guests", client.IPAddress, attempt.Failures)
+		return lu.loginAttemptStore.Lock(ctx, key, attempt.WindowStart.Add(guestWindow))
+	}
+	return nil
+}
+
+// RegisterSuccess clears the account counters. The IP counters are kept: logging
+// into one account must not reset the attempts against the others.
+func (lu *loginAttemptUsecase) RegisterSuccess(c context.Context, email string) error {
+	ctx, cancel := context.WithTimeout(c, lu.contextTimeout)
+	defer cancel()
+
+	return lu.loginAttemptStore.Reset(ctx, accountKey(email))
+}
+
+// GetStatus returns the failed attempts and lockout of a user
+func (lu *loginAttemptUsecase) GetStatus(c context.Context, userID uint) (domain.LoginLockoutStatus, error) {
+	ctx, cancel := context.WithTimeout(c, lu.contextTimeout)
+	defer cancel()
+
+	user, err := lu.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return domain.LoginLockoutStatus{}, err
+	}
+	attempt, err := lu.loginAttemptStore.Get(ctx, accountKey(user.Email))
+	if err != nil {
+		return domain.LoginLockoutStatus{}, err
+	}
+
+	now := time.Now()
+	status := domain.LoginLockoutStatus{
+		UserID:   user.ID,
+		Email:    user.Email,
+		Failures: attempt.Failures,
+		Lockouts: attempt.Lockouts,
+	}
+	if attempt.LockedUntil != nil && attempt.LockedUntil.After(now) {
+		status.Locked = true
+		status.LockedUntil = attempt.LockedUntil.Format("2006-01-02 15:04:05")
+	}
+	if attempt.NextAttemptAt != nil && attempt.NextAttemptAt.After(now) {
+		status.NextAttemptAt = attempt.NextAttemptAt.Format("2006-01-02 15:04:05")
+	}
+	return status, nil
+}
+
+// Unlock clears the lockout and failed attempts of a user (admin action)
+func (lu *loginAttemptUsecase) Unlock(c context.Context, userID uint, client domain.ClientInfo) error {
+	ctx, cancel := context.WithTimeout(c, lu.contextTimeout)
+	defer cancel()
+
+	user, err := lu.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := lu.loginAttemptStore.Reset(ctx, accountKey(user.Email)); err != nil {
+		return err
+	}
+
+	// LOG INTO USER LOG
+	lu.userLogRepository.Create(ctx, &domain.UserLog{
+		UserID:    user.ID,
+		IPAddress: client.IPAddress,
+		Action:    "account_unlocked",
+	})
+	return nil
+}
+
+// UnlockIP clears the lockout and failed attempts of a client IP (admin action)
+func (lu *loginAttemptUsecase) UnlockIP(c context.Context, ipAddress string) error {
+	ctx, cancel := context.WithTimeout(c, lu.contextTimeout)
+	defer cancel()
+
+	return lu.loginAttemptStore.Reset(ctx, ipKey(ipAddress))
+}
+
+// Prune forgets the counters that no longer matter
+func (lu *loginAttemptUsecase) Prune(c context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(c, lu.contextTimeout)
+	defer cancel()
+
+	// keep the lockout history for a while, it makes the next lockouts longer
+	return lu.loginAttemptStore.Prune(ctx, time.Now().Add(-loginMaxLockout))
+}
+
+// lockoutDuration doubles the configured lockout for each previous lockout
+func (lu *loginAttemptUsecase) lockoutDuration(previousLockouts int) time.Duration {
+	duration := lu.policy.LockoutDuration
+	for i := 0; i < previousLockouts && duration < loginMaxLockout; i++ {
+		duration *= 2
+	}
+	return min(duration, loginMaxLockout)
+}
+
+// failureDelay is the wait imposed before the attempt following the given number of failures
+func failureDelay(failures int, freeFailures int) time.Duration {
+	if failures <= freeFailures {
+		return 0
+	}
+	delay := loginBaseDelay
+	for i := freeFailures + 1; i < failures && delay < loginMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, loginMaxDelay)
+}
+
+func accountKey(email string) string {
+	return domain.LoginAttemptKeyAccount + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ipAddress string) string {
+	return domain.LoginAttemptKeyIP + ipAddress
+}
+
+func guestKey(ipAddress string) string {
+	return domain.LoginAttemptKeyGuest + ipAddress
+}
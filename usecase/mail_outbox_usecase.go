@@ -0,0 +1,130 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal/mailer"
+)
+
+const (
+	mailBatchSize      = 50
+	mailMaxAttempts    = 8
+	mailRetryBaseDelay = 30 * time.Second
+	mailRetryMaxDelay  = 2 * time.Hour
+	mailSendTimeout    = 30 * time.Second
+)
+
+type MailOutboxUsecase struct {
+	mailOutboxRepository domain.MailOutboxRepository
+	mailer               domain.Mailer
+	contextTimeout       time.Duration
+}
+
+func NewMailOutboxUsecase(mailOutboxRepository domain.MailOutboxRepository, mailer domain.Mailer, timeout time.Duration) *MailOutboxUsecase {
+	return &MailOutboxUsecase{
+		mailOutboxRepository: mailOutboxRepository,
+		mailer:               mailer,
+		contextTimeout:       timeout,
+	}
+}
+
+func (mu *MailOutboxUsecase) Enqueue(c context.Context, to []string, template string, locale string, data any) error {
+	ctx, cancel := context.WithTimeout(c, mu.contextTimeout)
+	defer cancel()
+
+	locale = mailer.ResolveLocale(locale)
+	message, err := mailer.Render(template, locale, data)
+	if err != nil {
+		log.Printf("Failed to render mail template %s (%s): %v", template, locale, err)
+		return domain.ErrInternalServerError
+	}
+
+	return mu.mailOutboxRepository.Create(ctx, &domain.MailOutbox{
+		To:            strings.Join(to, ","),
+		Template:      template,
+		Locale:        locale,
+		Subject:       message.Subject,
+		TextBody:      message.TextBody,
+		HTMLBody:      message.HTMLBody,
+		Status:        domain.MailStatusPending,
+		NextAttemptAt: time.Now(),
+	})
+}
+
+func (mu *MailOutboxUsecase) DeliverPending(c context.Context) (delivered int, err error) {
+	ctx, cancel := context.WithTimeout(c, mu.contextTimeout)
+	defer cancel()
+
+	mails, err := mu.mailOutboxRepository.FetchDue(ctx, time.Now(), mailBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, mail := range mails {
+		// another instance may have picked it up; the lease also covers a crash mid-send.
+		// The lease starts now, not with the batch, which can take minutes to get here.
+		now := time.Now()
+		claimed, err := mu.mailOutboxRepository.Claim(c, mail.ID, now, now.Add(mailSendTimeout*2))
+		if err != nil {
+			return delivered, err
+		}
+		if !claimed {
+			continue
+		}
+
+		if err := mu.send(c, &mail); err != nil {
+			attempts := mail.Attempts + 1
+			log.Printf("Failed to deliver mail %d (attempt %d/%d): %v", mail.ID, attempts, mailMaxAttempts, err)
+			if attempts >= mailMaxAttempts {
+				if err := mu.mailOutboxRepository.MarkFailed(c, mail.ID, attempts, truncate(err.Error(), 1024)); err != nil {
+					log.Printf("Failed to mark mail %d as failed: %v", mail.ID, err)
+				}
+				continue
+			}
+			if err := mu.mailOutboxRepository.MarkRetry(c, mail.ID, attempts, time.Now().Add(mailRetryDelay(attempts)), truncate(err.Error(), 1024)); err != nil {
+				log.Printf("Failed to schedule the retry of mail %d: %v", mail.ID, err)
+			}
+			continue
+		}
+
+		// the mail is out; if this fails it goes out again once the lease ends
+		if err := mu.mailOutboxRepository.MarkSent(c, mail.ID, time.Now()); err != nil {
+			log.Printf("Failed to mark mail %d as sent, it may be sent again: %v", mail.ID, err)
+		}
+		delivered++
+	}
+
+	return delivered, nil
+}
+
+func (mu *MailOutboxUsecase) send(c context.Context, mail *domain.MailOutbox) error {
+	ctx, cancel := context.WithTimeout(c, mailSendTimeout)
+	defer cancel()
+
+	return mu.mailer.Send(ctx, &domain.MailMessage{
+		To:       strings.Split(mail.To, ","),
+		Subject:  mail.Subject,
+		TextBody: mail.TextBody,
+		HTMLBody: mail.HTMLBody,
+	})
+}
+
+// mailRetryDelay doubles the wait after every failed attempt (30s, 1m, 2m, ...) up to mailRetryMaxDelay
+func mailRetryDelay(attempts int) time.Duration {
+	delay := mailRetryBaseDelay << (attempts - 1)
+	if delay <= 0 || delay > mailRetryMaxDelay {
+		return mailRetryMaxDelay
+	}
+	return delay
+}
+
+func truncate(value string, size int) string {
+	if len(value) <= size {
+		return value
+	}
+	return value[:size]
+}
@@ -0,0 +1,146 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal/parser"
+)
+
+type metricsUsecase struct {
+	organizationMetricsRepository domain.OrganizationMetricsRepository
+	userMetricsRepository         domain.UserMetricsRepository
+	usageRollupRepository         domain.UsageRollupRepository
+	organizationRepository        domain.OrganizationRepository
+	userRepository                domain.UserRepository
+	contextTimeout                time.Duration
+}
+
+func NewMetricsUsecase(organizationMetricsRepository domain.OrganizationMetricsRepository, userMetricsRepository domain.UserMetricsRepository, usageRollupRepository domain.UsageRollupRepository, organizationRepository domain.OrganizationRepository, userRepository domain.UserRepository, timeout time.Duration) domain.MetricsUsecase {
+	return &metricsUsecase{
+		organizationMetricsRepository: organizationMetricsRepository,
+		userMetricsRepository:         userMetricsRepository,
+		usageRollupRepository:         usageRollupRepository,
+		organizationRepository:        organizationRepository,
+		userRepository:                userRepository,
+		contextTimeout:                timeout,
+	}
+}
+
+// RecordLogin counts a login of the user
+func (mu *metricsUsecase) RecordLogin(c context.Context, userID uint, ipAddress string) {
+	ctx, cancel := mu.recordContext(c)
+	defer cancel()
+
+	if err := mu.userMetricsRepository.AddLogin(ctx, userID, ipAddress, time.Now()); err != nil {
+		log.Printf("Failed to record the login of user %d in its metrics: %v", userID, err)
+	}
+}
+
+// RecordSession counts a usage session in the rollups of the hour and the day it started
+func (mu *metricsUsecase) RecordSession(c context.Context, usageLog domain.UserServiceLog) {
+	ctx, cancel := mu.recordContext(c)
+	defer cancel()
+
+	if err := mu.usageRollupRepository.AddSession(ctx, usageLog.UserID, usageLog.ServiceID, usageLog.StartedAt); err != nil {
+		log.Printf("Failed to record usage session %d in the usage rollups: %v", usageLog.ID, err)
+	}
+}
+
+// RecordUsage credits usage to the user, its organization and the rollups of the session
+func (mu *metricsUsecase) RecordUsage(c context.Context, usageLog domain.UserServiceLog, duration time.Duration) {
+	ctx, cancel := mu.recordContext(c)
+	defer cancel()
+
+	// the rollups first, the favorite service of the user is elected from them
+	userID := usageLog.UserID
+	if err := mu.usageRollupRepository.AddUsage(ctx, userID, usageLog.ServiceID, usageLog.StartedAt, duration); err != nil {
+		log.Printf("Failed to record the usage of session %d in the usage rollups: %v", usageLog.ID, err)
+	}
+	if err := mu.userMetricsRepository.AddUsage(ctx, userID, duration); err != nil {
+		log.Printf("Failed to record the usage of user %d in its metrics: %v", userID, err)
+	}
+	if err := mu.organizationMetricsRepository.AddUsage(ctx, userID, duration); err != nil {
+		log.Printf("Failed to record the usage of user %d in the metrics of its organization: %v", userID, err)
+	}
+}
+
+// RecordReport counts a report of the organization
+func (mu *metricsUsecase) RecordReport(c context.Context, organizationID uint) {
+	ctx, cancel := mu.recordContext(c)
+	defer cancel()
+
+	if err := mu.organizationMetricsRepository.AddReport(ctx, organizationID, time.Now()); err != nil {
+		log.Printf("Failed to record a report of organization %d in its metrics: %v", organizationID, err)
+	}
+}
+
+// Recompute rebuilds the metrics of every organization and user. It is not bound to the
+// context timeout, a backfill of a large database takes longer.
+func (mu *metricsUsecase) Recompute(ctx context.Context) (int, int, error) {
+	organizations, err := mu.organizationMetricsRepository.Recompute(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	users, err := mu.userMetricsRepository.Recompute(ctx)
+	if err != nil {
+		return organizations, 0, err
+	}
+	return organizations, users, nil
+}
+
+// ReconcileUsageRollups rebuilds the usage rollups from the usage logs. Like Recompute, it
+// is not bound to the context timeout.
+func (mu *metricsUsecase) ReconcileUsageRollups(ctx context.Context, since time.Time) (int, error) {
+	return mu.usageRollupRepository.Reconcile(ctx, since)
+}
+
+// GetOrganizationMetrics returns the metrics of the organization, its user and service counts refreshed
+func (mu *metricsUsecase) GetOrganizationMetrics(c context.Context, organizationID uint) (domain.PublicOrganizationMetrics, error) {
+	ctx, cancel := context.WithTimeout(c, mu.contextTimeout)
+	defer cancel()
+
+	if _, err := mu.organizationRepository.GetByID(ctx, organizationID); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.PublicOrganizationMetrics{}, domain.ErrNotFound
+		}
+		return domain.PublicOrganizationMetrics{}, domain.ErrInternalServerError
+	}
+
+	if err := mu.organizationMetricsRepository.RefreshCounts(ctx, organizationID); err != nil {
+		return domain.PublicOrganizationMetrics{}, err
+	}
+	metrics, err := mu.organizationMetricsRepository.GetByOrganizationID(ctx, organizationID)
+	if err != nil {
+		return domain.PublicOrganizationMetrics{}, err
+	}
+	return parser.ToPublicOrganizationMetrics(metrics, time.Now()), nil
+}
+
+// GetUserMetrics returns the metrics of the user along with its organization, for the caller to check access
+func (mu *metricsUsecase) GetUserMetrics(c context.Context, userID uint) (domain.PublicUserMetrics, error) {
+	ctx, cancel := context.WithTimeout(c, mu.contextTimeout)
+	defer cancel()
+
+	user, err := mu.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.PublicUserMetrics{}, domain.ErrNotFound
+		}
+		return domain.PublicUserMetrics{}, domain.ErrInternalServerError
+	}
+
+	metrics, err := mu.userMetricsRepository.GetByUserID(ctx, userID)
+	if err != nil {
+		return domain.PublicUserMetrics{}, err
+	}
+	return parser.ToPublicUserMetrics(metrics, user.OrganizationID), nil
+}
+
+// recordContext outlives the request of the event, which may be over once it succeeded
+func (mu *metricsUsecase) recordContext(c context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(c), mu.contextTimeout)
+}
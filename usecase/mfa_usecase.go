@@ -0,0 +1,281 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal/tokenutil"
+	"github.com/gabrielfmcoelho/platform-core/internal/totp"
+)
+
+type MFAUsecase struct {
+	mfaRepository     domain.MFARepository
+	userRepository    domain.UserRepository
+	userLogRepository domain.UserLogRepository
+	issuer            string
+	encryptionKey     string
+	contextTimeout    time.Duration
+}
+
+func NewMFAUsecase(mfaRepository domain.MFARepository, userRepository domain.UserRepository, userLogRepository domain.UserLogRepository, issuer string, encryptionKey string, timeout time.Duration) *MFAUsecase {
+	return &MFAUsecase{
+		mfaRepository:     mfaRepository,
+		userRepository:    userRepository,
+		userLogRepository: userLogRepository,
+		issuer:            issuer,
+		encryptionKey:     encryptionKey,
+		contextTimeout:    timeout,
+	}
+}
+
+func (mu *MFAUsecase) GetStatus(c context.Context, userID uint) (domain.MFAStatus, error) {
+	ctx, cancel := context.WithTimeout(c, mu.contextTimeout)
+	defer cancel()
+
+	user, err := mu.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return domain.MFAStatus{}, err
+	}
+	status := domain.MFAStatus{RequiredByOrganization: user.Organization.RequireMFA}
+
+	mfa, err := mu.mfaRepository.GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return status, nil
+		}
+		return status, err
+	}
+	status.Enabled = mfa.IsEnabled
+
+	if status.Enabled {
+		status.RecoveryCodesRemaining, err = mu.mfaRepository.CountRecoveryCodes(ctx, userID)
+		if err != nil {
+			return status, err
+		}
+	}
+	return status, nil
+}
+
+// Enroll generates a new TOTP secret. It only becomes active after Activate.
+func (mu *MFAUsecase) Enroll(c context.Context, userID uint) (*domain.MFAEnrollment, error) {
+	ctx, cancel := context.WithTimeout(c, mu.contextTimeout)
+	defer cancel()
+
+	mfa, err := mu.mfaRepository.GetByUserID(ctx, userID)
+	if err == nil && mfa.IsEnabled {
+		return nil, domain.ErrMFAAlreadyEnabled
+	}
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, err
+	}
+
+	user, err := mu.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, domain.ErrInternalServerError
+	}
+	secretEncrypted, err := tokenutil.EncryptSecret(secret, mu.encryptionKey)
+	if err != nil {
+		return nil, domain.ErrInternalServerError
+	}
+
+	if err := mu.mfaRepository.Save(ctx, &domain.UserMFA{UserID: userID, SecretEncrypted: secretEncrypted}); err != nil {
+		return nil, err
+	}
+
+	return &domain.MFAEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(mu.issuer, user.Email, secret),
+	}, nil
+}
+
+// Activate confirms the enrollment with a first code and returns the recovery codes
+func (mu *MFAUsecase) Activate(c context.Context, userID uint, code string) (*domain.MFARecoveryCodes, error) {
+	ctx, cancel := context.WithTimeout(c, mu.contextTimeout)
+	defer cancel()
+
+	mfa, err := mu.getMFA(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa.IsEnabled {
+		return nil, domain.ErrMFAAlreadyEnabled
+	}
+
+	step, err := mu.validateCode(&mfa, code)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := mu.mfaRepository.Enable(ctx, userID, time.Now(), step); err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, err := mu.replaceRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// LOG INTO USER LOG
+	mu.userLogRepository.Create(ctx, &domain.UserLog{
+		UserID: userID,
+		Action: "mfa_enabled",
+	})
+
+	return recoveryCodes, nil
+}
+
+func (mu *MFAUsecase) Verify(c context.Context, userID uint, code string, recoveryCode string) error {
+	ctx, cancel := context.WithTimeout(c, mu.contextTimeout)
+	defer cancel()
+
+	mfa, err := mu.getMFA(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !mfa.IsEnabled {
+		return domain.ErrMFANotEnrolled
+	}
+
+	if code != "" {
+		step, err := mu.validateCode(&mfa, code)
+		if err != nil {
+			return err
+		}
+		// refuses a code already accepted (replay within its validity window)
+		return mu.mfaRepository.UseStep(ctx, userID, step)
+	}
+
+	if recoveryCode != "" {
+		codeHash := tokenutil.HashOpaqueToken(totp.NormalizeRecoveryCode(recoveryCode))
+		if err := mu.mfaRepository.UseRecoveryCode(ctx, userID, codeHash, time.Now()); err != nil {
+			return err
+		}
+		mu.userLogRepository.Create(ctx, &domain.UserLog{
+			UserID: userID,
+			Action: "mfa_recovery_code_used",
+		})
+		return nil
+	}
+
+	return domain.ErrInvalidMFACode
+}
+
+// Disable removes the factor. Users of an organization that requires MFA cannot opt out.
+func (mu *MFAUsecase) Disable(c context.Context, userID uint, code string, recoveryCode string) error {
+	status, err := mu.GetStatus(c, userID)
+	if err != nil {
+		return err
+	}
+	if status.RequiredByOrganization {
+		return domain.ErrMFARequired
+	}
+
+	if err := mu.Verify(c, userID, code, recoveryCode); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(c, mu.contextTimeout)
+	defer cancel()
+
+	if err := mu.mfaRepository.DeleteByUserID(ctx, userID); err != nil {
+		return err
+	}
+
+	// LOG INTO USER LOG
+	mu.userLogRepository.Create(ctx, &domain.UserLog{
+		UserID: userID,
+		Action: "mfa_disabled",
+	})
+	return nil
+}
+
+func (mu *MFAUsecase) RegenerateRecoveryCodes(c context.Context, userID uint, code string) (*domain.MFARecoveryCodes, error) {
+	if code == "" {
+		return nil, domain.ErrInvalidMFACode
+	}
+	if err := mu.Verify(c, userID, code, ""); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(c, mu.contextTimeout)
+	defer cancel()
+
+	recoveryCodes, err := mu.replaceRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// LOG INTO USER LOG
+	mu.userLogRepository.Create(ctx, &domain.UserLog{
+		UserID: userID,
+		Action: "mfa_recovery_codes_regenerated",
+	})
+	return recoveryCodes, nil
+}
+
+func (mu *MFAUsecase) Reset(c context.Context, userID uint) error {
+	ctx, cancel := context.WithTimeout(c, mu.contextTimeout)
+	defer cancel()
+
+	if _, err := mu.getMFA(ctx, userID); err != nil {
+		return err
+	}
+	if err := mu.mfaRepository.DeleteByUserID(ctx, userID); err != nil {
+		return err
+	}
+
+	// LOG INTO USER LOG
+	mu.userLogRepository.Create(ctx, &domain.UserLog{
+		UserID: userID,
+		Action: "mfa_reset",
+	})
+	return nil
+}
+
+func (mu *MFAUsecase) getMFA(ctx context.Context, userID uint) (domain.UserMFA, error) {
+	mfa, err := mu.mfaRepository.GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return mfa, domain.ErrMFANotEnrolled
+		}
+		return mfa, err
+	}
+	return mfa, nil
+}
+
+func (mu *MFAUsecase) validateCode(mfa *domain.UserMFA, code string) (int64, error) {
+	secret, err := tokenutil.DecryptSecret(mfa.SecretEncrypted, mu.encryptionKey)
+	if err != nil {
+		return 0, domain.ErrInternalServerError
+	}
+	step, ok := totp.Validate(secret, code, time.Now())
+	if !ok || step <= mfa.LastUsedStep {
+		return 0, domain.ErrInvalidMFACode
+	}
+	return step, nil
+}
+
+func (mu *MFAUsecase) replaceRecoveryCodes(ctx context.Context, userID uint) (*domain.MFARecoveryCodes, error) {
+	codes := make([]string, 0, totp.RecoveryCodeCount)
+	hashes := make([]string, 0, totp.RecoveryCodeCount)
+	for i := 0; i < totp.RecoveryCodeCount; i++ {
+		code, err := totp.GenerateRecoveryCode()
+		if err != nil {
+			return nil, domain.ErrInternalServerError
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, tokenutil.HashOpaqueToken(code))
+	}
+
+	if err := mu.mfaRepository.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return &domain.MFARecoveryCodes{RecoveryCodes: codes}, nil
+}
@@ -0,0 +1,151 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal/parser"
+	"github.com/gabrielfmcoelho/platform-core/internal/tokenutil"
+)
+
+const oauthClientIDPrefix = "app_"
+
+type oauthClientUsecase struct {
+	oauthClientRepository domain.OAuthClientRepository
+	serviceRepository     domain.ServiceRepository
+	contextTimeout        time.Duration
+}
+
+func NewOAuthClientUsecase(oauthClientRepository domain.OAuthClientRepository, serviceRepository domain.ServiceRepository, timeout time.Duration) domain.OAuthClientUsecase {
+	return &oauthClientUsecase{
+		oauthClientRepository: oauthClientRepository,
+		serviceRepository:     serviceRepository,
+		contextTimeout:        timeout,
+	}
+}
+
+// Create registers a new OAuth client of a service and returns its credentials (the secret is only shown here)
+func (ou *oauthClientUsecase) Create(c context.Context, create *domain.CreateOAuthClient) (domain.OAuthClientCredentials, error) {
+	ctx, cancel := context.WithTimeout(c, ou.contextTimeout)
+	defer cancel()
+
+	var credentials domain.OAuthClientCredentials
+
+	service, err := ou.serviceRepository.GetByID(ctx, create.ServiceID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return credentials, domain.ErrNotFound
+		}
+		return credentials, domain.ErrInternalServerError
+	}
+
+	clientID, err := tokenutil.GenerateOpaqueToken(12)
+	if err != nil {
+		return credentials, domain.ErrInternalServerError
+	}
+	clientID = oauthClientIDPrefix + clientID
+
+	// public clients cannot keep a secret, PKCE alone proves they started the flow
+	clientSecret, secretHash := "", ""
+	if !create.Public {
+		clientSecret, secretHash, err = generateClientSecret()
+		if err != nil {
+			return credentials, err
+		}
+	}
+
+	client := domain.OAuthClient{
+		ServiceID:        service.ID,
+		Name:             create.Name,
+		ClientID:         clientID,
+		ClientSecretHash: secretHash,
+		RedirectURIs:     create.RedirectURIs,
+		FirstParty:       create.FirstParty,
+	}
+	if err := ou.oauthClientRepository.Create(ctx, &client); err != nil {
+		return credentials, err
+	}
+	client.Service = service
+
+	return domain.OAuthClientCredentials{
+		OAuthClient:  parser.ToPublicOAuthClient(client),
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+	}, nil
+}
+
+// Fetch returns all OAuth clients
+func (ou *oauthClientUsecase) Fetch(c context.Context) ([]domain.PublicOAuthClient, error) {
+	ctx, cancel := context.WithTimeout(c, ou.contextTimeout)
+	defer cancel()
+
+	clients, err := ou.oauthClientRepository.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	publicClients := make([]domain.PublicOAuthClient, 0, len(clients))
+	for _, client := range clients {
+		publicClients = append(publicClients, parser.ToPublicOAuthClient(client))
+	}
+	return publicClients, nil
+}
+
+// Update changes the name, the redirect URIs and the first-party flag of a client
+func (ou *oauthClientUsecase) Update(c context.Context, oauthClientID uint, update *domain.UpdateOAuthClient) (domain.PublicOAuthClient, error) {
+	ctx, cancel := context.WithTimeout(c, ou.contextTimeout)
+	defer cancel()
+
+	client, err := ou.oauthClientRepository.GetByID(ctx, oauthClientID)
+	if err != nil {
+		return domain.PublicOAuthClient{}, err
+	}
+
+	client.Name = update.Name
+	client.RedirectURIs = update.RedirectURIs
+	client.FirstParty = update.FirstParty
+	if err := ou.oauthClientRepository.Update(ctx, &client); err != nil {
+		return domain.PublicOAuthClient{}, err
+	}
+	return parser.ToPublicOAuthClient(client), nil
+}
+
+// RotateSecret issues a new client secret, invalidating the previous one. Public clients have no secret.
+func (ou *oauthClientUsecase) RotateSecret(c context.Context, oauthClientID uint) (domain.OAuthClientCredentials, error) {
+	ctx, cancel := context.WithTimeout(c, ou.contextTimeout)
+	defer cancel()
+
+	var credentials domain.OAuthClientCredentials
+
+	client, err := ou.oauthClientRepository.GetByID(ctx, oauthClientID)
+	if err != nil {
+		return credentials, err
+	}
+	if client.ClientSecretHash == "" {
+		return credentials, domain.ErrBadRequest
+	}
+
+	clientSecret, secretHash, err := generateClientSecret()
+	if err != nil {
+		return credentials, err
+	}
+	if err := ou.oauthClientRepository.UpdateSecret(ctx, client.ID, secretHash); err != nil {
+		return credentials, err
+	}
+
+	return domain.OAuthClientCredentials{
+		OAuthClient:  parser.ToPublicOAuthClient(client),
+		ClientID:     client.ClientID,
+		ClientSecret: clientSecret,
+	}, nil
+}
+
+// Delete revokes an OAuth client; codes not redeemed yet become useless
+func (ou *oauthClientUsecase) Delete(c context.Context, oauthClientID uint) error {
+	ctx, cancel := context.WithTimeout(c, ou.contextTimeout)
+	defer cancel()
+
+	return ou.oauthClientRepository.Delete(ctx, oauthClientID)
+}
@@ -0,0 +1,370 @@
+package usecase
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal"
+	"github.com/gabrielfmcoelho/platform-core/internal/oidc"
+	"github.com/gabrielfmcoelho/platform-core/internal/parser"
+	"github.com/gabrielfmcoelho/platform-core/internal/password"
+	"github.com/gabrielfmcoelho/platform-core/internal/tokenutil"
+)
+
+// oauthCodeLifetime is how long a client has to redeem an authorization code
+const oauthCodeLifetime = time.Minute
+
+type oauthUsecase struct {
+	oauthClientRepository       domain.OAuthClientRepository
+	authorizationCodeRepository domain.OAuthAuthorizationCodeRepository
+	consentRepository           domain.OAuthConsentRepository
+	userRepository              domain.UserRepository
+	organizationRepository      domain.OrganizationRepository
+	userLogRepository           domain.UserLogRepository
+	keyRing                     domain.KeyRing
+	issuer                      string
+	authorizationURL            string
+	tokenExpiryMinute           int
+	contextTimeout              time.Duration
+}
+
+func NewOAuthUsecase(oauthClientRepository domain.OAuthClientRepository, authorizationCodeRepository domain.OAuthAuthorizationCodeRepository, consentRepository domain.OAuthConsentRepository, userRepository domain.UserRepository, organizationRepository domain.OrganizationRepository, userLogRepository domain.UserLogRepository, keyRing domain.KeyRing, issuer string, authorizationURL string, tokenExpiryMinute int, timeout time.Duration) domain.OAuthUsecase {
+	return &oauthUsecase{
+		oauthClientRepository:       oauthClientRepository,
+		authorizationCodeRepository: authorizationCodeRepository,
+		consentRepository:           consentRepository,
+		userRepository:              userRepository,
+		organizationRepository:      organizationRepository,
+		userLogRepository:           userLogRepository,
+		keyRing:                     keyRing,
+		issuer:                      issuer,
+		authorizationURL:            authorizationURL,
+		tokenExpiryMinute:           tokenExpiryMinute,
+		contextTimeout:              timeout,
+	}
+}
+
+// Authorize validates an authorization request of the signed-in user. First-party
+// clients, and third-party clients the user already consented to, get a code
+// right away; other clients need the consent of the user first (see Consent).
+func (ou *oauthUsecase) Authorize(c context.Context, userID uint, request *domain.AuthorizeRequest) (domain.AuthorizeResponse, error) {
+	ctx, cancel := context.WithTimeout(c, ou.contextTimeout)
+	defer cancel()
+
+	client, scopes, err := ou.validateAuthorization(ctx, userID, request)
+	if err != nil {
+		return ou.redirectError(request, err)
+	}
+
+	if !client.FirstParty {
+		consent, err := ou.consentRepository.Get(ctx, userID, client.ID)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return domain.AuthorizeResponse{}, err
+		}
+		if err != nil || !coversScopes(strings.Fields(consent.Scope), scopes) {
+			publicClient := parser.ToPublicOAuthClient(client)
+			return domain.AuthorizeResponse{
+				ConsentRequired: true,
+				Client:          &publicClient,
+				Scopes:          scopes,
+			}, nil
+		}
+	}
+
+	return ou.issueCode(ctx, userID, &client, scopes, request)
+}
+
+// Consent records the answer of the user to the consent screen and completes the authorization
+func (ou *oauthUsecase) Consent(c context.Context, userID uint, request *domain.ConsentRequest) (domain.AuthorizeResponse, error) {
+	ctx, cancel := context.WithTimeout(c, ou.contextTimeout)
+	defer cancel()
+
+	client, scopes, err := ou.validateAuthorization(ctx, userID, &request.AuthorizeRequest)
+	if err != nil {
+		return ou.redirectError(&request.AuthorizeRequest, err)
+	}
+
+	if !*request.Approve {
+		return ou.redirectError(&request.AuthorizeRequest, &domain.OAuthRedirectError{Code: "access_denied", Description: "the user denied the request"})
+	}
+
+	// keep what was granted before, a client asking less does not lose the rest
+	granted := slices.Clone(scopes)
+	if consent, err := ou.consentRepository.Get(ctx, userID, client.ID); err == nil {
+		for _, scope := range strings.Fields(consent.Scope) {
+			if !slices.Contains(granted, scope) {
+				granted = append(granted, scope)
+			}
+		}
+	}
+	if err := ou.consentRepository.Save(ctx, &domain.OAuthConsent{
+		UserID:        userID,
+		OAuthClientID: client.ID,
+		Scope:         strings.Join(granted, " "),
+	}); err != nil {
+		return domain.AuthorizeResponse{}, err
+	}
+
+	// LOG INTO USER LOG
+	ou.userLogRepository.Create(ctx, &domain.UserLog{
+		UserID: userID,
+		Action: "oauth_consent_granted",
+	})
+
+	return ou.issueCode(ctx, userID, &client, scopes, &request.AuthorizeRequest)
+}
+
+// ExchangeCode implements the authorization_code grant (RFC 6749 section 4.1.3) with
+// the PKCE verification of RFC 7636. It returns an access token for /userinfo and
+// an ID token carrying the organization of the user.
+func (ou *oauthUsecase) ExchangeCode(c context.Context, request *domain.TokenRequest) (domain.TokenResponse, error) {
+	ctx, cancel := context.WithTimeout(c, ou.contextTimeout)
+	defer cancel()
+
+	var response domain.TokenResponse
+
+	if request.GrantType != domain.GrantTypeAuthorizationCode {
+		return response, domain.ErrUnsupportedGrantType
+	}
+	client, err := authenticateOAuthClient(ctx, ou.oauthClientRepository, request.ClientID, request.ClientSecret)
+	if err != nil {
+		return response, err
+	}
+
+	if request.Code == "" || request.CodeVerifier == "" {
+		return response, domain.ErrInvalidGrant
+	}
+	// a client cannot burn the code of another one
+	code, err := ou.authorizationCodeRepository.Consume(ctx, tokenutil.HashOpaqueToken(request.Code), client.ID, time.Now())
+	if err != nil {
+		return response, err
+	}
+	if code.RedirectURI != request.RedirectURI ||
+		subtle.ConstantTimeCompare([]byte(oidc.CodeChallenge(request.CodeVerifier)), []byte(code.CodeChallenge)) != 1 {
+		return response, domain.ErrInvalidGrant
+	}
+
+	user, err := ou.userRepository.GetByID(ctx, code.UserID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			// archived since the code was issued
+			return response, domain.ErrInvalidGrant
+		}
+		return response, domain.ErrInternalServerError
+	}
+
+	accessToken, idToken, expiresIn, err := tokenutil.CreateOAuthTokens(&user, client.ClientID, code.Scope, code.Nonce, ou.issuer, ou.keyRing, ou.tokenExpiryMinute)
+	if err != nil {
+		return response, domain.ErrInternalServerError
+	}
+
+	// Not critical for the token issuance, only informative
+	ou.oauthClientRepository.TouchLastUsed(ctx, client.ID, time.Now())
+
+	return domain.TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   expiresIn,
+		Scope:       code.Scope,
+		IDToken:     idToken,
+	}, nil
+}
+
+// UserInfo returns the claims of the user behind an OAuth access token, filtered by its scopes
+func (ou *oauthUsecase) UserInfo(c context.Context, accessToken string) (domain.UserInfoResponse, error) {
+	ctx, cancel := context.WithTimeout(c, ou.contextTimeout)
+	defer cancel()
+
+	claims, err := tokenutil.ExtractOAuthAccessClaimsFromToken(accessToken, ou.keyRing, ou.issuer)
+	if err != nil {
+		return domain.UserInfoResponse{}, domain.ErrInvalidOAuthToken
+	}
+	userID, err := internal.ParseUint(claims.Subject)
+	if err != nil {
+		return domain.UserInfoResponse{}, domain.ErrInvalidOAuthToken
+	}
+
+	user, err := ou.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.UserInfoResponse{}, domain.ErrInvalidOAuthToken
+		}
+		return domain.UserInfoResponse{}, domain.ErrInternalServerError
+	}
+	return parser.ToUserInfoResponse(&user, strings.Fields(claims.Scope)), nil
+}
+
+// Discovery returns the OpenID provider metadata of the core
+func (ou *oauthUsecase) Discovery() domain.OpenIDConfiguration {
+	return domain.OpenIDConfiguration{
+		Issuer:                            ou.issuer,
+		AuthorizationEndpoint:             ou.authorizationURL,
+		TokenEndpoint:                     ou.issuer + "/token",
+		UserInfoEndpoint:                  ou.issuer + "/oauth/userinfo",
+		JWKSURI:                           ou.issuer + "/.well-known/jwks.json",
+		ScopesSupported:                   domain.OAuthAvailableScopes,
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{domain.GrantTypeAuthorizationCode}, // client_credentials is for service accounts only
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{domain.SigningAlgorithmRS256, domain.SigningAlgorithmEdDSA},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+		ClaimsSupported: []string{
+			"iss", "sub", "aud", "exp", "iat", "nonce", "name", "email", "email_verified",
+			"user_role_id", "organization_id", "organization_name", "organization_role_id",
+		},
+	}
+}
+
+// validateAuthorization checks the client and the redirect URI first: when they are
+// wrong the error is shown to the user (ErrInvalidRedirectURI), never sent to the
+// URI. Any other problem is an OAuthRedirectError reported to the client.
+func (ou *oauthUsecase) validateAuthorization(ctx context.Context, userID uint, request *domain.AuthorizeRequest) (domain.OAuthClient, []string, error) {
+	client, err := ou.oauthClientRepository.GetByClientID(ctx, request.ClientID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return client, nil, domain.ErrInvalidRedirectURI
+		}
+		return client, nil, domain.ErrInternalServerError
+	}
+	if !slices.Contains(client.RedirectURIs, request.RedirectURI) {
+		return client, nil, domain.ErrInvalidRedirectURI
+	}
+
+	if request.ResponseType != "code" {
+		return client, nil, &domain.OAuthRedirectError{Code: "unsupported_response_type", Description: "only the code response type is supported"}
+	}
+	if request.CodeChallenge == "" || request.CodeChallengeMethod != "S256" {
+		return client, nil, &domain.OAuthRedirectError{Code: "invalid_request", Description: "a S256 code challenge is required"}
+	}
+
+	scopes := strings.Fields(request.Scope)
+	if !slices.Contains(scopes, domain.OAuthScopeOpenID) {
+		return client, nil, &domain.OAuthRedirectError{Code: "invalid_scope", Description: "the openid scope is required"}
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(domain.OAuthAvailableScopes, scope) {
+			return client, nil, &domain.OAuthRedirectError{Code: "invalid_scope", Description: "unknown scope " + scope}
+		}
+	}
+	scopes = slices.Compact(slices.Sorted(slices.Values(scopes)))
+
+	// the service of the client must be subscribed by the organization of the user, with an active subscription
+	user, err := ou.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return client, nil, domain.ErrInternalServerError
+	}
+	if user.RoleID != domain.UserRoleAdmin || user.Organization.RoleID != domain.OrganizationRoleAdmin {
+		organization, err := ou.organizationRepository.GetByID(ctx, user.OrganizationID)
+		if err != nil {
+			return client, nil, domain.ErrInternalServerError
+		}
+		subscribed := slices.ContainsFunc(organization.SubscribedServices, func(service domain.Service) bool {
+			return service.ID == client.ServiceID
+		})
+		if !subscribed {
+			return client, nil, &domain.OAuthRedirectError{Code: "access_denied", Description: domain.ErrServiceNotSubscribed.Error()}
+		}
+		if err := organization.Subscription.CheckAccess(time.Now()); err != nil {
+			return client, nil, &domain.OAuthRedirectError{Code: "access_denied", Description: err.Error()}
+		}
+	}
+
+	return client, scopes, nil
+}
+
+// authenticateOAuthClient checks the credentials of a client; public clients only send their client ID
+func authenticateOAuthClient(ctx context.Context, oauthClientRepository domain.OAuthClientRepository, clientID string, clientSecret string) (domain.OAuthClient, error) {
+	if clientID == "" {
+		return domain.OAuthClient{}, domain.ErrInvalidClient
+	}
+
+	client, err := oauthClientRepository.GetByClientID(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return client, domain.ErrInvalidClient
+		}
+		return client, domain.ErrInternalServerError
+	}
+	if client.ClientSecretHash != "" {
+		if clientSecret == "" || password.VerifyPassword(client.ClientSecretHash, clientSecret) != nil {
+			return client, domain.ErrInvalidClient
+		}
+	}
+	return client, nil
+}
+
+// issueCode stores a new authorization code and returns the redirect carrying it
+func (ou *oauthUsecase) issueCode(ctx context.Context, userID uint, client *domain.OAuthClient, scopes []string, request *domain.AuthorizeRequest) (domain.AuthorizeResponse, error) {
+	code, err := tokenutil.GenerateOpaqueToken(32)
+	if err != nil {
+		return domain.AuthorizeResponse{}, domain.ErrInternalServerError
+	}
+
+	err = ou.authorizationCodeRepository.Create(ctx, &domain.OAuthAuthorizationCode{
+		CodeHash:      tokenutil.HashOpaqueToken(code),
+		OAuthClientID: client.ID,
+		UserID:        userID,
+		RedirectURI:   request.RedirectURI,
+		Scope:         strings.Join(scopes, " "),
+		Nonce:         request.Nonce,
+		CodeChallenge: request.CodeChallenge,
+		ExpiresAt:     time.Now().Add(oauthCodeLifetime),
+	})
+	if err != nil {
+		return domain.AuthorizeResponse{}, err
+	}
+
+	return domain.AuthorizeResponse{
+		RedirectTo: redirectWith(request.RedirectURI, url.Values{"code": {code}}, request.State),
+	}, nil
+}
+
+// redirectError turns an OAuthRedirectError into a redirect to the client; other errors are returned as is
+func (ou *oauthUsecase) redirectError(request *domain.AuthorizeRequest, err error) (domain.AuthorizeResponse, error) {
+	var redirectErr *domain.OAuthRedirectError
+	if !errors.As(err, &redirectErr) {
+		return domain.AuthorizeResponse{}, err
+	}
+	log.Printf("Authorization request of client %s refused: %v", request.ClientID, redirectErr)
+	return domain.AuthorizeResponse{
+		RedirectTo: redirectWith(request.RedirectURI, url.Values{
+			"error":             {redirectErr.Code},
+			"error_description": {redirectErr.Description},
+		}, request.State),
+	}, nil
+}
+
+// redirectWith adds the parameters and the state to the query of a registered redirect URI
+func redirectWith(redirectURI string, params url.Values, state string) string {
+	target, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+	query := target.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	if state != "" {
+		query.Set("state", state)
+	}
+	target.RawQuery = query.Encode()
+	return target.String()
+}
+
+// coversScopes reports whether every requested scope was granted
+func coversScopes(granted []string, requested []string) bool {
+	for _, scope := range requested {
+		if !slices.Contains(granted, scope) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,178 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal/parser"
+)
+
+type organizationSubscriptionUsecase struct {
+	subscriptionRepository domain.OrganizationSubscriptionRepository
+	organizationRepository domain.OrganizationRepository
+	reportRepository       domain.ServiceReportRepository
+	graceDays              int
+	contextTimeout         time.Duration
+}
+
+func NewOrganizationSubscriptionUsecase(subscriptionRepository domain.OrganizationSubscriptionRepository, organizationRepository domain.OrganizationRepository, reportRepository domain.ServiceReportRepository, graceDays int, timeout time.Duration) domain.OrganizationSubscriptionUsecase {
+	return &organizationSubscriptionUsecase{
+		subscriptionRepository: subscriptionRepository,
+		organizationRepository: organizationRepository,
+		reportRepository:       reportRepository,
+		graceDays:              graceDays,
+		contextTimeout:         timeout,
+	}
+}
+
+// Create subscribes an organization to a plan; an organization has at most one subscription
+func (su *organizationSubscriptionUsecase) Create(c context.Context, organizationID uint, request *domain.SubscriptionRequest) (domain.PublicSubscription, error) {
+	ctx, cancel := context.WithTimeout(c, su.contextTimeout)
+	defer cancel()
+
+	organization, err := su.organizationRepository.GetByID(ctx, organizationID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.PublicSubscription{}, domain.ErrNotFound
+		}
+		return domain.PublicSubscription{}, domain.ErrInternalServerError
+	}
+	if organization.Subscription.ID != 0 {
+		return domain.PublicSubscription{}, domain.ErrSubscriptionExists
+	}
+
+	subscription := parser.ToOrganizationSubscription(organizationID, request)
+	subscription.GraceEndsAt = graceEnd(subscription.EndsAt, su.graceDays)
+	if err := su.subscriptionRepository.Create(ctx, &subscription); err != nil {
+		return domain.PublicSubscription{}, err
+	}
+	organization.Subscription = subscription
+
+	return su.toPublic(ctx, organization)
+}
+
+// GetByOrganizationID returns the subscription of an organization with its usage
+func (su *organizationSubscriptionUsecase) GetByOrganizationID(c context.Context, organizationID uint) (domain.PublicSubscription, error) {
+	ctx, cancel := context.WithTimeout(c, su.contextTimeout)
+	defer cancel()
+
+	organization, err := su.getSubscribedOrganization(ctx, organizationID)
+	if err != nil {
+		return domain.PublicSubscription{}, err
+	}
+	return su.toPublic(ctx, organization)
+}
+
+// Update replaces the plan and the dates of the subscription. Lowering a limit below
+// the current usage does not remove anyone, it only blocks what comes next. The
+// expiry e-mails are sent again for the new end date.
+func (su *organizationSubscriptionUsecase) Update(c context.Context, organizationID uint, request *domain.SubscriptionRequest) (domain.PublicSubscription, error) {
+	ctx, cancel := context.WithTimeout(c, su.contextTimeout)
+	defer cancel()
+
+	organization, err := su.getSubscribedOrganization(ctx, organizationID)
+	if err != nil {
+		return domain.PublicSubscription{}, err
+	}
+
+	subscription := parser.ToOrganizationSubscription(organizationID, request)
+	subscription.GraceEndsAt = graceEnd(subscription.EndsAt, su.graceDays)
+	if err := su.subscriptionRepository.Update(ctx, organization.Subscription.ID, &subscription); err != nil {
+		return domain.PublicSubscription{}, err
+	}
+
+	return su.reload(ctx, organization)
+}
+
+// SetActive activates or deactivates the subscription
+func (su *organizationSubscriptionUsecase) SetActive(c context.Context, organizationID uint, active bool) (domain.PublicSubscription, error) {
+	ctx, cancel := context.WithTimeout(c, su.contextTimeout)
+	defer cancel()
+
+	organization, err := su.getSubscribedOrganization(ctx, organizationID)
+	if err != nil {
+		return domain.PublicSubscription{}, err
+	}
+
+	if err := su.subscriptionRepository.SetActive(ctx, organization.Subscription.ID, active); err != nil {
+		return domain.PublicSubscription{}, err
+	}
+
+	return su.reload(ctx, organization)
+}
+
+// Delete removes the subscription; the organization is no longer limited
+func (su *organizationSubscriptionUsecase) Delete(c context.Context, organizationID uint) error {
+	ctx, cancel := context.WithTimeout(c, su.contextTimeout)
+	defer cancel()
+
+	organization, err := su.getSubscribedOrganization(ctx, organizationID)
+	if err != nil {
+		return err
+	}
+	return su.subscriptionRepository.Delete(ctx, organization.Subscription.ID)
+}
+
+// FetchRenewals returns the periods added to the subscription by its automatic renewal
+func (su *organizationSubscriptionUsecase) FetchRenewals(c context.Context, organizationID uint) ([]domain.PublicSubscriptionRenewal, error) {
+	ctx, cancel := context.WithTimeout(c, su.contextTimeout)
+	defer cancel()
+
+	organization, err := su.getSubscribedOrganization(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	renewals, err := su.subscriptionRepository.FetchRenewals(ctx, organization.Subscription.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	publicRenewals := make([]domain.PublicSubscriptionRenewal, 0, len(renewals))
+	for _, renewal := range renewals {
+		publicRenewals = append(publicRenewals, parser.ToPublicSubscriptionRenewal(renewal))
+	}
+	return publicRenewals, nil
+}
+
+// getSubscribedOrganization returns the organization, ErrNotFound when it has no subscription
+func (su *organizationSubscriptionUsecase) getSubscribedOrganization(ctx context.Context, organizationID uint) (domain.Organization, error) {
+	organization, err := su.organizationRepository.GetByID(ctx, organizationID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return organization, domain.ErrNotFound
+		}
+		return organization, domain.ErrInternalServerError
+	}
+	if organization.Subscription.ID == 0 {
+		return organization, domain.ErrNotFound
+	}
+	return organization, nil
+}
+
+// reload reads the subscription back after a change
+func (su *organizationSubscriptionUsecase) reload(ctx context.Context, organization domain.Organization) (domain.PublicSubscription, error) {
+	subscription, err := su.subscriptionRepository.GetByID(ctx, organization.Subscription.ID)
+	if err != nil {
+		return domain.PublicSubscription{}, err
+	}
+	organization.Subscription = subscription
+	return su.toPublic(ctx, organization)
+}
+
+func (su *organizationSubscriptionUsecase) toPublic(ctx context.Context, organization domain.Organization) (domain.PublicSubscription, error) {
+	now := time.Now()
+	reportsUsed, err := su.reportRepository.CountByOrganizationSince(ctx, organization.ID, domain.MonthStart(now))
+	if err != nil {
+		return domain.PublicSubscription{}, err
+	}
+	return parser.ToPublicSubscription(organization.Subscription, now, countMembers(organization), reportsUsed), nil
+}
+
+// graceEnd is the end of the grace period of a subscription ending at endsAt
+func graceEnd(endsAt time.Time, graceDays int) *time.Time {
+	end := endsAt.AddDate(0, 0, max(graceDays, 0))
+	return &end
+}
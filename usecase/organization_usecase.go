@@ -4,28 +4,33 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/gabrielfmcoelho/platform-core/domain"
 	"github.com/gabrielfmcoelho/platform-core/internal/parser"
 )
 
 type organizationUsecase struct {
-	repo domain.OrganizationRepository
+	repo     domain.OrganizationRepository
+	roleRepo domain.OrganizationRoleRepository
 }
 
 // NewOrganizationUsecase retorna uma instância que implementa a interface OrganizationUsecase
-func NewOrganizationUsecase(repo domain.OrganizationRepository) domain.OrganizationUsecase {
+func NewOrganizationUsecase(repo domain.OrganizationRepository, roleRepo domain.OrganizationRoleRepository) domain.OrganizationUsecase {
 	return &organizationUsecase{
-		repo: repo,
+		repo:     repo,
+		roleRepo: roleRepo,
 	}
 }
 
 // Create cria uma nova organização
 func (uc *organizationUsecase) Create(ctx context.Context, organization *domain.Organization) error {
-	// Poderia validar se a Role existe, etc.
 	if organization.Name == "" {
 		return errors.New("organization name is required")
 	}
+	if err := uc.validate(ctx, 0, organization.Name, organization.RoleID); err != nil {
+		return err
+	}
 
 	// Criar no repositório
 	if err := uc.repo.Create(ctx, organization); err != nil {
@@ -34,22 +39,28 @@ func (uc *organizationUsecase) Create(ctx context.Context, organization *domain.
 	return nil
 }
 
-// Fetch retorna todas as organizações, convertendo para PublicOrganization
-func (uc *organizationUsecase) Fetch(ctx context.Context) ([]domain.PublicOrganization, error) {
-	orgs, err := uc.repo.Fetch(ctx)
+// Fetch retorna todas as organizações (ou as arquivadas), convertendo para PublicOrganization
+func (uc *organizationUsecase) Fetch(ctx context.Context, archived bool) ([]domain.PublicOrganization, error) {
+	var orgs []domain.Organization
+	var err error
+	if archived {
+		orgs, err = uc.repo.FetchArchived(ctx)
+	} else {
+		orgs, err = uc.repo.Fetch(ctx)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	var result []domain.PublicOrganization
+	result := make([]domain.PublicOrganization, 0, len(orgs))
 	for _, org := range orgs {
 		result = append(result, parser.ToPublicOrganization(org))
 	}
 	return result, nil
 }
 
-// GetByIdentifier busca organização por ID ou Nome (exemplo)
-func (uc *organizationUsecase) GetByIdentifier(ctx context.Context, identifier string) (domain.PublicOrganization, error) {
+// GetByIdentifier busca organização por ID ou Nome, com usuários, serviços e assinatura
+func (uc *organizationUsecase) GetByIdentifier(ctx context.Context, identifier string) (domain.OrganizationDetails, error) {
 	// tentar converter identifier para uint
 	var org domain.Organization
 	var err error
@@ -63,9 +74,9 @@ func (uc *organizationUsecase) GetByIdentifier(ctx context.Context, identifier s
 		org, err = uc.repo.GetByName(ctx, identifier)
 	}
 	if err != nil {
-		return domain.PublicOrganization{}, err
+		return domain.OrganizationDetails{}, err
 	}
-	return parser.ToPublicOrganization(org), nil
+	return parser.ToOrganizationDetails(org), nil
 }
 
 // GetUsers retorna a lista de usuários da organização, convertendo para PublicUser
@@ -97,12 +108,97 @@ func (uc *organizationUsecase) GetSubscribedServices(ctx context.Context, id uin
 	return uc.repo.GetSubscribedServices(ctx, id)
 }
 
-// Update atualiza uma organização
-func (uc *organizationUsecase) Update(ctx context.Context, organizationID uint, organization *domain.Organization) error {
-	return uc.repo.Update(ctx, organizationID, organization)
+// Update aplica os campos enviados. Mudar o papel da organização exige allowRoleChange
+// (admins da plataforma); reenviar o papel atual é aceito.
+func (uc *organizationUsecase) Update(ctx context.Context, organizationID uint, patch *domain.PatchOrganization, allowRoleChange bool) (domain.OrganizationDetails, error) {
+	org, err := uc.repo.GetByID(ctx, organizationID)
+	if err != nil {
+		return domain.OrganizationDetails{}, err
+	}
+
+	if patch.OrganizationRoleID != nil && *patch.OrganizationRoleID != org.RoleID {
+		if !allowRoleChange {
+			return domain.OrganizationDetails{}, domain.ErrForbidden
+		}
+		org.RoleID = *patch.OrganizationRoleID
+	}
+	if patch.Name != nil {
+		org.Name = strings.TrimSpace(*patch.Name)
+		if org.Name == "" {
+			return domain.OrganizationDetails{}, domain.ErrBadRequest
+		}
+	}
+	if patch.Nickname != nil {
+		org.Nickname = strings.TrimSpace(*patch.Nickname)
+	}
+	if patch.LogoUrl != nil {
+		org.LogoUrl = *patch.LogoUrl
+	}
+
+	if err := uc.validate(ctx, org.ID, org.Name, org.RoleID); err != nil {
+		return domain.OrganizationDetails{}, err
+	}
+	if err := uc.repo.Update(ctx, organizationID, &domain.Organization{
+		Name:     org.Name,
+		Nickname: org.Nickname,
+		LogoUrl:  org.LogoUrl,
+		RoleID:   org.RoleID,
+	}); err != nil {
+		return domain.OrganizationDetails{}, err
+	}
+
+	// recarrega para trazer o papel atualizado
+	org, err = uc.repo.GetByID(ctx, organizationID)
+	if err != nil {
+		return domain.OrganizationDetails{}, err
+	}
+	return parser.ToOrganizationDetails(org), nil
+}
+
+// SetMFAPolicy define se a organização exige MFA de todos os seus usuários
+func (uc *organizationUsecase) SetMFAPolicy(ctx context.Context, organizationID uint, requireMFA bool) (domain.PublicOrganization, error) {
+	if err := uc.repo.SetRequireMFA(ctx, organizationID, requireMFA); err != nil {
+		return domain.PublicOrganization{}, err
+	}
+	org, err := uc.repo.GetByID(ctx, organizationID)
+	if err != nil {
+		return domain.PublicOrganization{}, err
+	}
+	return parser.ToPublicOrganization(org), nil
 }
 
 // Delete remove a organização
 func (uc *organizationUsecase) Delete(ctx context.Context, organizationID uint) error {
 	return uc.repo.Delete(ctx, organizationID)
 }
+
+// Restore reativa uma organização removida
+func (uc *organizationUsecase) Restore(ctx context.Context, organizationID uint) (domain.OrganizationDetails, error) {
+	if err := uc.repo.Restore(ctx, organizationID); err != nil {
+		return domain.OrganizationDetails{}, err
+	}
+	org, err := uc.repo.GetByID(ctx, organizationID)
+	if err != nil {
+		return domain.OrganizationDetails{}, err
+	}
+	return parser.ToOrganizationDetails(org), nil
+}
+
+// validate verifica se o nome está livre e se o papel existe
+func (uc *organizationUsecase) validate(ctx context.Context, organizationID uint, name string, roleID uint) error {
+	taken, err := uc.repo.NameTaken(ctx, name, organizationID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return domain.ErrOrganizationNameTaken
+	}
+
+	if _, err := uc.roleRepo.GetByID(ctx, roleID); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.ErrOrgRoleNotFound
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal/password"
+)
+
+type passwordUsecase struct {
+	userRepository            domain.UserRepository
+	passwordHistoryRepository domain.PasswordHistoryRepository
+	policy                    domain.PasswordPolicy
+	contextTimeout            time.Duration
+
+	// dummyHash is compared on logins without a user, made once with the policy cost
+	dummyHash     string
+	dummyHashOnce sync.Once
+}
+
+func NewPasswordUsecase(userRepository domain.UserRepository, passwordHistoryRepository domain.PasswordHistoryRepository, policy domain.PasswordPolicy, timeout time.Duration) domain.PasswordUsecase {
+	return &passwordUsecase{
+		userRepository:            userRepository,
+		passwordHistoryRepository: passwordHistoryRepository,
+		policy:                    policy,
+		contextTimeout:            timeout,
+	}
+}
+
+// Hash refuses passwords breaking the policy. For an existing user the current password
+// and the last HistorySize ones cannot be reused.
+func (pu *passwordUsecase) Hash(c context.Context, user *domain.User, rawPassword string) (string, error) {
+	ctx, cancel := context.WithTimeout(c, pu.contextTimeout)
+	defer cancel()
+
+	if err := password.Validate(pu.policy, rawPassword); err != nil {
+		return "", err
+	}
+
+	if user != nil && user.ID != 0 && pu.policy.HistorySize > 0 {
+		history, err := pu.passwordHistoryRepository.FetchRecentByUserID(ctx, user.ID, pu.policy.HistorySize)
+		if err != nil {
+			return "", err
+		}
+		hashes := []string{user.Password}
+		for _, entry := range history {
+			hashes = append(hashes, entry.PasswordHash)
+		}
+		for _, hash := range hashes {
+			if hash != "" && password.VerifyPassword(hash, rawPassword) == nil {
+				return "", &domain.PasswordPolicyError{
+					Err:         domain.ErrPasswordReused,
+					Requirement: fmt.Sprintf("choose a password different from your last %d", pu.policy.HistorySize),
+				}
+			}
+		}
+	}
+
+	return password.HashPasswordWithCost(rawPassword, pu.policy.BcryptCost)
+}
+
+// Record keeps the hash in the history of the user, only the last HistorySize are kept
+func (pu *passwordUsecase) Record(c context.Context, userID uint, hashedPassword string) error {
+	ctx, cancel := context.WithTimeout(c, pu.contextTimeout)
+	defer cancel()
+
+	if pu.policy.HistorySize <= 0 {
+		return nil
+	}
+	if err := pu.passwordHistoryRepository.Create(ctx, &domain.PasswordHistory{
+		UserID:       userID,
+		PasswordHash: hashedPassword,
+	}); err != nil {
+		return err
+	}
+	return pu.passwordHistoryRepository.Prune(ctx, userID, pu.policy.HistorySize)
+}
+
+func (pu *passwordUsecase) Save(c context.Context, userID uint, hashedPassword string) error {
+	ctx, cancel := context.WithTimeout(c, pu.contextTimeout)
+	defer cancel()
+
+	if err := pu.userRepository.UpdatePassword(ctx, userID, hashedPassword, false); err != nil {
+		return err
+	}
+	return pu.Record(ctx, userID, hashedPassword)
+}
+
+// VerifyUnknown compares the password against a dummy hash of the policy cost, so that
+// a login with an unknown e-mail takes as long to refuse as a wrong password
+func (pu *passwordUsecase) VerifyUnknown(rawPassword string) error {
+	pu.dummyHashOnce.Do(func() {
+		hash, err := password.HashPasswordWithCost("unknown user", pu.policy.BcryptCost)
+		if err != nil {
+			log.Printf("Failed to hash the dummy password: %v", err)
+			return
+		}
+		pu.dummyHash = hash
+	})
+	password.VerifyPassword(pu.dummyHash, rawPassword)
+	return domain.ErrUserPasswordNotMatch
+}
+
+// Verify checks the password of a user signing in. On success a hash made with another
+// cost is replaced, and a password the policy now refuses sets user.MustChangePassword.
+func (pu *passwordUsecase) Verify(c context.Context, user *domain.User, rawPassword string) error {
+	ctx, cancel := context.WithTimeout(c, pu.contextTimeout)
+	defer cancel()
+
+	if err := password.VerifyPassword(user.Password, rawPassword); err != nil {
+		return err
+	}
+
+	flagged := false
+	if pu.policy.ChangeWeakOnLogin && !user.MustChangePassword && password.Validate(pu.policy, rawPassword) != nil {
+		user.MustChangePassword = true
+		flagged = true
+	}
+
+	// the password is right either way, failing to store the new hash or flag only delays them
+	hashedPassword := user.Password
+	if password.NeedsRehash(user.Password, pu.policy.BcryptCost) {
+		rehashed, err := password.HashPasswordWithCost(rawPassword, pu.policy.BcryptCost)
+		if err != nil {
+			log.Printf("Failed to rehash the password of user %d: %v", user.ID, err)
+		} else {
+			hashedPassword = rehashed
+		}
+	}
+	if hashedPassword != user.Password || flagged {
+		if err := pu.userRepository.UpdatePassword(ctx, user.ID, hashedPassword, user.MustChangePassword); err != nil {
+			log.Printf("Failed to update the password of user %d: %v", user.ID, err)
+			return nil
+		}
+		user.Password = hashedPassword
+	}
+	return nil
+}
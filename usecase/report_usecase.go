@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+)
+
+type reportUsecase struct {
+	reportRepository         domain.ServiceReportRepository
+	userServiceLogRepository domain.UserServiceLogRepository
+	userRepository           domain.UserRepository
+	oauthClientRepository    domain.OAuthClientRepository
+	metricsUsecase           domain.MetricsUsecase
+	contextTimeout           time.Duration
+}
+
+func NewReportUsecase(reportRepository domain.ServiceReportRepository, userServiceLogRepository domain.UserServiceLogRepository, userRepository domain.UserRepository, oauthClientRepository domain.OAuthClientRepository, metricsUsecase domain.MetricsUsecase, timeout time.Duration) domain.ReportUsecase {
+	return &reportUsecase{
+		reportRepository:         reportRepository,
+		userServiceLogRepository: userServiceLogRepository,
+		userRepository:           userRepository,
+		oauthClientRepository:    oauthClientRepository,
+		metricsUsecase:           metricsUsecase,
+		contextTimeout:           timeout,
+	}
+}
+
+// Record counts a report of a service for the user of one of its usage logs. The
+// organization of the user must be allowed to use the services and stay within the
+// monthly reports limit of its subscription; platform admins are not limited.
+func (ru *reportUsecase) Record(c context.Context, request *domain.ReportRequest) (domain.ReportQuota, error) {
+	ctx, cancel := context.WithTimeout(c, ru.contextTimeout)
+	defer cancel()
+
+	var quota domain.ReportQuota
+
+	client, err := authenticateOAuthClient(ctx, ru.oauthClientRepository, request.ClientID, request.ClientSecret)
+	if err != nil {
+		return quota, err
+	}
+	if client.ClientSecretHash == "" {
+		// a public client runs in the browser, it cannot vouch for the service
+		return quota, domain.ErrInvalidClient
+	}
+
+	// a service only reports for its own users, the logs of other services look unknown
+	log, err := ru.userServiceLogRepository.GetByID(ctx, request.LogID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return quota, domain.ErrUsageLogNotFound
+		}
+		return quota, domain.ErrInternalServerError
+	}
+	if log.ServiceID != client.ServiceID {
+		return quota, domain.ErrUsageLogNotFound
+	}
+
+	user, err := ru.userRepository.GetByID(ctx, log.UserID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return quota, domain.ErrUsageLogNotFound
+		}
+		return quota, domain.ErrInternalServerError
+	}
+
+	now := time.Now()
+	subscription := user.Organization.Subscription
+	platformAdmin := user.RoleID == domain.UserRoleAdmin && user.Organization.RoleID == domain.OrganizationRoleAdmin
+	if !platformAdmin {
+		if err := subscription.CheckAccess(now); err != nil {
+			return quota, err
+		}
+	}
+
+	// concurrent reports may both pass the count, the limit is a quota rather than a hard cap
+	used, err := ru.reportRepository.CountByOrganizationSince(ctx, user.OrganizationID, domain.MonthStart(now))
+	if err != nil {
+		return quota, err
+	}
+	limit := subscription.SubscriptionReportsLimit
+	if !platformAdmin && limit > 0 && used >= int64(limit) {
+		return quota, domain.ErrReportsLimitReached
+	}
+
+	report := domain.ServiceReport{
+		OrganizationID:   user.OrganizationID,
+		ServiceID:        log.ServiceID,
+		UserID:           log.UserID,
+		UserServiceLogID: log.ID,
+	}
+	if err := ru.reportRepository.Create(ctx, &report); err != nil {
+		return quota, err
+	}
+	ru.metricsUsecase.RecordReport(ctx, user.OrganizationID)
+
+	quota = domain.ReportQuota{
+		ReportID:     report.ID,
+		ReportsUsed:  used + 1,
+		ReportsLimit: limit,
+		Remaining:    -1,
+	}
+	if limit > 0 {
+		quota.Remaining = max(int64(limit)-quota.ReportsUsed, 0)
+	}
+	return quota, nil
+}
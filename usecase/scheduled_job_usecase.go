@@ -0,0 +1,39 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal/parser"
+)
+
+type scheduledJobUsecase struct {
+	scheduledJobRepository domain.ScheduledJobRepository
+	contextTimeout         time.Duration
+}
+
+func NewScheduledJobUsecase(scheduledJobRepository domain.ScheduledJobRepository, timeout time.Duration) domain.ScheduledJobUsecase {
+	return &scheduledJobUsecase{
+		scheduledJobRepository: scheduledJobRepository,
+		contextTimeout:         timeout,
+	}
+}
+
+// Fetch returns the scheduled jobs with the outcome of their last run
+func (su *scheduledJobUsecase) Fetch(c context.Context) ([]domain.PublicScheduledJob, error) {
+	ctx, cancel := context.WithTimeout(c, su.contextTimeout)
+	defer cancel()
+
+	jobs, err := su.scheduledJobRepository.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	publicJobs := make([]domain.PublicScheduledJob, 0, len(jobs))
+	for _, job := range jobs {
+		publicJobs = append(publicJobs, parser.ToPublicScheduledJob(job, now))
+	}
+	return publicJobs, nil
+}
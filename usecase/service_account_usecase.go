@@ -0,0 +1,230 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal/parser"
+	"github.com/gabrielfmcoelho/platform-core/internal/password"
+	"github.com/gabrielfmcoelho/platform-core/internal/tokenutil"
+)
+
+const (
+	grantTypeClientCredentials = "client_credentials"
+	clientIDPrefix             = "sa_"
+)
+
+type serviceAccountUsecase struct {
+	serviceAccountRepository domain.ServiceAccountRepository
+	contextTimeout           time.Duration
+}
+
+func NewServiceAccountUsecase(serviceAccountRepository domain.ServiceAccountRepository, timeout time.Duration) domain.ServiceAccountUsecase {
+	return &serviceAccountUsecase{
+		serviceAccountRepository: serviceAccountRepository,
+		contextTimeout:           timeout,
+	}
+}
+
+// Create registers a new service account and returns its credentials (the secret is only shown here)
+func (su *serviceAccountUsecase) Create(c context.Context, create *domain.CreateServiceAccount) (domain.ServiceAccountCredentials, error) {
+	ctx, cancel := context.WithTimeout(c, su.contextTimeout)
+	defer cancel()
+
+	var credentials domain.ServiceAccountCredentials
+
+	for _, scope := range create.Scopes {
+		if !slices.Contains(domain.AvailableScopes, scope) {
+			return credentials, domain.ErrInvalidScope
+		}
+	}
+
+	clientID, err := tokenutil.GenerateOpaqueToken(12)
+	if err != nil {
+		return credentials, domain.ErrInternalServerError
+	}
+	clientID = clientIDPrefix + clientID
+
+	clientSecret, secretHash, err := generateClientSecret()
+	if err != nil {
+		return credentials, err
+	}
+
+	serviceAccount := domain.ServiceAccount{
+		Name:             create.Name,
+		Description:      create.Description,
+		ClientID:         clientID,
+		ClientSecretHash: secretHash,
+		Scopes:           strings.Join(create.Scopes, " "),
+	}
+	if err := su.serviceAccountRepository.Create(ctx, &serviceAccount); err != nil {
+		if errors.Is(err, domain.ErrDataBaseInternalError) {
+			return credentials, domain.ErrDataBaseInternalError
+		}
+		return credentials, domain.ErrInternalServerError
+	}
+
+	return domain.ServiceAccountCredentials{
+		ServiceAccount: parser.ToPublicServiceAccount(serviceAccount),
+		ClientID:       clientID,
+		ClientSecret:   clientSecret,
+	}, nil
+}
+
+// Fetch returns all service accounts
+func (su *serviceAccountUsecase) Fetch(c context.Context) ([]domain.PublicServiceAccount, error) {
+	ctx, cancel := context.WithTimeout(c, su.contextTimeout)
+	defer cancel()
+
+	serviceAccounts, err := su.serviceAccountRepository.Fetch(ctx)
+	if err != nil {
+		if errors.Is(err, domain.ErrDataBaseInternalError) {
+			return nil, domain.ErrDataBaseInternalError
+		}
+		return nil, domain.ErrInternalServerError
+	}
+
+	publicServiceAccounts := make([]domain.PublicServiceAccount, 0, len(serviceAccounts))
+	for _, sa := range serviceAccounts {
+		publicServiceAccounts = append(publicServiceAccounts, parser.ToPublicServiceAccount(sa))
+	}
+	return publicServiceAccounts, nil
+}
+
+// RotateSecret issues a new client secret, invalidating the previous one
+func (su *serviceAccountUsecase) RotateSecret(c context.Context, serviceAccountID uint) (domain.ServiceAccountCredentials, error) {
+	ctx, cancel := context.WithTimeout(c, su.contextTimeout)
+	defer cancel()
+
+	var credentials domain.ServiceAccountCredentials
+
+	serviceAccount, err := su.serviceAccountRepository.GetByID(ctx, serviceAccountID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return credentials, domain.ErrNotFound
+		}
+		return credentials, domain.ErrInternalServerError
+	}
+
+	clientSecret, secretHash, err := generateClientSecret()
+	if err != nil {
+		return credentials, err
+	}
+
+	if err := su.serviceAccountRepository.UpdateSecret(ctx, serviceAccount.ID, secretHash); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return credentials, domain.ErrNotFound
+		}
+		return credentials, domain.ErrDataBaseInternalError
+	}
+
+	return domain.ServiceAccountCredentials{
+		ServiceAccount: parser.ToPublicServiceAccount(serviceAccount),
+		ClientID:       serviceAccount.ClientID,
+		ClientSecret:   clientSecret,
+	}, nil
+}
+
+// Delete revokes a service account
+func (su *serviceAccountUsecase) Delete(c context.Context, serviceAccountID uint) error {
+	ctx, cancel := context.WithTimeout(c, su.contextTimeout)
+	defer cancel()
+
+	if err := su.serviceAccountRepository.Delete(ctx, serviceAccountID); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.ErrNotFound
+		}
+		return domain.ErrDataBaseInternalError
+	}
+	return nil
+}
+
+// Validate accepts the token of a service account while the account exists and the token
+// was issued with its current secret
+func (su *serviceAccountUsecase) Validate(c context.Context, serviceAccountID uint, secretVersion int) error {
+	ctx, cancel := context.WithTimeout(c, su.contextTimeout)
+	defer cancel()
+
+	serviceAccount, err := su.serviceAccountRepository.GetByID(ctx, serviceAccountID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.ErrServiceAccountRevoked
+		}
+		return err
+	}
+	if serviceAccount.SecretVersion != secretVersion {
+		return domain.ErrServiceAccountRevoked
+	}
+	return nil
+}
+
+// IssueToken implements the client_credentials grant. The requested scope must be a
+// subset of the account scopes; when empty, every scope of the account is granted.
+func (su *serviceAccountUsecase) IssueToken(c context.Context, request *domain.TokenRequest, tokenSecret string, tokenExpiry int) (domain.TokenResponse, error) {
+	ctx, cancel := context.WithTimeout(c, su.contextTimeout)
+	defer cancel()
+
+	var response domain.TokenResponse
+
+	if request.GrantType != grantTypeClientCredentials {
+		return response, domain.ErrUnsupportedGrantType
+	}
+	if request.ClientID == "" || request.ClientSecret == "" {
+		return response, domain.ErrInvalidClient
+	}
+
+	serviceAccount, err := su.serviceAccountRepository.GetByClientID(ctx, request.ClientID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return response, domain.ErrInvalidClient
+		}
+		return response, domain.ErrInternalServerError
+	}
+
+	if err := password.VerifyPassword(serviceAccount.ClientSecretHash, request.ClientSecret); err != nil {
+		return response, domain.ErrInvalidClient
+	}
+
+	grantedScopes := strings.Fields(serviceAccount.Scopes)
+	scope := serviceAccount.Scopes
+	if request.Scope != "" {
+		for _, requested := range strings.Fields(request.Scope) {
+			if !slices.Contains(grantedScopes, requested) {
+				return response, domain.ErrInvalidScope
+			}
+		}
+		scope = strings.Join(strings.Fields(request.Scope), " ")
+	}
+
+	accessToken, expiresIn, err := tokenutil.CreateServiceAccountToken(&serviceAccount, scope, tokenSecret, tokenExpiry)
+	if err != nil {
+		return response, domain.ErrInternalServerError
+	}
+
+	// Not critical for the token issuance, only informative
+	su.serviceAccountRepository.TouchLastUsed(ctx, serviceAccount.ID, time.Now())
+
+	return domain.TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   expiresIn,
+		Scope:       scope,
+	}, nil
+}
+
+// generateClientSecret returns a new random secret and its bcrypt hash
+func generateClientSecret() (string, string, error) {
+	clientSecret, err := tokenutil.GenerateOpaqueToken(32)
+	if err != nil {
+		return "", "", domain.ErrInternalServerError
+	}
+	secretHash, err := password.HashPassword(clientSecret)
+	if err != nil {
+		return "", "", err
+	}
+	return clientSecret, secretHash, nil
+}
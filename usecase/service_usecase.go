@@ -13,14 +13,22 @@ import (
 type serviceUsecase struct {
 	serviceRepository        domain.ServiceRepository
 	userServiceLogRepository domain.UserServiceLogRepository
+	userRepository           domain.UserRepository
+	guestAccessRepository    domain.GuestAccessRepository
+	metricsUsecase           domain.MetricsUsecase
+	heartbeatTimeout         time.Duration
 	contextTimeout           time.Duration
 }
 
 // NewServiceUsecase cria um novo caso de uso para Service
-func NewServiceUsecase(serviceRepository domain.ServiceRepository, userServiceLogRepository domain.UserServiceLogRepository, timeout time.Duration) domain.ServiceUsecase {
+func NewServiceUsecase(serviceRepository domain.ServiceRepository, userServiceLogRepository domain.UserServiceLogRepository, userRepository domain.UserRepository, guestAccessRepository domain.GuestAccessRepository, metricsUsecase domain.MetricsUsecase, heartbeatTimeout time.Duration, timeout time.Duration) domain.ServiceUsecase {
 	return &serviceUsecase{
 		serviceRepository:        serviceRepository,
 		userServiceLogRepository: userServiceLogRepository,
+		userRepository:           userRepository,
+		guestAccessRepository:    guestAccessRepository,
+		metricsUsecase:           metricsUsecase,
+		heartbeatTimeout:         heartbeatTimeout,
 		contextTimeout:           timeout,
 	}
 }
@@ -175,20 +183,70 @@ func (su *serviceUsecase) RemoveAvailabilityFromOrganization(ctx context.Context
 	return nil
 }
 
-func (su *serviceUsecase) Use(ctx context.Context, userID uint, serviceID uint) (domain.UseService, uint, error) {
+// Use opens a usage session of a service. The service must exist (not archived), be
+// active and be subscribed by the organization of the user, whose subscription must
+// be active; platform admins may open any service. Guests are further limited to
+// the services opened to them.
+func (su *serviceUsecase) Use(ctx context.Context, userID uint, sessionID uint, serviceID uint) (domain.UseService, uint, error) {
 	ctx, cancel := context.WithTimeout(ctx, su.contextTimeout)
 	defer cancel()
 
-	var service domain.Service
 	var useService domain.UseService
 	var logID uint
 
+	user, err := su.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return useService, logID, domain.ErrNotFound
+		}
+		return useService, logID, domain.ErrInternalServerError
+	}
+
+	service, err := su.serviceRepository.GetByID(ctx, serviceID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return useService, logID, domain.ErrServiceNotFound
+		}
+		return useService, logID, domain.ErrInternalServerError
+	}
+	if !service.IsActive() {
+		return useService, logID, domain.ErrServiceInactive
+	}
+
+	if user.RoleID != domain.UserRoleAdmin || user.Organization.RoleID != domain.OrganizationRoleAdmin {
+		subscribed, err := su.serviceRepository.IsAvailableToOrganization(ctx, serviceID, user.OrganizationID)
+		if err != nil {
+			return useService, logID, domain.ErrInternalServerError
+		}
+		if !subscribed {
+			return useService, logID, domain.ErrServiceNotSubscribed
+		}
+		if err := user.Organization.Subscription.CheckAccess(time.Now()); err != nil {
+			return useService, logID, err
+		}
+	}
+
+	// guests only launch the services their organization opened to them
+	if user.RoleID == domain.UserRoleGuest {
+		guestAccess, err := su.guestAccessRepository.GetByOrganizationID(ctx, user.OrganizationID)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return useService, logID, domain.ErrInternalServerError
+		}
+		if !guestAccess.Allows(serviceID) {
+			return useService, logID, domain.ErrGuestServiceForbidden
+		}
+	}
+
+	now := usageNow()
 	log := domain.UserServiceLog{
-		UserID:    userID,
-		ServiceID: serviceID,
+		UserID:          userID,
+		ServiceID:       serviceID,
+		SessionID:       sessionID,
+		StartedAt:       now,
+		LastHeartbeatAt: now,
 	}
 
-	err := su.userServiceLogRepository.Create(ctx, &log)
+	err = su.userServiceLogRepository.Create(ctx, &log)
 	if err != nil {
 		if errors.Is(err, domain.ErrDataBaseInternalError) {
 			return useService, logID, domain.ErrDataBaseInternalError
@@ -197,36 +255,99 @@ func (su *serviceUsecase) Use(ctx context.Context, userID uint, serviceID uint)
 	}
 
 	logID = log.ID
+	su.metricsUsecase.RecordSession(ctx, log)
 
-	service, err = su.serviceRepository.GetByID(ctx, serviceID)
+	return parser.ToUseService(service), logID, nil
+}
+
+// Heartbeat keeps a usage session of the user open and credits the time since its
+// previous heartbeat, capped by the heartbeat timeout: a client that went silent
+// longer is only credited up to the timeout, like a session closed by the reaper.
+func (su *serviceUsecase) Heartbeat(ctx context.Context, userID uint, logID uint) error {
+	ctx, cancel := context.WithTimeout(ctx, su.contextTimeout)
+	defer cancel()
+
+	log, err := su.openUsageLog(ctx, userID, logID)
 	if err != nil {
-		if errors.Is(err, domain.ErrNotFound) {
-			return useService, logID, domain.ErrNotFound
+		return err
+	}
+
+	now := usageNow()
+	credit := su.credit(&log, now)
+	credited, err := su.userServiceLogRepository.RecordHeartbeat(ctx, logID, log.LastHeartbeatAt, now, credit)
+	if err != nil {
+		if errors.Is(err, domain.ErrDataBaseInternalError) {
+			return domain.ErrDataBaseInternalError
 		}
-		return useService, logID, domain.ErrInternalServerError
+		return domain.ErrInternalServerError
+	}
+	if credited {
+		su.metricsUsecase.RecordUsage(ctx, log, credit)
 	}
 
-	return parser.ToUseService(service), logID, nil
+	return nil
 }
 
-func (su *serviceUsecase) Heartbeat(ctx context.Context, logID uint, duration int) error {
+// Close ends a usage session of the user, crediting the time since its last heartbeat
+func (su *serviceUsecase) Close(ctx context.Context, userID uint, logID uint) error {
 	ctx, cancel := context.WithTimeout(ctx, su.contextTimeout)
 	defer cancel()
 
-	err := su.userServiceLogRepository.UpdateDuration(ctx, logID, duration)
+	log, err := su.openUsageLog(ctx, userID, logID)
 	if err != nil {
-		if errors.Is(err, domain.ErrNotFound) {
-			return domain.ErrNotFound
-		}
-		if errors.Is(err, domain.ErrDataBaseInternalError) {
+		return err
+	}
+
+	now := usageNow()
+	credit := su.credit(&log, now)
+	err = su.userServiceLogRepository.End(ctx, logID, now, credit, domain.UsageEndReasonClosed)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrUsageSessionEnded):
+			return domain.ErrUsageSessionEnded
+		case errors.Is(err, domain.ErrDataBaseInternalError):
 			return domain.ErrDataBaseInternalError
 		}
 		return domain.ErrInternalServerError
 	}
+	su.metricsUsecase.RecordUsage(ctx, log, credit)
 
 	return nil
 }
 
+// openUsageLog returns a usage session of the user that has not ended yet
+func (su *serviceUsecase) openUsageLog(ctx context.Context, userID uint, logID uint) (domain.UserServiceLog, error) {
+	log, err := su.userServiceLogRepository.GetByID(ctx, logID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return log, domain.ErrUsageLogNotFound
+		}
+		return log, domain.ErrInternalServerError
+	}
+	if log.UserID != userID {
+		return log, domain.ErrUsageLogNotOwned
+	}
+	if log.EndedAt != nil {
+		return log, domain.ErrUsageSessionEnded
+	}
+	return log, nil
+}
+
+// credit is the usage between the last heartbeat of a session and now, at most the heartbeat timeout
+func (su *serviceUsecase) credit(log *domain.UserServiceLog, now time.Time) time.Duration {
+	elapsed := now.Sub(log.LastHeartbeatAt)
+	if elapsed < 0 {
+		return 0
+	}
+	return min(elapsed, su.heartbeatTimeout)
+}
+
+// usageNow is the timestamp of usage sessions. It is kept in UTC at the precision of
+// the database so a stored heartbeat reads back equal and can guard the next update.
+func usageNow() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
 // Update atualiza os dados de um serviço
 func (su *serviceUsecase) Update(ctx context.Context, serviceID uint, service *domain.Service) error {
 	ctx, cancel := context.WithTimeout(ctx, su.contextTimeout)
@@ -0,0 +1,103 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal/parser"
+)
+
+// sessionTouchInterval throttles the last seen updates, a session is not written on every request
+const sessionTouchInterval = time.Minute
+
+type sessionUsecase struct {
+	sessionRepository  domain.SessionRepository
+	userLogRepository  domain.UserLogRepository
+	usageLogRepository domain.UserServiceLogRepository
+	contextTimeout     time.Duration
+}
+
+func NewSessionUsecase(sessionRepository domain.SessionRepository, userLogRepository domain.UserLogRepository, usageLogRepository domain.UserServiceLogRepository, timeout time.Duration) domain.SessionUsecase {
+	return &sessionUsecase{
+		sessionRepository:  sessionRepository,
+		userLogRepository:  userLogRepository,
+		usageLogRepository: usageLogRepository,
+		contextTimeout:     timeout,
+	}
+}
+
+func (su *sessionUsecase) FetchByUserID(c context.Context, userID uint, currentSessionID uint) ([]domain.PublicSession, error) {
+	ctx, cancel := context.WithTimeout(c, su.contextTimeout)
+	defer cancel()
+
+	sessions, err := su.sessionRepository.FetchActiveByUserID(ctx, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	publicSessions := make([]domain.PublicSession, 0, len(sessions))
+	for _, session := range sessions {
+		publicSessions = append(publicSessions, parser.ToPublicSession(session, session.ID == currentSessionID))
+	}
+	return publicSessions, nil
+}
+
+// Revoke signs the device out: its refresh tokens stop rotating and its access tokens are refused.
+// Sessions of other users are reported as not found.
+func (su *sessionUsecase) Revoke(c context.Context, userID uint, sessionID uint, client domain.ClientInfo) error {
+	ctx, cancel := context.WithTimeout(c, su.contextTimeout)
+	defer cancel()
+
+	session, err := su.sessionRepository.GetByID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if session.UserID != userID {
+		return domain.ErrNotFound
+	}
+	if session.RevokedAt != nil {
+		return domain.ErrSessionRevoked
+	}
+
+	if err := su.sessionRepository.Revoke(ctx, session.ID, time.Now()); err != nil {
+		return err
+	}
+	su.usageLogRepository.EndBySessionID(ctx, session.ID, domain.UsageEndReasonLogout)
+
+	// LOG INTO USER LOG
+	su.userLogRepository.Create(ctx, &domain.UserLog{
+		UserID:    session.UserID,
+		IPAddress: client.IPAddress,
+		Action:    "session_revoked",
+	})
+	return nil
+}
+
+func (su *sessionUsecase) Validate(c context.Context, sessionID uint) error {
+	ctx, cancel := context.WithTimeout(c, su.contextTimeout)
+	defer cancel()
+
+	session, err := su.sessionRepository.GetByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.ErrSessionRevoked
+		}
+		return err
+	}
+
+	nowTime := time.Now()
+	if session.RevokedAt != nil || nowTime.After(session.ExpiresAt) {
+		return domain.ErrSessionRevoked
+	}
+
+	// a failed touch only makes last seen stale, the request goes on
+	if nowTime.Sub(session.LastSeenAt) >= sessionTouchInterval {
+		if err := su.sessionRepository.Touch(ctx, session.ID, nowTime); err != nil {
+			log.Printf("Failed to touch session %d: %v", session.ID, err)
+		}
+	}
+	return nil
+}
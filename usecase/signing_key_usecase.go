@@ -0,0 +1,75 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal/keyring"
+	"github.com/gabrielfmcoelho/platform-core/internal/parser"
+)
+
+type signingKeyUsecase struct {
+	signingKeyRepository domain.SigningKeyRepository
+	keyRing              domain.KeyRing
+	contextTimeout       time.Duration
+}
+
+func NewSigningKeyUsecase(signingKeyRepository domain.SigningKeyRepository, keyRing domain.KeyRing, timeout time.Duration) domain.SigningKeyUsecase {
+	return &signingKeyUsecase{
+		signingKeyRepository: signingKeyRepository,
+		keyRing:              keyRing,
+		contextTimeout:       timeout,
+	}
+}
+
+// Fetch returns all signing keys, including the ones that no longer verify tokens
+func (su *signingKeyUsecase) Fetch(c context.Context) ([]domain.PublicSigningKey, error) {
+	ctx, cancel := context.WithTimeout(c, su.contextTimeout)
+	defer cancel()
+
+	signingKeys, err := su.signingKeyRepository.Fetch(ctx)
+	if err != nil {
+		if errors.Is(err, domain.ErrDataBaseInternalError) {
+			return nil, domain.ErrDataBaseInternalError
+		}
+		return nil, domain.ErrInternalServerError
+	}
+
+	publicSigningKeys := make([]domain.PublicSigningKey, 0, len(signingKeys))
+	for _, sk := range signingKeys {
+		publicSigningKeys = append(publicSigningKeys, parser.ToPublicSigningKey(sk))
+	}
+	return publicSigningKeys, nil
+}
+
+// Rotate generates a new active key. The previous key is retired but still
+// verifies the tokens it signed until they expire.
+func (su *signingKeyUsecase) Rotate(c context.Context, algorithm string, encryptionKey string) (domain.PublicSigningKey, error) {
+	ctx, cancel := context.WithTimeout(c, su.contextTimeout)
+	defer cancel()
+
+	signingKey, err := keyring.GenerateKey(algorithm, encryptionKey)
+	if err != nil {
+		return domain.PublicSigningKey{}, domain.ErrInternalServerError
+	}
+	if err := su.signingKeyRepository.Activate(ctx, signingKey, time.Now()); err != nil {
+		return domain.PublicSigningKey{}, err
+	}
+	log.Printf("Signing key rotated, new active key %s (%s)", signingKey.KID, signingKey.Algorithm)
+
+	if err := su.keyRing.Reload(ctx); err != nil {
+		return domain.PublicSigningKey{}, err
+	}
+	return parser.ToPublicSigningKey(*signingKey), nil
+}
+
+// GetJWKS returns the public keys that verify access tokens
+func (su *signingKeyUsecase) GetJWKS(c context.Context) (domain.JWKS, error) {
+	ctx, cancel := context.WithTimeout(c, su.contextTimeout)
+	defer cancel()
+
+	return su.keyRing.JWKS(ctx)
+}
@@ -0,0 +1,400 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal/oidc"
+	"github.com/gabrielfmcoelho/platform-core/internal/parser"
+	"github.com/gabrielfmcoelho/platform-core/internal/password"
+	"github.com/gabrielfmcoelho/platform-core/internal/tokenutil"
+)
+
+type ssoUsecase struct {
+	identityProviderRepository domain.IdentityProviderRepository
+	loginStateRepository       domain.SSOLoginStateRepository
+	userIdentityRepository     domain.UserIdentityRepository
+	userRepository             domain.UserRepository
+	organizationRepository     domain.OrganizationRepository
+	userLogRepository          domain.UserLogRepository
+	authUsecase                domain.AuthUsecase
+	oidcClient                 *oidc.Client
+	redirectURL                string
+	encryptionKey              string
+	stateExpiryMinute          int
+	contextTimeout             time.Duration
+}
+
+func NewSSOUsecase(identityProviderRepository domain.IdentityProviderRepository, loginStateRepository domain.SSOLoginStateRepository, userIdentityRepository domain.UserIdentityRepository, userRepository domain.UserRepository, organizationRepository domain.OrganizationRepository, userLogRepository domain.UserLogRepository, authUsecase domain.AuthUsecase, oidcClient *oidc.Client, redirectURL string, encryptionKey string, stateExpiryMinute int, timeout time.Duration) domain.SSOUsecase {
+	return &ssoUsecase{
+		identityProviderRepository: identityProviderRepository,
+		loginStateRepository:       loginStateRepository,
+		userIdentityRepository:     userIdentityRepository,
+		userRepository:             userRepository,
+		organizationRepository:     organizationRepository,
+		userLogRepository:          userLogRepository,
+		authUsecase:                authUsecase,
+		oidcClient:                 oidcClient,
+		redirectURL:                redirectURL,
+		encryptionKey:              encryptionKey,
+		stateExpiryMinute:          stateExpiryMinute,
+		contextTimeout:             timeout,
+	}
+}
+
+// GetConfig returns the identity provider of an organization; organizations never configured are disabled
+func (su *ssoUsecase) GetConfig(c context.Context, organizationID uint) (domain.PublicIdentityProvider, error) {
+	ctx, cancel := context.WithTimeout(c, su.contextTimeout)
+	defer cancel()
+
+	if _, err := su.organizationRepository.GetByID(ctx, organizationID); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.PublicIdentityProvider{}, domain.ErrNotFound
+		}
+		return domain.PublicIdentityProvider{}, domain.ErrInternalServerError
+	}
+
+	identityProvider, err := su.identityProviderRepository.GetByOrganizationID(ctx, organizationID)
+	if err != nil {
+		if !errors.Is(err, domain.ErrNotFound) {
+			return domain.PublicIdentityProvider{}, err
+		}
+		identityProvider = domain.OrganizationIdentityProvider{
+			OrganizationID: organizationID,
+			DefaultRoleID:  domain.UserRoleUser,
+		}
+	}
+	return parser.ToPublicIdentityProvider(identityProvider, su.redirectURL), nil
+}
+
+// Configure creates or replaces the identity provider of an organization. The
+// issuer must be reachable by the OIDC client and answer the discovery request
+// before it is saved.
+func (su *ssoUsecase) Configure(c context.Context, organizationID uint, config *domain.IdentityProviderConfig, configuredRoleID uint) (domain.PublicIdentityProvider, error) {
+	ctx, cancel := context.WithTimeout(c, su.contextTimeout)
+	defer cancel()
+
+	if _, err := su.organizationRepository.GetByID(ctx, organizationID); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.PublicIdentityProvider{}, domain.ErrNotFound
+		}
+		return domain.PublicIdentityProvider{}, domain.ErrInternalServerError
+	}
+
+	issuer := strings.TrimSuffix(strings.TrimSpace(config.Issuer), "/")
+	if err := su.oidcClient.CheckIssuer(ctx, issuer); err != nil {
+		log.Printf("Refused identity provider %s: %v", issuer, err)
+		return domain.PublicIdentityProvider{}, domain.ErrInvalidIssuer
+	}
+	if *config.Enabled {
+		if _, err := su.oidcClient.Discover(ctx, issuer); err != nil {
+			log.Printf("Failed to discover identity provider %s: %v", issuer, err)
+			return domain.PublicIdentityProvider{}, domain.ErrSSOProviderError
+		}
+	}
+
+	// an omitted client secret keeps the current one
+	clientSecret := ""
+	if config.ClientSecret != "" {
+		sealed, err := tokenutil.EncryptSecret(config.ClientSecret, su.encryptionKey)
+		if err != nil {
+			return domain.PublicIdentityProvider{}, domain.ErrInternalServerError
+		}
+		clientSecret = sealed
+	} else {
+		current, err := su.identityProviderRepository.GetByOrganizationID(ctx, organizationID)
+		switch {
+		case err == nil:
+			clientSecret = current.ClientSecret
+		case !errors.Is(err, domain.ErrNotFound):
+			return domain.PublicIdentityProvider{}, err
+		}
+	}
+
+	defaultRoleID := config.DefaultRoleID
+	if defaultRoleID == 0 {
+		defaultRoleID = domain.UserRoleUser
+	}
+	identityProvider := domain.OrganizationIdentityProvider{
+		OrganizationID:    organizationID,
+		Enabled:           *config.Enabled,
+		Issuer:            issuer,
+		ClientID:          config.ClientID,
+		ClientSecret:      clientSecret,
+		Scopes:            strings.TrimSpace(config.Scopes),
+		RoleClaim:         strings.TrimSpace(config.RoleClaim),
+		RoleMapping:       config.RoleMapping,
+		DefaultRoleID:     defaultRoleID,
+		AllowProvisioning: config.AllowProvisioning,
+		ConfiguredRoleID:  configuredRoleID,
+	}
+	if err := su.identityProviderRepository.Save(ctx, &identityProvider); err != nil {
+		return domain.PublicIdentityProvider{}, err
+	}
+	return parser.ToPublicIdentityProvider(identityProvider, su.redirectURL), nil
+}
+
+// AuthorizationURL records a new login state (state, nonce and PKCE verifier) and
+// returns the authorization request to send the browser to
+func (su *ssoUsecase) AuthorizationURL(c context.Context, organizationID uint) (string, error) {
+	ctx, cancel := context.WithTimeout(c, su.contextTimeout)
+	defer cancel()
+
+	identityProvider, err := su.enabledProvider(ctx, organizationID)
+	if err != nil {
+		return "", err
+	}
+
+	metadata, err := su.oidcClient.Discover(ctx, identityProvider.Issuer)
+	if err != nil {
+		log.Printf("Failed to discover identity provider of organization %d: %v", organizationID, err)
+		return "", domain.ErrSSOProviderError
+	}
+
+	state, err := tokenutil.GenerateOpaqueToken(32)
+	if err != nil {
+		return "", domain.ErrInternalServerError
+	}
+	nonce, err := tokenutil.GenerateOpaqueToken(32)
+	if err != nil {
+		return "", domain.ErrInternalServerError
+	}
+	codeVerifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		return "", domain.ErrInternalServerError
+	}
+
+	err = su.loginStateRepository.Create(ctx, &domain.SSOLoginState{
+		StateHash:      tokenutil.HashOpaqueToken(state),
+		OrganizationID: organizationID,
+		Nonce:          nonce,
+		CodeVerifier:   codeVerifier,
+		ExpiresAt:      time.Now().Add(time.Minute * time.Duration(su.stateExpiryMinute)),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return oidc.AuthorizationURL(metadata, identityProvider.ClientID, su.redirectURL, requestedScopes(identityProvider.Scopes), state, nonce, codeVerifier)
+}
+
+// Callback redeems the authorization code, verifies the ID token and signs in the
+// user linked to its subject. A user of the organization with the same verified
+// e-mail is linked on its first SSO login; anyone else is provisioned when the
+// organization allows it.
+func (su *ssoUsecase) Callback(c context.Context, request *domain.SSOCallbackRequest, client domain.ClientInfo, accessExpiry int, refreshExpiry int) (*domain.LoginResponse, error) {
+	ctx, cancel := context.WithTimeout(c, su.contextTimeout)
+	defer cancel()
+
+	state, err := su.loginStateRepository.Consume(ctx, tokenutil.HashOpaqueToken(request.State), time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	identityProvider, err := su.enabledProvider(ctx, state.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	clientSecret := ""
+	if identityProvider.ClientSecret != "" {
+		clientSecret, err = tokenutil.DecryptSecret(identityProvider.ClientSecret, su.encryptionKey)
+		if err != nil {
+			return nil, domain.ErrInternalServerError
+		}
+	}
+
+	metadata, err := su.oidcClient.Discover(ctx, identityProvider.Issuer)
+	if err != nil {
+		log.Printf("Failed to discover identity provider of organization %d: %v", state.OrganizationID, err)
+		return nil, domain.ErrSSOProviderError
+	}
+	tokens, err := su.oidcClient.Exchange(ctx, metadata, identityProvider.ClientID, clientSecret, request.Code, su.redirectURL, state.CodeVerifier)
+	if err != nil {
+		log.Printf("Failed to redeem authorization code of organization %d: %v", state.OrganizationID, err)
+		return nil, domain.ErrSSOProviderError
+	}
+	claims, err := su.oidcClient.VerifyIDToken(ctx, metadata, tokens.IDToken, identityProvider.ClientID, state.Nonce)
+	if err != nil {
+		log.Printf("Rejected id token of organization %d: %v", state.OrganizationID, err)
+		return nil, domain.ErrSSOProviderError
+	}
+
+	user, err := su.resolveUser(ctx, identityProvider, claims, client)
+	if err != nil {
+		return nil, err
+	}
+
+	// keep the role in line with the provider claims
+	if identityProvider.RoleClaim != "" && !outranksProvider(user, identityProvider) {
+		roleID := mapRole(identityProvider, claims.StringValues(identityProvider.RoleClaim))
+		if roleID != user.RoleID {
+			if err := su.userRepository.Update(ctx, user.ID, &domain.User{RoleID: roleID}); err != nil {
+				return nil, err
+			}
+			if user, err = su.userRepository.GetByID(ctx, user.ID); err != nil {
+				return nil, domain.ErrInternalServerError
+			}
+		}
+	}
+
+	return su.authUsecase.LoginExternalUser(ctx, &user, client, accessExpiry, refreshExpiry)
+}
+
+// resolveUser finds the user behind the provider subject, linking or provisioning it when needed
+func (su *ssoUsecase) resolveUser(ctx context.Context, identityProvider domain.OrganizationIdentityProvider, claims oidc.IDTokenClaims, client domain.ClientInfo) (domain.User, error) {
+	identity, err := su.userIdentityRepository.GetBySubject(ctx, identityProvider.ID, claims.Subject)
+	switch {
+	case err == nil:
+		user, err := su.userRepository.GetByID(ctx, identity.UserID)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				// archived since its last login
+				return domain.User{}, domain.ErrUnauthorized
+			}
+			return domain.User{}, domain.ErrInternalServerError
+		}
+		if outranksProvider(user, identityProvider) {
+			return domain.User{}, domain.ErrSSOAccountConflict
+		}
+		return user, nil
+	case !errors.Is(err, domain.ErrNotFound):
+		return domain.User{}, err
+	}
+
+	email := strings.ToLower(strings.TrimSpace(claims.Email))
+	if email == "" {
+		return domain.User{}, domain.ErrSSOProviderError
+	}
+
+	// first SSO login of an existing member: only a verified e-mail of the same organization is linked
+	user, err := su.userRepository.GetByEmail(ctx, email)
+	switch {
+	case err == nil:
+		if user.OrganizationID != identityProvider.OrganizationID || !claims.EmailVerified || outranksProvider(user, identityProvider) {
+			return domain.User{}, domain.ErrSSOAccountConflict
+		}
+		if err := su.userIdentityRepository.Create(ctx, &domain.UserIdentity{
+			UserID:             user.ID,
+			IdentityProviderID: identityProvider.ID,
+			Subject:            claims.Subject,
+		}); err != nil {
+			return domain.User{}, err
+		}
+
+		// LOG INTO USER LOG
+		su.userLogRepository.Create(ctx, &domain.UserLog{
+			UserID:    user.ID,
+			IPAddress: client.IPAddress,
+			Action:    "sso_linked",
+		})
+		return user, nil
+	case !errors.Is(err, domain.ErrUserEmailNotFound):
+		return domain.User{}, domain.ErrInternalServerError
+	}
+
+	if !identityProvider.AllowProvisioning {
+		return domain.User{}, domain.ErrSSOUserNotProvisioned
+	}
+
+	organization, err := su.organizationRepository.GetByID(ctx, identityProvider.OrganizationID)
+	if err != nil {
+		return domain.User{}, domain.ErrInternalServerError
+	}
+	if usersLimitReached(organization) {
+		return domain.User{}, domain.ErrUsersLimitReached
+	}
+
+	// nobody knows the password of a provisioned user, it signs in through its provider
+	rawPassword, err := tokenutil.GenerateOpaqueToken(32)
+	if err != nil {
+		return domain.User{}, domain.ErrInternalServerError
+	}
+	hashedPassword, err := password.HashPassword(rawPassword)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = email
+	}
+	user = domain.User{
+		Name:           name,
+		Email:          email,
+		Password:       hashedPassword,
+		OrganizationID: identityProvider.OrganizationID,
+		RoleID:         mapRole(identityProvider, claims.StringValues(identityProvider.RoleClaim)),
+	}
+	if err := su.userIdentityRepository.CreateWithUser(ctx, &user, &domain.UserIdentity{
+		IdentityProviderID: identityProvider.ID,
+		Subject:            claims.Subject,
+	}); err != nil {
+		return domain.User{}, err
+	}
+
+	// LOG INTO USER LOG
+	su.userLogRepository.Create(ctx, &domain.UserLog{
+		UserID:    user.ID,
+		IPAddress: client.IPAddress,
+		Action:    "sso_provisioned",
+	})
+
+	// reload with the organization, the token claims need its role
+	user, err = su.userRepository.GetByID(ctx, user.ID)
+	if err != nil {
+		return domain.User{}, domain.ErrInternalServerError
+	}
+	return user, nil
+}
+
+func (su *ssoUsecase) enabledProvider(ctx context.Context, organizationID uint) (domain.OrganizationIdentityProvider, error) {
+	identityProvider, err := su.identityProviderRepository.GetByOrganizationID(ctx, organizationID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return identityProvider, domain.ErrSSODisabled
+		}
+		return identityProvider, err
+	}
+	if !identityProvider.Enabled {
+		return identityProvider, domain.ErrSSODisabled
+	}
+	return identityProvider, nil
+}
+
+// mapRole returns the most privileged role (lowest ID) mapped from the claim values, or the
+// default role, never more privileged than the configurer of the provider
+func mapRole(identityProvider domain.OrganizationIdentityProvider, values []string) uint {
+	roleID := identityProvider.DefaultRoleID
+	matched := false
+	for _, value := range values {
+		mapped, ok := identityProvider.RoleMapping[value]
+		if ok && (!matched || mapped < roleID) {
+			roleID = mapped
+			matched = true
+		}
+	}
+	return max(roleID, identityProvider.ConfiguredRoleID)
+}
+
+// outranksProvider reports whether the user is more privileged than the configurer of the
+// provider, who could otherwise assert the user's identity and take the account over
+func outranksProvider(user domain.User, identityProvider domain.OrganizationIdentityProvider) bool {
+	return user.RoleID < identityProvider.ConfiguredRoleID
+}
+
+// requestedScopes always asks for openid, email and profile on top of the configured scopes
+func requestedScopes(configured string) []string {
+	scopes := []string{"openid", "email", "profile"}
+	for _, scope := range strings.Fields(configured) {
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
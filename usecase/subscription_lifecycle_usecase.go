@@ -0,0 +1,198 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+)
+
+type subscriptionLifecycleUsecase struct {
+	subscriptionRepository domain.OrganizationSubscriptionRepository
+	organizationRepository domain.OrganizationRepository
+	mailOutboxUsecase      domain.MailOutboxUsecase
+	warningDays            int
+	graceDays              int
+	contextTimeout         time.Duration
+}
+
+func NewSubscriptionLifecycleUsecase(subscriptionRepository domain.OrganizationSubscriptionRepository, organizationRepository domain.OrganizationRepository, mailOutboxUsecase domain.MailOutboxUsecase, warningDays int, graceDays int, timeout time.Duration) domain.SubscriptionLifecycleUsecase {
+	return &subscriptionLifecycleUsecase{
+		subscriptionRepository: subscriptionRepository,
+		organizationRepository: organizationRepository,
+		mailOutboxUsecase:      mailOutboxUsecase,
+		warningDays:            warningDays,
+		graceDays:              graceDays,
+		contextTimeout:         timeout,
+	}
+}
+
+// SendExpiryWarnings warns the managers once per end date, warningDays before it
+func (lu *subscriptionLifecycleUsecase) SendExpiryWarnings(c context.Context) (int, error) {
+	now := time.Now().UTC()
+	subscriptions, err := lu.fetch(c, func(ctx context.Context) ([]domain.OrganizationSubscription, error) {
+		return lu.subscriptionRepository.FetchExpiring(ctx, now, now.AddDate(0, 0, lu.warningDays))
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return lu.each(c, subscriptions, func(ctx context.Context, subscription domain.OrganizationSubscription) error {
+		err := lu.notify(ctx, subscription, domain.MailTemplateSubscriptionExpiring, map[string]any{
+			"EndsAt":   subscription.EndsAt.Format("2006-01-02"),
+			"DaysLeft": int(math.Ceil(subscription.EndsAt.Sub(now).Hours() / 24)),
+		})
+		if err != nil {
+			return err
+		}
+		return lu.subscriptionRepository.MarkExpiryWarned(ctx, subscription.ID, now)
+	})
+}
+
+// NotifyGracePeriods tells the managers the subscription ended and until when the services stay available
+func (lu *subscriptionLifecycleUsecase) NotifyGracePeriods(c context.Context) (int, error) {
+	now := time.Now().UTC()
+	subscriptions, err := lu.fetch(c, func(ctx context.Context) ([]domain.OrganizationSubscription, error) {
+		return lu.subscriptionRepository.FetchInGrace(ctx, now)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return lu.each(c, subscriptions, func(ctx context.Context, subscription domain.OrganizationSubscription) error {
+		err := lu.notify(ctx, subscription, domain.MailTemplateSubscriptionExpired, map[string]any{
+			"EndsAt":      subscription.EndsAt.Format("2006-01-02"),
+			"GraceEndsAt": subscription.GraceEndsAt.Format("2006-01-02"),
+		})
+		if err != nil {
+			return err
+		}
+		return lu.subscriptionRepository.MarkExpiryNotified(ctx, subscription.ID, now)
+	})
+}
+
+// DeactivateExpired deactivates the subscriptions past their grace period and tells the managers
+func (lu *subscriptionLifecycleUsecase) DeactivateExpired(c context.Context) (int, error) {
+	now := time.Now().UTC()
+	subscriptions, err := lu.fetch(c, func(ctx context.Context) ([]domain.OrganizationSubscription, error) {
+		return lu.subscriptionRepository.FetchGraceOver(ctx, now)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return lu.each(c, subscriptions, func(ctx context.Context, subscription domain.OrganizationSubscription) error {
+		if err := lu.subscriptionRepository.SetActive(ctx, subscription.ID, false); err != nil {
+			return err
+		}
+		// the subscription is already deactivated, a lost e-mail is only logged
+		err := lu.notify(ctx, subscription, domain.MailTemplateSubscriptionDeactivated, map[string]any{
+			"EndsAt": subscription.EndsAt.Format("2006-01-02"),
+		})
+		if err != nil {
+			log.Printf("Failed to notify the deactivation of subscription %d: %v", subscription.ID, err)
+		}
+		return nil
+	})
+}
+
+// RenewDue adds as many billing periods as needed for the subscription to end after now,
+// recording each of them. The periods are counted from StartsAt (see
+// domain.SubscriptionPeriodEnd). A subscription changed meanwhile is left to the next run.
+func (lu *subscriptionLifecycleUsecase) RenewDue(c context.Context) (int, error) {
+	now := time.Now().UTC()
+	subscriptions, err := lu.fetch(c, func(ctx context.Context) ([]domain.OrganizationSubscription, error) {
+		return lu.subscriptionRepository.FetchDueRenewal(ctx, now)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return lu.each(c, subscriptions, func(ctx context.Context, subscription domain.OrganizationSubscription) error {
+		// the first period ending after the current end
+		period := 1
+		for !domain.SubscriptionPeriodEnd(subscription.StartsAt, subscription.SubscriptionPeriod, period).After(subscription.EndsAt) {
+			period++
+		}
+
+		var renewals []domain.SubscriptionRenewal
+		for ; !subscription.EndsAt.After(now); period++ {
+			endsAt := domain.SubscriptionPeriodEnd(subscription.StartsAt, subscription.SubscriptionPeriod, period)
+			renewals = append(renewals, domain.SubscriptionRenewal{
+				OrganizationSubscriptionID: subscription.ID,
+				OrganizationID:             subscription.OrganizationID,
+				Period:                     subscription.SubscriptionPeriod,
+				Value:                      subscription.SubscriptionValue,
+				StartsAt:                   subscription.EndsAt,
+				EndsAt:                     endsAt,
+			})
+			subscription.EndsAt = endsAt
+		}
+		subscription.GraceEndsAt = graceEnd(subscription.EndsAt, lu.graceDays)
+
+		err := lu.subscriptionRepository.Renew(ctx, &subscription, renewals)
+		if errors.Is(err, domain.ErrSubscriptionChanged) {
+			return nil
+		}
+		return err
+	})
+}
+
+func (lu *subscriptionLifecycleUsecase) fetch(c context.Context, fetch func(ctx context.Context) ([]domain.OrganizationSubscription, error)) ([]domain.OrganizationSubscription, error) {
+	ctx, cancel := context.WithTimeout(c, lu.contextTimeout)
+	defer cancel()
+
+	return fetch(ctx)
+}
+
+// each handles the subscriptions one by one, each within the context timeout. A failure
+// does not stop the others; the failures are returned together.
+func (lu *subscriptionLifecycleUsecase) each(c context.Context, subscriptions []domain.OrganizationSubscription, handle func(ctx context.Context, subscription domain.OrganizationSubscription) error) (int, error) {
+	handled := 0
+	var failures []error
+	for _, subscription := range subscriptions {
+		if c.Err() != nil {
+			failures = append(failures, c.Err())
+			break
+		}
+
+		ctx, cancel := context.WithTimeout(c, lu.contextTimeout)
+		err := handle(ctx, subscription)
+		cancel()
+
+		if err != nil {
+			log.Printf("Failed to handle subscription %d: %v", subscription.ID, err)
+			failures = append(failures, err)
+			continue
+		}
+		handled++
+	}
+	return handled, errors.Join(failures...)
+}
+
+// notify e-mails the administrators and managers of the organization of the subscription
+func (lu *subscriptionLifecycleUsecase) notify(ctx context.Context, subscription domain.OrganizationSubscription, template string, data map[string]any) error {
+	organization, err := lu.organizationRepository.GetByID(ctx, subscription.OrganizationID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil // archived organization, nobody to tell
+	}
+	if err != nil {
+		return err
+	}
+
+	var recipients []string
+	for _, user := range organization.Users {
+		if user.RoleID == domain.UserRoleAdmin || user.RoleID == domain.UserRoleManager {
+			recipients = append(recipients, user.Email)
+		}
+	}
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	data["OrganizationName"] = organization.Name
+	return lu.mailOutboxUsecase.Enqueue(ctx, recipients, template, "", data)
+}
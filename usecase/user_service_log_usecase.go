@@ -3,6 +3,7 @@ package usecase
 import (
 	"context"
 	"errors"
+	"slices"
 	"time"
 
 	"github.com/gabrielfmcoelho/platform-core/domain"
@@ -12,15 +13,18 @@ import (
 
 type userServiceLogUsecase struct {
 	userServiceLogRepo domain.UserServiceLogRepository
+	usageRollupRepo    domain.UsageRollupRepository
 	contextTimeout     time.Duration
 }
 
 func NewUserServiceLogUsecase(
 	repo domain.UserServiceLogRepository,
+	usageRollupRepo domain.UsageRollupRepository,
 	timeout time.Duration,
 ) domain.UserServiceLogUsecase {
 	return &userServiceLogUsecase{
 		userServiceLogRepo: repo,
+		usageRollupRepo:    usageRollupRepo,
 		contextTimeout:     timeout,
 	}
 }
@@ -118,18 +122,315 @@ func (u *userServiceLogUsecase) Delete(ctx context.Context, userServiceLogID uin
 	return nil
 }
 
-// GetUsageStatistics returns aggregated usage statistics for admin dashboard
-func (u *userServiceLogUsecase) GetUsageStatistics(ctx context.Context, organizationID *uint, startDate *string, endDate *string) (domain.UsageStatistics, error) {
+// maxUsageBuckets bounds the time series of the usage statistics, e.g. a year by hour
+const maxUsageBuckets = 9000
+
+// usageDefaultWindows is how far before its end the statistics start without a start date
+var usageDefaultWindows = map[string]time.Duration{
+	domain.UsageGranularityHour:  7 * 24 * time.Hour,
+	domain.UsageGranularityDay:   90 * 24 * time.Hour,
+	domain.UsageGranularityWeek:  52 * 7 * 24 * time.Hour,
+	domain.UsageGranularityMonth: 2 * 365 * 24 * time.Hour,
+}
+
+// GetUsageStatistics returns aggregated usage statistics for admin dashboard. The days
+// before the current one (UTC) are read from the usage rollups; the current day is read
+// from the usage logs, its rollups being still credited. Out of UTC or by hour, and for
+// a range not made of whole days, the hourly rollups are read: bounds within an hour
+// are then rounded out to the hour. As usage is recorded by UTC hour, time zones offset
+// by a fraction of an hour are refused. The time series covers the whole range, empty
+// buckets included, in chronological order.
+func (u *userServiceLogUsecase) GetUsageStatistics(ctx context.Context, query *domain.UsageStatisticsQuery) (domain.UsageStatistics, error) {
 	c, cancel := context.WithTimeout(ctx, u.contextTimeout)
 	defer cancel()
 
-	stats, err := u.userServiceLogRepo.GetUsageStatistics(c, organizationID, startDate, endDate)
+	location := time.UTC
+	if query.Timezone != "" {
+		var err error
+		if location, err = time.LoadLocation(query.Timezone); err != nil {
+			return domain.UsageStatistics{}, domain.ErrInvalidTimezone
+		}
+	}
+	granularity := query.Granularity
+	if granularity == "" {
+		granularity = domain.UsageGranularityDay
+	}
+
+	from, to, err := parseUsageRange(query.StartDate, query.EndDate, location)
 	if err != nil {
-		if errors.Is(err, domain.ErrDataBaseInternalError) {
-			return domain.UsageStatistics{}, domain.ErrDataBaseInternalError
+		return domain.UsageStatistics{}, err
+	}
+
+	now := time.Now().UTC()
+	from, last := usageSeriesRange(from, to, now, granularity, location)
+	if !wholeHourOffset(from, location) || !wholeHourOffset(last, location) {
+		return domain.UsageStatistics{}, domain.ErrUnsupportedTimezone
+	}
+	today := now.Truncate(24 * time.Hour)
+	var byUser, byBucket []domain.UsageTotal
+
+	// the completed days, from the rollups
+	rollupFrom, rollupTo := from, to
+	if rollupTo.IsZero() || rollupTo.After(today) {
+		rollupTo = today
+	}
+	if rollupFrom.Before(rollupTo) {
+		rollupGranularity := domain.UsageGranularityDay
+		if location != time.UTC || granularity == domain.UsageGranularityHour || !isDayStart(rollupFrom) || !isDayStart(rollupTo) {
+			rollupGranularity = domain.UsageGranularityHour
+			rollupFrom = rollupFrom.Truncate(time.Hour)
+			if !rollupTo.Equal(rollupTo.Truncate(time.Hour)) {
+				rollupTo = rollupTo.Truncate(time.Hour).Add(time.Hour)
+			}
+		}
+		users, buckets, err := u.usageRollupRepo.FetchTotals(c, rollupGranularity, query.OrganizationID, rollupFrom, rollupTo)
+		if err != nil {
+			return domain.UsageStatistics{}, err
+		}
+		byUser = append(byUser, users...)
+		byBucket = append(byBucket, buckets...)
+	}
+
+	// the current day, from the usage logs
+	if to.IsZero() || to.After(today) {
+		users, buckets, err := u.userServiceLogRepo.FetchUsageTotals(c, query.OrganizationID, later(from, today), to)
+		if err != nil {
+			return domain.UsageStatistics{}, err
 		}
-		return domain.UsageStatistics{}, domain.ErrInternalServerError
+		byUser = append(byUser, users...)
+		byBucket = append(byBucket, buckets...)
+	}
+
+	stats := summarizeUsage(byUser)
+	stats.TimeSeriesData, err = usageTimeSeries(byBucket, byUser, granularity, location, from, last)
+	if err != nil {
+		return domain.UsageStatistics{}, err
+	}
+
+	if query.OrganizationID != nil {
+		totalOrgUsers, err := u.userServiceLogRepo.CountOrganizationUsers(c, *query.OrganizationID)
+		if err != nil {
+			return domain.UsageStatistics{}, err
+		}
+		stats.TotalOrgUsers = int(totalOrgUsers)
+	}
+
+	stats.RecentActivity, err = u.userServiceLogRepo.FetchRecentActivity(c, query.OrganizationID, from, to, 10)
+	if err != nil {
+		return domain.UsageStatistics{}, err
 	}
 
 	return stats, nil
 }
+
+// usageSeriesRange returns the start and the end of the statistics: an open start is the
+// bucket usageDefaultWindows before the end, an open end is now
+func usageSeriesRange(from time.Time, to time.Time, now time.Time, granularity string, location *time.Location) (time.Time, time.Time) {
+	last := to
+	if last.IsZero() {
+		last = now
+	}
+	if from.IsZero() {
+		from = usageBucketStart(last.Add(-usageDefaultWindows[granularity]), granularity, location).UTC()
+	}
+	return from, last
+}
+
+// wholeHourOffset reports whether the offset of location at t is a whole number of hours
+func wholeHourOffset(t time.Time, location *time.Location) bool {
+	_, offset := t.In(location).Zone()
+	return offset%3600 == 0
+}
+
+// parseUsageRange reads the optional bounds of the statistics as [from, to) in UTC, the
+// dates and the times without offset being in location. A date without time as end date
+// includes its whole day; missing bounds are left zero (open).
+func parseUsageRange(startDate string, endDate string, location *time.Location) (time.Time, time.Time, error) {
+	var from, to time.Time
+	if startDate != "" {
+		start, _, err := parseUsageDate(startDate, location)
+		if err != nil {
+			return from, to, domain.ErrInvalidDateRange
+		}
+		from = start.UTC()
+	}
+	if endDate != "" {
+		end, dateOnly, err := parseUsageDate(endDate, location)
+		if err != nil {
+			return from, to, domain.ErrInvalidDateRange
+		}
+		if dateOnly {
+			end = end.AddDate(0, 0, 1)
+		}
+		to = end.UTC()
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return from, to, domain.ErrInvalidDateRange
+	}
+	return from, to, nil
+}
+
+// parseUsageDate reads a date or a time in location, unless the time has an offset
+func parseUsageDate(value string, location *time.Location) (time.Time, bool, error) {
+	if date, err := time.ParseInLocation("2006-01-02", value, location); err == nil {
+		return date, true, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05"} {
+		if t, err := time.ParseInLocation(layout, value, location); err == nil {
+			return t, false, nil
+		}
+	}
+	return time.Time{}, false, domain.ErrInvalidDateRange
+}
+
+// summarizeUsage builds the statistics from the usage totals by user, the services ordered by ID
+func summarizeUsage(byUser []domain.UsageTotal) domain.UsageStatistics {
+	stats := domain.UsageStatistics{
+		ServiceStats:   make([]domain.ServiceUsageStats, 0),
+		RecentActivity: make([]domain.RecentActivityItem, 0),
+		TimeSeriesData: make([]domain.TimeSeriesDataPoint, 0),
+	}
+
+	type serviceTotal struct {
+		name     string
+		users    map[uint]bool
+		duration time.Duration
+	}
+	users := make(map[uint]bool)
+	services := make(map[uint]*serviceTotal)
+	var serviceIDs []uint
+	var totalDuration time.Duration
+	for _, total := range byUser {
+		users[total.UserID] = true
+		totalDuration += total.Duration
+
+		service, ok := services[total.ServiceID]
+		if !ok {
+			service = &serviceTotal{name: total.ServiceName, users: make(map[uint]bool)}
+			services[total.ServiceID] = service
+			serviceIDs = append(serviceIDs, total.ServiceID)
+		}
+		service.users[total.UserID] = true
+		service.duration += total.Duration
+	}
+	stats.TotalUsers = len(users)
+	stats.TotalDuration = int(totalDuration / time.Second)
+
+	slices.Sort(serviceIDs)
+	for _, serviceID := range serviceIDs {
+		service := services[serviceID]
+		totalSeconds := int(service.duration / time.Second)
+		stats.ServiceStats = append(stats.ServiceStats, domain.ServiceUsageStats{
+			ServiceID:    serviceID,
+			ServiceName:  service.name,
+			TotalUsers:   len(service.users),
+			TotalSeconds: totalSeconds,
+			AvgDuration:  float64(totalSeconds) / float64(len(service.users)),
+		})
+	}
+
+	return stats
+}
+
+// usageTimeSeries buckets the usage totals by granularity in location, from the bucket of
+// first to the bucket of last, every service of the range in every bucket. The seconds
+// are summed before being truncated so the buckets add up to the totals.
+func usageTimeSeries(byBucket []domain.UsageTotal, byUser []domain.UsageTotal, granularity string, location *time.Location, first time.Time, last time.Time) ([]domain.TimeSeriesDataPoint, error) {
+	series := make([]domain.TimeSeriesDataPoint, 0)
+
+	type bucketTotal struct {
+		duration time.Duration
+		sessions int64
+	}
+	totals := make(map[int64]map[string]*bucketTotal)
+	for _, total := range byBucket {
+		start := usageBucketStart(total.BucketStart, granularity, location).Unix()
+		if totals[start] == nil {
+			totals[start] = make(map[string]*bucketTotal)
+		}
+		if totals[start][total.ServiceName] == nil {
+			totals[start][total.ServiceName] = &bucketTotal{}
+		}
+		totals[start][total.ServiceName].duration += total.Duration
+		totals[start][total.ServiceName].sessions += total.Sessions
+	}
+
+	var names []string
+	for _, total := range byUser {
+		if !slices.Contains(names, total.ServiceName) {
+			names = append(names, total.ServiceName)
+		}
+	}
+
+	lastStart := usageBucketStart(last.Add(-time.Nanosecond), granularity, location)
+	for start := usageBucketStart(first, granularity, location); !start.After(lastStart); start = nextUsageBucket(start, granularity, location) {
+		if len(series) == maxUsageBuckets {
+			return nil, domain.ErrTooManyBuckets
+		}
+		point := domain.TimeSeriesDataPoint{
+			Date:     formatUsageBucket(start, granularity),
+			Start:    start.Format(time.RFC3339),
+			Services: make(map[string]domain.TimeSeriesService, len(names)),
+		}
+		for _, name := range names {
+			var service domain.TimeSeriesService
+			if total := totals[start.Unix()][name]; total != nil {
+				service.TotalSeconds = int(total.duration / time.Second)
+				service.AccessCount = int(total.sessions)
+			}
+			point.Services[name] = service
+		}
+		series = append(series, point)
+	}
+	return series, nil
+}
+
+// usageBucketStart is the start of the bucket of t in location. Hours follow the offset in
+// effect at t, so the hours repeated or skipped by daylight saving time stay distinct.
+func usageBucketStart(t time.Time, granularity string, location *time.Location) time.Time {
+	t = t.In(location)
+	switch granularity {
+	case domain.UsageGranularityHour:
+		_, offset := t.Zone()
+		shift := time.Duration(offset) * time.Second
+		return t.Add(shift).Truncate(time.Hour).Add(-shift)
+	case domain.UsageGranularityWeek:
+		return time.Date(t.Year(), t.Month(), t.Day()-(int(t.Weekday())+6)%7, 0, 0, 0, 0, location)
+	case domain.UsageGranularityMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, location)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, location)
+}
+
+func nextUsageBucket(start time.Time, granularity string, location *time.Location) time.Time {
+	switch granularity {
+	case domain.UsageGranularityHour:
+		return usageBucketStart(start.Add(time.Hour), granularity, location)
+	case domain.UsageGranularityWeek:
+		return time.Date(start.Year(), start.Month(), start.Day()+7, 0, 0, 0, 0, location)
+	case domain.UsageGranularityMonth:
+		return time.Date(start.Year(), start.Month()+1, 1, 0, 0, 0, 0, location)
+	}
+	return time.Date(start.Year(), start.Month(), start.Day()+1, 0, 0, 0, 0, location)
+}
+
+func formatUsageBucket(start time.Time, granularity string) string {
+	switch granularity {
+	case domain.UsageGranularityHour:
+		return start.Format("2006-01-02 15:00")
+	case domain.UsageGranularityMonth:
+		return start.Format("2006-01")
+	}
+	return start.Format("2006-01-02")
+}
+
+func isDayStart(t time.Time) bool {
+	return t.IsZero() || t.Equal(t.Truncate(24*time.Hour))
+}
+
+func later(a time.Time, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
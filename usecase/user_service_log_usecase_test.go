@@ -0,0 +1,297 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+	_ "time/tzdata" // the same zones wherever the tests run
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("load %s: %v", name, err)
+	}
+	return location
+}
+
+func utc(value string) time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		panic(err)
+	}
+	return t.UTC()
+}
+
+func seriesStarts(series []domain.TimeSeriesDataPoint) []string {
+	starts := make([]string, 0, len(series))
+	for _, point := range series {
+		starts = append(starts, point.Start)
+	}
+	return starts
+}
+
+func equalStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestUsageBucketStart(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+	saoPaulo := mustLoadLocation(t, "America/Sao_Paulo")
+
+	tests := []struct {
+		name        string
+		at          time.Time
+		granularity string
+		location    *time.Location
+		want        string
+	}{
+		{"hour in UTC", utc("2026-10-16T20:45:00Z"), domain.UsageGranularityHour, time.UTC, "2026-10-16T20:00:00Z"},
+		{"day in a negative offset", utc("2026-10-10T02:30:00Z"), domain.UsageGranularityDay, saoPaulo, "2026-10-09T00:00:00-03:00"},
+		{"first 01:00 of a fall back", utc("2026-11-01T05:30:00Z"), domain.UsageGranularityHour, newYork, "2026-11-01T01:00:00-04:00"},
+		{"repeated 01:00 of a fall back", utc("2026-11-01T06:30:00Z"), domain.UsageGranularityHour, newYork, "2026-11-01T01:00:00-05:00"},
+		{"week of a Sunday", utc("2026-10-04T12:00:00Z"), domain.UsageGranularityWeek, time.UTC, "2026-09-28T00:00:00Z"},
+		{"week of a Monday", utc("2026-10-05T00:00:00Z"), domain.UsageGranularityWeek, time.UTC, "2026-10-05T00:00:00Z"},
+		{"week across a month", utc("2026-11-01T12:00:00Z"), domain.UsageGranularityWeek, time.UTC, "2026-10-26T00:00:00Z"},
+		{"month in a negative offset", utc("2026-11-01T02:00:00Z"), domain.UsageGranularityMonth, saoPaulo, "2026-10-01T00:00:00-03:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := usageBucketStart(tt.at, tt.granularity, tt.location).Format(time.RFC3339)
+			if got != tt.want {
+				t.Errorf("usageBucketStart(%s) = %s, want %s", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUsageTimeSeriesBuckets(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+	saoPaulo := mustLoadLocation(t, "America/Sao_Paulo")
+
+	tests := []struct {
+		name        string
+		granularity string
+		location    *time.Location
+		first       time.Time
+		last        time.Time
+		want        []string
+	}{
+		{
+			name:        "hours around a fall back",
+			granularity: domain.UsageGranularityHour,
+			location:    newYork,
+			first:       utc("2026-11-01T04:00:00Z"),
+			last:        utc("2026-11-01T08:00:00Z"),
+			want: []string{
+				"2026-11-01T00:00:00-04:00",
+				"2026-11-01T01:00:00-04:00",
+				"2026-11-01T01:00:00-05:00",
+				"2026-11-01T02:00:00-05:00",
+			},
+		},
+		{
+			name:        "hours around a spring forward",
+			granularity: domain.UsageGranularityHour,
+			location:    newYork,
+			first:       utc("2026-03-08T06:00:00Z"),
+			last:        utc("2026-03-08T09:00:00Z"),
+			want: []string{
+				"2026-03-08T01:00:00-05:00",
+				"2026-03-08T03:00:00-04:00",
+				"2026-03-08T04:00:00-04:00",
+			},
+		},
+		{
+			name:        "days across a fall back",
+			granularity: domain.UsageGranularityDay,
+			location:    newYork,
+			first:       utc("2026-10-31T04:00:00Z"),
+			last:        utc("2026-11-02T05:00:00Z"),
+			want: []string{
+				"2026-10-31T00:00:00-04:00",
+				"2026-11-01T00:00:00-04:00",
+			},
+		},
+		{
+			name:        "weeks start on Monday",
+			granularity: domain.UsageGranularityWeek,
+			location:    time.UTC,
+			first:       utc("2026-10-01T00:00:00Z"),
+			last:        utc("2026-10-16T00:00:00Z"),
+			want: []string{
+				"2026-09-28T00:00:00Z",
+				"2026-10-05T00:00:00Z",
+				"2026-10-12T00:00:00Z",
+			},
+		},
+		{
+			name:        "months across a year",
+			granularity: domain.UsageGranularityMonth,
+			location:    saoPaulo,
+			first:       utc("2026-11-15T12:00:00Z"),
+			last:        utc("2027-02-10T12:00:00Z"),
+			want: []string{
+				"2026-11-01T00:00:00-03:00",
+				"2026-12-01T00:00:00-03:00",
+				"2027-01-01T00:00:00-03:00",
+				"2027-02-01T00:00:00-03:00",
+			},
+		},
+		{
+			name:        "an end on a boundary is excluded",
+			granularity: domain.UsageGranularityDay,
+			location:    time.UTC,
+			first:       utc("2026-10-09T00:00:00Z"),
+			last:        utc("2026-10-11T00:00:00Z"),
+			want: []string{
+				"2026-10-09T00:00:00Z",
+				"2026-10-10T00:00:00Z",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			series, err := usageTimeSeries(nil, nil, tt.granularity, tt.location, tt.first, tt.last)
+			if err != nil {
+				t.Fatalf("usageTimeSeries: %v", err)
+			}
+			if got := seriesStarts(series); !equalStrings(got, tt.want) {
+				t.Errorf("buckets = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUsageTimeSeriesFillsEveryService(t *testing.T) {
+	saoPaulo := mustLoadLocation(t, "America/Sao_Paulo")
+	byUser := []domain.UsageTotal{
+		{ServiceID: 1, ServiceName: "Resistracker", UserID: 1},
+		{ServiceID: 2, ServiceName: "Planner", UserID: 3},
+	}
+	byBucket := []domain.UsageTotal{
+		// the 9th in Sao Paulo, the 10th in UTC
+		{BucketStart: utc("2026-10-10T02:00:00Z"), ServiceID: 1, ServiceName: "Resistracker", Sessions: 1, Duration: 1800 * time.Second},
+		{BucketStart: utc("2026-10-11T14:00:00Z"), ServiceID: 2, ServiceName: "Planner", Sessions: 1, Duration: 600 * time.Second},
+		{BucketStart: utc("2026-10-11T15:00:00Z"), ServiceID: 2, ServiceName: "Planner", Sessions: 2, Duration: 900 * time.Second},
+	}
+
+	series, err := usageTimeSeries(byBucket, byUser, domain.UsageGranularityDay, saoPaulo, utc("2026-10-09T03:00:00Z"), utc("2026-10-12T03:00:00Z"))
+	if err != nil {
+		t.Fatalf("usageTimeSeries: %v", err)
+	}
+
+	want := []struct {
+		date     string
+		services map[string]domain.TimeSeriesService
+	}{
+		{"2026-10-09", map[string]domain.TimeSeriesService{
+			"Resistracker": {TotalSeconds: 1800, AccessCount: 1},
+			"Planner":      {},
+		}},
+		{"2026-10-10", map[string]domain.TimeSeriesService{
+			"Resistracker": {},
+			"Planner":      {},
+		}},
+		{"2026-10-11", map[string]domain.TimeSeriesService{
+			"Resistracker": {},
+			"Planner":      {TotalSeconds: 1500, AccessCount: 3},
+		}},
+	}
+	if len(series) != len(want) {
+		t.Fatalf("got %d buckets, want %d", len(series), len(want))
+	}
+	for i, point := range series {
+		if point.Date != want[i].date {
+			t.Errorf("bucket %d date = %s, want %s", i, point.Date, want[i].date)
+		}
+		if len(point.Services) != len(want[i].services) {
+			t.Errorf("bucket %s has %d services, want %d", point.Date, len(point.Services), len(want[i].services))
+		}
+		for name, service := range want[i].services {
+			if got, ok := point.Services[name]; !ok || got != service {
+				t.Errorf("bucket %s service %s = %+v, want %+v", point.Date, name, got, service)
+			}
+		}
+	}
+}
+
+func TestUsageTimeSeriesTooManyBuckets(t *testing.T) {
+	_, err := usageTimeSeries(nil, nil, domain.UsageGranularityHour, time.UTC, utc("2025-01-01T00:00:00Z"), utc("2026-10-16T00:00:00Z"))
+	if !errors.Is(err, domain.ErrTooManyBuckets) {
+		t.Errorf("err = %v, want %v", err, domain.ErrTooManyBuckets)
+	}
+
+	series, err := usageTimeSeries(nil, nil, domain.UsageGranularityDay, time.UTC, utc("2025-01-01T00:00:00Z"), utc("2026-10-16T00:00:00Z"))
+	if err != nil || len(series) != 653 {
+		t.Errorf("got %d buckets, %v, want 653 buckets", len(series), err)
+	}
+}
+
+func TestUsageSeriesRangeOpenBounds(t *testing.T) {
+	saoPaulo := mustLoadLocation(t, "America/Sao_Paulo")
+	now := utc("2026-10-16T20:45:00Z")
+
+	tests := []struct {
+		name        string
+		from        time.Time
+		to          time.Time
+		granularity string
+		location    *time.Location
+		wantFrom    time.Time
+		wantLast    time.Time
+	}{
+		{"both open by hour", time.Time{}, time.Time{}, domain.UsageGranularityHour, time.UTC, utc("2026-10-09T20:00:00Z"), now},
+		{"both open by day", time.Time{}, time.Time{}, domain.UsageGranularityDay, time.UTC, utc("2026-07-18T00:00:00Z"), now},
+		{"open start by day in a zone", time.Time{}, utc("2026-10-12T03:00:00Z"), domain.UsageGranularityDay, saoPaulo, utc("2026-07-14T03:00:00Z"), utc("2026-10-12T03:00:00Z")},
+		{"open start by week", time.Time{}, time.Time{}, domain.UsageGranularityWeek, time.UTC, utc("2025-10-13T00:00:00Z"), now},
+		{"open end", utc("2026-10-01T00:00:00Z"), time.Time{}, domain.UsageGranularityMonth, time.UTC, utc("2026-10-01T00:00:00Z"), now},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, last := usageSeriesRange(tt.from, tt.to, now, tt.granularity, tt.location)
+			if !from.Equal(tt.wantFrom) || !last.Equal(tt.wantLast) {
+				t.Errorf("range = [%s, %s), want [%s, %s)", from, last, tt.wantFrom, tt.wantLast)
+			}
+		})
+	}
+
+	// the default windows stay within the bucket limit
+	for granularity := range usageDefaultWindows {
+		from, last := usageSeriesRange(time.Time{}, time.Time{}, now, granularity, time.UTC)
+		if _, err := usageTimeSeries(nil, nil, granularity, time.UTC, from, last); err != nil {
+			t.Errorf("default window by %s: %v", granularity, err)
+		}
+	}
+}
+
+func TestWholeHourOffset(t *testing.T) {
+	at := utc("2026-10-16T12:00:00Z")
+	tests := []struct {
+		zone string
+		want bool
+	}{
+		{"UTC", true},
+		{"America/Sao_Paulo", true},
+		{"Europe/Berlin", true},
+		{"Asia/Kolkata", false},
+		{"Asia/Kathmandu", false},
+		{"Australia/Adelaide", false},
+	}
+	for _, tt := range tests {
+		if got := wholeHourOffset(at, mustLoadLocation(t, tt.zone)); got != tt.want {
+			t.Errorf("wholeHourOffset(%s) = %v, want %v", tt.zone, got, tt.want)
+		}
+	}
+}
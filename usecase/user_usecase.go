@@ -3,26 +3,31 @@ package usecase
 import (
 	"context"
 	"errors"
+	"log"
 	"time"
 
 	"github.com/gabrielfmcoelho/platform-core/domain"
 	"github.com/gabrielfmcoelho/platform-core/internal"
 	"github.com/gabrielfmcoelho/platform-core/internal/parser"
-	"github.com/gabrielfmcoelho/platform-core/internal/password"
 )
 
 type UserUsecase struct {
-	userRepository domain.UserRepository
-	contextTimeout time.Duration
+	userRepository         domain.UserRepository
+	organizationRepository domain.OrganizationRepository
+	passwordUsecase        domain.PasswordUsecase
+	contextTimeout         time.Duration
 }
 
-func NewUserUsecase(userRepository domain.UserRepository, timeout time.Duration) *UserUsecase {
+func NewUserUsecase(userRepository domain.UserRepository, organizationRepository domain.OrganizationRepository, passwordUsecase domain.PasswordUsecase, timeout time.Duration) *UserUsecase {
 	return &UserUsecase{
-		userRepository: userRepository,
-		contextTimeout: timeout,
+		userRepository:         userRepository,
+		organizationRepository: organizationRepository,
+		passwordUsecase:        passwordUsecase,
+		contextTimeout:         timeout,
 	}
 }
 
+// Create adds a user to an organization; members (not guests) need a free seat of its subscription
 func (uu *UserUsecase) Create(c context.Context, createUser *domain.CreateUser) error {
 	ctx, cancel := context.WithTimeout(c, uu.contextTimeout)
 	defer cancel()
@@ -32,7 +37,18 @@ func (uu *UserUsecase) Create(c context.Context, createUser *domain.CreateUser)
 		return domain.ErrUserAlreadyExists
 	}
 
-	hashedPassword, err := password.HashPassword(createUser.Password)
+	organization, err := uu.organizationRepository.GetByID(ctx, createUser.OrganizationID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.ErrNotFound
+		}
+		return domain.ErrInternalServerError
+	}
+	if createUser.RoleID != domain.UserRoleGuest && usersLimitReached(organization) {
+		return domain.ErrUsersLimitReached
+	}
+
+	hashedPassword, err := uu.passwordUsecase.Hash(ctx, nil, createUser.Password)
 	if err != nil {
 		return err
 	}
@@ -48,6 +64,9 @@ func (uu *UserUsecase) Create(c context.Context, createUser *domain.CreateUser)
 		return domain.ErrInternalServerError
 	}
 
+	if err := uu.passwordUsecase.Record(ctx, user.ID, hashedPassword); err != nil {
+		log.Printf("Failed to record the password history of user %d: %v", user.ID, err)
+	}
 	return nil
 }
 
@@ -104,10 +123,25 @@ func (uu *UserUsecase) GetByIdentifier(c context.Context, identifier string) (do
 	return parser.ToPublicUser(user), nil
 }
 
+// Update changes the user data. A new password goes through the password policy and is
+// stored hashed, the other fields are updated as given.
 func (uu *UserUsecase) Update(c context.Context, userID uint, user *domain.User) error {
 	ctx, cancel := context.WithTimeout(c, uu.contextTimeout)
 	defer cancel()
 
+	hashedPassword := ""
+	if user.Password != "" {
+		current, err := uu.userRepository.GetByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		hashedPassword, err = uu.passwordUsecase.Hash(ctx, &current, user.Password)
+		if err != nil {
+			return err
+		}
+		user.Password = ""
+	}
+
 	err := uu.userRepository.Update(ctx, userID, user)
 	if err != nil {
 		if errors.Is(err, domain.ErrDataBaseInternalError) {
@@ -116,9 +150,20 @@ func (uu *UserUsecase) Update(c context.Context, userID uint, user *domain.User)
 		return domain.ErrInternalServerError
 	}
 
+	if hashedPassword != "" {
+		return uu.passwordUsecase.Save(ctx, userID, hashedPassword)
+	}
 	return nil
 }
 
+// RequirePasswordChange makes the user change their password on the next login
+func (uu *UserUsecase) RequirePasswordChange(c context.Context, userID uint) error {
+	ctx, cancel := context.WithTimeout(c, uu.contextTimeout)
+	defer cancel()
+
+	return uu.userRepository.RequirePasswordChange(ctx, userID)
+}
+
 func (uu *UserUsecase) Archive(c context.Context, userID uint) error {
 	ctx, cancel := context.WithTimeout(c, uu.contextTimeout)
 	defer cancel()
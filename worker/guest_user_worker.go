@@ -0,0 +1,31 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/repository"
+	"github.com/gabrielfmcoelho/platform-core/usecase"
+	"gorm.io/gorm"
+)
+
+// NewGuestUserWorker removes the guest users whose session is over, with their data
+func NewGuestUserWorker(ctx context.Context, env *bootstrap.Env, timeout time.Duration, db *gorm.DB) {
+	gar := repository.NewGuestAccessRepository(db)
+	gur := repository.NewGuestUserRepository(db)
+	or := repository.NewOrganizationRepository(db)
+	gau := usecase.NewGuestAccessUsecase(gar, gur, or, env.GuestSessionMinute, timeout)
+
+	interval := time.Duration(env.GuestCleanupIntervalMinute) * time.Minute
+	every(ctx, "guest user cleanup", interval, func(ctx context.Context) {
+		removed, err := gau.CleanupExpired(ctx)
+		if err != nil {
+			log.Printf("[Worker] guest user cleanup: %v", err)
+		}
+		if removed > 0 {
+			log.Printf("[Worker] guest user cleanup: %d guest(s) removed", removed)
+		}
+	})
+}
@@ -0,0 +1,30 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/repository"
+	"github.com/gabrielfmcoelho/platform-core/usecase"
+	"gorm.io/gorm"
+)
+
+// NewLoginAttemptWorker forgets the login attempt counters that no longer matter
+func NewLoginAttemptWorker(ctx context.Context, env *bootstrap.Env, timeout time.Duration, db *gorm.DB, loginAttemptStore domain.LoginAttemptStore) {
+	ur := repository.NewUserRepository(db)
+	ulr := repository.NewUserLogRepository(db)
+	lau := usecase.NewLoginAttemptUsecase(loginAttemptStore, ur, ulr, bootstrap.NewLoginThrottlePolicy(env), timeout)
+
+	every(ctx, "login attempt prune", time.Hour, func(ctx context.Context) {
+		pruned, err := lau.Prune(ctx)
+		if err != nil {
+			log.Printf("[Worker] login attempt prune: %v", err)
+		}
+		if pruned > 0 {
+			log.Printf("[Worker] login attempt prune: %d counter(s) removed", pruned)
+		}
+	})
+}
@@ -0,0 +1,29 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/repository"
+	"github.com/gabrielfmcoelho/platform-core/usecase"
+	"gorm.io/gorm"
+)
+
+// NewMailOutboxWorker delivers the e-mails queued in the mail outbox
+func NewMailOutboxWorker(ctx context.Context, env *bootstrap.Env, timeout time.Duration, db *gorm.DB, mailer domain.Mailer) {
+	mor := repository.NewMailOutboxRepository(db)
+	mou := usecase.NewMailOutboxUsecase(mor, mailer, timeout)
+
+	every(ctx, "mail outbox", time.Duration(env.MailOutboxPollSecond)*time.Second, func(ctx context.Context) {
+		delivered, err := mou.DeliverPending(ctx)
+		if err != nil {
+			log.Printf("[Worker] mail outbox: %v", err)
+		}
+		if delivered > 0 {
+			log.Printf("[Worker] mail outbox: %d e-mail(s) delivered", delivered)
+		}
+	})
+}
@@ -0,0 +1,28 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/repository"
+	"gorm.io/gorm"
+)
+
+// NewOAuthCodeWorker removes the authorization codes that were redeemed or expired
+func NewOAuthCodeWorker(ctx context.Context, timeout time.Duration, db *gorm.DB) {
+	acr := repository.NewOAuthAuthorizationCodeRepository(db)
+
+	every(ctx, "oauth code cleanup", time.Hour, func(ctx context.Context) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		removed, err := acr.DeleteExpired(ctx, time.Now())
+		if err != nil {
+			log.Printf("[Worker] oauth code cleanup: %v", err)
+		}
+		if removed > 0 {
+			log.Printf("[Worker] oauth code cleanup: %d code(s) removed", removed)
+		}
+	})
+}
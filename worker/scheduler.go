@@ -0,0 +1,133 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/internal/cron"
+	"github.com/gabrielfmcoelho/platform-core/internal/tokenutil"
+)
+
+// jobLease bounds a run of a scheduled job; a replica dying mid-run blocks the job for at most this long
+const jobLease = 10 * time.Minute
+
+// Scheduler runs jobs on cron expressions evaluated in UTC. Every replica runs it:
+// each minute they all try to lease the due jobs in the scheduled_jobs table and
+// only the winner runs the occurrence. A run missed while no replica was up
+// happens as soon as one starts.
+type Scheduler struct {
+	repository domain.ScheduledJobRepository
+	owner      string
+	timeout    time.Duration
+}
+
+func NewScheduler(repository domain.ScheduledJobRepository, timeout time.Duration) *Scheduler {
+	hostname, _ := os.Hostname()
+	suffix, _ := tokenutil.GenerateOpaqueToken(4)
+	return &Scheduler{
+		repository: repository,
+		owner:      fmt.Sprintf("%s/%d/%s", hostname, os.Getpid(), suffix),
+		timeout:    timeout,
+	}
+}
+
+// cron registers the job and runs it on the schedule until ctx is cancelled. The job
+// returns a short summary of what it did, stored with its status.
+func (s *Scheduler) cron(ctx context.Context, name string, spec string, job func(ctx context.Context) (string, error)) {
+	schedule, err := cron.Parse(spec)
+	if err != nil {
+		log.Printf("[Scheduler] %s not scheduled: %v", name, err)
+		return
+	}
+	if schedule.Next(time.Now().UTC()).IsZero() {
+		log.Printf("[Scheduler] %s not scheduled: %q never matches", name, spec)
+		return
+	}
+
+	go func() {
+		registerCtx, cancel := context.WithTimeout(ctx, s.timeout)
+		err := s.repository.Register(registerCtx, name, spec, schedule.Next(time.Now().UTC()))
+		cancel()
+		if err != nil {
+			log.Printf("[Scheduler] %s not scheduled: %v", name, err)
+			return
+		}
+		log.Printf("[Scheduler] %s scheduled (%s UTC)", name, spec)
+
+		for {
+			s.tryRun(ctx, name, schedule, job)
+
+			// wake up right after the start of the next minute
+			now := time.Now()
+			timer := time.NewTimer(now.Truncate(time.Minute).Add(time.Minute + time.Second).Sub(now))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				log.Printf("[Scheduler] %s stopped", name)
+				return
+			case <-timer.C:
+			}
+		}
+	}()
+}
+
+// tryRun runs the job when it is due and this replica wins its lease
+func (s *Scheduler) tryRun(ctx context.Context, name string, schedule *cron.Schedule, job func(ctx context.Context) (string, error)) {
+	now := time.Now().UTC()
+
+	acquireCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	acquired, err := s.repository.Acquire(acquireCtx, name, s.owner, now, now.Add(jobLease), schedule.Next(now))
+	cancel()
+	if err != nil {
+		log.Printf("[Scheduler] %s: %v", name, err)
+		return
+	}
+	if !acquired {
+		return
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, jobLease)
+	result, runErr := runJob(runCtx, name, job)
+	cancel()
+
+	status, errorMessage := domain.JobStatusSucceeded, ""
+	if runErr != nil {
+		status, errorMessage = domain.JobStatusFailed, runErr.Error()
+		log.Printf("[Scheduler] %s failed: %v", name, runErr)
+	}
+
+	// recorded even when ctx was cancelled meanwhile, so the lease is released on shutdown
+	finishCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.timeout)
+	defer cancel()
+	if err := s.repository.Finish(finishCtx, name, s.owner, time.Now().UTC(), status, limitLength(result), limitLength(errorMessage)); err != nil {
+		log.Printf("[Scheduler] %s: %v", name, err)
+	}
+}
+
+// runJob runs one occurrence; a panic fails the run instead of the scheduler
+func runJob(ctx context.Context, name string, job func(ctx context.Context) (string, error)) (result string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return job(ctx)
+}
+
+// limitLength keeps a message within the size of the columns of ScheduledJob, cut on a
+// character boundary so it stays valid UTF-8
+func limitLength(message string) string {
+	if len(message) <= 1024 {
+		return message
+	}
+	end := 1024
+	for end > 0 && !utf8.RuneStart(message[end]) {
+		end--
+	}
+	return message[:end]
+}
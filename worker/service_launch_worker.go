@@ -0,0 +1,28 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/repository"
+	"gorm.io/gorm"
+)
+
+// NewServiceLaunchWorker removes the expired launch tokens
+func NewServiceLaunchWorker(ctx context.Context, timeout time.Duration, db *gorm.DB) {
+	slr := repository.NewServiceLaunchRepository(db)
+
+	every(ctx, "service launch cleanup", time.Hour, func(ctx context.Context) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		removed, err := slr.DeleteExpired(ctx, time.Now())
+		if err != nil {
+			log.Printf("[Worker] service launch cleanup: %v", err)
+		}
+		if removed > 0 {
+			log.Printf("[Worker] service launch cleanup: %d launch(es) removed", removed)
+		}
+	})
+}
@@ -0,0 +1,25 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/domain"
+)
+
+// signingKeyReloadInterval bounds how long an instance keeps signing with a
+// key that was rotated by another instance
+const signingKeyReloadInterval = time.Minute
+
+// NewSigningKeyWorker keeps the key ring in sync with the signing keys table
+func NewSigningKeyWorker(ctx context.Context, timeout time.Duration, keyRing domain.KeyRing) {
+	every(ctx, "signing key reload", signingKeyReloadInterval, func(ctx context.Context) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		if err := keyRing.Reload(ctx); err != nil {
+			log.Printf("[Worker] signing key reload: %v", err)
+		}
+	})
+}
@@ -0,0 +1,28 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/repository"
+	"gorm.io/gorm"
+)
+
+// NewSSOStateWorker removes the single sign-on login states nobody came back with
+func NewSSOStateWorker(ctx context.Context, timeout time.Duration, db *gorm.DB) {
+	lsr := repository.NewSSOLoginStateRepository(db)
+
+	every(ctx, "sso state cleanup", time.Hour, func(ctx context.Context) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		removed, err := lsr.DeleteExpired(ctx, time.Now())
+		if err != nil {
+			log.Printf("[Worker] sso state cleanup: %v", err)
+		}
+		if removed > 0 {
+			log.Printf("[Worker] sso state cleanup: %d state(s) removed", removed)
+		}
+	})
+}
@@ -0,0 +1,44 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/repository"
+	"github.com/gabrielfmcoelho/platform-core/usecase"
+	"gorm.io/gorm"
+)
+
+// NewSubscriptionWorker schedules the subscription jobs: renewal of the auto-renewed
+// subscriptions first, then the e-mails of the grace period and the deactivation of
+// the subscriptions past it, and once a day the warnings before the end date.
+func NewSubscriptionWorker(ctx context.Context, env *bootstrap.Env, timeout time.Duration, db *gorm.DB, mailer domain.Mailer, scheduler *Scheduler) {
+	slu := usecase.NewSubscriptionLifecycleUsecase(
+		repository.NewOrganizationSubscriptionRepository(db),
+		repository.NewOrganizationRepository(db),
+		usecase.NewMailOutboxUsecase(repository.NewMailOutboxRepository(db), mailer, timeout),
+		env.SubscriptionWarningDays,
+		env.SubscriptionGraceDays,
+		timeout,
+	)
+
+	scheduler.cron(ctx, "subscription renewal", "0 * * * *", func(ctx context.Context) (string, error) {
+		renewed, err := slu.RenewDue(ctx)
+		return fmt.Sprintf("%d subscription(s) renewed", renewed), err
+	})
+	scheduler.cron(ctx, "subscription grace period", "10 * * * *", func(ctx context.Context) (string, error) {
+		notified, err := slu.NotifyGracePeriods(ctx)
+		return fmt.Sprintf("%d organization(s) notified", notified), err
+	})
+	scheduler.cron(ctx, "subscription deactivation", "20 * * * *", func(ctx context.Context) (string, error) {
+		deactivated, err := slu.DeactivateExpired(ctx)
+		return fmt.Sprintf("%d subscription(s) deactivated", deactivated), err
+	})
+	scheduler.cron(ctx, "subscription expiry warning", "0 9 * * *", func(ctx context.Context) (string, error) {
+		warned, err := slu.SendExpiryWarnings(ctx)
+		return fmt.Sprintf("%d organization(s) warned", warned), err
+	})
+}
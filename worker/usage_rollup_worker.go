@@ -0,0 +1,33 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/repository"
+	"github.com/gabrielfmcoelho/platform-core/usecase"
+	"gorm.io/gorm"
+)
+
+// NewUsageRollupWorker schedules the reconciliation of the usage rollups: every hour the
+// rollups of the last days are rebuilt from the usage logs, which repairs the credits
+// lost or doubled by the incremental updates. Older rollups are backfilled by the
+// migration that creates them (see bootstrap.AutoMigrate).
+func NewUsageRollupWorker(ctx context.Context, env *bootstrap.Env, timeout time.Duration, db *gorm.DB, scheduler *Scheduler) {
+	mu := usecase.NewMetricsUsecase(
+		repository.NewOrganizationMetricsRepository(db),
+		repository.NewUserMetricsRepository(db),
+		repository.NewUsageRollupRepository(db),
+		repository.NewOrganizationRepository(db),
+		repository.NewUserRepository(db),
+		timeout,
+	)
+
+	scheduler.cron(ctx, "usage rollup reconciliation", "30 * * * *", func(ctx context.Context) (string, error) {
+		since := time.Now().UTC().AddDate(0, 0, -env.UsageRollupReconcileDays)
+		rebuilt, err := mu.ReconcileUsageRollups(ctx, since)
+		return fmt.Sprintf("%d rollup(s) rebuilt", rebuilt), err
+	})
+}
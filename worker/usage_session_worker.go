@@ -0,0 +1,30 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/repository"
+	"gorm.io/gorm"
+)
+
+// NewUsageSessionWorker closes the usage sessions whose heartbeats stopped, at their last heartbeat
+func NewUsageSessionWorker(ctx context.Context, env *bootstrap.Env, timeout time.Duration, db *gorm.DB) {
+	uslr := repository.NewUserServiceLogRepository(db)
+	heartbeatTimeout := time.Duration(env.UsageHeartbeatTimeoutSecond) * time.Second
+
+	every(ctx, "usage session reaper", time.Minute, func(ctx context.Context) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		closed, err := uslr.EndStale(ctx, time.Now().UTC().Add(-heartbeatTimeout))
+		if err != nil {
+			log.Printf("[Worker] usage session reaper: %v", err)
+		}
+		if closed > 0 {
+			log.Printf("[Worker] usage session reaper: %d session(s) timed out", closed)
+		}
+	})
+}
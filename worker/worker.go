@@ -0,0 +1,58 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gabrielfmcoelho/platform-core/bootstrap"
+	"github.com/gabrielfmcoelho/platform-core/domain"
+	"github.com/gabrielfmcoelho/platform-core/repository"
+	"gorm.io/gorm"
+)
+
+// Setup starts the background jobs of the application. They stop when ctx is cancelled.
+func Setup(ctx context.Context, env *bootstrap.Env, timeout time.Duration, db *gorm.DB, mailer domain.Mailer, keyRing domain.KeyRing, loginAttemptStore domain.LoginAttemptStore) {
+	NewMailOutboxWorker(ctx, env, timeout, db, mailer)
+	NewSigningKeyWorker(ctx, timeout, keyRing)
+	NewLoginAttemptWorker(ctx, env, timeout, db, loginAttemptStore)
+	NewGuestUserWorker(ctx, env, timeout, db)
+	NewSSOStateWorker(ctx, timeout, db)
+	NewOAuthCodeWorker(ctx, timeout, db)
+	NewServiceLaunchWorker(ctx, timeout, db)
+	NewUsageSessionWorker(ctx, env, timeout, db)
+
+	// Jobs on a cron schedule, run by a single replica at a time
+	scheduler := NewScheduler(repository.NewScheduledJobRepository(db), timeout)
+	NewSubscriptionWorker(ctx, env, timeout, db, mailer, scheduler)
+	NewUsageRollupWorker(ctx, env, timeout, db, scheduler)
+}
+
+// every runs job immediately and then at each interval until ctx is cancelled.
+// A panic in one run is logged and does not stop the following runs.
+func every(ctx context.Context, name string, interval time.Duration, job func(ctx context.Context)) {
+	go func() {
+		log.Printf("[Worker] %s started (every %s)", name, interval)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			runSafely(ctx, name, job)
+			select {
+			case <-ctx.Done():
+				log.Printf("[Worker] %s stopped", name)
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func runSafely(ctx context.Context, name string, job func(ctx context.Context)) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[Worker] %s panicked: %v", name, r)
+		}
+	}()
+	job(ctx)
+}